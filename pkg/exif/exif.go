@@ -0,0 +1,243 @@
+// Package exif reads the image file directories of EXIF data and TIFF images.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+)
+
+// OrientationNormal is the orientation of images which are displayed as they are stored
+const OrientationNormal = 1
+
+// the tags of the first image file directory
+const (
+	TagMake        = 0x010f
+	TagModel       = 0x0110
+	TagOrientation = 0x0112
+	TagExifIFD     = 0x8769
+	TagGPSIFD      = 0x8825
+)
+
+// the tags of the EXIF image file directory
+const (
+	TagExposureTime     = 0x829a
+	TagFNumber          = 0x829d
+	TagISO              = 0x8827
+	TagDateTimeOriginal = 0x9003
+	TagFocalLength      = 0x920a
+)
+
+// the tags of the GPS image file directory
+const (
+	TagGPSLatitudeRef  = 0x0001
+	TagGPSLatitude     = 0x0002
+	TagGPSLongitudeRef = 0x0003
+	TagGPSLongitude    = 0x0004
+	TagGPSAltitudeRef  = 0x0005
+	TagGPSAltitude     = 0x0006
+)
+
+// the tiff field types
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeUndefined = 7
+	typeSLong     = 9
+	typeSRational = 10
+)
+
+const (
+	markerAPP1     = 0xe1
+	markerSOS      = 0xda
+	markerEOI      = 0xd9
+	markerTEM      = 0x01
+	markerRSTFirst = 0xd0
+	markerRSTLast  = 0xd7
+)
+
+var (
+	exifHeader   = []byte("Exif\x00\x00")
+	tiffHeaderLE = []byte("II*\x00")
+	tiffHeaderBE = []byte("MM\x00*")
+	jpegSOI      = []byte{0xff, 0xd8}
+)
+
+// typeSizes are the sizes of the values of the tiff field types
+var typeSizes = map[uint16]uint64{
+	typeByte:      1,
+	typeASCII:     1,
+	typeShort:     2,
+	typeLong:      4,
+	typeRational:  8,
+	typeUndefined: 1,
+	typeSLong:     4,
+	typeSRational: 8,
+}
+
+// TIFF reads the image file directories of tiff files and EXIF data
+type TIFF struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+// Parse returns the EXIF tiff structure of a JPEG image or the TIFF image itself.
+// All APP1 segments of a JPEG image are searched because the EXIF data does not need to be the first one.
+// It returns nil if the image has no EXIF data.
+func Parse(b []byte) *TIFF {
+	if t := newTIFF(b); t != nil {
+		return t
+	}
+	if !bytes.HasPrefix(b, jpegSOI) {
+		return nil
+	}
+
+	for i := len(jpegSOI); i+4 <= len(b); {
+		if b[i] != 0xff {
+			return nil
+		}
+		marker := b[i+1]
+		switch {
+		case marker == 0xff:
+			// fill byte
+			i++
+			continue
+		case marker == markerSOS, marker == markerEOI:
+			// the metadata segments precede the image data
+			return nil
+		case marker == markerTEM, marker >= markerRSTFirst && marker <= markerRSTLast:
+			// markers without a segment
+			i += 2
+			continue
+		}
+
+		size := int(binary.BigEndian.Uint16(b[i+2:]))
+		if size < 2 || i+2+size > len(b) {
+			return nil
+		}
+		segment := b[i+4 : i+2+size]
+		if marker == markerAPP1 && bytes.HasPrefix(segment, exifHeader) {
+			return newTIFF(segment[len(exifHeader):])
+		}
+		i += 2 + size
+	}
+	return nil
+}
+
+func newTIFF(b []byte) *TIFF {
+	switch {
+	case len(b) < 8:
+		return nil
+	case bytes.HasPrefix(b, tiffHeaderLE):
+		return &TIFF{b: b, order: binary.LittleEndian}
+	case bytes.HasPrefix(b, tiffHeaderBE):
+		return &TIFF{b: b, order: binary.BigEndian}
+	}
+	return nil
+}
+
+// FirstIFD returns the offset of the first image file directory
+func (t *TIFF) FirstIFD() uint64 {
+	return uint64(t.order.Uint32(t.b[4:]))
+}
+
+// Entry is an entry of an image file directory
+type Entry struct {
+	typ   uint16
+	count uint64
+	data  []byte
+	order binary.ByteOrder
+}
+
+// IFD is an image file directory
+type IFD map[uint16]Entry
+
+// IFD reads the image file directory at the offset
+func (t *TIFF) IFD(offset uint64) (IFD, bool) {
+	if offset < 8 || offset+2 > uint64(len(t.b)) {
+		return nil, false
+	}
+	n := uint64(t.order.Uint16(t.b[offset:]))
+	ifd := IFD{}
+	for e := offset + 2; e < offset+2+n*12 && e+12 <= uint64(len(t.b)); e += 12 {
+		typ := t.order.Uint16(t.b[e+2:])
+		count := uint64(t.order.Uint32(t.b[e+4:]))
+		size, ok := typeSizes[typ]
+		if !ok {
+			continue
+		}
+		data := t.b[e+8 : e+12]
+		if size*count > 4 {
+			start := uint64(t.order.Uint32(t.b[e+8:]))
+			if start+size*count > uint64(len(t.b)) {
+				continue
+			}
+			data = t.b[start : start+size*count]
+		}
+		ifd[t.order.Uint16(t.b[e:])] = Entry{typ: typ, count: count, data: data, order: t.order}
+	}
+	return ifd, true
+}
+
+// String returns the value of an ascii entry
+func (ifd IFD) String(tag uint16) string {
+	e, ok := ifd[tag]
+	if !ok || e.typ != typeASCII {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.data[:min(e.count, uint64(len(e.data)))]), "\x00"))
+}
+
+// Uint returns the first value of a byte, short or long entry
+func (ifd IFD) Uint(tag uint16) uint64 {
+	e, ok := ifd[tag]
+	switch {
+	case !ok || e.count == 0:
+		return 0
+	case e.typ == typeByte:
+		return uint64(e.data[0])
+	case e.typ == typeShort:
+		return uint64(e.order.Uint16(e.data))
+	case e.typ == typeLong:
+		return uint64(e.order.Uint32(e.data))
+	}
+	return 0
+}
+
+// Rational returns the numerator and denominator of the i-th value of a rational entry
+func (ifd IFD) Rational(tag uint16, i int) (float64, float64, bool) {
+	e, ok := ifd[tag]
+	if !ok || (e.typ != typeRational && e.typ != typeSRational) || uint64(i) >= e.count {
+		return 0, 0, false
+	}
+	num, den := float64(e.order.Uint32(e.data[i*8:])), float64(e.order.Uint32(e.data[i*8+4:]))
+	if e.typ == typeSRational {
+		num, den = float64(int32(e.order.Uint32(e.data[i*8:]))), float64(int32(e.order.Uint32(e.data[i*8+4:])))
+	}
+	if den == 0 || math.IsNaN(num/den) {
+		return 0, 0, false
+	}
+	return num, den, true
+}
+
+// Orientation returns the EXIF orientation of a JPEG or TIFF image.
+// It returns OrientationNormal if the orientation is missing or invalid.
+func Orientation(b []byte) int {
+	t := Parse(b)
+	if t == nil {
+		return OrientationNormal
+	}
+	ifd, ok := t.IFD(t.FirstIFD())
+	if !ok {
+		return OrientationNormal
+	}
+	o := ifd.Uint(TagOrientation)
+	if o < 1 || o > 8 {
+		return OrientationNormal
+	}
+	return int(o)
+}
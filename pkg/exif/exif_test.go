@@ -0,0 +1,66 @@
+package exif
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/exif/exiftest"
+)
+
+// tiffStructure returns a TIFF structure with the orientation, the model and a GPS IFD with the altitude
+func tiffStructure(order binary.AppendByteOrder, orientation int) []byte {
+	return exiftest.TIFF(order,
+		exiftest.Short(TagOrientation, uint16(orientation)),
+		exiftest.ASCII(TagModel, "Camera "),
+		exiftest.Pointer(TagGPSIFD,
+			exiftest.Byte(TagGPSAltitudeRef, 1),
+			exiftest.Rational(TagGPSAltitude, 25, 2),
+		),
+	)
+}
+
+// jpegWithExif returns the start of a jpeg image with an XMP segment followed by the EXIF segment
+func jpegWithExif(tiff []byte) []byte {
+	return exiftest.JPEG([]byte{0xff, 0xd8, 0xff, 0xda}, tiff)
+}
+
+func TestParse(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+		withFill := append([]byte{0xff, 0xd8, 0xff}, jpegWithExif(tiffStructure(order, 6))[2:]...)
+		for _, data := range [][]byte{tiffStructure(order, 6), jpegWithExif(tiffStructure(order, 6)), withFill} {
+			tiff := Parse(data)
+			require.NotNil(t, tiff)
+			ifd0, ok := tiff.IFD(tiff.FirstIFD())
+			require.True(t, ok)
+			assert.Equal(t, uint64(6), ifd0.Uint(TagOrientation))
+			assert.Equal(t, "Camera", ifd0.String(TagModel))
+
+			gps, ok := tiff.IFD(ifd0.Uint(TagGPSIFD))
+			require.True(t, ok)
+			assert.Equal(t, uint64(1), gps.Uint(TagGPSAltitudeRef))
+			num, den, ok := gps.Rational(TagGPSAltitude, 0)
+			assert.True(t, ok)
+			assert.Equal(t, 12.5, num/den)
+			_, _, ok = gps.Rational(TagGPSAltitude, 1)
+			assert.False(t, ok)
+		}
+	}
+
+	assert.Nil(t, Parse(nil))
+	assert.Nil(t, Parse([]byte("GIF89a")))
+	assert.Nil(t, Parse([]byte{0xff, 0xd8, 0xff, 0xda}))
+}
+
+func TestOrientation(t *testing.T) {
+	assert.Equal(t, 6, Orientation(tiffStructure(binary.LittleEndian, 6)))
+	assert.Equal(t, 8, Orientation(tiffStructure(binary.BigEndian, 8)))
+	assert.Equal(t, 3, Orientation(jpegWithExif(tiffStructure(binary.BigEndian, 3))))
+
+	assert.Equal(t, OrientationNormal, Orientation(nil))
+	assert.Equal(t, OrientationNormal, Orientation([]byte{0xff, 0xd8, 0xff}))
+	assert.Equal(t, OrientationNormal, Orientation(tiffStructure(binary.LittleEndian, 9)))
+	assert.Equal(t, OrientationNormal, Orientation(tiffStructure(binary.LittleEndian, 6)[:20]))
+}
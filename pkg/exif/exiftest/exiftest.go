@@ -0,0 +1,158 @@
+// Package exiftest builds TIFF structures and JPEG images with EXIF data for tests.
+package exiftest
+
+import (
+	"encoding/binary"
+)
+
+// the field types of the entries
+const (
+	typeByte     = 1
+	typeASCII    = 2
+	typeShort    = 3
+	typeLong     = 4
+	typeRational = 5
+)
+
+// Entry is an entry of an image file directory
+type Entry struct {
+	tag, typ uint16
+	count    uint32
+	// the value of byte and ascii entries
+	raw []byte
+	// the values of the numeric entries, rationals are pairs of numerator and denominator
+	values []uint32
+	// the referenced image file directory of pointer entries
+	ifd []Entry
+}
+
+// Byte returns an entry with byte values
+func Byte(tag uint16, values ...byte) Entry {
+	return Entry{tag: tag, typ: typeByte, count: uint32(len(values)), raw: values}
+}
+
+// ASCII returns an entry with the null terminated string
+func ASCII(tag uint16, s string) Entry {
+	return Entry{tag: tag, typ: typeASCII, count: uint32(len(s) + 1), raw: append([]byte(s), 0)}
+}
+
+// Short returns an entry with short values
+func Short(tag uint16, values ...uint16) Entry {
+	e := Entry{tag: tag, typ: typeShort, count: uint32(len(values))}
+	for _, v := range values {
+		e.values = append(e.values, uint32(v))
+	}
+	return e
+}
+
+// Long returns an entry with long values
+func Long(tag uint16, values ...uint32) Entry {
+	return Entry{tag: tag, typ: typeLong, count: uint32(len(values)), values: values}
+}
+
+// Rational returns an entry with rational values given as pairs of numerator and denominator
+func Rational(tag uint16, values ...uint32) Entry {
+	return Entry{tag: tag, typ: typeRational, count: uint32(len(values) / 2), values: values}
+}
+
+// Pointer returns an entry with the offset of an image file directory like the EXIF or GPS IFD
+func Pointer(tag uint16, entries ...Entry) Entry {
+	return Entry{tag: tag, typ: typeLong, count: 1, ifd: entries}
+}
+
+// value returns the encoded value of the entry, the offset of pointers is written separately
+func (e Entry) value(order binary.AppendByteOrder) []byte {
+	if e.ifd != nil {
+		return nil
+	}
+	b := e.raw
+	for _, v := range e.values {
+		if e.typ == typeShort {
+			b = order.AppendUint16(b, uint16(v))
+		} else {
+			b = order.AppendUint32(b, v)
+		}
+	}
+	return b
+}
+
+// TIFF returns a TIFF structure with the entries in the first image file directory.
+// Every IFD is followed by the values which don't fit into the entries and then by the IFDs it references.
+func TIFF(order binary.AppendByteOrder, entries ...Entry) []byte {
+	var b []byte
+	if order == binary.LittleEndian {
+		b = []byte("II*\x00")
+	} else {
+		b = []byte("MM\x00*")
+	}
+	b = order.AppendUint32(b, 8)
+	return appendIFD(b, order, entries)
+}
+
+// ifdSize returns the size of the IFD with its values and referenced IFDs
+func ifdSize(order binary.AppendByteOrder, entries []Entry) int {
+	size := 2 + len(entries)*12 + 4
+	for _, e := range entries {
+		if v := e.value(order); len(v) > 4 {
+			size += len(v)
+		}
+		if e.ifd != nil {
+			size += ifdSize(order, e.ifd)
+		}
+	}
+	return size
+}
+
+func appendIFD(b []byte, order binary.AppendByteOrder, entries []Entry) []byte {
+	valueOffset := len(b) + 2 + len(entries)*12 + 4
+	var values []byte
+	for _, e := range entries {
+		if v := e.value(order); len(v) > 4 {
+			values = append(values, v...)
+		}
+	}
+	ifdOffset := valueOffset + len(values)
+
+	b = order.AppendUint16(b, uint16(len(entries)))
+	for _, e := range entries {
+		b = order.AppendUint16(b, e.tag)
+		b = order.AppendUint16(b, e.typ)
+		b = order.AppendUint32(b, e.count)
+
+		v := e.value(order)
+		switch {
+		case e.ifd != nil:
+			b = order.AppendUint32(b, uint32(ifdOffset))
+			ifdOffset += ifdSize(order, e.ifd)
+		case len(v) > 4:
+			b = order.AppendUint32(b, uint32(valueOffset))
+			valueOffset += len(v)
+		default:
+			b = append(b, v...)
+			b = append(b, make([]byte, 4-len(v))...)
+		}
+	}
+	b = order.AppendUint32(b, 0)
+	b = append(b, values...)
+
+	for _, e := range entries {
+		if e.ifd != nil {
+			b = appendIFD(b, order, e.ifd)
+		}
+	}
+	return b
+}
+
+// JPEG returns the jpeg image with an XMP segment followed by the EXIF segment with the TIFF structure
+// after the start of image marker
+func JPEG(jpeg, tiff []byte) []byte {
+	segment := func(marker byte, data []byte) []byte {
+		s := []byte{0xff, marker, 0, 0}
+		binary.BigEndian.PutUint16(s[2:], uint16(len(data)+2))
+		return append(s, data...)
+	}
+	out := append([]byte{}, jpeg[:2]...)
+	out = append(out, segment(0xe1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>"))...)
+	out = append(out, segment(0xe1, append([]byte("Exif\x00\x00"), tiff...))...)
+	return append(out, jpeg[2:]...)
+}
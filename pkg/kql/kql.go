@@ -47,3 +47,16 @@ func (b Builder) Build(q string) (*ast.Ast, error) {
 // timeNow mirrors time.Now by default, the only reason why this exists
 // is to monkey patch it from the tests. See PatchTimeNow
 var timeNow = time.Now
+
+// TimeRanges are the natural language time ranges which can be used as value of datetime properties, like mtime:"last 7 days"
+var TimeRanges = []string{"today", "yesterday", "this week", "last week", "last 7 days", "this month", "last month", "last 30 days", "this year", "last year"}
+
+// TimeRange returns the inclusive bounds of the natural language time range as the query would use them
+func TimeRange(name string) (time.Time, time.Time, error) {
+	from, to, err := toTimeRange(name)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return *from, *to, nil
+}
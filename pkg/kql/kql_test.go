@@ -2,6 +2,7 @@ package kql_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/opencloud-eu/opencloud/pkg/ast"
 	"github.com/opencloud-eu/opencloud/pkg/kql"
@@ -52,3 +53,27 @@ func TestNewAST(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeRange(t *testing.T) {
+	kql.PatchTimeNow(func() time.Time {
+		return time.Date(2023, 11, 15, 12, 0, 0, 0, time.UTC)
+	})
+	defer kql.PatchTimeNow(time.Now)
+
+	assert := tAssert.New(t)
+
+	for _, name := range kql.TimeRanges {
+		from, to, err := kql.TimeRange(name)
+		assert.Nil(err)
+		assert.True(from.Before(to), name)
+
+		// the bounds match the ones of the query
+		got, err := kql.Builder{}.Build(`mtime:"` + name + `"`)
+		assert.Nil(err)
+		assert.Equal(from, got.Nodes[0].(*ast.DateTimeNode).Value, name)
+		assert.Equal(to, got.Nodes[2].(*ast.DateTimeNode).Value, name)
+	}
+
+	_, _, err := kql.TimeRange("next week")
+	assert.NotNil(err)
+}
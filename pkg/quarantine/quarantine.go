@@ -0,0 +1,8 @@
+// Package quarantine contains the definitions shared by the services handling quarantined files.
+package quarantine
+
+import "github.com/opencloud-eu/reva/v2/pkg/events"
+
+// PPOutcomeQuarantine means that the infected file has been moved to the quarantine space.
+// The storage provider doesn't know about the quarantine, for the storage the upload is deleted.
+const PPOutcomeQuarantine events.PostprocessingOutcome = "quarantine"
@@ -0,0 +1,62 @@
+package roles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
+)
+
+var (
+	// ErrUnauthenticated is returned when the permission of a request without a user is checked
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrPermissionDenied is returned when none of the roles of the user grants the permission
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// CheckPermission checks if one of the roles of the user grants the permission. The roles are read from
+// the context and fetched from the settings service if the context doesn't carry them.
+func (m *Manager) CheckPermission(ctx context.Context, userID string, permissionID string) error {
+	if userID == "" {
+		return ErrUnauthenticated
+	}
+
+	roleIDs, ok := ReadRoleIDsFromContext(ctx)
+	if !ok || len(roleIDs) == 0 {
+		var err error
+		roleIDs, err = m.FindRoleIDsForUser(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get roles for user '%s': %w", userID, err)
+		}
+	}
+
+	if m.FindPermissionByID(ctx, roleIDs, permissionID) == nil {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
+
+// RequirePermission returns a middleware which only passes requests of users holding the permission
+func RequirePermission(m *Manager, permissionID string, logger log.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u, _ := revactx.ContextGetUser(r.Context())
+
+			switch err := m.CheckPermission(r.Context(), u.GetId().GetOpaqueId(), permissionID); {
+			case err == nil:
+				next.ServeHTTP(w, r)
+			case errors.Is(err, ErrUnauthenticated):
+				w.WriteHeader(http.StatusUnauthorized)
+			case errors.Is(err, ErrPermissionDenied):
+				w.WriteHeader(http.StatusForbidden)
+			default:
+				logger.Error().Err(err).Str("permission", permissionID).Msg("cannot check the permissions of the user")
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+	}
+}
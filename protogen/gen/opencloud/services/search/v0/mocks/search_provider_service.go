@@ -39,6 +39,89 @@ func (_m *SearchProviderService) EXPECT() *SearchProviderService_Expecter {
 	return &SearchProviderService_Expecter{mock: &_m.Mock}
 }
 
+// DeleteSavedSearch provides a mock function for the type SearchProviderService
+func (_mock *SearchProviderService) DeleteSavedSearch(ctx context.Context, in *v0.DeleteSavedSearchRequest, opts ...client.CallOption) (*v0.DeleteSavedSearchResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSavedSearch")
+	}
+
+	var r0 *v0.DeleteSavedSearchResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.DeleteSavedSearchRequest, ...client.CallOption) (*v0.DeleteSavedSearchResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.DeleteSavedSearchRequest, ...client.CallOption) *v0.DeleteSavedSearchResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.DeleteSavedSearchResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.DeleteSavedSearchRequest, ...client.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// SearchProviderService_DeleteSavedSearch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteSavedSearch'
+type SearchProviderService_DeleteSavedSearch_Call struct {
+	*mock.Call
+}
+
+// DeleteSavedSearch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v0.DeleteSavedSearchRequest
+//   - opts ...client.CallOption
+func (_e *SearchProviderService_Expecter) DeleteSavedSearch(ctx interface{}, in interface{}, opts ...interface{}) *SearchProviderService_DeleteSavedSearch_Call {
+	return &SearchProviderService_DeleteSavedSearch_Call{Call: _e.mock.On("DeleteSavedSearch",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *SearchProviderService_DeleteSavedSearch_Call) Run(run func(ctx context.Context, in *v0.DeleteSavedSearchRequest, opts ...client.CallOption)) *SearchProviderService_DeleteSavedSearch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.DeleteSavedSearchRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.DeleteSavedSearchRequest)
+		}
+		var arg2 []client.CallOption
+		var variadicArgs []client.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *SearchProviderService_DeleteSavedSearch_Call) Return(deleteSavedSearchResponse *v0.DeleteSavedSearchResponse, err error) *SearchProviderService_DeleteSavedSearch_Call {
+	_c.Call.Return(deleteSavedSearchResponse, err)
+	return _c
+}
+
+func (_c *SearchProviderService_DeleteSavedSearch_Call) RunAndReturn(run func(ctx context.Context, in *v0.DeleteSavedSearchRequest, opts ...client.CallOption) (*v0.DeleteSavedSearchResponse, error)) *SearchProviderService_DeleteSavedSearch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // IndexSpace provides a mock function for the type SearchProviderService
 func (_mock *SearchProviderService) IndexSpace(ctx context.Context, in *v0.IndexSpaceRequest, opts ...client.CallOption) (*v0.IndexSpaceResponse, error) {
 	var tmpRet mock.Arguments
@@ -122,6 +205,172 @@ func (_c *SearchProviderService_IndexSpace_Call) RunAndReturn(run func(ctx conte
 	return _c
 }
 
+// ListSavedSearches provides a mock function for the type SearchProviderService
+func (_mock *SearchProviderService) ListSavedSearches(ctx context.Context, in *v0.ListSavedSearchesRequest, opts ...client.CallOption) (*v0.ListSavedSearchesResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSavedSearches")
+	}
+
+	var r0 *v0.ListSavedSearchesResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.ListSavedSearchesRequest, ...client.CallOption) (*v0.ListSavedSearchesResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.ListSavedSearchesRequest, ...client.CallOption) *v0.ListSavedSearchesResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.ListSavedSearchesResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.ListSavedSearchesRequest, ...client.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// SearchProviderService_ListSavedSearches_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSavedSearches'
+type SearchProviderService_ListSavedSearches_Call struct {
+	*mock.Call
+}
+
+// ListSavedSearches is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v0.ListSavedSearchesRequest
+//   - opts ...client.CallOption
+func (_e *SearchProviderService_Expecter) ListSavedSearches(ctx interface{}, in interface{}, opts ...interface{}) *SearchProviderService_ListSavedSearches_Call {
+	return &SearchProviderService_ListSavedSearches_Call{Call: _e.mock.On("ListSavedSearches",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *SearchProviderService_ListSavedSearches_Call) Run(run func(ctx context.Context, in *v0.ListSavedSearchesRequest, opts ...client.CallOption)) *SearchProviderService_ListSavedSearches_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.ListSavedSearchesRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.ListSavedSearchesRequest)
+		}
+		var arg2 []client.CallOption
+		var variadicArgs []client.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *SearchProviderService_ListSavedSearches_Call) Return(listSavedSearchesResponse *v0.ListSavedSearchesResponse, err error) *SearchProviderService_ListSavedSearches_Call {
+	_c.Call.Return(listSavedSearchesResponse, err)
+	return _c
+}
+
+func (_c *SearchProviderService_ListSavedSearches_Call) RunAndReturn(run func(ctx context.Context, in *v0.ListSavedSearchesRequest, opts ...client.CallOption) (*v0.ListSavedSearchesResponse, error)) *SearchProviderService_ListSavedSearches_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveSearch provides a mock function for the type SearchProviderService
+func (_mock *SearchProviderService) SaveSearch(ctx context.Context, in *v0.SaveSearchRequest, opts ...client.CallOption) (*v0.SaveSearchResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSearch")
+	}
+
+	var r0 *v0.SaveSearchResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.SaveSearchRequest, ...client.CallOption) (*v0.SaveSearchResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.SaveSearchRequest, ...client.CallOption) *v0.SaveSearchResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.SaveSearchResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.SaveSearchRequest, ...client.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// SearchProviderService_SaveSearch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveSearch'
+type SearchProviderService_SaveSearch_Call struct {
+	*mock.Call
+}
+
+// SaveSearch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v0.SaveSearchRequest
+//   - opts ...client.CallOption
+func (_e *SearchProviderService_Expecter) SaveSearch(ctx interface{}, in interface{}, opts ...interface{}) *SearchProviderService_SaveSearch_Call {
+	return &SearchProviderService_SaveSearch_Call{Call: _e.mock.On("SaveSearch",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *SearchProviderService_SaveSearch_Call) Run(run func(ctx context.Context, in *v0.SaveSearchRequest, opts ...client.CallOption)) *SearchProviderService_SaveSearch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.SaveSearchRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.SaveSearchRequest)
+		}
+		var arg2 []client.CallOption
+		var variadicArgs []client.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *SearchProviderService_SaveSearch_Call) Return(saveSearchResponse *v0.SaveSearchResponse, err error) *SearchProviderService_SaveSearch_Call {
+	_c.Call.Return(saveSearchResponse, err)
+	return _c
+}
+
+func (_c *SearchProviderService_SaveSearch_Call) RunAndReturn(run func(ctx context.Context, in *v0.SaveSearchRequest, opts ...client.CallOption) (*v0.SaveSearchResponse, error)) *SearchProviderService_SaveSearch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Search provides a mock function for the type SearchProviderService
 func (_mock *SearchProviderService) Search(ctx context.Context, in *v0.SearchRequest, opts ...client.CallOption) (*v0.SearchResponse, error) {
 	var tmpRet mock.Arguments
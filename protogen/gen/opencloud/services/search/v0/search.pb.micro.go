@@ -47,6 +47,24 @@ func NewSearchProviderEndpoints() []*api.Endpoint {
 			Method:  []string{"POST"},
 			Handler: "rpc",
 		},
+		{
+			Name:    "SearchProvider.SaveSearch",
+			Path:    []string{"/api/v0/search/saved-searches/save"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "SearchProvider.ListSavedSearches",
+			Path:    []string{"/api/v0/search/saved-searches/list"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
+		{
+			Name:    "SearchProvider.DeleteSavedSearch",
+			Path:    []string{"/api/v0/search/saved-searches/delete"},
+			Method:  []string{"POST"},
+			Handler: "rpc",
+		},
 	}
 }
 
@@ -55,6 +73,9 @@ func NewSearchProviderEndpoints() []*api.Endpoint {
 type SearchProviderService interface {
 	Search(ctx context.Context, in *SearchRequest, opts ...client.CallOption) (*SearchResponse, error)
 	IndexSpace(ctx context.Context, in *IndexSpaceRequest, opts ...client.CallOption) (*IndexSpaceResponse, error)
+	SaveSearch(ctx context.Context, in *SaveSearchRequest, opts ...client.CallOption) (*SaveSearchResponse, error)
+	ListSavedSearches(ctx context.Context, in *ListSavedSearchesRequest, opts ...client.CallOption) (*ListSavedSearchesResponse, error)
+	DeleteSavedSearch(ctx context.Context, in *DeleteSavedSearchRequest, opts ...client.CallOption) (*DeleteSavedSearchResponse, error)
 }
 
 type searchProviderService struct {
@@ -89,17 +110,53 @@ func (c *searchProviderService) IndexSpace(ctx context.Context, in *IndexSpaceRe
 	return out, nil
 }
 
+func (c *searchProviderService) SaveSearch(ctx context.Context, in *SaveSearchRequest, opts ...client.CallOption) (*SaveSearchResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.SaveSearch", in)
+	out := new(SaveSearchResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchProviderService) ListSavedSearches(ctx context.Context, in *ListSavedSearchesRequest, opts ...client.CallOption) (*ListSavedSearchesResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.ListSavedSearches", in)
+	out := new(ListSavedSearchesResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchProviderService) DeleteSavedSearch(ctx context.Context, in *DeleteSavedSearchRequest, opts ...client.CallOption) (*DeleteSavedSearchResponse, error) {
+	req := c.c.NewRequest(c.name, "SearchProvider.DeleteSavedSearch", in)
+	out := new(DeleteSavedSearchResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for SearchProvider service
 
 type SearchProviderHandler interface {
 	Search(context.Context, *SearchRequest, *SearchResponse) error
 	IndexSpace(context.Context, *IndexSpaceRequest, *IndexSpaceResponse) error
+	SaveSearch(context.Context, *SaveSearchRequest, *SaveSearchResponse) error
+	ListSavedSearches(context.Context, *ListSavedSearchesRequest, *ListSavedSearchesResponse) error
+	DeleteSavedSearch(context.Context, *DeleteSavedSearchRequest, *DeleteSavedSearchResponse) error
 }
 
 func RegisterSearchProviderHandler(s server.Server, hdlr SearchProviderHandler, opts ...server.HandlerOption) error {
 	type searchProvider interface {
 		Search(ctx context.Context, in *SearchRequest, out *SearchResponse) error
 		IndexSpace(ctx context.Context, in *IndexSpaceRequest, out *IndexSpaceResponse) error
+		SaveSearch(ctx context.Context, in *SaveSearchRequest, out *SaveSearchResponse) error
+		ListSavedSearches(ctx context.Context, in *ListSavedSearchesRequest, out *ListSavedSearchesResponse) error
+		DeleteSavedSearch(ctx context.Context, in *DeleteSavedSearchRequest, out *DeleteSavedSearchResponse) error
 	}
 	type SearchProvider struct {
 		searchProvider
@@ -117,6 +174,24 @@ func RegisterSearchProviderHandler(s server.Server, hdlr SearchProviderHandler,
 		Method:  []string{"POST"},
 		Handler: "rpc",
 	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.SaveSearch",
+		Path:    []string{"/api/v0/search/saved-searches/save"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.ListSavedSearches",
+		Path:    []string{"/api/v0/search/saved-searches/list"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "SearchProvider.DeleteSavedSearch",
+		Path:    []string{"/api/v0/search/saved-searches/delete"},
+		Method:  []string{"POST"},
+		Handler: "rpc",
+	}))
 	return s.Handle(s.NewHandler(&SearchProvider{h}, opts...))
 }
 
@@ -132,6 +207,18 @@ func (h *searchProviderHandler) IndexSpace(ctx context.Context, in *IndexSpaceRe
 	return h.SearchProviderHandler.IndexSpace(ctx, in, out)
 }
 
+func (h *searchProviderHandler) SaveSearch(ctx context.Context, in *SaveSearchRequest, out *SaveSearchResponse) error {
+	return h.SearchProviderHandler.SaveSearch(ctx, in, out)
+}
+
+func (h *searchProviderHandler) ListSavedSearches(ctx context.Context, in *ListSavedSearchesRequest, out *ListSavedSearchesResponse) error {
+	return h.SearchProviderHandler.ListSavedSearches(ctx, in, out)
+}
+
+func (h *searchProviderHandler) DeleteSavedSearch(ctx context.Context, in *DeleteSavedSearchRequest, out *DeleteSavedSearchResponse) error {
+	return h.SearchProviderHandler.DeleteSavedSearch(ctx, in, out)
+}
+
 // Api Endpoints for IndexProvider service
 
 func NewIndexProviderEndpoints() []*api.Endpoint {
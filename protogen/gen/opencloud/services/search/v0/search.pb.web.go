@@ -75,6 +75,84 @@ func (h *webSearchProviderHandler) IndexSpace(w http.ResponseWriter, r *http.Req
 	render.JSON(w, r, resp)
 }
 
+func (h *webSearchProviderHandler) SaveSearch(w http.ResponseWriter, r *http.Request) {
+	req := &SaveSearchRequest{}
+	resp := &SaveSearchResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.SaveSearch(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webSearchProviderHandler) ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	req := &ListSavedSearchesRequest{}
+	resp := &ListSavedSearchesResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.ListSavedSearches(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webSearchProviderHandler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	req := &DeleteSavedSearchRequest{}
+	resp := &DeleteSavedSearchResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.DeleteSavedSearch(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
 func RegisterSearchProviderWeb(r chi.Router, i SearchProviderHandler, middlewares ...func(http.Handler) http.Handler) {
 	handler := &webSearchProviderHandler{
 		r: r,
@@ -83,6 +161,9 @@ func RegisterSearchProviderWeb(r chi.Router, i SearchProviderHandler, middleware
 
 	r.MethodFunc("POST", "/api/v0/search/search", handler.Search)
 	r.MethodFunc("POST", "/api/v0/search/index-space", handler.IndexSpace)
+	r.MethodFunc("POST", "/api/v0/search/saved-searches/save", handler.SaveSearch)
+	r.MethodFunc("POST", "/api/v0/search/saved-searches/list", handler.ListSavedSearches)
+	r.MethodFunc("POST", "/api/v0/search/saved-searches/delete", handler.DeleteSavedSearch)
 }
 
 type webIndexProviderHandler struct {
@@ -344,3 +425,219 @@ func (m *IndexSpaceResponse) UnmarshalJSON(b []byte) error {
 }
 
 var _ json.Unmarshaler = (*IndexSpaceResponse)(nil)
+
+// SaveSearchRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of SaveSearchRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var SaveSearchRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *SaveSearchRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := SaveSearchRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*SaveSearchRequest)(nil)
+
+// SaveSearchRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of SaveSearchRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var SaveSearchRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *SaveSearchRequest) UnmarshalJSON(b []byte) error {
+	return SaveSearchRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*SaveSearchRequest)(nil)
+
+// SaveSearchResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of SaveSearchResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var SaveSearchResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *SaveSearchResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := SaveSearchResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*SaveSearchResponse)(nil)
+
+// SaveSearchResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of SaveSearchResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var SaveSearchResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *SaveSearchResponse) UnmarshalJSON(b []byte) error {
+	return SaveSearchResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*SaveSearchResponse)(nil)
+
+// ListSavedSearchesRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of ListSavedSearchesRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListSavedSearchesRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *ListSavedSearchesRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := ListSavedSearchesRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*ListSavedSearchesRequest)(nil)
+
+// ListSavedSearchesRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of ListSavedSearchesRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListSavedSearchesRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *ListSavedSearchesRequest) UnmarshalJSON(b []byte) error {
+	return ListSavedSearchesRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*ListSavedSearchesRequest)(nil)
+
+// ListSavedSearchesResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of ListSavedSearchesResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListSavedSearchesResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *ListSavedSearchesResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := ListSavedSearchesResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*ListSavedSearchesResponse)(nil)
+
+// ListSavedSearchesResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of ListSavedSearchesResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListSavedSearchesResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *ListSavedSearchesResponse) UnmarshalJSON(b []byte) error {
+	return ListSavedSearchesResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*ListSavedSearchesResponse)(nil)
+
+// DeleteSavedSearchRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of DeleteSavedSearchRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var DeleteSavedSearchRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *DeleteSavedSearchRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := DeleteSavedSearchRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*DeleteSavedSearchRequest)(nil)
+
+// DeleteSavedSearchRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of DeleteSavedSearchRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var DeleteSavedSearchRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *DeleteSavedSearchRequest) UnmarshalJSON(b []byte) error {
+	return DeleteSavedSearchRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*DeleteSavedSearchRequest)(nil)
+
+// DeleteSavedSearchResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of DeleteSavedSearchResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var DeleteSavedSearchResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *DeleteSavedSearchResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := DeleteSavedSearchResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*DeleteSavedSearchResponse)(nil)
+
+// DeleteSavedSearchResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of DeleteSavedSearchResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var DeleteSavedSearchResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *DeleteSavedSearchResponse) UnmarshalJSON(b []byte) error {
+	return DeleteSavedSearchResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*DeleteSavedSearchResponse)(nil)
@@ -39,6 +39,89 @@ func (_m *ThumbnailService) EXPECT() *ThumbnailService_Expecter {
 	return &ThumbnailService_Expecter{mock: &_m.Mock}
 }
 
+// GetBlurHash provides a mock function for the type ThumbnailService
+func (_mock *ThumbnailService) GetBlurHash(ctx context.Context, in *v0.GetBlurHashRequest, opts ...client.CallOption) (*v0.GetBlurHashResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBlurHash")
+	}
+
+	var r0 *v0.GetBlurHashResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.GetBlurHashRequest, ...client.CallOption) (*v0.GetBlurHashResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v0.GetBlurHashRequest, ...client.CallOption) *v0.GetBlurHashResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.GetBlurHashResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v0.GetBlurHashRequest, ...client.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// ThumbnailService_GetBlurHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBlurHash'
+type ThumbnailService_GetBlurHash_Call struct {
+	*mock.Call
+}
+
+// GetBlurHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v0.GetBlurHashRequest
+//   - opts ...client.CallOption
+func (_e *ThumbnailService_Expecter) GetBlurHash(ctx interface{}, in interface{}, opts ...interface{}) *ThumbnailService_GetBlurHash_Call {
+	return &ThumbnailService_GetBlurHash_Call{Call: _e.mock.On("GetBlurHash",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *ThumbnailService_GetBlurHash_Call) Run(run func(ctx context.Context, in *v0.GetBlurHashRequest, opts ...client.CallOption)) *ThumbnailService_GetBlurHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v0.GetBlurHashRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v0.GetBlurHashRequest)
+		}
+		var arg2 []client.CallOption
+		var variadicArgs []client.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]client.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *ThumbnailService_GetBlurHash_Call) Return(getBlurHashResponse *v0.GetBlurHashResponse, err error) *ThumbnailService_GetBlurHash_Call {
+	_c.Call.Return(getBlurHashResponse, err)
+	return _c
+}
+
+func (_c *ThumbnailService_GetBlurHash_Call) RunAndReturn(run func(ctx context.Context, in *v0.GetBlurHashRequest, opts ...client.CallOption) (*v0.GetBlurHashResponse, error)) *ThumbnailService_GetBlurHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetThumbnail provides a mock function for the type ThumbnailService
 func (_mock *ThumbnailService) GetThumbnail(ctx context.Context, in *v0.GetThumbnailRequest, opts ...client.CallOption) (*v0.GetThumbnailResponse, error) {
 	var tmpRet mock.Arguments
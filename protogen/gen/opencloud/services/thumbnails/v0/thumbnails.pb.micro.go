@@ -40,6 +40,8 @@ func NewThumbnailServiceEndpoints() []*api.Endpoint {
 type ThumbnailService interface {
 	// Generates the thumbnail and returns it.
 	GetThumbnail(ctx context.Context, in *GetThumbnailRequest, opts ...client.CallOption) (*GetThumbnailResponse, error)
+	// Returns the BlurHash placeholder of the image.
+	GetBlurHash(ctx context.Context, in *GetBlurHashRequest, opts ...client.CallOption) (*GetBlurHashResponse, error)
 }
 
 type thumbnailService struct {
@@ -64,16 +66,29 @@ func (c *thumbnailService) GetThumbnail(ctx context.Context, in *GetThumbnailReq
 	return out, nil
 }
 
+func (c *thumbnailService) GetBlurHash(ctx context.Context, in *GetBlurHashRequest, opts ...client.CallOption) (*GetBlurHashResponse, error) {
+	req := c.c.NewRequest(c.name, "ThumbnailService.GetBlurHash", in)
+	out := new(GetBlurHashResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for ThumbnailService service
 
 type ThumbnailServiceHandler interface {
 	// Generates the thumbnail and returns it.
 	GetThumbnail(context.Context, *GetThumbnailRequest, *GetThumbnailResponse) error
+	// Returns the BlurHash placeholder of the image.
+	GetBlurHash(context.Context, *GetBlurHashRequest, *GetBlurHashResponse) error
 }
 
 func RegisterThumbnailServiceHandler(s server.Server, hdlr ThumbnailServiceHandler, opts ...server.HandlerOption) error {
 	type thumbnailService interface {
 		GetThumbnail(ctx context.Context, in *GetThumbnailRequest, out *GetThumbnailResponse) error
+		GetBlurHash(ctx context.Context, in *GetBlurHashRequest, out *GetBlurHashResponse) error
 	}
 	type ThumbnailService struct {
 		thumbnailService
@@ -89,3 +104,7 @@ type thumbnailServiceHandler struct {
 func (h *thumbnailServiceHandler) GetThumbnail(ctx context.Context, in *GetThumbnailRequest, out *GetThumbnailResponse) error {
 	return h.ThumbnailServiceHandler.GetThumbnail(ctx, in, out)
 }
+
+func (h *thumbnailServiceHandler) GetBlurHash(ctx context.Context, in *GetBlurHashRequest, out *GetBlurHashResponse) error {
+	return h.ThumbnailServiceHandler.GetBlurHash(ctx, in, out)
+}
@@ -0,0 +1,96 @@
+// Package cache provides a cache for scan results.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/opencloud-eu/reva/v2/pkg/store"
+	microstore "go-micro.dev/v4/store"
+
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/scanners"
+)
+
+// NewStore creates the store backing the cache
+func NewStore(cfg config.ScanCache) microstore.Store {
+	return store.Create(
+		store.Store(cfg.Store),
+		store.TTL(cfg.TTL),
+		microstore.Nodes(cfg.Nodes...),
+		microstore.Database(cfg.Database),
+		microstore.Table(cfg.Table),
+		store.Authentication(cfg.AuthUsername, cfg.AuthPassword),
+	)
+}
+
+// Cache holds scan results keyed by the checksum of the scanned content
+// and the version of the signature database used for scanning
+type Cache struct {
+	store microstore.Store
+	ttl   time.Duration
+}
+
+// New returns a Cache using the given store
+func New(st microstore.Store, ttl time.Duration) *Cache {
+	return &Cache{store: st, ttl: ttl}
+}
+
+// Key returns the cache key for the given content checksum and signature database version
+func Key(checksum string, version string) string {
+	v := sha256.Sum256([]byte(version))
+	return checksum + "-" + hex.EncodeToString(v[:8])
+}
+
+// Get returns the cached result for the given key
+func (c *Cache) Get(key string) (scanners.Result, bool, error) {
+	recs, err := c.store.Read(key)
+	switch {
+	case errors.Is(err, microstore.ErrNotFound):
+		return scanners.Result{}, false, nil
+	case err != nil:
+		return scanners.Result{}, false, err
+	case len(recs) == 0:
+		return scanners.Result{}, false, nil
+	}
+
+	var res scanners.Result
+	if err := json.Unmarshal(recs[0].Value, &res); err != nil {
+		return scanners.Result{}, false, err
+	}
+
+	return res, true, nil
+}
+
+// Set caches the result for the given key
+func (c *Cache) Set(key string, res scanners.Result) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	return c.store.Write(&microstore.Record{
+		Key:    key,
+		Value:  b,
+		Expiry: c.ttl,
+	})
+}
+
+// Flush removes all cached results and returns the number of removed entries
+func (c *Cache) Flush() (int, error) {
+	keys, err := c.store.List()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, k := range keys {
+		if err := c.store.Delete(k); err != nil {
+			return i, err
+		}
+	}
+
+	return len(keys), nil
+}
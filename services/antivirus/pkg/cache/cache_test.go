@@ -0,0 +1,57 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	microstore "go-micro.dev/v4/store"
+
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/cache"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/scanners"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, cache.Key("abc", "v1"), cache.Key("abc", "v1"))
+	assert.NotEqual(t, cache.Key("abc", "v1"), cache.Key("abc", "v2"))
+	assert.NotEqual(t, cache.Key("abc", "v1"), cache.Key("abd", "v1"))
+}
+
+func TestCache(t *testing.T) {
+	c := cache.New(microstore.NewMemoryStore(), time.Hour)
+	key := cache.Key("abc", "v1")
+
+	_, ok, err := c.Get(key)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	in := scanners.Result{Infected: true, Description: "Eicar", ScanTime: time.Now().UTC().Truncate(time.Second)}
+	require.NoError(t, c.Set(key, in))
+	require.NoError(t, c.Set(cache.Key("abd", "v1"), scanners.Result{}))
+
+	out, ok, err := c.Get(key)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, in, out)
+
+	n, err := c.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, ok, err = c.Get(key)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCache_Expiry(t *testing.T) {
+	c := cache.New(microstore.NewMemoryStore(), time.Millisecond)
+	key := cache.Key("abc", "v1")
+
+	require.NoError(t, c.Set(key, scanners.Result{}))
+	time.Sleep(10 * time.Millisecond)
+
+	_, ok, err := c.Get(key)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
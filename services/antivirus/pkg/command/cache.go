@@ -0,0 +1,43 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/opencloud-eu/opencloud/pkg/config/configlog"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/cache"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config/parser"
+)
+
+// Cache wraps scan cache related sub-commands.
+func Cache(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "manage the scan result cache",
+		Subcommands: []*cli.Command{
+			FlushCache(cfg),
+		},
+	}
+}
+
+// FlushCache cli command removes all cached scan results.
+func FlushCache(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "flush",
+		Usage: "remove all cached scan results, e.g. after changing the scanner configuration",
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			n, err := cache.New(cache.NewStore(cfg.ScanCache), cfg.ScanCache.TTL).Flush()
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("could not flush the scan cache: %s", err), 1)
+			}
+
+			fmt.Printf("removed %d cached scan results\n", n)
+			return nil
+		},
+	}
+}
@@ -12,6 +12,7 @@ import (
 func GetCommands(cfg *config.Config) cli.Commands {
 	return []*cli.Command{
 		Server(cfg),
+		Cache(cfg),
 		Health(cfg),
 		Version(cfg),
 	}
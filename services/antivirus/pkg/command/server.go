@@ -4,15 +4,24 @@ import (
 	"context"
 	"fmt"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	"github.com/oklog/run"
+	"github.com/opencloud-eu/reva/v2/pkg/events/stream"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/opencloud-eu/reva/v2/pkg/store"
 	"github.com/urfave/cli/v2"
+	microstore "go-micro.dev/v4/store"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/opencloud-eu/opencloud/pkg/config/configlog"
 	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/pkg/quarantine"
+	"github.com/opencloud-eu/opencloud/pkg/registry"
 	"github.com/opencloud-eu/opencloud/pkg/tracing"
 	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config/parser"
 	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/server/debug"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/server/http"
 	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/service"
 )
 
@@ -43,7 +52,20 @@ func Server(cfg *config.Config) *cli.Command {
 				return err
 			}
 			{
-				svc, err := service.NewAntivirus(cfg, logger, traceProvider)
+				gatewaySelector, err := newGatewaySelector(cfg, traceProvider)
+				if err != nil {
+					return err
+				}
+
+				st := store.Create(
+					store.Store(cfg.Store.Store),
+					microstore.Nodes(cfg.Store.Nodes...),
+					microstore.Database(cfg.Store.Database),
+					microstore.Table(cfg.Store.Table),
+					store.Authentication(cfg.Store.AuthUsername, cfg.Store.AuthPassword),
+				)
+
+				svc, err := service.NewAntivirus(cfg, logger, traceProvider, gatewaySelector, st)
 				if err != nil {
 					return cli.Exit(err.Error(), 1)
 				}
@@ -51,6 +73,39 @@ func Server(cfg *config.Config) *cli.Command {
 				gr.Add(svc.Run, func(_ error) {
 					cancel()
 				})
+
+				if cfg.Rescan.Enabled {
+					natsStream, err := stream.NatsFromConfig(cfg.Service.Name, false, stream.NatsConfig(cfg.Events))
+					if err != nil {
+						return err
+					}
+
+					rescanner := service.NewRescanner(svc, cfg, natsStream, logger)
+
+					gr.Add(func() error {
+						return rescanner.Run(ctx)
+					}, func(_ error) {
+						cancel()
+					})
+				}
+
+				if cfg.InfectedFileHandling == string(quarantine.PPOutcomeQuarantine) {
+					server, err := http.Server(
+						http.Logger(logger),
+						http.Context(ctx),
+						http.Config(cfg),
+						http.TracerProvider(traceProvider),
+						http.Antivirus(svc),
+					)
+					if err != nil {
+						logger.Info().Err(err).Str("transport", "http").Msg("Failed to initialize server")
+						return err
+					}
+
+					gr.Add(server.Run, func(_ error) {
+						cancel()
+					})
+				}
 			}
 
 			{
@@ -74,3 +129,23 @@ func Server(cfg *config.Config) *cli.Command {
 		},
 	}
 }
+
+func newGatewaySelector(cfg *config.Config, tp trace.TracerProvider) (pool.Selectable[gateway.GatewayAPIClient], error) {
+	tm, err := pool.StringToTLSMode(cfg.GRPCClientTLS.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	gatewaySelector, err := pool.GatewaySelector(
+		cfg.RevaGateway,
+		pool.WithTLSCACert(cfg.GRPCClientTLS.CACert),
+		pool.WithTLSMode(tm),
+		pool.WithRegistry(registry.GetRegistry()),
+		pool.WithTracerProvider(tp),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not get reva client selector: %w", err)
+	}
+
+	return gatewaySelector, nil
+}
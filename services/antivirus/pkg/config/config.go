@@ -3,6 +3,8 @@ package config
 import (
 	"context"
 	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/shared"
 )
 
 // ScannerType gives info which scanner is used
@@ -13,6 +15,18 @@ const (
 	ScannerTypeClamAV ScannerType = "clamav"
 	// ScannerTypeICap defines that icap is used
 	ScannerTypeICap ScannerType = "icap"
+	// ScannerTypeHTTP defines that a generic http scan endpoint is used
+	ScannerTypeHTTP ScannerType = "http"
+)
+
+// ScannerPolicy defines when a file is considered infected if several scanners are configured
+type ScannerPolicy string
+
+const (
+	// ScannerPolicyAny defines that a file is infected if any scanner reports an infection
+	ScannerPolicyAny ScannerPolicy = "any"
+	// ScannerPolicyMajority defines that a file is infected if the majority of the scanners report an infection
+	ScannerPolicyMajority ScannerPolicy = "majority"
 )
 
 // MaxScanSizeMode defines the mode of handling files that exceed the maximum scan size
@@ -27,6 +41,8 @@ const (
 
 // Config combines all available configuration parts.
 type Config struct {
+	Commons *shared.Commons `yaml:"-"` // don't use this directly as configuration for a service
+
 	File string
 	Log  *Log
 
@@ -36,13 +52,24 @@ type Config struct {
 
 	Tracing *Tracing `yaml:"tracing"`
 
-	InfectedFileHandling string `yaml:"infected-file-handling" env:"ANTIVIRUS_INFECTED_FILE_HANDLING" desc:"Defines the behaviour when a virus has been found. Supported options are: 'delete', 'continue' and 'abort '. Delete will delete the file. Continue will mark the file as infected but continues further processing. Abort will keep the file in the uploads folder for further admin inspection and will not move it to its final destination." introductionVersion:"1.0.0"`
+	InfectedFileHandling string `yaml:"infected-file-handling" env:"ANTIVIRUS_INFECTED_FILE_HANDLING" desc:"Defines the behaviour when a virus has been found. Supported options are: 'delete', 'continue', 'abort ' and 'quarantine'. Delete will delete the file. Continue will mark the file as infected but continues further processing. Abort will keep the file in the uploads folder for further admin inspection and will not move it to its final destination. Quarantine will move the file to the space configured via ANTIVIRUS_QUARANTINE_SPACE_ID for review by an admin." introductionVersion:"1.0.0"`
 	Events               Events
 	Workers              int `yaml:"workers" env:"ANTIVIRUS_WORKERS" desc:"The number of concurrent go routines that fetch events from the event queue." introductionVersion:"1.0.0"`
 
-	Scanner         Scanner
-	MaxScanSize     string          `yaml:"max-scan-size" env:"ANTIVIRUS_MAX_SCAN_SIZE" desc:"The maximum scan size the virus scanner can handle.0 means unlimited. Usable common abbreviations: [KB, KiB, MB, MiB, GB, GiB, TB, TiB, PB, PiB, EB, EiB], example: 2GB." introductionVersion:"1.0.0"`
-	MaxScanSizeMode MaxScanSizeMode `yaml:"max-scan-size-mode" env:"ANTIVIRUS_MAX_SCAN_SIZE_MODE" desc:"Defines the mode of handling files that exceed the maximum scan size. Supported options are: 'skip', which skips files that are bigger than the max scan size, and 'truncate' (default), which only uses the file up to the max size." introductionVersion:"2.1.0"`
+	Scanner   Scanner
+	ScanCache ScanCache `yaml:"scan_cache"`
+	Rescan    Rescan    `yaml:"rescan"`
+	Store     Store     `yaml:"store"`
+
+	Quarantine   Quarantine    `yaml:"quarantine"`
+	HTTP         HTTPServer    `yaml:"http"`
+	TokenManager *TokenManager `yaml:"token_manager"`
+
+	RevaGateway     string                `yaml:"reva_gateway" env:"OC_REVA_GATEWAY" desc:"CS3 gateway used to walk the spaces when rescanning stored files." introductionVersion:"%%NEXT%%"`
+	GRPCClientTLS   *shared.GRPCClientTLS `yaml:"grpc_client_tls"`
+	ServiceAccount  ServiceAccount        `yaml:"service_account"`
+	MaxScanSize     string                `yaml:"max-scan-size" env:"ANTIVIRUS_MAX_SCAN_SIZE" desc:"The maximum scan size the virus scanner can handle.0 means unlimited. Usable common abbreviations: [KB, KiB, MB, MiB, GB, GiB, TB, TiB, PB, PiB, EB, EiB], example: 2GB." introductionVersion:"1.0.0"`
+	MaxScanSizeMode MaxScanSizeMode       `yaml:"max-scan-size-mode" env:"ANTIVIRUS_MAX_SCAN_SIZE_MODE" desc:"Defines the mode of handling files that exceed the maximum scan size. Supported options are: 'skip', which skips files that are bigger than the max scan size, and 'truncate' (default), which only uses the file up to the max size." introductionVersion:"2.1.0"`
 
 	Context context.Context `json:"-" yaml:"-"`
 
@@ -83,10 +110,13 @@ type Events struct {
 
 // Scanner provides configuration options for the virus scanner
 type Scanner struct {
-	Type ScannerType `yaml:"type" env:"ANTIVIRUS_SCANNER_TYPE" desc:"The antivirus scanner to use. Supported values are 'clamav' and 'icap'." introductionVersion:"1.0.0"`
+	Type   ScannerType   `yaml:"type" env:"ANTIVIRUS_SCANNER_TYPE" desc:"The antivirus scanner to use. Supported values are 'clamav', 'icap' and 'http'." introductionVersion:"1.0.0"`
+	Types  []ScannerType `yaml:"types" env:"ANTIVIRUS_SCANNER_TYPES" desc:"A list of antivirus scanners which scan each file concurrently. Supported values are 'clamav', 'icap' and 'http'. If set, ANTIVIRUS_SCANNER_TYPE is ignored. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	Policy ScannerPolicy `yaml:"policy" env:"ANTIVIRUS_SCANNER_POLICY" desc:"Defines when a file is considered infected if several scanners are configured via ANTIVIRUS_SCANNER_TYPES. Supported options are: 'any', which treats a file as infected as soon as one scanner reports an infection, and 'majority', which requires the majority of the scanners to report an infection." introductionVersion:"%%NEXT%%"`
 
 	ClamAV ClamAV // only if Type == clamav
 	ICAP   ICAP   // only if Type == icap
+	HTTP   HTTP   // only if Type == http
 }
 
 // ClamAV provides configuration option for clamav
@@ -101,3 +131,77 @@ type ICAP struct {
 	URL     string        `yaml:"url" env:"ANTIVIRUS_ICAP_URL" desc:"URL of the ICAP server." introductionVersion:"1.0.0"`
 	Service string        `yaml:"service" env:"ANTIVIRUS_ICAP_SERVICE" desc:"The name of the ICAP service." introductionVersion:"1.0.0"`
 }
+
+// HTTP provides configuration options for a generic http scan endpoint
+type HTTP struct {
+	URL              string        `yaml:"url" env:"ANTIVIRUS_HTTP_URL" desc:"URL of the HTTP scan endpoint. The file is streamed to this endpoint as multipart/form-data POST request." introductionVersion:"%%NEXT%%"`
+	Timeout          time.Duration `yaml:"scan_timeout" env:"ANTIVIRUS_HTTP_SCAN_TIMEOUT" desc:"Scan timeout for the HTTP client. Defaults to '5m' (5 minutes). See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	FormField        string        `yaml:"form_field" env:"ANTIVIRUS_HTTP_FORM_FIELD" desc:"The name of the multipart form field which carries the file." introductionVersion:"%%NEXT%%"`
+	Authorization    string        `yaml:"authorization" env:"ANTIVIRUS_HTTP_AUTHORIZATION" desc:"The value of the Authorization header sent with every scan request, e.g. 'Bearer <token>'. Leave empty to send no Authorization header." introductionVersion:"%%NEXT%%"`
+	InfectedField    string        `yaml:"infected_field" env:"ANTIVIRUS_HTTP_INFECTED_FIELD" desc:"The path to the field of the JSON response which tells if the file is infected, e.g. 'result.infected'. The path uses the GJSON syntax." introductionVersion:"%%NEXT%%"`
+	InfectedValue    string        `yaml:"infected_value" env:"ANTIVIRUS_HTTP_INFECTED_VALUE" desc:"The value of the infected field which marks a file as infected, e.g. 'infected'. The comparison is case-insensitive. If empty, the infected field must be a JSON boolean." introductionVersion:"%%NEXT%%"`
+	DescriptionField string        `yaml:"description_field" env:"ANTIVIRUS_HTTP_DESCRIPTION_FIELD" desc:"The path to the field of the JSON response which contains the signature name of the detected virus. The path uses the GJSON syntax." introductionVersion:"%%NEXT%%"`
+	Insecure         bool          `yaml:"insecure" env:"OC_INSECURE;ANTIVIRUS_HTTP_INSECURE" desc:"Ignore untrusted TLS certificates of the HTTP scan endpoint." introductionVersion:"%%NEXT%%"`
+}
+
+// ScanCache configures the cache for scan results
+type ScanCache struct {
+	Enabled      bool          `yaml:"enabled" env:"ANTIVIRUS_SCAN_CACHE_ENABLED" desc:"Enable caching of scan results. Files with identical content are not scanned again as long as the signature database of the scanner does not change. The content is identified by the checksum computed by the storage. Results of scanners which do not report the version of their signature database, like the 'http' scanner, are not cached." introductionVersion:"%%NEXT%%"`
+	Store        string        `yaml:"store" env:"OC_CACHE_STORE;ANTIVIRUS_SCAN_CACHE_STORE" desc:"The type of the cache store. Supported values are: 'memory', 'redis-sentinel', 'nats-js-kv', 'noop'. See the text description for details." introductionVersion:"%%NEXT%%"`
+	Nodes        []string      `yaml:"nodes" env:"OC_CACHE_STORE_NODES;ANTIVIRUS_SCAN_CACHE_STORE_NODES" desc:"A list of nodes to access the configured store. This has no effect when 'memory' store is configured. Note that the behaviour how nodes are used is dependent on the library of the configured store. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	Database     string        `yaml:"database" env:"ANTIVIRUS_SCAN_CACHE_STORE_DATABASE" desc:"The database name the configured store should use." introductionVersion:"%%NEXT%%"`
+	Table        string        `yaml:"table" env:"ANTIVIRUS_SCAN_CACHE_STORE_TABLE" desc:"The database table the store should use." introductionVersion:"%%NEXT%%"`
+	TTL          time.Duration `yaml:"ttl" env:"ANTIVIRUS_SCAN_CACHE_TTL" desc:"Time to live for cached scan results. Defaults to '168h' (1 week). See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	AuthUsername string        `yaml:"username" env:"OC_CACHE_AUTH_USERNAME;ANTIVIRUS_SCAN_CACHE_AUTH_USERNAME" desc:"The username to authenticate with the cache. Only applies when store type 'nats-js-kv' is configured." introductionVersion:"%%NEXT%%"`
+	AuthPassword string        `yaml:"password" env:"OC_CACHE_AUTH_PASSWORD;ANTIVIRUS_SCAN_CACHE_AUTH_PASSWORD" desc:"The password to authenticate with the cache. Only applies when store type 'nats-js-kv' is configured." introductionVersion:"%%NEXT%%"`
+}
+
+// Rescan configures the periodic rescan of stored files
+type Rescan struct {
+	Enabled   bool          `yaml:"enabled" env:"ANTIVIRUS_RESCAN_ENABLED" desc:"Enable the periodic rescan of already stored files. A rescan of all personal and project spaces is started whenever the signature database of the scanner changes." introductionVersion:"%%NEXT%%"`
+	Interval  time.Duration `yaml:"interval" env:"ANTIVIRUS_RESCAN_INTERVAL" desc:"The interval in which the scanner is checked for a new signature database version. For scanners not reporting a version, a rescan is started when this interval has elapsed since the previous rescan finished. Defaults to '24h'. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	RateLimit int           `yaml:"rate_limit" env:"ANTIVIRUS_RESCAN_RATE_LIMIT" desc:"The maximum number of files rescanned per second. 0 means unlimited." introductionVersion:"%%NEXT%%"`
+}
+
+// Store configures the store used to persist the rescan progress
+type Store struct {
+	Store        string   `yaml:"store" env:"OC_PERSISTENT_STORE;ANTIVIRUS_STORE" desc:"The type of the store. Supported values are: 'memory', 'redis-sentinel', 'nats-js-kv', 'noop'. See the text description for details." introductionVersion:"%%NEXT%%"`
+	Nodes        []string `yaml:"nodes" env:"OC_PERSISTENT_STORE_NODES;ANTIVIRUS_STORE_NODES" desc:"A list of nodes to access the configured store. This has no effect when 'memory' store is configured. Note that the behaviour how nodes are used is dependent on the library of the configured store. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	Database     string   `yaml:"database" env:"ANTIVIRUS_STORE_DATABASE" desc:"The database name the configured store should use." introductionVersion:"%%NEXT%%"`
+	Table        string   `yaml:"table" env:"ANTIVIRUS_STORE_TABLE" desc:"The database table the store should use." introductionVersion:"%%NEXT%%"`
+	AuthUsername string   `yaml:"username" env:"OC_PERSISTENT_STORE_AUTH_USERNAME;ANTIVIRUS_STORE_AUTH_USERNAME" desc:"The username to authenticate with the store. Only applies when store type 'nats-js-kv' is configured." introductionVersion:"%%NEXT%%"`
+	AuthPassword string   `yaml:"password" env:"OC_PERSISTENT_STORE_AUTH_PASSWORD;ANTIVIRUS_STORE_AUTH_PASSWORD" desc:"The password to authenticate with the store. Only applies when store type 'nats-js-kv' is configured." introductionVersion:"%%NEXT%%"`
+}
+
+// ServiceAccount is the configuration for the used service account
+type ServiceAccount struct {
+	ServiceAccountID     string `yaml:"service_account_id" env:"OC_SERVICE_ACCOUNT_ID;ANTIVIRUS_SERVICE_ACCOUNT_ID" desc:"The ID of the service account the service should use. See the 'auth-service' service description for more details." introductionVersion:"%%NEXT%%"`
+	ServiceAccountSecret string `yaml:"service_account_secret" env:"OC_SERVICE_ACCOUNT_SECRET;ANTIVIRUS_SERVICE_ACCOUNT_SECRET" desc:"The service account secret." introductionVersion:"%%NEXT%%"`
+}
+
+// Quarantine configures the quarantine for infected files
+type Quarantine struct {
+	SpaceID string `yaml:"space_id" env:"ANTIVIRUS_QUARANTINE_SPACE_ID" desc:"The ID of the project space infected files are moved to when ANTIVIRUS_INFECTED_FILE_HANDLING is set to 'quarantine'. The space needs to be created beforehand, only its managers can review, release and delete the quarantined files." introductionVersion:"%%NEXT%%"`
+}
+
+// CORS defines the available cors configuration.
+type CORS struct {
+	AllowedOrigins   []string `yaml:"allow_origins" env:"OC_CORS_ALLOW_ORIGINS;ANTIVIRUS_CORS_ALLOW_ORIGINS" desc:"A list of allowed CORS origins. See following chapter for more details: *Access-Control-Allow-Origin* at https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Access-Control-Allow-Origin. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	AllowedMethods   []string `yaml:"allow_methods" env:"OC_CORS_ALLOW_METHODS;ANTIVIRUS_CORS_ALLOW_METHODS" desc:"A list of allowed CORS methods. See following chapter for more details: *Access-Control-Request-Method* at https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Access-Control-Request-Method. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	AllowedHeaders   []string `yaml:"allow_headers" env:"OC_CORS_ALLOW_HEADERS;ANTIVIRUS_CORS_ALLOW_HEADERS" desc:"A list of allowed CORS headers. See following chapter for more details: *Access-Control-Request-Headers* at https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Access-Control-Request-Headers. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	AllowCredentials bool     `yaml:"allow_credentials" env:"OC_CORS_ALLOW_CREDENTIALS;ANTIVIRUS_CORS_ALLOW_CREDENTIALS" desc:"Allow credentials for CORS.See following chapter for more details: *Access-Control-Allow-Credentials* at https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Access-Control-Allow-Credentials." introductionVersion:"%%NEXT%%"`
+}
+
+// HTTPServer defines the available http configuration of the quarantine api.
+type HTTPServer struct {
+	Addr      string                `yaml:"addr" env:"ANTIVIRUS_HTTP_ADDR" desc:"The bind address of the HTTP service. It serves the quarantine API and is only started when ANTIVIRUS_INFECTED_FILE_HANDLING is set to 'quarantine'." introductionVersion:"%%NEXT%%"`
+	Namespace string                `yaml:"-"`
+	Root      string                `yaml:"root" env:"ANTIVIRUS_HTTP_ROOT" desc:"Subdirectory that serves as the root for this HTTP service." introductionVersion:"%%NEXT%%"`
+	CORS      CORS                  `yaml:"cors"`
+	TLS       shared.HTTPServiceTLS `yaml:"tls"`
+}
+
+// TokenManager is the config for using the reva token manager
+type TokenManager struct {
+	JWTSecret string `yaml:"jwt_secret" env:"OC_JWT_SECRET;ANTIVIRUS_JWT_SECRET" desc:"The secret to mint and validate jwt tokens." introductionVersion:"%%NEXT%%"`
+}
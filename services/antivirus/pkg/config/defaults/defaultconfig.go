@@ -3,6 +3,8 @@ package defaults
 import (
 	"time"
 
+	"github.com/opencloud-eu/opencloud/pkg/shared"
+	"github.com/opencloud-eu/opencloud/pkg/structs"
 	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
 )
 
@@ -34,8 +36,36 @@ func DefaultConfig() *config.Config {
 		// https://github.com/Cisco-Talos/clamav/blob/main/etc/clamd.conf.sample
 		MaxScanSize:     "100MB",
 		MaxScanSizeMode: config.MaxScanSizeModePartial,
+		RevaGateway:     shared.DefaultRevaConfig().Address,
+		Rescan: config.Rescan{
+			Interval:  24 * time.Hour,
+			RateLimit: 10,
+		},
+		Store: config.Store{
+			Store:    "nats-js-kv",
+			Nodes:    []string{"127.0.0.1:9233"},
+			Database: "antivirus",
+		},
+		HTTP: config.HTTPServer{
+			Addr:      "127.0.0.1:9276",
+			Root:      "/",
+			Namespace: "eu.opencloud.web",
+			CORS: config.CORS{
+				AllowedOrigins:   []string{"*"},
+				AllowedMethods:   []string{"GET", "POST", "DELETE"},
+				AllowedHeaders:   []string{"Authorization", "Origin", "Content-Type", "Accept", "X-Requested-With", "X-Request-Id"},
+				AllowCredentials: true,
+			},
+		},
+		ScanCache: config.ScanCache{
+			Store:    "nats-js-kv",
+			Nodes:    []string{"127.0.0.1:9233"},
+			Database: "cache-antivirus",
+			TTL:      7 * 24 * time.Hour,
+		},
 		Scanner: config.Scanner{
-			Type: config.ScannerTypeClamAV,
+			Type:   config.ScannerTypeClamAV,
+			Policy: config.ScannerPolicyAny,
 			ClamAV: config.ClamAV{
 				Socket:  "/run/clamav/clamd.ctl",
 				Timeout: 5 * time.Minute,
@@ -45,6 +75,13 @@ func DefaultConfig() *config.Config {
 				Service: "avscan",
 				Timeout: 5 * time.Minute,
 			},
+			HTTP: config.HTTP{
+				URL:              "http://127.0.0.1:8080/scan",
+				Timeout:          5 * time.Minute,
+				FormField:        "file",
+				InfectedField:    "infected",
+				DescriptionField: "description",
+			},
 		},
 	}
 }
@@ -58,6 +95,22 @@ func EnsureDefaults(cfg *config.Config) {
 	if cfg.Tracing == nil {
 		cfg.Tracing = &config.Tracing{}
 	}
+
+	if cfg.GRPCClientTLS == nil && cfg.Commons != nil {
+		cfg.GRPCClientTLS = structs.CopyOrZeroValue(cfg.Commons.GRPCClientTLS)
+	}
+
+	if cfg.TokenManager == nil && cfg.Commons != nil && cfg.Commons.TokenManager != nil {
+		cfg.TokenManager = &config.TokenManager{
+			JWTSecret: cfg.Commons.TokenManager.JWTSecret,
+		}
+	} else if cfg.TokenManager == nil {
+		cfg.TokenManager = &config.TokenManager{}
+	}
+
+	if cfg.Commons != nil {
+		cfg.HTTP.TLS = cfg.Commons.HTTPServiceTLS
+	}
 }
 
 // Sanitize sanitizes the configuration
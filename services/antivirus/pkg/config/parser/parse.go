@@ -2,12 +2,15 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 
 	occfg "github.com/opencloud-eu/opencloud/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config/defaults"
 
 	"github.com/opencloud-eu/opencloud/pkg/config/envdecode"
+	"github.com/opencloud-eu/opencloud/pkg/quarantine"
+	"github.com/opencloud-eu/opencloud/pkg/shared"
 )
 
 // ParseConfig loads configuration from known paths.
@@ -34,5 +37,28 @@ func ParseConfig(cfg *config.Config) error {
 
 // Validate validates our little config
 func Validate(cfg *config.Config) error {
+	isQuarantine := cfg.InfectedFileHandling == string(quarantine.PPOutcomeQuarantine)
+
+	if isQuarantine {
+		if cfg.Quarantine.SpaceID == "" {
+			return fmt.Errorf("the quarantine space has not been configured for %s, "+
+				"infected file handling 'quarantine' requires ANTIVIRUS_QUARANTINE_SPACE_ID to be set", cfg.Service.Name)
+		}
+
+		if cfg.TokenManager.JWTSecret == "" {
+			return shared.MissingJWTTokenError(cfg.Service.Name)
+		}
+	}
+
+	// the scan cache reads the checksums of the files with the service account
+	if isQuarantine || cfg.Rescan.Enabled || cfg.ScanCache.Enabled {
+		if cfg.ServiceAccount.ServiceAccountID == "" {
+			return shared.MissingServiceAccountID(cfg.Service.Name)
+		}
+		if cfg.ServiceAccount.ServiceAccountSecret == "" {
+			return shared.MissingServiceAccountSecret(cfg.Service.Name)
+		}
+	}
+
 	return nil
 }
@@ -49,3 +49,21 @@ func (s ClamAV) Scan(in Input) (Result, error) {
 		}, nil
 	}
 }
+
+// Version returns the clamav program and signature database version
+func (s ClamAV) Version() (string, error) {
+	ch, err := s.clamd.Version()
+	if err != nil {
+		return "", err
+	}
+
+	select {
+	case <-time.After(s.timeout):
+		return "", fmt.Errorf("%w: version", ErrScanTimeout)
+	case v, ok := <-ch:
+		if !ok || v == nil {
+			return "", fmt.Errorf("%w: no version received", ErrScannerNotReachable)
+		}
+		return v.Raw, nil
+	}
+}
@@ -0,0 +1,163 @@
+package scanners
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// HTTPOptions defines the available options for the HTTP scanner
+type HTTPOptions struct {
+	// URL is the scan endpoint the file gets posted to
+	URL string
+	// Timeout is the maximum duration of a single scan request
+	Timeout time.Duration
+	// FormField is the name of the multipart form field which carries the file
+	FormField string
+	// Headers are added to every scan request, e.g. to authenticate against the scanner
+	Headers map[string]string
+	// InfectedField is the gjson path of the infected flag in the response document
+	InfectedField string
+	// InfectedValue is the value of InfectedField which marks a file as infected,
+	// if empty, InfectedField is expected to be a boolean
+	InfectedValue string
+	// DescriptionField is the gjson path of the signature name in the response document
+	DescriptionField string
+	// Insecure skips the tls verification of the scanner endpoint
+	Insecure bool
+}
+
+// NewHTTP returns a Scanner talking to a generic HTTP scan endpoint
+func NewHTTP(o HTTPOptions) (HTTP, error) {
+	if _, err := url.ParseRequestURI(o.URL); err != nil {
+		return HTTP{}, err
+	}
+
+	if o.FormField == "" {
+		return HTTP{}, fmt.Errorf("http scanner: form field must not be empty")
+	}
+
+	if o.InfectedField == "" {
+		return HTTP{}, fmt.Errorf("http scanner: infected field must not be empty")
+	}
+
+	return HTTP{
+		Client: &http.Client{
+			Timeout: o.Timeout,
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: o.Insecure, //nolint:gosec
+				},
+			},
+		},
+		options: o,
+	}, nil
+}
+
+// HTTP is responsible for scanning files using a HTTP endpoint which
+// accepts multipart uploads and responds with a json document
+type HTTP struct {
+	Client  *http.Client
+	options HTTPOptions
+}
+
+// Scan streams the input to the scan endpoint and evaluates the response
+func (s HTTP) Scan(in Input) (Result, error) {
+	result := Result{}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	// stream the body instead of buffering it, files might be huge
+	go func() {
+		part, err := mw.CreateFormFile(s.options.FormField, formFileName(in.Name))
+		if err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+
+		if in.Body != nil {
+			if _, err := io.Copy(part, in.Body); err != nil {
+				_ = pw.CloseWithError(err)
+				return
+			}
+		}
+
+		_ = pw.CloseWithError(mw.Close())
+	}()
+
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, s.options.URL, pr)
+	if err != nil {
+		_ = pr.Close()
+		return result, err
+	}
+
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	for k, v := range s.options.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		_ = pr.Close()
+		return result, fmt.Errorf("%w: %w", ErrScannerNotReachable, err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	// the scanner might answer before consuming the whole body, unblock the writer
+	_ = pr.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return result, fmt.Errorf("unexpected status code from http scanner %v", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return result, err
+	}
+
+	if !gjson.ValidBytes(body) {
+		return result, fmt.Errorf("http scanner responded with an invalid json document")
+	}
+
+	infected := gjson.GetBytes(body, s.options.InfectedField)
+	if !infected.Exists() {
+		return result, fmt.Errorf("http scanner response does not contain field '%s'", s.options.InfectedField)
+	}
+
+	switch {
+	case s.options.InfectedValue != "":
+		result.Infected = strings.EqualFold(infected.String(), s.options.InfectedValue)
+	case infected.IsBool():
+		result.Infected = infected.Bool()
+	default:
+		return result, fmt.Errorf("http scanner response field '%s' is not a boolean", s.options.InfectedField)
+	}
+
+	if s.options.DescriptionField != "" {
+		result.Description = gjson.GetBytes(body, s.options.DescriptionField).String()
+	}
+
+	result.ScanTime = time.Now()
+
+	return result, nil
+}
+
+func formFileName(name string) string {
+	if name == "" {
+		return "file"
+	}
+
+	return name
+}
@@ -0,0 +1,175 @@
+package scanners_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/scanners"
+)
+
+func newHTTPScanServer(t testing.TB, status int, response string) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		// the handler runs outside of the test goroutine, it must not stop the test
+		f, fh, err := r.FormFile("upload")
+		if !assert.NoError(t, err) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		b, err := io.ReadAll(f)
+		if !assert.NoError(t, err) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		assert.Equal(t, "DATA", string(b))
+		assert.Equal(t, "report.pdf", fh.Filename)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestNewHTTP(t *testing.T) {
+	t.Run("fails with an invalid url", func(t *testing.T) {
+		_, err := scanners.NewHTTP(scanners.HTTPOptions{URL: "::", FormField: "file", InfectedField: "infected"})
+		assert.Error(t, err)
+	})
+
+	t.Run("fails without a form field", func(t *testing.T) {
+		_, err := scanners.NewHTTP(scanners.HTTPOptions{URL: "http://localhost", InfectedField: "infected"})
+		assert.Error(t, err)
+	})
+
+	t.Run("fails without an infected field", func(t *testing.T) {
+		_, err := scanners.NewHTTP(scanners.HTTPOptions{URL: "http://localhost", FormField: "file"})
+		assert.Error(t, err)
+	})
+}
+
+func TestHTTP_Scan(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		response    string
+		options     scanners.HTTPOptions
+		infected    bool
+		description string
+		wantErr     bool
+	}{
+		{
+			name:     "clean file",
+			status:   http.StatusOK,
+			response: `{"infected": false}`,
+			options:  scanners.HTTPOptions{InfectedField: "infected", DescriptionField: "signature"},
+		},
+		{
+			name:        "infected file",
+			status:      http.StatusOK,
+			response:    `{"infected": true, "signature": "Win.Test.EICAR_HDB-1"}`,
+			options:     scanners.HTTPOptions{InfectedField: "infected", DescriptionField: "signature"},
+			infected:    true,
+			description: "Win.Test.EICAR_HDB-1",
+		},
+		{
+			name:        "nested fields",
+			status:      http.StatusOK,
+			response:    `{"result": {"verdict": {"malicious": true}, "threats": [{"name": "Eicar"}]}}`,
+			options:     scanners.HTTPOptions{InfectedField: "result.verdict.malicious", DescriptionField: "result.threats.0.name"},
+			infected:    true,
+			description: "Eicar",
+		},
+		{
+			name:        "infected value",
+			status:      http.StatusOK,
+			response:    `{"status": "INFECTED", "virus": "Eicar"}`,
+			options:     scanners.HTTPOptions{InfectedField: "status", InfectedValue: "infected", DescriptionField: "virus"},
+			infected:    true,
+			description: "Eicar",
+		},
+		{
+			name:     "infected value mismatch",
+			status:   http.StatusOK,
+			response: `{"status": "clean"}`,
+			options:  scanners.HTTPOptions{InfectedField: "status", InfectedValue: "infected"},
+		},
+		{
+			name:     "missing infected field",
+			status:   http.StatusOK,
+			response: `{"clean": true}`,
+			options:  scanners.HTTPOptions{InfectedField: "infected"},
+			wantErr:  true,
+		},
+		{
+			name:     "infected field is not a boolean",
+			status:   http.StatusOK,
+			response: `{"infected": "yes"}`,
+			options:  scanners.HTTPOptions{InfectedField: "infected"},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid json",
+			status:   http.StatusOK,
+			response: `<html></html>`,
+			options:  scanners.HTTPOptions{InfectedField: "infected"},
+			wantErr:  true,
+		},
+		{
+			name:     "unexpected status code",
+			status:   http.StatusInternalServerError,
+			response: `{"infected": false}`,
+			options:  scanners.HTTPOptions{InfectedField: "infected"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newHTTPScanServer(t, tt.status, tt.response)
+
+			tt.options.URL = srv.URL
+			tt.options.FormField = "upload"
+			tt.options.Timeout = 10 * time.Second
+			tt.options.Headers = map[string]string{"Authorization": "Bearer secret"}
+
+			scanner, err := scanners.NewHTTP(tt.options)
+			require.NoError(t, err)
+
+			result, err := scanner.Scan(scanners.Input{Body: strings.NewReader("DATA"), Size: 4, Name: "report.pdf"})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.infected, result.Infected)
+			assert.Equal(t, tt.description, result.Description)
+			assert.False(t, result.ScanTime.IsZero())
+		})
+	}
+
+	t.Run("scanner not reachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		scanner, err := scanners.NewHTTP(scanners.HTTPOptions{URL: srv.URL, FormField: "file", InfectedField: "infected"})
+		require.NoError(t, err)
+
+		_, err = scanner.Scan(scanners.Input{Body: strings.NewReader("DATA")})
+		assert.ErrorIs(t, err, scanners.ErrScannerNotReachable)
+	})
+}
@@ -110,3 +110,18 @@ func (s ICAP) Scan(in Input) (Result, error) {
 
 	return result, nil
 }
+
+// Version returns the ISTag of the ICAP service, which changes whenever the signature database is updated
+func (s ICAP) Version() (string, error) {
+	req, err := ic.NewRequest(context.TODO(), ic.MethodOPTIONS, s.URL, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	return res.Header.Get("ISTag"), nil
+}
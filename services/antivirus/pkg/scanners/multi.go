@@ -0,0 +1,193 @@
+package scanners
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
+)
+
+// errScanDone is used to detach a scanner from the body once it finished scanning
+var errScanDone = errors.New("scan done")
+
+// Backend is the common interface of all scanners
+type Backend interface {
+	Scan(in Input) (Result, error)
+}
+
+// NewMulti returns a Scanner which runs all given backends concurrently
+func NewMulti(policy config.ScannerPolicy, backends map[string]Backend) (Multi, error) {
+	switch policy {
+	case config.ScannerPolicyAny, config.ScannerPolicyMajority:
+	default:
+		return Multi{}, fmt.Errorf("unknown scanner policy '%s'", policy)
+	}
+
+	if len(backends) == 0 {
+		return Multi{}, errors.New("multi scanner: no scanners given")
+	}
+
+	return Multi{policy: policy, backends: backends}, nil
+}
+
+// Multi is a Scanner which fans out a single input to several scanners
+// and combines their results according to its policy
+type Multi struct {
+	policy   config.ScannerPolicy
+	backends map[string]Backend
+}
+
+// Version combines the versions of all backends, it fails with ErrUnversioned if one of them can not report a version
+func (m Multi) Version() (string, error) {
+	versions := make([]string, 0, len(m.backends))
+	for name, backend := range m.backends {
+		v, ok := backend.(Versioner)
+		if !ok {
+			return "", fmt.Errorf("%s: %w", name, ErrUnversioned)
+		}
+
+		version, err := v.Version()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+
+		versions = append(versions, name+"="+version)
+	}
+
+	slices.Sort(versions)
+
+	return strings.Join(versions, ";"), nil
+}
+
+type multiOutcome struct {
+	name   string
+	result Result
+	err    error
+}
+
+// Scan tees the input body to all scanners and evaluates their results
+func (m Multi) Scan(in Input) (Result, error) {
+	var wg sync.WaitGroup
+	outcomes := make(chan multiOutcome, len(m.backends))
+	writers := make([]*io.PipeWriter, 0, len(m.backends))
+
+	for name, backend := range m.backends {
+		pr, pw := io.Pipe()
+		writers = append(writers, pw)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := backend.Scan(Input{Body: pr, Size: in.Size, Url: in.Url, Name: in.Name})
+			// the scanner doesn't need any more data, make sure it doesn't block the others
+			_ = pr.CloseWithError(errScanDone)
+
+			outcomes <- multiOutcome{name: name, result: res, err: err}
+		}()
+	}
+
+	var copyErr error
+	if in.Body != nil {
+		_, copyErr = io.Copy(&fanOut{writers: writers}, in.Body)
+	}
+	for _, w := range writers {
+		// a nil error signals EOF to the scanners
+		_ = w.CloseWithError(copyErr)
+	}
+
+	wg.Wait()
+	close(outcomes)
+
+	// a scanner tolerating a short read might report a clean result for a partial body
+	if copyErr != nil && !errors.Is(copyErr, errScanDone) {
+		return Result{}, fmt.Errorf("multi scanner: could not read the body: %w", copyErr)
+	}
+
+	return m.evaluate(outcomes)
+}
+
+func (m Multi) evaluate(outcomes <-chan multiOutcome) (Result, error) {
+	var (
+		infected, failed int
+		descriptions     []string
+		errs             []error
+	)
+
+	for o := range outcomes {
+		switch {
+		case o.err != nil:
+			failed++
+			errs = append(errs, fmt.Errorf("%s: %w", o.name, o.err))
+		case o.result.Infected:
+			infected++
+		}
+
+		if o.result.Description != "" {
+			descriptions = append(descriptions, o.name+": "+o.result.Description)
+		}
+	}
+
+	// map iteration order is random, keep the description stable
+	slices.Sort(descriptions)
+
+	result := Result{
+		ScanTime:    time.Now(),
+		Description: strings.Join(descriptions, "; "),
+	}
+
+	switch m.policy {
+	case config.ScannerPolicyMajority:
+		quorum := len(m.backends)/2 + 1
+		result.Infected = infected >= quorum
+		// the failed scanners might have tipped the scale
+		if !result.Infected && infected+failed >= quorum {
+			return result, errors.Join(errs...)
+		}
+	default:
+		result.Infected = infected > 0
+		// a failed scanner might have found something
+		if !result.Infected && failed > 0 {
+			return result, errors.Join(errs...)
+		}
+	}
+
+	return result, nil
+}
+
+// fanOut writes to all pipes and drops the ones which have been closed by their reader
+type fanOut struct {
+	writers []*io.PipeWriter
+	closed  []bool
+}
+
+func (f *fanOut) Write(p []byte) (int, error) {
+	if f.closed == nil {
+		f.closed = make([]bool, len(f.writers))
+	}
+
+	active := 0
+	for i, w := range f.writers {
+		if f.closed[i] {
+			continue
+		}
+
+		if _, err := w.Write(p); err != nil {
+			f.closed[i] = true
+			continue
+		}
+
+		active++
+	}
+
+	if active == 0 {
+		return 0, errScanDone
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,229 @@
+package scanners_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/scanners"
+)
+
+type fakeBackend struct {
+	result scanners.Result
+	err    error
+	// read defines how many bytes are consumed before returning, -1 reads everything
+	read int64
+	body *strings.Builder
+}
+
+func (f *fakeBackend) Scan(in scanners.Input) (scanners.Result, error) {
+	r := in.Body
+	if f.read >= 0 {
+		r = io.LimitReader(in.Body, f.read)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return scanners.Result{}, err
+	}
+
+	if f.body != nil {
+		f.body.Write(b)
+	}
+
+	return f.result, f.err
+}
+
+func TestNewMulti(t *testing.T) {
+	t.Run("fails with an unknown policy", func(t *testing.T) {
+		_, err := scanners.NewMulti("unknown", map[string]scanners.Backend{"a": &fakeBackend{}})
+		assert.Error(t, err)
+	})
+
+	t.Run("fails without backends", func(t *testing.T) {
+		_, err := scanners.NewMulti(config.ScannerPolicyAny, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestMulti_Scan(t *testing.T) {
+	var (
+		clean    = func() *fakeBackend { return &fakeBackend{read: -1} }
+		infected = func(d string) *fakeBackend {
+			return &fakeBackend{read: -1, result: scanners.Result{Infected: true, Description: d}}
+		}
+		failing = func() *fakeBackend { return &fakeBackend{read: -1, err: errors.New("unreachable")} }
+	)
+
+	tests := []struct {
+		name        string
+		policy      config.ScannerPolicy
+		backends    map[string]scanners.Backend
+		infected    bool
+		description string
+		wantErr     bool
+	}{
+		{
+			name:     "any: all clean",
+			policy:   config.ScannerPolicyAny,
+			backends: map[string]scanners.Backend{"a": clean(), "b": clean()},
+		},
+		{
+			name:        "any: one infected",
+			policy:      config.ScannerPolicyAny,
+			backends:    map[string]scanners.Backend{"a": clean(), "b": infected("Eicar")},
+			infected:    true,
+			description: "b: Eicar",
+		},
+		{
+			name:        "any: one infected, one failed",
+			policy:      config.ScannerPolicyAny,
+			backends:    map[string]scanners.Backend{"a": failing(), "b": infected("Eicar")},
+			infected:    true,
+			description: "b: Eicar",
+		},
+		{
+			name:     "any: one clean, one failed",
+			policy:   config.ScannerPolicyAny,
+			backends: map[string]scanners.Backend{"a": clean(), "b": failing()},
+			wantErr:  true,
+		},
+		{
+			name:        "majority: one of three infected",
+			policy:      config.ScannerPolicyMajority,
+			backends:    map[string]scanners.Backend{"a": clean(), "b": infected("Eicar"), "c": clean()},
+			description: "b: Eicar",
+		},
+		{
+			name:        "majority: two of three infected",
+			policy:      config.ScannerPolicyMajority,
+			backends:    map[string]scanners.Backend{"a": infected("Eicar"), "b": infected("Eicar-Test"), "c": clean()},
+			infected:    true,
+			description: "a: Eicar; b: Eicar-Test",
+		},
+		{
+			name:        "majority: one of two infected",
+			policy:      config.ScannerPolicyMajority,
+			backends:    map[string]scanners.Backend{"a": infected("Eicar"), "b": clean()},
+			description: "a: Eicar",
+		},
+		{
+			name:        "majority: failed scanner could tip the scale",
+			policy:      config.ScannerPolicyMajority,
+			backends:    map[string]scanners.Backend{"a": infected("Eicar"), "b": failing(), "c": clean()},
+			description: "a: Eicar",
+			wantErr:     true,
+		},
+		{
+			name:     "majority: failed scanner can't tip the scale",
+			policy:   config.ScannerPolicyMajority,
+			backends: map[string]scanners.Backend{"a": clean(), "b": failing(), "c": clean()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner, err := scanners.NewMulti(tt.policy, tt.backends)
+			require.NoError(t, err)
+
+			result, err := scanner.Scan(scanners.Input{Body: strings.NewReader("DATA")})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.infected, result.Infected)
+			assert.Equal(t, tt.description, result.Description)
+		})
+	}
+
+	t.Run("all scanners receive the whole body", func(t *testing.T) {
+		data := strings.Repeat("DATA", 100000)
+		a, b := &strings.Builder{}, &strings.Builder{}
+
+		scanner, err := scanners.NewMulti(config.ScannerPolicyAny, map[string]scanners.Backend{
+			"a": &fakeBackend{read: -1, body: a},
+			"b": &fakeBackend{read: -1, body: b},
+		})
+		require.NoError(t, err)
+
+		_, err = scanner.Scan(scanners.Input{Body: strings.NewReader(data)})
+		require.NoError(t, err)
+		assert.Equal(t, data, a.String())
+		assert.Equal(t, data, b.String())
+	})
+
+	t.Run("fails if the body can not be read completely", func(t *testing.T) {
+		scanner, err := scanners.NewMulti(config.ScannerPolicyAny, map[string]scanners.Backend{
+			"a": tolerantBackend{},
+		})
+		require.NoError(t, err)
+
+		_, err = scanner.Scan(scanners.Input{Body: io.MultiReader(strings.NewReader("DATA"), iotest.ErrReader(errors.New("broken")))})
+		assert.Error(t, err)
+	})
+
+	t.Run("a scanner which stops reading does not block the others", func(t *testing.T) {
+		data := strings.Repeat("DATA", 100000)
+		b := &strings.Builder{}
+
+		scanner, err := scanners.NewMulti(config.ScannerPolicyAny, map[string]scanners.Backend{
+			"a": &fakeBackend{read: 10},
+			"b": &fakeBackend{read: -1, body: b},
+		})
+		require.NoError(t, err)
+
+		_, err = scanner.Scan(scanners.Input{Body: strings.NewReader(data)})
+		require.NoError(t, err)
+		assert.Equal(t, data, b.String())
+	})
+}
+
+// tolerantBackend reports a clean result for whatever it could read
+type tolerantBackend struct{}
+
+func (tolerantBackend) Scan(in scanners.Input) (scanners.Result, error) {
+	_, _ = io.ReadAll(in.Body)
+	return scanners.Result{}, nil
+}
+
+type versionedBackend struct {
+	fakeBackend
+	version string
+}
+
+func (v *versionedBackend) Version() (string, error) {
+	return v.version, nil
+}
+
+func TestMulti_Version(t *testing.T) {
+	t.Run("combines the versions of all scanners", func(t *testing.T) {
+		scanner, err := scanners.NewMulti(config.ScannerPolicyAny, map[string]scanners.Backend{
+			"b": &versionedBackend{version: "2"},
+			"a": &versionedBackend{version: "1"},
+		})
+		require.NoError(t, err)
+
+		version, err := scanner.Version()
+		require.NoError(t, err)
+		assert.Equal(t, "a=1;b=2", version)
+	})
+
+	t.Run("fails if one scanner does not report a version", func(t *testing.T) {
+		scanner, err := scanners.NewMulti(config.ScannerPolicyAny, map[string]scanners.Backend{
+			"a": &versionedBackend{version: "1"},
+			"b": &fakeBackend{},
+		})
+		require.NoError(t, err)
+
+		_, err = scanner.Version()
+		assert.ErrorIs(t, err, scanners.ErrUnversioned)
+	})
+}
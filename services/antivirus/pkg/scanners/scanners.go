@@ -11,9 +11,16 @@ var (
 	ErrScanTimeout = errors.New("time out waiting for clamav to respond while scanning")
 	// ErrScannerNotReachable is returned when the scanner is not reachable
 	ErrScannerNotReachable = errors.New("failed to reach the scanner")
+	// ErrUnversioned is returned when a scanner does not report the version of its signature database
+	ErrUnversioned = errors.New("the scanner does not report a signature version")
 )
 
 type (
+	// Versioner is implemented by scanners which can report the version of their signature database
+	Versioner interface {
+		Version() (string, error)
+	}
+
 	// The Result is the common scan result to all scanners
 	Result struct {
 		Infected    bool
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
+)
+
+// QuarantineHandler serves the api to review the quarantined files
+type QuarantineHandler struct {
+	av  Antivirus
+	mux *chi.Mux
+}
+
+// NewQuarantineHandler returns the http handler of the quarantine api
+func NewQuarantineHandler(av Antivirus, mux *chi.Mux) *QuarantineHandler {
+	h := &QuarantineHandler{av: av, mux: mux}
+
+	mux.Route("/antivirus/v1/quarantine", func(r chi.Router) {
+		r.Get("/", h.HandleList)
+		r.Post("/{id}/release", h.HandleRelease)
+		r.Delete("/{id}", h.HandleDelete)
+	})
+
+	return h
+}
+
+// ServeHTTP fulfills Handler interface
+func (h *QuarantineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// HandleList is the GET handler listing the quarantined files
+func (h *QuarantineHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.token(w, r)
+	if !ok {
+		return
+	}
+
+	files, err := h.av.ListQuarantine(token)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, files)
+}
+
+// HandleRelease is the POST handler restoring a quarantined file to its original location
+func (h *QuarantineHandler) HandleRelease(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.token(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.av.ReleaseQuarantined(token, chi.URLParam(r, "id")); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleDelete is the DELETE handler removing a quarantined file
+func (h *QuarantineHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.token(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.av.DeleteQuarantined(token, chi.URLParam(r, "id")); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// token returns the reva token of the authenticated user
+func (h *QuarantineHandler) token(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if _, ok := revactx.ContextGetUser(r.Context()); !ok {
+		h.av.log.Error().Int("returned statuscode", http.StatusUnauthorized).Msg("user unauthorized")
+		w.WriteHeader(http.StatusUnauthorized)
+		return "", false
+	}
+
+	return r.Header.Get(revactx.TokenHeader), true
+}
+
+func (h *QuarantineHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNotQuarantined):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, ErrForbidden):
+		w.WriteHeader(http.StatusForbidden)
+	default:
+		h.av.log.Error().Err(err).Str("path", r.URL.Path).Msg("quarantine request failed")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
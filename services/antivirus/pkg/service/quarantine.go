@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/google/uuid"
+	ctxpkg "github.com/opencloud-eu/reva/v2/pkg/ctx"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/rhttp"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	"github.com/opencloud-eu/reva/v2/pkg/utils"
+	microstore "go-micro.dev/v4/store"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/scanners"
+)
+
+// the arbitrary metadata keys of a quarantined file
+const (
+	QuarantineDescriptionKey = "opencloud.quarantine.description"
+	QuarantineScandateKey    = "opencloud.quarantine.scandate"
+	QuarantineSpaceKey       = "opencloud.quarantine.space"
+	QuarantinePathKey        = "opencloud.quarantine.path"
+	QuarantineUploaderKey    = "opencloud.quarantine.uploader"
+	QuarantineChecksumKey    = "opencloud.quarantine.checksum"
+)
+
+// releasedKeyPrefix prefixes the store keys of released files, a released file is not quarantined again
+const releasedKeyPrefix = "released-"
+
+// releasedTTL is the time a released file has to pass the postprocessing of its original location
+const releasedTTL = 24 * time.Hour
+
+var (
+	// ErrNotQuarantined is returned when a file is not part of the quarantine
+	ErrNotQuarantined = errors.New("file is not quarantined")
+	// ErrForbidden is returned when the user is not allowed to manage the quarantine
+	ErrForbidden = errors.New("not allowed to manage the quarantine")
+)
+
+// QuarantinedFile describes a file in the quarantine space
+type QuarantinedFile struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Size         uint64    `json:"size"`
+	Description  string    `json:"description"`
+	Scandate     time.Time `json:"scandate"`
+	SpaceID      string    `json:"spaceId"`
+	Path         string    `json:"path"`
+	UploaderID   string    `json:"uploaderId,omitempty"`
+	Quarantined  time.Time `json:"quarantined"`
+	checksum     string
+	originalRoot *provider.ResourceId
+}
+
+// isQuarantineSpace tells if the resource lives in the quarantine space
+func (av Antivirus) isQuarantineSpace(id *provider.ResourceId) bool {
+	return av.quarantineSpace != nil && id.GetSpaceId() == av.quarantineSpace.GetSpaceId()
+}
+
+// quarantine copies the file to the quarantine space, the caller is responsible to remove the original
+func (av Antivirus) quarantine(ev events.StartPostprocessingStep, res scanners.Result) error {
+	ctx, err := av.serviceUserContext(context.Background())
+	if err != nil {
+		return err
+	}
+
+	gwc, err := av.gatewaySelector.Next()
+	if err != nil {
+		return err
+	}
+
+	// the path is only used to restore the file, fall back to the space root
+	path := "/" + ev.Filename
+	pres, err := gwc.GetPath(ctx, &provider.GetPathRequest{ResourceId: ev.ResourceID})
+	if err == nil && pres.GetStatus().GetCode() == rpc.Code_CODE_OK {
+		path = pres.GetPath()
+	}
+
+	checksum, err := av.checksum(ev)
+	if err != nil {
+		return err
+	}
+
+	rrc, err := av.download(ev, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rrc.Close()
+	}()
+
+	ref := &provider.Reference{
+		ResourceId: av.quarantineSpace,
+		Path:       utils.MakeRelativePath(uuid.New().String() + "-" + ev.Filename),
+	}
+
+	if err := av.upload(ctx, ref, ev.Filesize, rrc); err != nil {
+		return fmt.Errorf("cannot upload file to the quarantine space: %w", err)
+	}
+
+	md := map[string]string{
+		QuarantineDescriptionKey: res.Description,
+		QuarantineScandateKey:    res.ScanTime.UTC().Format(time.RFC3339),
+		QuarantineSpaceKey: storagespace.FormatResourceID(&provider.ResourceId{
+			StorageId: ev.ResourceID.GetStorageId(),
+			SpaceId:   ev.ResourceID.GetSpaceId(),
+			OpaqueId:  ev.ResourceID.GetSpaceId(),
+		}),
+		QuarantinePathKey:     path,
+		QuarantineChecksumKey: checksum,
+	}
+	if ev.ExecutingUser != nil {
+		md[QuarantineUploaderKey] = ev.ExecutingUser.GetId().GetOpaqueId()
+	}
+
+	mres, err := gwc.SetArbitraryMetadata(ctx, &provider.SetArbitraryMetadataRequest{
+		Ref:               ref,
+		ArbitraryMetadata: &provider.ArbitraryMetadata{Metadata: md},
+	})
+	switch {
+	case err != nil:
+		return err
+	case mres.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return fmt.Errorf("cannot set quarantine metadata: %s", mres.GetStatus().GetMessage())
+	}
+
+	return nil
+}
+
+// released tells if an admin released the file from the quarantine, a released file is only accepted once.
+// Released files are restored by the service account to their original location, uploads by anybody else
+// or to a different location are never accepted.
+func (av Antivirus) released(ev events.StartPostprocessingStep) (bool, error) {
+	if !av.isServiceAccount(ev.ExecutingUser) {
+		return false, nil
+	}
+
+	ctx, err := av.serviceUserContext(context.Background())
+	if err != nil {
+		return false, err
+	}
+
+	gwc, err := av.gatewaySelector.Next()
+	if err != nil {
+		return false, err
+	}
+
+	pres, err := gwc.GetPath(ctx, &provider.GetPathRequest{ResourceId: ev.ResourceID})
+	switch {
+	case err != nil:
+		return false, err
+	case pres.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return false, fmt.Errorf("cannot get path: %s", pres.GetStatus().GetMessage())
+	}
+
+	checksum, err := av.checksum(ev)
+	if err != nil {
+		return false, err
+	}
+
+	key := releasedKey(ev.ResourceID.GetSpaceId(), pres.GetPath(), checksum)
+	_, err = av.store.Read(key)
+	switch {
+	case errors.Is(err, microstore.ErrNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+
+	return true, av.store.Delete(key)
+}
+
+// releasedKey returns the store key of a file released to the given location
+func releasedKey(spaceID string, path string, checksum string) string {
+	h := sha256.Sum256([]byte(spaceID + "\x00" + utils.MakeRelativePath(path) + "\x00" + checksum))
+	return releasedKeyPrefix + hex.EncodeToString(h[:])
+}
+
+// isServiceAccount tells if the user is the service account of the antivirus service
+func (av Antivirus) isServiceAccount(u *user.User) bool {
+	return u.GetId().GetOpaqueId() != "" && u.GetId().GetOpaqueId() == av.config.ServiceAccount.ServiceAccountID
+}
+
+// ListQuarantine lists the quarantined files, the token needs to grant access to the quarantine space
+func (av Antivirus) ListQuarantine(token string) ([]QuarantinedFile, error) {
+	ctx := userContext(token)
+
+	gwc, err := av.gatewaySelector.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := gwc.ListContainer(ctx, &provider.ListContainerRequest{
+		Ref:                   &provider.Reference{ResourceId: av.quarantineSpace, Path: "."},
+		ArbitraryMetadataKeys: quarantineMetadataKeys(),
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case res.GetStatus().GetCode() == rpc.Code_CODE_NOT_FOUND, res.GetStatus().GetCode() == rpc.Code_CODE_PERMISSION_DENIED:
+		return nil, ErrForbidden
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return nil, fmt.Errorf("cannot list the quarantine space: %s", res.GetStatus().GetMessage())
+	}
+
+	files := make([]QuarantinedFile, 0, len(res.GetInfos()))
+	for _, info := range res.GetInfos() {
+		f, err := toQuarantinedFile(info)
+		if err != nil {
+			// the space might contain files which were not put there by us
+			continue
+		}
+
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// ReleaseQuarantined restores the quarantined file to its original location and removes it from the quarantine
+func (av Antivirus) ReleaseQuarantined(token string, id string) error {
+	f, ref, err := av.statQuarantined(token, id)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := av.serviceUserContext(context.Background())
+	if err != nil {
+		return err
+	}
+
+	gwc, err := av.gatewaySelector.Next()
+	if err != nil {
+		return err
+	}
+
+	dres, err := gwc.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{Ref: ref})
+	switch {
+	case err != nil:
+		return err
+	case dres.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return fmt.Errorf("cannot initiate download: %s", dres.GetStatus().GetMessage())
+	}
+
+	var ep, tk string
+	for _, p := range dres.GetProtocols() {
+		if p.GetProtocol() == "spaces" {
+			ep, tk = p.GetDownloadEndpoint(), p.GetToken()
+			break
+		}
+	}
+	if ep == "" {
+		return errors.New("cannot initiate download: no spaces download protocol")
+	}
+
+	revaToken, _ := ctxpkg.ContextGetToken(ctx)
+	rrc, err := av.downloadViaReva(ep, tk, revaToken, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rrc.Close()
+	}()
+
+	// the file is scanned again in its original location, let it pass
+	if err := av.store.Write(&microstore.Record{
+		Key:    releasedKey(f.originalRoot.GetSpaceId(), f.Path, f.checksum),
+		Value:  []byte(id),
+		Expiry: releasedTTL,
+	}); err != nil {
+		return err
+	}
+
+	target := &provider.Reference{ResourceId: f.originalRoot, Path: utils.MakeRelativePath(f.Path)}
+	if err := av.upload(ctx, target, f.Size, rrc); err != nil {
+		return fmt.Errorf("cannot restore file to its original location: %w", err)
+	}
+
+	av.log.Info().Str("id", id).Str("spaceid", f.SpaceID).Str("path", f.Path).Msg("Released file from quarantine")
+
+	return av.deleteQuarantined(userContext(token), ref)
+}
+
+// DeleteQuarantined deletes the quarantined file
+func (av Antivirus) DeleteQuarantined(token string, id string) error {
+	_, ref, err := av.statQuarantined(token, id)
+	if err != nil {
+		return err
+	}
+
+	av.log.Info().Str("id", id).Msg("Deleting quarantined file")
+
+	return av.deleteQuarantined(userContext(token), ref)
+}
+
+// statQuarantined returns the quarantined file if the token grants the permission to delete it
+func (av Antivirus) statQuarantined(token string, id string) (QuarantinedFile, *provider.Reference, error) {
+	rid, err := storagespace.ParseID(id)
+	if err != nil || !av.isQuarantineSpace(&rid) {
+		return QuarantinedFile{}, nil, ErrNotQuarantined
+	}
+
+	gwc, err := av.gatewaySelector.Next()
+	if err != nil {
+		return QuarantinedFile{}, nil, err
+	}
+
+	ref := &provider.Reference{ResourceId: &rid}
+	res, err := gwc.Stat(userContext(token), &provider.StatRequest{Ref: ref, ArbitraryMetadataKeys: quarantineMetadataKeys()})
+	switch {
+	case err != nil:
+		return QuarantinedFile{}, nil, err
+	case res.GetStatus().GetCode() == rpc.Code_CODE_NOT_FOUND:
+		return QuarantinedFile{}, nil, ErrNotQuarantined
+	case res.GetStatus().GetCode() == rpc.Code_CODE_PERMISSION_DENIED:
+		return QuarantinedFile{}, nil, ErrForbidden
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return QuarantinedFile{}, nil, fmt.Errorf("cannot stat quarantined file: %s", res.GetStatus().GetMessage())
+	case !res.GetInfo().GetPermissionSet().GetDelete():
+		return QuarantinedFile{}, nil, ErrForbidden
+	}
+
+	f, err := toQuarantinedFile(res.GetInfo())
+	if err != nil {
+		return QuarantinedFile{}, nil, err
+	}
+
+	return f, ref, nil
+}
+
+func (av Antivirus) deleteQuarantined(ctx context.Context, ref *provider.Reference) error {
+	gwc, err := av.gatewaySelector.Next()
+	if err != nil {
+		return err
+	}
+
+	res, err := gwc.Delete(ctx, &provider.DeleteRequest{Ref: ref})
+	switch {
+	case err != nil:
+		return err
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return fmt.Errorf("cannot delete quarantined file: %s", res.GetStatus().GetMessage())
+	}
+
+	return nil
+}
+
+// upload streams the body to the referenced location
+func (av Antivirus) upload(ctx context.Context, ref *provider.Reference, size uint64, body io.Reader) error {
+	gwc, err := av.gatewaySelector.Next()
+	if err != nil {
+		return err
+	}
+
+	res, err := gwc.InitiateFileUpload(ctx, &provider.InitiateFileUploadRequest{
+		Ref:    ref,
+		Opaque: utils.AppendPlainToOpaque(nil, "Upload-Length", strconv.FormatUint(size, 10)),
+	})
+	switch {
+	case err != nil:
+		return err
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return fmt.Errorf("cannot initiate upload: %s", res.GetStatus().GetMessage())
+	}
+
+	var ep, tk string
+	for _, p := range res.GetProtocols() {
+		if p.GetProtocol() == "simple" {
+			ep, tk = p.GetUploadEndpoint(), p.GetToken()
+		}
+	}
+	if ep == "" {
+		return errors.New("cannot initiate upload: no simple upload protocol")
+	}
+
+	req, err := rhttp.NewRequest(ctx, http.MethodPut, ep, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Reva-Transfer", tk)
+	req.ContentLength = int64(size)
+
+	ures, err := av.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = ures.Body.Close()
+	}()
+
+	if ures.StatusCode != http.StatusOK && ures.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status code from upload %v", ures.StatusCode)
+	}
+
+	return nil
+}
+
+// serviceUserContext returns a context authenticated as the service account
+func (av Antivirus) serviceUserContext(ctx context.Context) (context.Context, error) {
+	gwc, err := av.gatewaySelector.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GetServiceUserToken(ctx, gwc, av.config.ServiceAccount.ServiceAccountID, av.config.ServiceAccount.ServiceAccountSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return withToken(ctx, token), nil
+}
+
+// userContext returns a context authenticated with the given reva token,
+// the token is needed for the grpc calls and for up- and downloading files
+func userContext(token string) context.Context {
+	return withToken(context.Background(), token)
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	ctx = ctxpkg.ContextSetToken(ctx, token)
+	return metadata.AppendToOutgoingContext(ctx, ctxpkg.TokenHeader, token)
+}
+
+func quarantineMetadataKeys() []string {
+	return []string{
+		QuarantineDescriptionKey,
+		QuarantineScandateKey,
+		QuarantineSpaceKey,
+		QuarantinePathKey,
+		QuarantineUploaderKey,
+		QuarantineChecksumKey,
+	}
+}
+
+func toQuarantinedFile(info *provider.ResourceInfo) (QuarantinedFile, error) {
+	md := info.GetArbitraryMetadata().GetMetadata()
+
+	space, ok := md[QuarantineSpaceKey]
+	if !ok || info.GetType() != provider.ResourceType_RESOURCE_TYPE_FILE {
+		return QuarantinedFile{}, ErrNotQuarantined
+	}
+
+	root, err := storagespace.ParseID(space)
+	if err != nil {
+		return QuarantinedFile{}, err
+	}
+
+	scandate, _ := time.Parse(time.RFC3339, md[QuarantineScandateKey])
+
+	return QuarantinedFile{
+		ID:           storagespace.FormatResourceID(info.GetId()),
+		Name:         info.GetName(),
+		Size:         info.GetSize(),
+		Description:  md[QuarantineDescriptionKey],
+		Scandate:     scandate,
+		SpaceID:      space,
+		Path:         md[QuarantinePathKey],
+		UploaderID:   md[QuarantineUploaderKey],
+		Quarantined:  utils.TSToTime(info.GetMtime()),
+		checksum:     md[QuarantineChecksumKey],
+		originalRoot: &root,
+	}, nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/status"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	cs3mocks "github.com/opencloud-eu/reva/v2/tests/cs3mocks/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	microevents "go-micro.dev/v4/events"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/opencloud-eu/opencloud/pkg/quarantine"
+)
+
+var (
+	quarantineSpace = &provider.ResourceId{StorageId: "storage-id", SpaceId: "quarantine-space", OpaqueId: "quarantine-space"}
+	quarantinedID   = &provider.ResourceId{StorageId: "storage-id", SpaceId: "quarantine-space", OpaqueId: "quarantined-file"}
+	serviceAccount  = &user.User{Id: &user.UserId{OpaqueId: "service-account"}}
+	uploader        = &user.User{Id: &user.UserId{OpaqueId: "uploader"}}
+)
+
+type testPublisher struct {
+	published []events.PostprocessingStepFinished
+}
+
+func (p *testPublisher) Publish(_ string, ev interface{}, _ ...microevents.PublishOption) error {
+	p.published = append(p.published, ev.(events.PostprocessingStepFinished))
+	return nil
+}
+
+// uploadServer receives the uploaded files
+type uploadServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	uploads []string
+	status  int
+}
+
+func newUploadServer(t *testing.T) *uploadServer {
+	us := &uploadServer{status: http.StatusCreated}
+	us.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+
+		us.mu.Lock()
+		defer us.mu.Unlock()
+		us.uploads = append(us.uploads, string(b))
+		w.WriteHeader(us.status)
+	}))
+	t.Cleanup(us.Close)
+
+	return us
+}
+
+func newQuarantineAntivirus(t *testing.T, scanner Scanner) (Antivirus, *cs3mocks.GatewayAPIClient, *uploadServer, *testPublisher) {
+	gatewayClient, gatewaySelector := newGatewayClient(t)
+	av := newTestAntivirus(scanner, gatewaySelector)
+	av.cache = nil
+	av.outcome = quarantine.PPOutcomeQuarantine
+	av.quarantineSpace = quarantineSpace
+	av.config.ServiceAccount.ServiceAccountID = serviceAccount.GetId().GetOpaqueId()
+	av.tracerProvider = noop.NewTracerProvider()
+
+	uploads := newUploadServer(t)
+	gatewayClient.On("InitiateFileUpload", mock.Anything, mock.Anything).Return(&gateway.InitiateFileUploadResponse{
+		Status:    status.NewOK(nil),
+		Protocols: []*gateway.FileUploadProtocol{{Protocol: "simple", UploadEndpoint: uploads.URL}},
+	}, nil).Maybe()
+	gatewayClient.On("GetPath", mock.Anything, mock.Anything).Return(&provider.GetPathResponse{
+		Status: status.NewOK(nil),
+		Path:   "/dir/file.txt",
+	}, nil).Maybe()
+
+	return av, gatewayClient, uploads, &testPublisher{}
+}
+
+// quarantinedStat makes the storage return the quarantined file with the given permissions
+func quarantinedStat(gatewayClient *cs3mocks.GatewayAPIClient, canDelete bool) {
+	gatewayClient.On("Stat", mock.Anything, mock.MatchedBy(func(req *provider.StatRequest) bool {
+		return req.GetRef().GetResourceId().GetOpaqueId() == quarantinedID.GetOpaqueId()
+	})).Return(&provider.StatResponse{
+		Status: status.NewOK(nil),
+		Info: &provider.ResourceInfo{
+			Type:          provider.ResourceType_RESOURCE_TYPE_FILE,
+			Id:            quarantinedID,
+			Name:          "quarantined-file.txt",
+			Size:          7,
+			PermissionSet: &provider.ResourcePermissions{Delete: canDelete},
+			ArbitraryMetadata: &provider.ArbitraryMetadata{Metadata: map[string]string{
+				QuarantineSpaceKey:    "storage-id$space-id!space-id",
+				QuarantinePathKey:     "/dir/file.txt",
+				QuarantineChecksumKey: "RESOURCE_CHECKSUM_TYPE_SHA1:abc",
+			}},
+		},
+	}, nil)
+}
+
+func infectedUpload(url string, u *user.User) events.Event {
+	ev := uploadEvent(url)
+	ev.ExecutingUser = u
+	return events.Event{Event: ev}
+}
+
+func TestProcessEvent_Quarantine(t *testing.T) {
+	t.Run("infected files are moved to the quarantine", func(t *testing.T) {
+		av, gatewayClient, uploads, pub := newQuarantineAntivirus(t, &fakeScanner{infected: true})
+		statChecksum(gatewayClient, "abc")
+		files := newFileServer(t, "content")
+
+		var md map[string]string
+		gatewayClient.On("SetArbitraryMetadata", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			md = args.Get(1).(*provider.SetArbitraryMetadataRequest).GetArbitraryMetadata().GetMetadata()
+		}).Return(&provider.SetArbitraryMetadataResponse{Status: status.NewOK(nil)}, nil)
+
+		require.NoError(t, av.processEvent(infectedUpload(files.URL, uploader), pub))
+
+		require.Len(t, pub.published, 1)
+		assert.Equal(t, quarantine.PPOutcomeQuarantine, pub.published[0].Outcome)
+		assert.Equal(t, []string{"content"}, uploads.uploads)
+		assert.Equal(t, "/dir/file.txt", md[QuarantinePathKey])
+		assert.Equal(t, "uploader", md[QuarantineUploaderKey])
+		assert.Equal(t, "RESOURCE_CHECKSUM_TYPE_SHA1:abc", md[QuarantineChecksumKey])
+		assert.Equal(t, "Eicar-Test-Signature", md[QuarantineDescriptionKey])
+	})
+
+	t.Run("files which can not be quarantined are retried", func(t *testing.T) {
+		av, gatewayClient, uploads, pub := newQuarantineAntivirus(t, &fakeScanner{infected: true})
+		statChecksum(gatewayClient, "abc")
+		files := newFileServer(t, "content")
+		uploads.status = http.StatusInternalServerError
+
+		require.NoError(t, av.processEvent(infectedUpload(files.URL, uploader), pub))
+
+		require.Len(t, pub.published, 1)
+		assert.Equal(t, events.PPOutcomeRetry, pub.published[0].Outcome)
+	})
+
+	t.Run("scan errors of infected files are not retried in other modes", func(t *testing.T) {
+		av, _, _, pub := newQuarantineAntivirus(t, &fakeScanner{infected: true, err: errors.New("one scanner failed")})
+		av.outcome = events.PPOutcomeDelete
+		files := newFileServer(t, "content")
+
+		require.NoError(t, av.processEvent(infectedUpload(files.URL, uploader), pub))
+
+		require.Len(t, pub.published, 1)
+		assert.Equal(t, events.PPOutcomeDelete, pub.published[0].Outcome)
+	})
+
+	t.Run("uploads of users to the quarantine space are scanned", func(t *testing.T) {
+		scanner := &fakeScanner{}
+		av, _, _, pub := newQuarantineAntivirus(t, scanner)
+		files := newFileServer(t, "content")
+
+		ev := uploadEvent(files.URL)
+		ev.ResourceID = quarantinedID
+
+		ev.ExecutingUser = serviceAccount
+		require.NoError(t, av.processEvent(events.Event{Event: ev}, pub))
+		assert.EqualValues(t, 0, scanner.scans.Load())
+
+		ev.ExecutingUser = uploader
+		require.NoError(t, av.processEvent(events.Event{Event: ev}, pub))
+		assert.EqualValues(t, 1, scanner.scans.Load())
+	})
+}
+
+func TestReleaseQuarantined(t *testing.T) {
+	t.Run("the released file passes the scan of its original location once", func(t *testing.T) {
+		av, gatewayClient, uploads, pub := newQuarantineAntivirus(t, &fakeScanner{infected: true})
+		quarantinedStat(gatewayClient, true)
+		statChecksum(gatewayClient, "abc")
+		files := newFileServer(t, "content")
+		gatewayClient.On("InitiateFileDownload", mock.Anything, mock.Anything).Return(&gateway.InitiateFileDownloadResponse{
+			Status:    status.NewOK(nil),
+			Protocols: []*gateway.FileDownloadProtocol{{Protocol: "spaces", DownloadEndpoint: files.URL}},
+		}, nil)
+		gatewayClient.On("Delete", mock.Anything, mock.MatchedBy(func(req *provider.DeleteRequest) bool {
+			return req.GetRef().GetResourceId().GetOpaqueId() == quarantinedID.GetOpaqueId()
+		})).Return(&provider.DeleteResponse{Status: status.NewOK(nil)}, nil).Once()
+		gatewayClient.On("SetArbitraryMetadata", mock.Anything, mock.Anything).Return(&provider.SetArbitraryMetadataResponse{Status: status.NewOK(nil)}, nil)
+
+		require.NoError(t, av.ReleaseQuarantined("admin-token", storagespace.FormatResourceID(quarantinedID)))
+		assert.Equal(t, []string{"content"}, uploads.uploads)
+
+		// an identical file of another user is still quarantined
+		require.NoError(t, av.processEvent(infectedUpload(files.URL, uploader), pub))
+		require.Len(t, pub.published, 1)
+		assert.Equal(t, quarantine.PPOutcomeQuarantine, pub.published[0].Outcome)
+
+		// the restored file is accepted
+		require.NoError(t, av.processEvent(infectedUpload(files.URL, serviceAccount), pub))
+		require.Len(t, pub.published, 2)
+		assert.Equal(t, events.PPOutcomeContinue, pub.published[1].Outcome)
+
+		// but only once
+		require.NoError(t, av.processEvent(infectedUpload(files.URL, serviceAccount), pub))
+		require.Len(t, pub.published, 3)
+		assert.Equal(t, quarantine.PPOutcomeQuarantine, pub.published[2].Outcome)
+	})
+
+	t.Run("files outside the quarantine space can not be released", func(t *testing.T) {
+		av, _, _, _ := newQuarantineAntivirus(t, &fakeScanner{})
+
+		err := av.ReleaseQuarantined("admin-token", "storage-id$space-id!file-id")
+		assert.ErrorIs(t, err, ErrNotQuarantined)
+	})
+}
+
+func TestDeleteQuarantined(t *testing.T) {
+	t.Run("deletes the quarantined file", func(t *testing.T) {
+		av, gatewayClient, _, _ := newQuarantineAntivirus(t, &fakeScanner{})
+		quarantinedStat(gatewayClient, true)
+		gatewayClient.On("Delete", mock.Anything, mock.Anything).Return(&provider.DeleteResponse{Status: status.NewOK(nil)}, nil).Once()
+
+		require.NoError(t, av.DeleteQuarantined("admin-token", storagespace.FormatResourceID(quarantinedID)))
+	})
+
+	t.Run("requires the permission to delete the file", func(t *testing.T) {
+		av, gatewayClient, _, _ := newQuarantineAntivirus(t, &fakeScanner{})
+		quarantinedStat(gatewayClient, false)
+
+		err := av.DeleteQuarantined("user-token", storagespace.FormatResourceID(quarantinedID))
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	ctxpkg "github.com/opencloud-eu/reva/v2/pkg/ctx"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/opencloud-eu/reva/v2/pkg/storage/utils/walker"
+	"github.com/opencloud-eu/reva/v2/pkg/utils"
+	microstore "go-micro.dev/v4/store"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/pkg/quarantine"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/scanners"
+)
+
+// rescanProgressKey is the store key of the rescan progress
+const rescanProgressKey = "rescan-progress"
+
+// RescanProgress is the persisted state of a rescan, it allows resuming an interrupted rescan
+type RescanProgress struct {
+	// Version is the signature database version the rescan was started for
+	Version string
+	Started time.Time
+	// Finished is zero as long as the rescan is running
+	Finished time.Time
+	// Done holds the ids of the spaces which have been rescanned completely
+	Done     []string
+	Scanned  int
+	Infected int
+}
+
+// Rescanner periodically rescans all stored files when the signature database of the scanner changes
+type Rescanner struct {
+	av              Antivirus
+	cfg             config.Rescan
+	gatewaySelector pool.Selectable[gateway.GatewayAPIClient]
+	store           microstore.Store
+	pub             events.Publisher
+	log             log.Logger
+}
+
+// NewRescanner returns a Rescanner for the given antivirus service
+func NewRescanner(av Antivirus, cfg *config.Config, pub events.Publisher, logger log.Logger) *Rescanner {
+	return &Rescanner{
+		av:              av,
+		cfg:             cfg.Rescan,
+		gatewaySelector: av.gatewaySelector,
+		store:           av.store,
+		pub:             pub,
+		log:             logger,
+	}
+}
+
+// Run checks for new signatures in the configured interval until the context is done
+func (r *Rescanner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.check(ctx); err != nil {
+			r.log.Error().Err(err).Msg("rescan failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// check starts or resumes a rescan if necessary
+func (r *Rescanner) check(ctx context.Context) error {
+	version, err := r.av.signatureVersion()
+	if err != nil && !errors.Is(err, scanners.ErrUnversioned) {
+		return fmt.Errorf("cannot get scanner version: %w", err)
+	}
+
+	progress, err := r.loadProgress()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case progress.Started.IsZero(), progress.Version != version:
+		// first run or new signatures, start over
+		progress = RescanProgress{Version: version, Started: time.Now()}
+	case progress.Finished.IsZero():
+		r.log.Info().Strs("done", progress.Done).Msg("resuming interrupted rescan")
+	case version != "" || time.Since(progress.Finished) < r.cfg.Interval:
+		// nothing changed since the last rescan
+		return nil
+	default:
+		// the scanner does not report a version, rescan periodically
+		progress = RescanProgress{Version: version, Started: time.Now()}
+	}
+
+	return r.rescan(ctx, progress)
+}
+
+func (r *Rescanner) rescan(ctx context.Context, progress RescanProgress) error {
+	r.log.Info().Str("version", progress.Version).Msg("starting rescan of stored files")
+
+	spaces, err := r.listSpaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	var limiter <-chan time.Time
+	if r.cfg.RateLimit > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(r.cfg.RateLimit))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	for _, space := range spaces {
+		if slices.Contains(progress.Done, space.GetId().GetOpaqueId()) {
+			continue
+		}
+
+		// an interrupted space is rescanned from the start, so it is only counted once it is done
+		scanned, infected, err := r.rescanSpace(ctx, space, limiter)
+		if err != nil {
+			return err
+		}
+
+		progress.Scanned += scanned
+		progress.Infected += infected
+		progress.Done = append(progress.Done, space.GetId().GetOpaqueId())
+		if err := r.storeProgress(progress); err != nil {
+			return err
+		}
+	}
+
+	progress.Finished = time.Now()
+	r.log.Info().Int("scanned", progress.Scanned).Int("infected", progress.Infected).Dur("duration", progress.Finished.Sub(progress.Started)).Msg("rescan of stored files finished")
+
+	return r.storeProgress(progress)
+}
+
+// rescanSpace rescans all files of the space and returns the number of scanned and infected files,
+// the walk stops as soon as the context is done
+func (r *Rescanner) rescanSpace(ctx context.Context, space *provider.StorageSpace, limiter <-chan time.Time) (int, int, error) {
+	// the token might have expired while scanning the previous space
+	sctx, err := r.av.serviceUserContext(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var scanned, infected int
+	w := walker.NewWalker(r.gatewaySelector)
+	err = w.Walk(sctx, space.GetRoot(), func(wd string, info *provider.ResourceInfo, err error) error {
+		if err != nil {
+			r.log.Error().Err(err).Str("spaceid", space.GetId().GetOpaqueId()).Msg("error walking the tree")
+			return err
+		}
+
+		if info == nil || info.GetType() != provider.ResourceType_RESOURCE_TYPE_FILE {
+			return nil
+		}
+
+		if limiter != nil {
+			select {
+			case <-sctx.Done():
+				return sctx.Err()
+			case <-limiter:
+			}
+		} else if sctx.Err() != nil {
+			return sctx.Err()
+		}
+
+		isInfected, err := r.rescanFile(sctx, filepath.Join(wd, info.GetPath()), info)
+		if err != nil {
+			// keep going, the file is scanned again with the next rescan
+			r.log.Error().Err(err).Str("path", filepath.Join(wd, info.GetPath())).Msg("error rescanning file")
+			return nil
+		}
+
+		scanned++
+		if isInfected {
+			infected++
+		}
+
+		return nil
+	})
+
+	return scanned, infected, err
+}
+
+func (r *Rescanner) rescanFile(ctx context.Context, path string, info *provider.ResourceInfo) (bool, error) {
+	gwc, err := r.gatewaySelector.Next()
+	if err != nil {
+		return false, err
+	}
+
+	ref := &provider.Reference{ResourceId: info.GetId(), Path: "."}
+	res, err := gwc.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{Ref: ref})
+	switch {
+	case err != nil:
+		return false, err
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return false, fmt.Errorf("cannot initiate download: %s", res.GetStatus().GetMessage())
+	case len(res.GetProtocols()) == 0:
+		return false, errors.New("cannot initiate download: no download protocol")
+	}
+
+	ep, tk := res.GetProtocols()[0].GetDownloadEndpoint(), res.GetProtocols()[0].GetToken()
+	for _, p := range res.GetProtocols() {
+		if p.GetProtocol() == "spaces" {
+			ep, tk = p.GetDownloadEndpoint(), p.GetToken()
+			break
+		}
+	}
+
+	revaToken, _ := ctxpkg.ContextGetToken(ctx)
+
+	// this is an on demand scan, so there is no upload id
+	ev := events.StartPostprocessingStep{
+		URL:         ep,
+		Token:       tk,
+		RevaToken:   revaToken,
+		ResourceID:  info.GetId(),
+		Filename:    info.GetName(),
+		Filesize:    info.GetSize(),
+		StepToStart: events.PPStepAntivirus,
+	}
+	if info.GetOwner() != nil {
+		ev.ExecutingUser = &user.User{Id: info.GetOwner()}
+	}
+
+	result, err := r.av.process(ev)
+	if err != nil {
+		return false, err
+	}
+
+	if !result.Infected {
+		return false, nil
+	}
+
+	outcome := r.av.outcome
+	r.log.Info().Str("path", path).Interface("resourceID", info.GetId()).Str("virus", result.Description).Str("outcome", string(outcome)).Msg("Stored file is infected")
+
+	if err := r.handleInfected(ctx, gwc, ref, ev, result, outcome); err != nil {
+		return true, err
+	}
+
+	if ev.ExecutingUser == nil {
+		// there is nobody to notify, e.g. for project spaces
+		return true, nil
+	}
+
+	return true, events.Publish(ctx, r.pub, events.PostprocessingStepFinished{
+		FinishedStep:  events.PPStepAntivirus,
+		Outcome:       outcome,
+		ExecutingUser: ev.ExecutingUser,
+		Filename:      ev.Filename,
+		Result: events.VirusscanResult{
+			Infected:    result.Infected,
+			Description: result.Description,
+			Scandate:    time.Now(),
+			ResourceID:  info.GetId(),
+		},
+	})
+}
+
+// handleInfected applies the infected file handling to an already stored file
+func (r *Rescanner) handleInfected(ctx context.Context, gwc gateway.GatewayAPIClient, ref *provider.Reference, ev events.StartPostprocessingStep, result scanners.Result, outcome events.PostprocessingOutcome) error {
+	switch outcome {
+	case quarantine.PPOutcomeQuarantine:
+		if err := r.av.quarantine(ev, result); err != nil {
+			return err
+		}
+		fallthrough
+	case events.PPOutcomeDelete:
+		res, err := gwc.Delete(ctx, &provider.DeleteRequest{Ref: ref})
+		switch {
+		case err != nil:
+			return err
+		case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+			return fmt.Errorf("cannot delete infected file: %s", res.GetStatus().GetMessage())
+		}
+	default:
+		// the file is already stored, there is nothing to abort, the owner gets notified
+	}
+
+	return nil
+}
+
+func (r *Rescanner) listSpaces(ctx context.Context) ([]*provider.StorageSpace, error) {
+	ctx, err := r.av.serviceUserContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gwc, err := r.gatewaySelector.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := gwc.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{
+		Opaque: utils.AppendPlainToOpaque(nil, "unrestricted", "T"),
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return nil, fmt.Errorf("cannot list spaces: %s", res.GetStatus().GetMessage())
+	}
+
+	spaces := make([]*provider.StorageSpace, 0, len(res.GetStorageSpaces()))
+	for _, s := range res.GetStorageSpaces() {
+		if r.av.isQuarantineSpace(s.GetRoot()) {
+			// the quarantined files are known to be infected
+			continue
+		}
+
+		if s.GetSpaceType() == "personal" || s.GetSpaceType() == "project" {
+			spaces = append(spaces, s)
+		}
+	}
+
+	return spaces, nil
+}
+
+func (r *Rescanner) loadProgress() (RescanProgress, error) {
+	var progress RescanProgress
+
+	recs, err := r.store.Read(rescanProgressKey)
+	switch {
+	case errors.Is(err, microstore.ErrNotFound):
+		return progress, nil
+	case err != nil:
+		return progress, err
+	case len(recs) == 0:
+		return progress, nil
+	}
+
+	return progress, json.Unmarshal(recs[0].Value, &progress)
+}
+
+func (r *Rescanner) storeProgress(progress RescanProgress) error {
+	b, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	return r.store.Write(&microstore.Record{Key: rescanProgressKey, Value: b})
+}
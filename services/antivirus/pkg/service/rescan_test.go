@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/status"
+	cs3mocks "github.com/opencloud-eu/reva/v2/tests/cs3mocks/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
+)
+
+// storeSpaces makes the storage return the given spaces, each space contains the given number of files
+func storeSpaces(gatewayClient *cs3mocks.GatewayAPIClient, url string, files map[string]int) {
+	spaces := make([]*provider.StorageSpace, 0, len(files))
+	for id, n := range files {
+		root := &provider.ResourceId{StorageId: "storage-id", SpaceId: id, OpaqueId: id}
+		spaces = append(spaces, &provider.StorageSpace{
+			Id:        &provider.StorageSpaceId{OpaqueId: id},
+			Root:      root,
+			SpaceType: "project",
+		})
+
+		infos := make([]*provider.ResourceInfo, 0, n)
+		for i := range n {
+			infos = append(infos, &provider.ResourceInfo{
+				Type: provider.ResourceType_RESOURCE_TYPE_FILE,
+				Id:   &provider.ResourceId{StorageId: "storage-id", SpaceId: id, OpaqueId: id + "-" + string(rune('a'+i))},
+				Path: string(rune('a'+i)) + ".txt",
+				Name: string(rune('a'+i)) + ".txt",
+				Size: 7,
+			})
+		}
+
+		gatewayClient.On("Stat", mock.Anything, mock.MatchedBy(func(req *provider.StatRequest) bool {
+			return req.GetRef().GetResourceId().GetOpaqueId() == id
+		})).Return(&provider.StatResponse{
+			Status: status.NewOK(nil),
+			Info:   &provider.ResourceInfo{Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER, Id: root, Path: "."},
+		}, nil).Maybe()
+		gatewayClient.On("ListContainer", mock.Anything, mock.MatchedBy(func(req *provider.ListContainerRequest) bool {
+			return req.GetRef().GetResourceId().GetOpaqueId() == id
+		})).Return(&provider.ListContainerResponse{Status: status.NewOK(nil), Infos: infos}, nil).Maybe()
+	}
+
+	gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&provider.ListStorageSpacesResponse{
+		Status:        status.NewOK(nil),
+		StorageSpaces: spaces,
+	}, nil)
+	gatewayClient.On("InitiateFileDownload", mock.Anything, mock.Anything).Return(&gateway.InitiateFileDownloadResponse{
+		Status:    status.NewOK(nil),
+		Protocols: []*gateway.FileDownloadProtocol{{Protocol: "spaces", DownloadEndpoint: url}},
+	}, nil).Maybe()
+}
+
+func TestRescanner(t *testing.T) {
+	t.Run("new signatures start a rescan of all spaces", func(t *testing.T) {
+		gatewayClient, gatewaySelector := newGatewayClient(t)
+		files := newFileServer(t, "content")
+		storeSpaces(gatewayClient, files.URL, map[string]int{"space-a": 2, "space-b": 1})
+		scanner := &versionedScanner{version: "1"}
+		av := newTestAntivirus(scanner, gatewaySelector)
+		av.cache = nil
+		r := NewRescanner(av, &config.Config{}, nil, log.NopLogger())
+
+		require.NoError(t, r.check(context.Background()))
+		progress, err := r.loadProgress()
+		require.NoError(t, err)
+		assert.Equal(t, "1", progress.Version)
+		assert.False(t, progress.Finished.IsZero())
+		assert.ElementsMatch(t, []string{"space-a", "space-b"}, progress.Done)
+		assert.Equal(t, 3, progress.Scanned)
+		assert.EqualValues(t, 3, scanner.scans.Load())
+
+		// nothing changed, nothing to do
+		require.NoError(t, r.check(context.Background()))
+		assert.EqualValues(t, 3, scanner.scans.Load())
+
+		scanner.version = "2"
+		require.NoError(t, r.check(context.Background()))
+		assert.EqualValues(t, 6, scanner.scans.Load())
+	})
+
+	t.Run("an interrupted rescan is resumed and every space is counted once", func(t *testing.T) {
+		gatewayClient, gatewaySelector := newGatewayClient(t)
+		files := newFileServer(t, "content")
+		storeSpaces(gatewayClient, files.URL, map[string]int{"space-a": 2, "space-b": 1})
+		scanner := &versionedScanner{version: "1"}
+		scanner.infected = true
+		av := newTestAntivirus(scanner, gatewaySelector)
+		av.cache = nil
+		av.outcome = events.PPOutcomeContinue
+		r := NewRescanner(av, &config.Config{}, nil, log.NopLogger())
+
+		require.NoError(t, r.storeProgress(RescanProgress{
+			Version:  "1",
+			Started:  time.Now(),
+			Done:     []string{"space-a"},
+			Scanned:  2,
+			Infected: 2,
+		}))
+
+		require.NoError(t, r.check(context.Background()))
+		progress, err := r.loadProgress()
+		require.NoError(t, err)
+		assert.False(t, progress.Finished.IsZero())
+		assert.Equal(t, 3, progress.Scanned)
+		assert.Equal(t, 3, progress.Infected)
+		assert.EqualValues(t, 1, scanner.scans.Load())
+	})
+
+	t.Run("a cancelled context stops the walk", func(t *testing.T) {
+		gatewayClient, gatewaySelector := newGatewayClient(t)
+		files := newFileServer(t, "content")
+		storeSpaces(gatewayClient, files.URL, map[string]int{"space-a": 2})
+		scanner := &versionedScanner{version: "1"}
+		av := newTestAntivirus(scanner, gatewaySelector)
+		r := NewRescanner(av, &config.Config{}, nil, log.NopLogger())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, r.check(ctx), context.Canceled)
+		progress, err := r.loadProgress()
+		require.NoError(t, err)
+		assert.True(t, progress.Finished.IsZero())
+		assert.EqualValues(t, 0, scanner.scans.Load())
+	})
+}
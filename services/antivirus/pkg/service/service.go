@@ -13,14 +13,22 @@ import (
 	"sync"
 	"time"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/opencloud-eu/reva/v2/pkg/bytesize"
 	ctxpkg "github.com/opencloud-eu/reva/v2/pkg/ctx"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 	"github.com/opencloud-eu/reva/v2/pkg/events/stream"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	"github.com/opencloud-eu/reva/v2/pkg/rhttp"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	microstore "go-micro.dev/v4/store"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/pkg/quarantine"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/cache"
 	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/scanners"
 )
@@ -38,22 +46,29 @@ type Scanner interface {
 }
 
 // NewAntivirus returns a service implementation for Service.
-func NewAntivirus(cfg *config.Config, logger log.Logger, tracerProvider trace.TracerProvider) (Antivirus, error) {
-	var scanner Scanner
-	var err error
-	switch cfg.Scanner.Type {
-	default:
-		return Antivirus{}, fmt.Errorf("unknown av scanner: '%s'", cfg.Scanner.Type)
-	case config.ScannerTypeClamAV:
-		scanner, err = scanners.NewClamAV(cfg.Scanner.ClamAV.Socket, cfg.Scanner.ClamAV.Timeout)
-	case config.ScannerTypeICap:
-		scanner, err = scanners.NewICAP(cfg.Scanner.ICAP.URL, cfg.Scanner.ICAP.Service, cfg.Scanner.ICAP.Timeout)
-	}
+func NewAntivirus(cfg *config.Config, logger log.Logger, tracerProvider trace.TracerProvider, gatewaySelector pool.Selectable[gateway.GatewayAPIClient], st microstore.Store) (Antivirus, error) {
+	scanner, err := newScanner(cfg.Scanner)
 	if err != nil {
 		return Antivirus{}, err
 	}
 
-	av := Antivirus{config: cfg, log: logger, tracerProvider: tracerProvider, scanner: scanner, client: rhttp.GetHTTPClient(rhttp.Insecure(true))}
+	av := Antivirus{
+		config:          cfg,
+		log:             logger,
+		tracerProvider:  tracerProvider,
+		scanner:         scanner,
+		gatewaySelector: gatewaySelector,
+		store:           st,
+		client:          rhttp.GetHTTPClient(rhttp.Insecure(true)),
+	}
+
+	_, versioned := scanner.(scanners.Versioner)
+	switch {
+	case cfg.ScanCache.Enabled && !versioned:
+		logger.Warn().Msg("the scanner does not report a signature version, scan results are not cached")
+	case cfg.ScanCache.Enabled:
+		av.cache = cache.New(cache.NewStore(cfg.ScanCache), cfg.ScanCache.TTL)
+	}
 
 	switch mode := cfg.MaxScanSizeMode; mode {
 	case config.MaxScanSizeModeSkip, config.MaxScanSizeModePartial:
@@ -65,6 +80,17 @@ func NewAntivirus(cfg *config.Config, logger log.Logger, tracerProvider trace.Tr
 	switch outcome := events.PostprocessingOutcome(cfg.InfectedFileHandling); outcome {
 	case events.PPOutcomeContinue, events.PPOutcomeAbort, events.PPOutcomeDelete:
 		av.outcome = outcome
+	case quarantine.PPOutcomeQuarantine:
+		root, err := storagespace.ParseID(cfg.Quarantine.SpaceID)
+		if err != nil {
+			return av, fmt.Errorf("invalid quarantine space id '%s': %w", cfg.Quarantine.SpaceID, err)
+		}
+		if root.GetOpaqueId() == "" {
+			root.OpaqueId = root.GetSpaceId()
+		}
+
+		av.outcome = outcome
+		av.quarantineSpace = &root
 	default:
 		return av, fmt.Errorf("unknown infected file handling '%s'", outcome)
 	}
@@ -81,6 +107,54 @@ func NewAntivirus(cfg *config.Config, logger log.Logger, tracerProvider trace.Tr
 	return av, nil
 }
 
+// newScanner returns the configured scanner, several scanners are combined into a multi scanner
+func newScanner(cfg config.Scanner) (Scanner, error) {
+	if len(cfg.Types) == 0 {
+		return newBackend(cfg.Type, cfg)
+	}
+
+	backends := make(map[string]scanners.Backend, len(cfg.Types))
+	for _, t := range cfg.Types {
+		if _, ok := backends[string(t)]; ok {
+			return nil, fmt.Errorf("duplicate av scanner: '%s'", t)
+		}
+
+		backend, err := newBackend(t, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		backends[string(t)] = backend
+	}
+
+	return scanners.NewMulti(cfg.Policy, backends)
+}
+
+func newBackend(t config.ScannerType, cfg config.Scanner) (Scanner, error) {
+	switch t {
+	default:
+		return nil, fmt.Errorf("unknown av scanner: '%s'", t)
+	case config.ScannerTypeClamAV:
+		return scanners.NewClamAV(cfg.ClamAV.Socket, cfg.ClamAV.Timeout)
+	case config.ScannerTypeICap:
+		return scanners.NewICAP(cfg.ICAP.URL, cfg.ICAP.Service, cfg.ICAP.Timeout)
+	case config.ScannerTypeHTTP:
+		o := scanners.HTTPOptions{
+			URL:              cfg.HTTP.URL,
+			Timeout:          cfg.HTTP.Timeout,
+			FormField:        cfg.HTTP.FormField,
+			InfectedField:    cfg.HTTP.InfectedField,
+			InfectedValue:    cfg.HTTP.InfectedValue,
+			DescriptionField: cfg.HTTP.DescriptionField,
+			Insecure:         cfg.HTTP.Insecure,
+		}
+		if cfg.HTTP.Authorization != "" {
+			o.Headers = map[string]string{"Authorization": cfg.HTTP.Authorization}
+		}
+		return scanners.NewHTTP(o)
+	}
+}
+
 // Antivirus defines implements the business logic for Service.
 type Antivirus struct {
 	config         *config.Config
@@ -88,8 +162,13 @@ type Antivirus struct {
 	scanner        Scanner
 	outcome        events.PostprocessingOutcome
 	maxScanSize    uint64
+	cache          *cache.Cache
 	tracerProvider trace.TracerProvider
 
+	gatewaySelector pool.Selectable[gateway.GatewayAPIClient]
+	store           microstore.Store
+	quarantineSpace *provider.ResourceId
+
 	client *http.Client
 }
 
@@ -180,6 +259,13 @@ func (av Antivirus) processEvent(e events.Event, s events.Publisher) error {
 		return fmt.Errorf("%w: no actual virus scan performed", ErrEvent)
 	}
 
+	if av.isQuarantineSpace(ev.ResourceID) && av.isServiceAccount(ev.ExecutingUser) {
+		// the file has been quarantined by us, scanning it would only quarantine it again
+		av.log.Debug().Str("uploadid", ev.UploadID).Str("filename", ev.Filename).Msg("Skipping virus scan of quarantined file.")
+		res := scanners.Result{ScanTime: time.Now()}
+		return av.publishResult(ctx, s, ev, res, events.PPOutcomeContinue, "")
+	}
+
 	av.log.Debug().Str("uploadid", ev.UploadID).Str("filename", ev.Filename).Msg("Starting virus scan.")
 
 	var errmsg string
@@ -188,10 +274,21 @@ func (av Antivirus) processEvent(e events.Event, s events.Publisher) error {
 	if err != nil {
 		errmsg = err.Error()
 	}
+
+	var quarantineErr error
+	if res.Infected && av.outcome == quarantine.PPOutcomeQuarantine {
+		res, quarantineErr = av.handleQuarantine(ev, res)
+		if quarantineErr != nil {
+			errmsg = quarantineErr.Error()
+		}
+	}
 	duration := time.Since(start)
 
 	var outcome events.PostprocessingOutcome
 	switch {
+	case quarantineErr != nil:
+		// the file could not be quarantined, try again later
+		outcome = events.PPOutcomeRetry
 	case res.Infected:
 		outcome = av.outcome
 	case !res.Infected && err == nil:
@@ -204,6 +301,30 @@ func (av Antivirus) processEvent(e events.Event, s events.Publisher) error {
 	}
 
 	av.log.Info().Str("uploadid", ev.UploadID).Interface("resourceID", ev.ResourceID).Str("virus", res.Description).Str("outcome", string(outcome)).Str("filename", ev.Filename).Str("user", ev.ExecutingUser.GetId().GetOpaqueId()).Bool("infected", res.Infected).Dur("duration", duration).Msg("File scanned")
+	return av.publishResult(ctx, s, ev, res, outcome, errmsg)
+}
+
+// handleQuarantine moves the infected file to the quarantine unless an admin released it
+func (av Antivirus) handleQuarantine(ev events.StartPostprocessingStep, res scanners.Result) (scanners.Result, error) {
+	released, err := av.released(ev)
+	switch {
+	case err != nil:
+		av.log.Error().Err(err).Str("uploadid", ev.UploadID).Msg("error checking if the file was released from quarantine")
+	case released:
+		av.log.Info().Str("uploadid", ev.UploadID).Str("virus", res.Description).Msg("File has been released from quarantine, accepting it")
+		return scanners.Result{ScanTime: res.ScanTime, Description: res.Description}, nil
+	}
+
+	if err := av.quarantine(ev, res); err != nil {
+		av.log.Error().Err(err).Str("uploadid", ev.UploadID).Msg("error moving file to quarantine")
+		return res, err
+	}
+
+	return res, nil
+}
+
+// publishResult publishes the outcome of the scan
+func (av Antivirus) publishResult(ctx context.Context, s events.Publisher, ev events.StartPostprocessingStep, res scanners.Result, outcome events.PostprocessingOutcome, errmsg string) error {
 	if err := events.Publish(ctx, s, events.PostprocessingStepFinished{
 		FinishedStep:  events.PPStepAntivirus,
 		Outcome:       outcome,
@@ -246,15 +367,29 @@ func (av Antivirus) process(ev events.StartPostprocessingStep) (scanners.Result,
 		headers["Range"] = fmt.Sprintf("bytes=0-%d", av.maxScanSize-1)
 	}
 
-	var err error
-	var rrc io.ReadCloser
+	var cacheKey string
+	if av.cache != nil {
+		key, err := av.cacheKey(ev, headers)
+		switch {
+		case errors.Is(err, scanners.ErrUnversioned):
+			av.log.Debug().Str("uploadid", ev.UploadID).Msg("scanner does not report a signature version, scanning without cache")
+		case err != nil:
+			av.log.Error().Err(err).Str("uploadid", ev.UploadID).Msg("error computing scan cache key, scanning without cache")
+		default:
+			res, ok, err := av.cache.Get(key)
+			switch {
+			case err != nil:
+				av.log.Error().Err(err).Str("uploadid", ev.UploadID).Msg("error reading scan cache")
+			case ok:
+				av.log.Debug().Str("uploadid", ev.UploadID).Msg("Found cached scan result, skipping virusscan")
+				return res, nil
+			}
 
-	switch ev.UploadID {
-	default:
-		rrc, err = av.downloadViaToken(ev.URL, headers)
-	case "":
-		rrc, err = av.downloadViaReva(ev.URL, ev.Token, ev.RevaToken, headers)
+			cacheKey = key
+		}
 	}
+
+	rrc, err := av.download(ev, headers)
 	if err != nil {
 		av.log.Error().Err(err).Str("uploadid", ev.UploadID).Msg("error downloading file")
 		return scanners.Result{}, err
@@ -268,9 +403,90 @@ func (av Antivirus) process(ev events.StartPostprocessingStep) (scanners.Result,
 	res, err := av.scanner.Scan(scanners.Input{Body: rrc, Size: int64(ev.Filesize), Url: ev.URL, Name: ev.Filename})
 	if err != nil {
 		av.log.Error().Err(err).Str("uploadid", ev.UploadID).Msg("error scanning file")
+		return res, err
+	}
+
+	if cacheKey != "" {
+		if err := av.cache.Set(cacheKey, res); err != nil {
+			av.log.Error().Err(err).Str("uploadid", ev.UploadID).Msg("error writing scan cache")
+		}
+	}
+
+	return res, nil
+}
+
+// cacheKey combines the checksum the storage computed for the file with the signature database version
+func (av Antivirus) cacheKey(ev events.StartPostprocessingStep, headers map[string]string) (string, error) {
+	version, err := av.signatureVersion()
+	if err != nil {
+		return "", err
+	}
+
+	if r := headers["Range"]; r != "" {
+		// only the first bytes have been scanned
+		version += ";" + r
 	}
 
-	return res, err
+	checksum, err := av.checksum(ev)
+	if err != nil {
+		return "", err
+	}
+
+	return cache.Key(checksum, version), nil
+}
+
+// signatureVersion returns the version of the signature database, the verdicts
+// of scanners which do not report a version might change at any time
+func (av Antivirus) signatureVersion() (string, error) {
+	v, ok := av.scanner.(scanners.Versioner)
+	if !ok {
+		return "", scanners.ErrUnversioned
+	}
+
+	version, err := v.Version()
+	switch {
+	case err != nil:
+		return "", err
+	case version == "":
+		return "", scanners.ErrUnversioned
+	}
+
+	return version, nil
+}
+
+// checksum returns the checksum the storage computed for the file
+func (av Antivirus) checksum(ev events.StartPostprocessingStep) (string, error) {
+	ctx, err := av.serviceUserContext(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	gwc, err := av.gatewaySelector.Next()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := gwc.Stat(ctx, &provider.StatRequest{Ref: &provider.Reference{ResourceId: ev.ResourceID}})
+	switch {
+	case err != nil:
+		return "", err
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return "", fmt.Errorf("cannot stat file: %s", res.GetStatus().GetMessage())
+	case res.GetInfo().GetChecksum().GetSum() == "":
+		return "", errors.New("the storage does not provide a checksum")
+	}
+
+	return res.GetInfo().GetChecksum().GetType().String() + ":" + res.GetInfo().GetChecksum().GetSum(), nil
+}
+
+// download returns the content of the file to be scanned
+func (av Antivirus) download(ev events.StartPostprocessingStep, headers map[string]string) (io.ReadCloser, error) {
+	switch ev.UploadID {
+	default:
+		return av.downloadViaToken(ev.URL, headers)
+	case "":
+		return av.downloadViaReva(ev.URL, ev.Token, ev.RevaToken, headers)
+	}
 }
 
 // download will download the file
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/status"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	cs3mocks "github.com/opencloud-eu/reva/v2/tests/cs3mocks/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	microstore "go-micro.dev/v4/store"
+	"google.golang.org/grpc"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/cache"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/antivirus/pkg/scanners"
+)
+
+// fakeScanner counts the scans and reports every file as infected if infected is set
+type fakeScanner struct {
+	scans    atomic.Int32
+	infected bool
+	err      error
+}
+
+func (s *fakeScanner) Scan(in scanners.Input) (scanners.Result, error) {
+	s.scans.Add(1)
+	if _, err := io.Copy(io.Discard, in.Body); err != nil {
+		return scanners.Result{}, err
+	}
+
+	res := scanners.Result{Infected: s.infected, ScanTime: time.Now()}
+	if s.infected {
+		res.Description = "Eicar-Test-Signature"
+	}
+	return res, s.err
+}
+
+// versionedScanner reports the version of its signature database
+type versionedScanner struct {
+	fakeScanner
+	version string
+}
+
+func (s *versionedScanner) Version() (string, error) {
+	return s.version, nil
+}
+
+// fileServer serves the content of the file to scan and counts the downloads
+type fileServer struct {
+	*httptest.Server
+	downloads atomic.Int32
+}
+
+func newFileServer(t *testing.T, content string) *fileServer {
+	fs := &fileServer{}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fs.downloads.Add(1)
+		_, _ = io.WriteString(w, content)
+	}))
+	t.Cleanup(fs.Close)
+
+	return fs
+}
+
+func newGatewayClient(t *testing.T) (*cs3mocks.GatewayAPIClient, pool.Selectable[gateway.GatewayAPIClient]) {
+	gatewayClient := &cs3mocks.GatewayAPIClient{}
+	pool.RemoveSelector("GatewaySelector" + "eu.opencloud.api.gateway")
+	gatewaySelector := pool.GetSelector[gateway.GatewayAPIClient](
+		"GatewaySelector",
+		"eu.opencloud.api.gateway",
+		func(cc grpc.ClientConnInterface) gateway.GatewayAPIClient {
+			return gatewayClient
+		},
+	)
+
+	gatewayClient.On("Authenticate", mock.Anything, mock.Anything).Return(&gateway.AuthenticateResponse{
+		Status: status.NewOK(nil),
+		Token:  "service-token",
+	}, nil).Maybe()
+	t.Cleanup(func() { gatewayClient.AssertExpectations(t) })
+
+	return gatewayClient, gatewaySelector
+}
+
+// statChecksum makes the storage report the given checksum for every file
+func statChecksum(gatewayClient *cs3mocks.GatewayAPIClient, sum string) {
+	gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&provider.StatResponse{
+		Status: status.NewOK(nil),
+		Info: &provider.ResourceInfo{
+			Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+			Checksum: &provider.ResourceChecksum{Type: provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_SHA1, Sum: sum},
+		},
+	}, nil)
+}
+
+func newTestAntivirus(scanner Scanner, gatewaySelector pool.Selectable[gateway.GatewayAPIClient]) Antivirus {
+	return Antivirus{
+		config:          &config.Config{MaxScanSizeMode: config.MaxScanSizeModePartial},
+		log:             log.NopLogger(),
+		scanner:         scanner,
+		outcome:         events.PPOutcomeDelete,
+		cache:           cache.New(microstore.NewMemoryStore(), time.Hour),
+		gatewaySelector: gatewaySelector,
+		store:           microstore.NewMemoryStore(),
+		client:          http.DefaultClient,
+	}
+}
+
+func uploadEvent(url string) events.StartPostprocessingStep {
+	return events.StartPostprocessingStep{
+		UploadID:    "upload-id",
+		URL:         url,
+		Filename:    "file.txt",
+		Filesize:    7,
+		ResourceID:  &provider.ResourceId{StorageId: "storage-id", SpaceId: "space-id", OpaqueId: "file-id"},
+		StepToStart: events.PPStepAntivirus,
+	}
+}
+
+func TestProcess_ScanCache(t *testing.T) {
+	t.Run("files with the same checksum are only scanned once", func(t *testing.T) {
+		gatewayClient, gatewaySelector := newGatewayClient(t)
+		statChecksum(gatewayClient, "abc")
+		files := newFileServer(t, "content")
+		scanner := &versionedScanner{version: "1"}
+		av := newTestAntivirus(scanner, gatewaySelector)
+
+		for range 3 {
+			res, err := av.process(uploadEvent(files.URL))
+			require.NoError(t, err)
+			assert.False(t, res.Infected)
+		}
+
+		assert.EqualValues(t, 1, scanner.scans.Load())
+		assert.EqualValues(t, 1, files.downloads.Load(), "the file must only be downloaded for the actual scan")
+	})
+
+	t.Run("new signatures invalidate the cached results", func(t *testing.T) {
+		gatewayClient, gatewaySelector := newGatewayClient(t)
+		statChecksum(gatewayClient, "abc")
+		files := newFileServer(t, "content")
+		scanner := &versionedScanner{version: "1"}
+		av := newTestAntivirus(scanner, gatewaySelector)
+
+		_, err := av.process(uploadEvent(files.URL))
+		require.NoError(t, err)
+
+		scanner.version = "2"
+		_, err = av.process(uploadEvent(files.URL))
+		require.NoError(t, err)
+
+		assert.EqualValues(t, 2, scanner.scans.Load())
+	})
+
+	t.Run("results of scanners without a version are not cached", func(t *testing.T) {
+		_, gatewaySelector := newGatewayClient(t)
+		files := newFileServer(t, "content")
+		scanner := &fakeScanner{}
+		av := newTestAntivirus(scanner, gatewaySelector)
+
+		for range 2 {
+			_, err := av.process(uploadEvent(files.URL))
+			require.NoError(t, err)
+		}
+
+		assert.EqualValues(t, 2, scanner.scans.Load())
+	})
+
+	t.Run("partial scans are cached separately", func(t *testing.T) {
+		gatewayClient, gatewaySelector := newGatewayClient(t)
+		statChecksum(gatewayClient, "abc")
+		files := newFileServer(t, "content")
+		scanner := &versionedScanner{version: "1"}
+		av := newTestAntivirus(scanner, gatewaySelector)
+
+		_, err := av.process(uploadEvent(files.URL))
+		require.NoError(t, err)
+
+		av.maxScanSize = 3
+		_, err = av.process(uploadEvent(files.URL))
+		require.NoError(t, err)
+
+		assert.EqualValues(t, 2, scanner.scans.Load())
+	})
+}
@@ -10,11 +10,16 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/opencloud-eu/opencloud/pkg/config/configlog"
+	ogrpc "github.com/opencloud-eu/opencloud/pkg/service/grpc"
+	"github.com/opencloud-eu/opencloud/pkg/tracing"
+	settingssvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/settings/v0"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/config/parser"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/logging"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/server/debug"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/server/http"
 	svc "github.com/opencloud-eu/opencloud/services/audit/pkg/service"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/store"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/types"
 )
 
@@ -45,8 +50,53 @@ func Server(cfg *config.Config) *cli.Command {
 				return err
 			}
 
+			var st *store.Store
+			if cfg.Store.Enabled {
+				st, err = store.Open(cfg.Store.Path)
+				if err != nil {
+					return err
+				}
+				defer st.Close()
+
+				gr.Add(func() error {
+					svc.ExpireEvents(ctx, st, cfg.Store.Retention, logger)
+					return nil
+				}, func(_ error) {
+					cancel()
+				})
+
+				traceProvider, err := tracing.GetServiceTraceProvider(cfg.Tracing, cfg.Service.Name)
+				if err != nil {
+					return err
+				}
+
+				grpcClient, err := ogrpc.NewClient(
+					append(ogrpc.GetClientOptions(cfg.GRPCClientTLS), ogrpc.WithTraceProvider(traceProvider))...,
+				)
+				if err != nil {
+					return err
+				}
+
+				server, err := http.Server(
+					http.Logger(logger),
+					http.Context(ctx),
+					http.Config(cfg),
+					http.TracerProvider(traceProvider),
+					http.Store(st),
+					http.Role(settingssvc.NewRoleService("eu.opencloud.api.settings", grpcClient)),
+				)
+				if err != nil {
+					logger.Info().Err(err).Str("transport", "http").Msg("Failed to initialize server")
+					return err
+				}
+
+				gr.Add(server.Run, func(_ error) {
+					cancel()
+				})
+			}
+
 			gr.Add(func() error {
-				svc.AuditLoggerFromConfig(ctx, cfg.Auditlog, evts, logger)
+				svc.AuditLoggerFromConfig(ctx, cfg.Auditlog, st, evts, logger)
 				return nil
 			}, func(err error) {
 				if err == nil {
@@ -0,0 +1,61 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/opencloud-eu/opencloud/pkg/config/configlog"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/config/parser"
+	svc "github.com/opencloud-eu/opencloud/services/audit/pkg/service"
+	"github.com/urfave/cli/v2"
+)
+
+// Verify is the entrypoint for the verify command.
+func Verify(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "verify",
+		Usage:     "verify the hash chain of an audit log file",
+		ArgsUsage: "[file]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "partial",
+				Usage: "allow the file to start in the middle of the chain, e.g. after log rotation",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(c *cli.Context) error {
+			path := cfg.Auditlog.FilePath
+			if c.Args().Present() {
+				path = c.Args().First()
+			}
+			if path == "" {
+				return errors.New("no audit log file given")
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			res, err := svc.VerifyChain(f, cfg.Auditlog.HashChain.HMACKey, c.Bool("partial"))
+			var cerr *svc.ChainError
+			switch {
+			case errors.As(err, &cerr):
+				fmt.Printf("%d records verified, first broken link:\n", res.Records)
+				fmt.Printf("  line:     %d\n  sequence: %d\n  reason:   %s\n", cerr.Line, cerr.Sequence, cerr.Reason)
+				return cli.Exit("", 1)
+			case err != nil:
+				return err
+			}
+
+			fmt.Printf("%d records verified, sequence %d to %d, %d checkpoints\n", res.Records, res.FirstSequence, res.LastSequence, res.Checkpoints)
+			fmt.Printf("last hash: %s\n", res.LastHash)
+			return nil
+		},
+	}
+}
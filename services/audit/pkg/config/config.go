@@ -2,8 +2,10 @@ package config
 
 import (
 	"context"
+	"time"
 
 	"github.com/opencloud-eu/opencloud/pkg/shared"
+	"github.com/opencloud-eu/opencloud/pkg/tracing"
 )
 
 // Config combines all available configuration parts.
@@ -16,8 +18,13 @@ type Config struct {
 	Log     *Log     `yaml:"log"`
 	Debug   Debug    `yaml:"debug"`
 
-	Events   Events   `yaml:"events"`
-	Auditlog Auditlog `yaml:"auditlog"`
+	Events   Events     `yaml:"events"`
+	Auditlog Auditlog   `yaml:"auditlog"`
+	Store    Store      `yaml:"store"`
+	HTTP     HTTPServer `yaml:"http"`
+
+	GRPCClientTLS *shared.GRPCClientTLS `yaml:"grpc_client_tls"`
+	TokenManager  *TokenManager         `yaml:"token_manager"`
 
 	Context context.Context `yaml:"-"`
 }
@@ -38,7 +45,69 @@ type Auditlog struct {
 	LogToConsole bool   `yaml:"log_to_console" env:"AUDIT_LOG_TO_CONSOLE" desc:"Logs to stdout if set to 'true'. Independent of the LOG_TO_FILE option." introductionVersion:"1.0.0"`
 	LogToFile    bool   `yaml:"log_to_file" env:"AUDIT_LOG_TO_FILE" desc:"Logs to file if set to 'true'. Independent of the LOG_TO_CONSOLE option." introductionVersion:"1.0.0"`
 	FilePath     string `yaml:"filepath" env:"AUDIT_FILEPATH" desc:"Filepath of the logfile. Mandatory if LOG_TO_FILE is set to 'true'." introductionVersion:"1.0.0"`
-	Format       string `yaml:"format" env:"AUDIT_FORMAT" desc:"Log format. Supported values are '' (empty) and 'json'. Using 'json' is advised, '' (empty) renders the 'minimal' format. See the text description for more details." introductionVersion:"1.0.0"`
+	Format       string `yaml:"format" env:"AUDIT_FORMAT" desc:"Log format. Supported values are '' (empty), 'json', 'cef' and 'leef'. Using 'json' is advised, '' (empty) renders the 'minimal' format. See the text description for more details." introductionVersion:"1.0.0"`
+
+	LogToSyslog bool   `yaml:"log_to_syslog" env:"AUDIT_LOG_TO_SYSLOG" desc:"Logs to a syslog server using the RFC 5424 format if set to 'true'. Independent of the other log options." introductionVersion:"%%NEXT%%"`
+	LogToHTTP   bool   `yaml:"log_to_http" env:"AUDIT_LOG_TO_HTTP" desc:"Sends batches of audit events to an HTTP endpoint if set to 'true'. Independent of the other log options." introductionVersion:"%%NEXT%%"`
+	Syslog      Syslog `yaml:"syslog"`
+	HTTP        HTTP   `yaml:"http"`
+	Buffer      Buffer `yaml:"buffer"`
+
+	HashChain HashChain `yaml:"hash_chain"`
+}
+
+// HashChain configures the tamper evident audit log file.
+type HashChain struct {
+	Enabled            bool   `yaml:"enabled" env:"AUDIT_HASH_CHAIN_ENABLED" desc:"Chains the records of the audit log file by hashes if set to 'true'. Requires AUDIT_LOG_TO_FILE to be 'true' and AUDIT_FORMAT to be 'json'. See the text description for more details." introductionVersion:"%%NEXT%%"`
+	HMACKey            string `yaml:"hmac_key" env:"AUDIT_HASH_CHAIN_HMAC_KEY" desc:"The key used to sign the hashes of the audit log records with HMAC-SHA256. If empty, plain SHA-256 hashes are used and no checkpoints are written." introductionVersion:"%%NEXT%%"`
+	CheckpointInterval int    `yaml:"checkpoint_interval" env:"AUDIT_HASH_CHAIN_CHECKPOINT_INTERVAL" desc:"The number of audit log records after which a signed checkpoint is written. Set to '0' to disable checkpoints." introductionVersion:"%%NEXT%%"`
+}
+
+// Syslog configures the syslog sink.
+type Syslog struct {
+	Network              string `yaml:"network" env:"AUDIT_SYSLOG_NETWORK" desc:"The network used to connect to the syslog server. Supported values are 'udp', 'tcp' and 'tls'." introductionVersion:"%%NEXT%%"`
+	Address              string `yaml:"address" env:"AUDIT_SYSLOG_ADDRESS" desc:"The address of the syslog server, e.g. 'siem.example.com:6514'. Mandatory if AUDIT_LOG_TO_SYSLOG is set to 'true'." introductionVersion:"%%NEXT%%"`
+	Facility             string `yaml:"facility" env:"AUDIT_SYSLOG_FACILITY" desc:"The syslog facility of the audit events. Supported values are 'auth', 'authpriv', 'user' and 'local0' to 'local7'." introductionVersion:"%%NEXT%%"`
+	AppName              string `yaml:"app_name" env:"AUDIT_SYSLOG_APP_NAME" desc:"The APP-NAME field of the syslog messages." introductionVersion:"%%NEXT%%"`
+	TLSInsecure          bool   `yaml:"tls_insecure" env:"OC_INSECURE;AUDIT_SYSLOG_TLS_INSECURE" desc:"Whether to skip the verification of the syslog server TLS certificate." introductionVersion:"%%NEXT%%"`
+	TLSRootCACertificate string `yaml:"tls_root_ca_certificate" env:"AUDIT_SYSLOG_TLS_ROOT_CA_CERTIFICATE" desc:"The root CA certificate used to validate the syslog server TLS certificate." introductionVersion:"%%NEXT%%"`
+}
+
+// HTTP configures the HTTP sink.
+type HTTP struct {
+	URL           string        `yaml:"url" env:"AUDIT_HTTP_URL" desc:"The URL the batches of audit events are posted to. Mandatory if AUDIT_LOG_TO_HTTP is set to 'true'." introductionVersion:"%%NEXT%%"`
+	Authorization string        `yaml:"authorization" env:"AUDIT_HTTP_AUTHORIZATION" desc:"The value of the Authorization header sent with each request, e.g. 'Bearer <token>'." introductionVersion:"%%NEXT%%"`
+	BatchSize     int           `yaml:"batch_size" env:"AUDIT_HTTP_BATCH_SIZE" desc:"The maximum number of audit events sent in one request." introductionVersion:"%%NEXT%%"`
+	FlushInterval time.Duration `yaml:"flush_interval" env:"AUDIT_HTTP_FLUSH_INTERVAL" desc:"The maximum time audit events are collected before a batch is sent. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	Timeout       time.Duration `yaml:"timeout" env:"AUDIT_HTTP_TIMEOUT" desc:"The timeout of the requests to the HTTP endpoint. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	Insecure      bool          `yaml:"insecure" env:"OC_INSECURE;AUDIT_HTTP_INSECURE" desc:"Whether to skip the verification of the HTTP endpoint TLS certificate." introductionVersion:"%%NEXT%%"`
+}
+
+// Buffer configures the buffering of the syslog and HTTP sinks.
+type Buffer struct {
+	Size         int           `yaml:"size" env:"AUDIT_BUFFER_SIZE" desc:"The number of audit events buffered per sink while the sink is slow or unavailable. Audit events are dropped if the buffer is full." introductionVersion:"%%NEXT%%"`
+	RetryBackoff time.Duration `yaml:"retry_backoff" env:"AUDIT_RETRY_BACKOFF" desc:"The initial duration to wait before retrying to deliver audit events to a failed sink. It doubles with every failure up to one minute. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"AUDIT_MAX_DELIVERY_ATTEMPTS" desc:"The number of attempts to deliver a batch of audit events to a failed sink before the batch is dropped. A value of 0 retries until the delivery succeeds." introductionVersion:"%%NEXT%%"`
+}
+
+// Store configures the queryable audit event store.
+type Store struct {
+	Enabled   bool          `yaml:"enabled" env:"AUDIT_STORE_ENABLED" desc:"Persists the audit events in an embedded store and serves the audit API if set to 'true'. Independent of the log options." introductionVersion:"%%NEXT%%"`
+	Path      string        `yaml:"path" env:"AUDIT_STORE_PATH" desc:"The path of the audit event store database file. If not defined, the root directory derives from $OC_BASE_DATA_PATH/audit." introductionVersion:"%%NEXT%%"`
+	Retention time.Duration `yaml:"retention" env:"AUDIT_STORE_RETENTION" desc:"The duration audit events are kept in the store. Set to '0' to keep the audit events forever. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+}
+
+// HTTPServer defines the available http configuration of the audit api.
+type HTTPServer struct {
+	Addr      string                `yaml:"addr" env:"AUDIT_HTTP_ADDR" desc:"The bind address of the HTTP service. It serves the audit API and is only started when AUDIT_STORE_ENABLED is set to 'true'." introductionVersion:"%%NEXT%%"`
+	Namespace string                `yaml:"-"`
+	Root      string                `yaml:"root" env:"AUDIT_HTTP_ROOT" desc:"Subdirectory that serves as the root for this HTTP service." introductionVersion:"%%NEXT%%"`
+	TLS       shared.HTTPServiceTLS `yaml:"tls"`
+}
+
+// TokenManager is the config for using the reva token manager
+type TokenManager struct {
+	JWTSecret string `yaml:"jwt_secret" env:"OC_JWT_SECRET;AUDIT_JWT_SECRET" desc:"The secret to mint and validate jwt tokens." introductionVersion:"%%NEXT%%"`
 }
 
 // Tracing defines the available tracing configuration.
@@ -48,3 +117,13 @@ type Tracing struct {
 	Endpoint  string `yaml:"endpoint" env:"OC_TRACING_ENDPOINT;AUDIT_TRACING_ENDPOINT" desc:"The endpoint of the tracing agent." introductionVersion:"1.0.0"`
 	Collector string `yaml:"collector" env:"OC_TRACING_COLLECTOR;AUDIT_TRACING_COLLECTOR" desc:"The HTTP endpoint for sending spans directly to a collector, i.e. http://jaeger-collector:14268/api/traces. Only used if the tracing endpoint is unset." introductionVersion:"1.0.0"`
 }
+
+// Convert Tracing to the tracing package's Config struct.
+func (t Tracing) Convert() tracing.Config {
+	return tracing.Config{
+		Enabled:   t.Enabled,
+		Type:      t.Type,
+		Endpoint:  t.Endpoint,
+		Collector: t.Collector,
+	}
+}
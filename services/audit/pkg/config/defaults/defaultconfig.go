@@ -1,6 +1,11 @@
 package defaults
 
 import (
+	"path/filepath"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/config/defaults"
+	"github.com/opencloud-eu/opencloud/pkg/structs"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
 )
 
@@ -31,6 +36,33 @@ func DefaultConfig() *config.Config {
 		Auditlog: config.Auditlog{
 			LogToConsole: true,
 			Format:       "json",
+			Syslog: config.Syslog{
+				Network:  "tcp",
+				Facility: "local0",
+				AppName:  "opencloud",
+			},
+			HTTP: config.HTTP{
+				BatchSize:     100,
+				FlushInterval: 5 * time.Second,
+				Timeout:       10 * time.Second,
+			},
+			Buffer: config.Buffer{
+				Size:         10000,
+				RetryBackoff: time.Second,
+				MaxAttempts:  10,
+			},
+			HashChain: config.HashChain{
+				CheckpointInterval: 1000,
+			},
+		},
+		Store: config.Store{
+			Path:      filepath.Join(defaults.BaseDataPath(), "audit", "audit.db"),
+			Retention: 90 * 24 * time.Hour,
+		},
+		HTTP: config.HTTPServer{
+			Addr:      "127.0.0.1:9228",
+			Root:      "/",
+			Namespace: "eu.opencloud.web",
 		},
 	}
 }
@@ -60,6 +92,22 @@ func EnsureDefaults(cfg *config.Config) {
 	} else if cfg.Tracing == nil {
 		cfg.Tracing = &config.Tracing{}
 	}
+
+	if cfg.GRPCClientTLS == nil && cfg.Commons != nil {
+		cfg.GRPCClientTLS = structs.CopyOrZeroValue(cfg.Commons.GRPCClientTLS)
+	}
+
+	if cfg.TokenManager == nil && cfg.Commons != nil && cfg.Commons.TokenManager != nil {
+		cfg.TokenManager = &config.TokenManager{
+			JWTSecret: cfg.Commons.TokenManager.JWTSecret,
+		}
+	} else if cfg.TokenManager == nil {
+		cfg.TokenManager = &config.TokenManager{}
+	}
+
+	if cfg.Commons != nil {
+		cfg.HTTP.TLS = cfg.Commons.HTTPServiceTLS
+	}
 }
 
 // Sanitize sanitized the configuration
@@ -2,8 +2,10 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 
 	occfg "github.com/opencloud-eu/opencloud/pkg/config"
+	"github.com/opencloud-eu/opencloud/pkg/shared"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/config/defaults"
 
@@ -34,5 +36,46 @@ func ParseConfig(cfg *config.Config) error {
 
 // Validate validates the configuration
 func Validate(cfg *config.Config) error {
+	switch cfg.Auditlog.Format {
+	case "", "minimal", "json", "cef", "leef":
+	default:
+		return fmt.Errorf("unsupported audit log format '%s'", cfg.Auditlog.Format)
+	}
+
+	if cfg.Auditlog.LogToSyslog {
+		switch cfg.Auditlog.Syslog.Network {
+		case "udp", "tcp", "tls":
+		default:
+			return fmt.Errorf("unsupported syslog network '%s'", cfg.Auditlog.Syslog.Network)
+		}
+
+		switch cfg.Auditlog.Syslog.Facility {
+		case "user", "auth", "authpriv", "local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7":
+		default:
+			return fmt.Errorf("unsupported syslog facility '%s'", cfg.Auditlog.Syslog.Facility)
+		}
+
+		if cfg.Auditlog.Syslog.Address == "" {
+			return errors.New("the syslog address is required when logging to syslog")
+		}
+	}
+
+	if cfg.Auditlog.LogToHTTP && cfg.Auditlog.HTTP.URL == "" {
+		return errors.New("the http url is required when logging to http")
+	}
+
+	if cfg.Auditlog.HashChain.Enabled {
+		if !cfg.Auditlog.LogToFile {
+			return errors.New("the hash chain requires logging to file")
+		}
+		if cfg.Auditlog.Format != "json" {
+			return errors.New("the hash chain requires the json format")
+		}
+	}
+
+	if cfg.Store.Enabled && cfg.TokenManager.JWTSecret == "" {
+		return shared.MissingJWTTokenError(cfg.Service.Name)
+	}
+
 	return nil
 }
@@ -0,0 +1,190 @@
+package svc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/pkg/roles"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/store"
+	settings "github.com/opencloud-eu/opencloud/services/settings/pkg/service/v0"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+var errInvalidQuery = errors.New("invalid query")
+
+// APIHandler serves the api to search the stored audit events
+type APIHandler struct {
+	st  *store.Store
+	mux *chi.Mux
+	log log.Logger
+}
+
+// EventsResponse is a page of audit events
+type EventsResponse struct {
+	Events []store.Record `json:"events"`
+	// Next is the cursor of the next page, it is empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+// NewAPIHandler returns the http handler of the audit api
+func NewAPIHandler(st *store.Store, rm *roles.Manager, mux *chi.Mux, log log.Logger) *APIHandler {
+	h := &APIHandler{st: st, mux: mux, log: log}
+
+	mux.Route("/audit/v1/events", func(r chi.Router) {
+		r.Use(roles.RequirePermission(rm, settings.AccountManagementPermissionID, log))
+		r.Get("/", h.HandleList)
+		r.Get("/export", h.HandleExport)
+	})
+
+	return h
+}
+
+// ServeHTTP fulfills Handler interface
+func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// HandleList is the GET handler returning a page of the audit events matching the query
+func (h *APIHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	q.Limit = defaultPageSize
+	if l := r.URL.Query().Get("limit"); l != "" {
+		q.Limit, err = strconv.Atoi(l)
+		if err != nil || q.Limit < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		q.Limit = min(q.Limit, maxPageSize)
+	}
+
+	events, next, err := h.st.Find(q)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, EventsResponse{Events: events, Next: next})
+}
+
+// HandleExport is the GET handler streaming all audit events matching the query as csv or json
+func (h *APIHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		h.exportJSON(w, r, q)
+	case "csv":
+		h.exportCSV(w, r, q)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (h *APIHandler) exportJSON(w http.ResponseWriter, r *http.Request, q store.Query) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.json"`)
+
+	enc := json.NewEncoder(w)
+	n := 0
+	_, _ = w.Write([]byte("["))
+	err := h.st.Walk(q, func(rec store.Record) error {
+		if n > 0 {
+			if _, err := w.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		n++
+		return enc.Encode(rec)
+	})
+	if err != nil {
+		// the status was already sent, the truncated output is invalid json
+		h.log.Error().Err(err).Msg("error exporting audit events")
+		return
+	}
+	_, _ = w.Write([]byte("]"))
+}
+
+func (h *APIHandler) exportCSV(w http.ResponseWriter, r *http.Request, q store.Query) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"id", "time", "user", "action", "resource_id", "space_id", "message", "event"})
+	err := h.st.Walk(q, func(rec store.Record) error {
+		return cw.Write([]string{
+			rec.ID,
+			rec.Time.Format(time.RFC3339),
+			rec.User,
+			rec.Action,
+			rec.ResourceID,
+			rec.SpaceID,
+			rec.Message,
+			string(rec.Event),
+		})
+	})
+	cw.Flush()
+	if err == nil {
+		err = cw.Error()
+	}
+	if err != nil {
+		h.log.Error().Err(err).Msg("error exporting audit events")
+	}
+}
+
+func (h *APIHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrInvalidCursor):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		h.log.Error().Err(err).Str("path", r.URL.Path).Msg("audit api request failed")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// parseQuery reads the filters of the audit events from the url query
+func parseQuery(r *http.Request) (store.Query, error) {
+	v := r.URL.Query()
+	q := store.Query{
+		User:       v.Get("user"),
+		Action:     v.Get("action"),
+		ResourceID: v.Get("resource"),
+		SpaceID:    v.Get("space"),
+		Cursor:     v.Get("cursor"),
+	}
+
+	for param, t := range map[string]*time.Time{"from": &q.From, "to": &q.To} {
+		s := v.Get(param)
+		if s == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return q, errInvalidQuery
+		}
+		*t = parsed
+	}
+
+	return q, nil
+}
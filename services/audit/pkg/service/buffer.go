@@ -0,0 +1,111 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
+)
+
+// maxRetryBackoff limits the time between two delivery attempts
+const maxRetryBackoff = time.Minute
+
+// Sender delivers a batch of audit events to a sink. It is retried until it succeeds, fails with a
+// NonRetryableError or runs out of attempts.
+type Sender func(ctx context.Context, batch [][]byte) error
+
+// NonRetryableError is returned by a Sender if the sink rejected the batch, sending it again would fail as well
+type NonRetryableError struct {
+	Err error
+}
+
+func (e *NonRetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NonRetryableError) Unwrap() error {
+	return e.Err
+}
+
+// Buffered returns a Log function which queues the audit events and delivers them in the background,
+// so a slow or unavailable sink does not block the event consumer. Events are dropped if the buffer is full.
+func Buffered(ctx context.Context, name string, cfg config.Buffer, batchSize int, flushInterval time.Duration, send Sender, log log.Logger) Log {
+	queue := make(chan []byte, cfg.Size)
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+
+		batch := make([][]byte, 0, batchSize)
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			deliver(ctx, name, cfg, batch, send, log)
+			batch = make([][]byte, 0, batchSize)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case b := <-queue:
+				batch = append(batch, b)
+				if len(batch) >= batchSize {
+					flush()
+				}
+			case <-ticker.C:
+				flush()
+			}
+		}
+	}()
+
+	return func(content []byte) {
+		select {
+		case queue <- content:
+		default:
+			log.Error().Str("sink", name).Msg("audit buffer is full, dropping event")
+		}
+	}
+}
+
+// deliver sends the batch until it succeeds, the sink rejects it, the attempts are exhausted or the context is done
+func deliver(ctx context.Context, name string, cfg config.Buffer, batch [][]byte, send Sender, log log.Logger) {
+	backoff := cfg.RetryBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := send(ctx, batch)
+		if err == nil {
+			return
+		}
+
+		var nre *NonRetryableError
+		switch {
+		case errors.As(err, &nre):
+			log.Error().Err(err).Str("sink", name).Int("events", len(batch)).Msg("audit events rejected by the sink, dropping them")
+			return
+		case cfg.MaxAttempts > 0 && attempt >= cfg.MaxAttempts:
+			log.Error().Err(err).Str("sink", name).Int("events", len(batch)).Int("attempts", attempt).Msg("error delivering audit events, dropping them")
+			return
+		}
+
+		log.Error().Err(err).Str("sink", name).Int("events", len(batch)).Dur("retry", backoff).Msg("error delivering audit events")
+
+		select {
+		case <-ctx.Done():
+			log.Error().Str("sink", name).Int("events", len(batch)).Msg("shutting down, dropping undelivered audit events")
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
@@ -0,0 +1,37 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
+)
+
+func TestDeliver(t *testing.T) {
+	cfg := config.Buffer{RetryBackoff: time.Millisecond, MaxAttempts: 3}
+
+	t.Run("gives up after the maximum number of attempts", func(t *testing.T) {
+		attempts := 0
+		deliver(context.Background(), "test", cfg, [][]byte{[]byte("event")}, func(context.Context, [][]byte) error {
+			attempts++
+			return errors.New("unavailable")
+		}, log.NopLogger())
+
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("drops batches the sink rejects", func(t *testing.T) {
+		attempts := 0
+		deliver(context.Background(), "test", cfg, [][]byte{[]byte("event")}, func(context.Context, [][]byte) error {
+			attempts++
+			return &NonRetryableError{Err: errors.New("bad request")}
+		}, log.NopLogger())
+
+		require.Equal(t, 1, attempts)
+	})
+}
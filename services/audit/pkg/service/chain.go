@@ -0,0 +1,318 @@
+package svc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/types"
+)
+
+// CheckpointAction is the action of the checkpoint records in a hash chained audit log
+const CheckpointAction = "audit_checkpoint"
+
+// hashField starts the hash of a record, it must be the last chain field of the record
+const hashField = `,"Hash":"`
+
+// maxRecordSize limits the size of the last record read when resuming a chain
+const maxRecordSize = 1 << 20
+
+// Chain links the records of an audit log by hashes. Every record carries its sequence number,
+// the hash of the previous record and its own hash, which covers the whole record including the previous hash.
+type Chain struct {
+	key      []byte
+	interval uint64
+
+	// Sequence is the sequence number of the last record
+	Sequence uint64
+	// Hash is the hash of the last record
+	Hash string
+}
+
+// ChainRecord holds the fields added to the records of a hash chained audit log
+type ChainRecord struct {
+	Action       string
+	Sequence     uint64
+	PreviousHash string
+	Hash         string
+	Signature    string // only set for checkpoints
+}
+
+// ChainError reports the first broken link of a hash chained audit log
+type ChainError struct {
+	Line     int
+	Sequence uint64
+	Reason   string
+}
+
+// Error implements the error interface
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("broken chain in line %d (sequence %d): %s", e.Line, e.Sequence, e.Reason)
+}
+
+// VerifyResult summarizes a verified hash chained audit log
+type VerifyResult struct {
+	Records       int
+	Checkpoints   int
+	FirstSequence uint64
+	LastSequence  uint64
+	LastHash      string
+}
+
+// NewChain returns a new chain. The hashes are HMAC signed if a key is given,
+// checkpoints are written every `interval` records if a key is given and the interval is positive.
+func NewChain(key string, interval int) *Chain {
+	c := &Chain{key: []byte(key)}
+	if key != "" && interval > 0 {
+		c.interval = uint64(interval)
+	}
+	return c
+}
+
+// Resume continues the chain of an existing audit log file
+func (c *Chain) Resume(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset := max(info.Size()-maxRecordSize, 0)
+	b := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(b, offset); err != nil && err != io.EOF {
+		return err
+	}
+
+	b = bytes.TrimRight(b, "\n")
+	if len(b) == 0 {
+		return nil
+	}
+	i := bytes.LastIndexByte(b, '\n')
+	if i < 0 && offset > 0 {
+		return fmt.Errorf("the last record of '%s' is too large", path)
+	}
+
+	rec, _, err := parseRecord(b[i+1:])
+	if err != nil {
+		return fmt.Errorf("cannot resume the chain of '%s': %w", path, err)
+	}
+
+	c.Sequence, c.Hash = rec.Sequence, rec.Hash
+	return nil
+}
+
+// link returns the json object with the chain fields added and its hash. It does not advance the chain.
+func (c *Chain) link(content []byte, checkpoint bool) ([]byte, string, error) {
+	content = bytes.TrimSpace(content)
+	if len(content) < 2 || content[0] != '{' || content[len(content)-1] != '}' {
+		return nil, "", errors.New("only json objects can be chained")
+	}
+
+	var b bytes.Buffer
+	b.Write(content[:len(content)-1])
+	if len(bytes.TrimSpace(content[1:len(content)-1])) > 0 {
+		b.WriteByte(',')
+	}
+	fmt.Fprintf(&b, `"Sequence":%d,"PreviousHash":"%s"`, c.Sequence+1, c.Hash)
+
+	hash := c.sum(b.Bytes())
+	b.WriteString(hashField + hash + `"`)
+	if checkpoint {
+		b.WriteString(`,"Signature":"` + c.sign(hash) + `"`)
+	}
+	b.WriteByte('}')
+
+	return b.Bytes(), hash, nil
+}
+
+// advance moves the chain to the record with the given hash
+func (c *Chain) advance(hash string) {
+	c.Sequence++
+	c.Hash = hash
+}
+
+// checkpointDue returns true if a checkpoint must be written after the last record
+func (c *Chain) checkpointDue() bool {
+	return c.interval > 0 && c.Sequence%(c.interval+1) == c.interval
+}
+
+// checkpoint returns a signed checkpoint record
+func (c *Chain) checkpoint(t time.Time) ([]byte, string, error) {
+	b, err := json.Marshal(types.AuditEvent{
+		User:    "audit",
+		Time:    t.Format(time.RFC3339),
+		App:     "admin_audit",
+		Message: fmt.Sprintf("checkpoint after record %d with hash '%s'", c.Sequence, c.Hash),
+		Action:  CheckpointAction,
+		Level:   1,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return c.link(b, true)
+}
+
+func (c *Chain) sum(b []byte) string {
+	if len(c.key) == 0 {
+		s := sha256.Sum256(b)
+		return hex.EncodeToString(s[:])
+	}
+	m := hmac.New(sha256.New, c.key)
+	m.Write(b)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func (c *Chain) sign(hash string) string {
+	m := hmac.New(sha256.New, c.key)
+	m.Write([]byte(CheckpointAction + ":" + hash))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// parseRecord returns the chain fields of a record and the part of the record covered by its hash
+func parseRecord(line []byte) (ChainRecord, []byte, error) {
+	var rec ChainRecord
+	i := bytes.LastIndex(line, []byte(hashField))
+	if i < 0 {
+		return rec, nil, errors.New("the record has no hash")
+	}
+
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return rec, nil, fmt.Errorf("the record is no valid json: %w", err)
+	}
+
+	trailer := hashField + rec.Hash + `"`
+	if rec.Signature != "" {
+		trailer += `,"Signature":"` + rec.Signature + `"`
+	}
+	if string(line[i:]) != trailer+"}" {
+		return rec, nil, errors.New("the hash is not the last field of the record")
+	}
+
+	return rec, line[:i], nil
+}
+
+// WriteToChainedFile returns a Log function writing hash chained records to a file
+func WriteToChainedFile(path string, chain *Chain, log log.Logger) Log {
+	write := func(file *os.File, record []byte, hash string) bool {
+		if _, err := file.Write(append(record, '\n')); err != nil {
+			log.Error().Err(err).Msgf("error writing to file '%s'", path)
+			return false
+		}
+		chain.advance(hash)
+		return true
+	}
+
+	return func(content []byte) {
+		record, hash, err := chain.link(content, false)
+		if err != nil {
+			log.Error().Err(err).Msg("error chaining the audit event")
+			return
+		}
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Error().Err(err).Msgf("error opening file '%s'", path)
+			return
+		}
+		defer file.Close()
+
+		if !write(file, record, hash) || !chain.checkpointDue() {
+			return
+		}
+
+		record, hash, err = chain.checkpoint(time.Now())
+		if err != nil {
+			log.Error().Err(err).Msg("error creating the audit checkpoint")
+			return
+		}
+		write(file, record, hash)
+	}
+}
+
+// VerifyChain verifies the hash chain of an audit log and returns a *ChainError for the first broken link.
+// Unless partial is true the log must start with the first record of the chain.
+func VerifyChain(r io.Reader, key string, partial bool) (VerifyResult, error) {
+	var (
+		res   VerifyResult
+		c     = NewChain(key, 0)
+		br    = bufio.NewReader(r)
+		lines int
+	)
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return res, err
+		}
+		lines++
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			rec, cerr := c.verify(line, res.Records == 0 && partial, key != "")
+			if cerr != nil {
+				cerr.Line = lines
+				return res, cerr
+			}
+
+			if res.Records == 0 {
+				res.FirstSequence = rec.Sequence
+			}
+			res.Records++
+			if rec.Action == CheckpointAction {
+				res.Checkpoints++
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	res.LastSequence, res.LastHash = c.Sequence, c.Hash
+	return res, nil
+}
+
+// verify checks that the record continues the chain and advances the chain
+func (c *Chain) verify(line []byte, anchor bool, signed bool) (ChainRecord, *ChainError) {
+	rec, covered, err := parseRecord(line)
+	if err != nil {
+		return rec, &ChainError{Sequence: c.Sequence + 1, Reason: err.Error()}
+	}
+
+	switch {
+	case anchor:
+		// a partial log starts anywhere in the chain
+		c.Sequence, c.Hash = rec.Sequence-1, rec.PreviousHash
+	case rec.Sequence != c.Sequence+1:
+		return rec, &ChainError{Sequence: rec.Sequence, Reason: fmt.Sprintf("expected sequence %d", c.Sequence+1)}
+	case rec.PreviousHash != c.Hash:
+		return rec, &ChainError{Sequence: rec.Sequence, Reason: "the previous hash does not match the hash of the previous record"}
+	}
+
+	if !hmac.Equal([]byte(c.sum(covered)), []byte(rec.Hash)) {
+		return rec, &ChainError{Sequence: rec.Sequence, Reason: "the hash does not match the record"}
+	}
+
+	if rec.Action == CheckpointAction && signed && !hmac.Equal([]byte(c.sign(rec.Hash)), []byte(rec.Signature)) {
+		return rec, &ChainError{Sequence: rec.Sequence, Reason: "the checkpoint signature is invalid"}
+	}
+
+	c.advance(rec.Hash)
+	return rec, nil
+}
@@ -0,0 +1,87 @@
+package svc
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+)
+
+func writeChain(t *testing.T, path string, key string, n int) {
+	chain := NewChain(key, 3)
+	require.NoError(t, chain.Resume(path))
+
+	l := WriteToChainedFile(path, chain, log.NopLogger())
+	for i := 0; i < n; i++ {
+		b, err := json.Marshal(auditEvent)
+		require.NoError(t, err)
+		l(b)
+	}
+}
+
+func TestChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeChain(t, path, "secret", 4)
+	// a restart continues the chain
+	writeChain(t, path, "secret", 3)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	res, err := VerifyChain(bytes.NewReader(b), "secret", false)
+	require.NoError(t, err)
+	require.Equal(t, 9, res.Records)
+	require.Equal(t, 2, res.Checkpoints)
+	require.Equal(t, uint64(9), res.LastSequence)
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Contains(t, lines[3], `"Action":"audit_checkpoint"`)
+
+	// a wrong key breaks the first link
+	_, err = VerifyChain(bytes.NewReader(b), "other", false)
+	var cerr *ChainError
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, 1, cerr.Line)
+
+	// a modified record is detected
+	tampered := strings.Replace(string(b), "trashed", "restored", 1)
+	res, err = VerifyChain(strings.NewReader(tampered), "secret", false)
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, 1, cerr.Line)
+	require.Equal(t, 0, res.Records)
+
+	// a removed record is detected
+	removed := strings.Join(append(append([]string{}, lines[:4]...), lines[5:]...), "\n")
+	_, err = VerifyChain(strings.NewReader(removed), "secret", false)
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, 5, cerr.Line)
+	require.Equal(t, uint64(6), cerr.Sequence)
+
+	// a partial log is only accepted if requested
+	partial := strings.Join(lines[2:], "\n")
+	_, err = VerifyChain(strings.NewReader(partial), "secret", false)
+	require.ErrorAs(t, err, &cerr)
+	res, err = VerifyChain(strings.NewReader(partial), "secret", true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), res.FirstSequence)
+}
+
+func TestChainWithoutKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeChain(t, path, "", 5)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	res, err := VerifyChain(f, "", false)
+	require.NoError(t, err)
+	require.Equal(t, 5, res.Records)
+	require.Equal(t, 0, res.Checkpoints)
+}
@@ -0,0 +1,70 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/opencloud-eu/reva/v2/pkg/rhttp"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
+)
+
+// WriteToHTTP returns a Log function posting batches of audit events to an HTTP endpoint.
+// The body contains one audit event per line.
+func WriteToHTTP(ctx context.Context, cfg config.HTTP, buf config.Buffer, format string, log log.Logger) Log {
+	client := rhttp.GetHTTPClient(
+		rhttp.Timeout(cfg.Timeout),
+		rhttp.Insecure(cfg.Insecure),
+	)
+
+	contentType := "text/plain; charset=utf-8"
+	if format == "json" {
+		contentType = "application/x-ndjson"
+	}
+
+	send := func(ctx context.Context, batch [][]byte) error {
+		body := bytes.Join(batch, []byte("\n"))
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(append(body, '\n')))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		if cfg.Authorization != "" {
+			req.Header.Set("Authorization", cfg.Authorization)
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			err := fmt.Errorf("unexpected status code %d", res.StatusCode)
+			if !retryable(res.StatusCode) {
+				return &NonRetryableError{Err: err}
+			}
+			return err
+		}
+
+		return nil
+	}
+
+	return Buffered(ctx, "http", buf, cfg.BatchSize, cfg.FlushInterval, send, log)
+}
+
+// retryable reports whether a request failing with the status code might succeed later,
+// the client errors mean the batch itself is rejected except for timeouts and rate limits
+func retryable(status int) bool {
+	switch {
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	case status >= 400 && status < 500:
+		return false
+	default:
+		return true
+	}
+}
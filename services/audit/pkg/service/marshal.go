@@ -0,0 +1,194 @@
+package svc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/version"
+)
+
+const (
+	deviceVendor  = "OpenCloud"
+	deviceProduct = "OpenCloud"
+)
+
+// cefKeys maps the audit event fields to the CEF extension keys defined by ArcSight
+var cefKeys = map[string]string{
+	"RemoteAddr": "src",
+	"User":       "suser",
+	"URL":        "request",
+	"Method":     "requestMethod",
+	"UserAgent":  "requestClientApplication",
+	"Time":       "rt",
+	"Message":    "msg",
+	"Action":     "act",
+}
+
+// cefCustomStrings maps the audit event fields without a predefined CEF key to the custom string
+// extensions cs1 to cs5, cs6 carries the remaining event specific fields as json
+var cefCustomStrings = []string{"FileID", "App", "CLI", "Level", "Owner"}
+
+const cefDetailsKey = "cs6"
+
+// leefKeys maps the audit event fields to the predefined LEEF attributes
+var leefKeys = map[string]string{
+	"RemoteAddr": "src",
+	"User":       "usrName",
+	"Time":       "devTime",
+	"Level":      "sev",
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefHeaderEscaper   = strings.NewReplacer(`|`, `\|`)
+	leefValueEscaper    = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+// MarshalCEF marshals an audit event to the ArcSight Common Event Format
+func MarshalCEF(ev interface{}) ([]byte, error) {
+	fields, err := flatten(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|%s|%s|%s|%s|%s|%s|",
+		cefHeaderEscaper.Replace(deviceVendor),
+		cefHeaderEscaper.Replace(deviceProduct),
+		cefHeaderEscaper.Replace(version.GetString()),
+		cefHeaderEscaper.Replace(fields["Action"]),
+		cefHeaderEscaper.Replace(fields["Message"]),
+		cefHeaderEscaper.Replace(fields["Level"]),
+	)
+
+	ext := make(map[string]string, len(fields))
+	for i, k := range cefCustomStrings {
+		if v, ok := fields[k]; ok {
+			ext[fmt.Sprintf("cs%d", i+1)] = v
+			ext[fmt.Sprintf("cs%dLabel", i+1)] = k
+			delete(fields, k)
+		}
+	}
+
+	details := make(map[string]string)
+	for k, v := range fields {
+		switch ck, ok := cefKeys[k]; {
+		case !ok:
+			details[k] = v
+		case ck == "rt":
+			ext[ck] = cefTimestamp(v)
+		default:
+			ext[ck] = v
+		}
+	}
+	if len(details) > 0 {
+		d, err := json.Marshal(details)
+		if err != nil {
+			return nil, err
+		}
+		ext[cefDetailsKey] = string(d)
+		ext[cefDetailsKey+"Label"] = "Details"
+	}
+
+	for i, k := range sortedKeys(ext) {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%s", k, cefExtensionEscaper.Replace(ext[k]))
+	}
+
+	return []byte(b.String()), nil
+}
+
+// MarshalLEEF marshals an audit event to the IBM QRadar Log Event Extended Format version 1.0
+func MarshalLEEF(ev interface{}) ([]byte, error) {
+	fields, err := flatten(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "LEEF:1.0|%s|%s|%s|%s|",
+		leefHeaderEscaper.Replace(deviceVendor),
+		leefHeaderEscaper.Replace(deviceProduct),
+		leefHeaderEscaper.Replace(version.GetString()),
+		leefHeaderEscaper.Replace(fields["Action"]),
+	)
+
+	if fields["Time"] != "" {
+		fields["devTimeFormat"] = "yyyy-MM-dd'T'HH:mm:ssXXX"
+	}
+
+	for i, k := range sortedKeys(fields) {
+		if i > 0 {
+			b.WriteByte('\t')
+		}
+		key := k
+		if lk, ok := leefKeys[k]; ok {
+			key = lk
+		}
+		fmt.Fprintf(&b, "%s=%s", key, leefValueEscaper.Replace(fields[k]))
+	}
+
+	return []byte(b.String()), nil
+}
+
+// cefTimestamp converts the RFC3339 time of the audit events to milliseconds since the epoch, one of
+// the formats the rt key accepts
+func cefTimestamp(v string) string {
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return v
+	}
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+// flatten returns the non-empty fields of the audit event as strings
+func flatten(ev interface{}) (map[string]string, error) {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string, len(m))
+	for k, v := range m {
+		switch v := v.(type) {
+		case nil:
+			continue
+		case string:
+			if v == "" {
+				continue
+			}
+			fields[k] = v
+		case map[string]interface{}, []interface{}:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			fields[k] = string(b)
+		default:
+			fields[k] = fmt.Sprint(v)
+		}
+	}
+
+	return fields, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,125 @@
+package svc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/types"
+)
+
+var auditEvent = types.AuditEventFileDeleted{
+	AuditEventFiles: types.AuditEventFiles{
+		AuditEvent: types.AuditEvent{
+			User:    "user_id",
+			Time:    "2018-05-08T08:26:00+00:00",
+			App:     "admin_audit",
+			Message: "user 'user_id' trashed file 'a|b=c'",
+			Action:  "file_delete",
+			Level:   1,
+		},
+		FileID: "item_id",
+	},
+}
+
+func TestMarshalCEF(t *testing.T) {
+	b, err := MarshalCEF(auditEvent)
+	require.NoError(t, err)
+
+	s := string(b)
+	require.True(t, strings.HasPrefix(s, `CEF:0|OpenCloud|OpenCloud|`), s)
+	require.Contains(t, s, `|file_delete|user 'user_id' trashed file 'a\|b=c'|1|`)
+	require.Contains(t, s, `msg=user 'user_id' trashed file 'a|b\=c'`)
+	require.Contains(t, s, `suser=user_id`)
+	require.Contains(t, s, `rt=1525767960000`)
+	require.Contains(t, s, `cs1=item_id cs1Label=FileID cs2=admin_audit cs2Label=App cs3=false cs3Label=CLI cs4=1 cs4Label=Level`)
+	require.NotContains(t, s, `FileID=`)
+	require.NotContains(t, s, `cs6=`)
+	require.NotContains(t, s, `src=`)
+}
+
+func TestMarshalLEEF(t *testing.T) {
+	b, err := MarshalLEEF(auditEvent)
+	require.NoError(t, err)
+
+	s := string(b)
+	require.True(t, strings.HasPrefix(s, `LEEF:1.0|OpenCloud|OpenCloud|`), s)
+	require.Contains(t, s, "|file_delete|")
+	require.Contains(t, s, "\tusrName=user_id\t")
+	require.Contains(t, s, "devTime=2018-05-08T08:26:00+00:00\t")
+	require.Contains(t, s, "sev=1")
+}
+
+func TestWriteToHTTP(t *testing.T) {
+	var (
+		calls  int
+		bodies = make(chan string, 1)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// the first attempt fails and must be retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := WriteToHTTP(ctx, config.HTTP{URL: srv.URL, BatchSize: 2, FlushInterval: time.Hour, Timeout: time.Second},
+		config.Buffer{Size: 10, RetryBackoff: 10 * time.Millisecond}, "json", log.NopLogger())
+	l([]byte(`{"a":1}`))
+	l([]byte(`{"b":2}`))
+
+	select {
+	case body := <-bodies:
+		require.Equal(t, "{\"a\":1}\n{\"b\":2}\n", body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch was not delivered")
+	}
+}
+
+func TestWriteToHTTPRejected(t *testing.T) {
+	var (
+		calls  int
+		bodies = make(chan string, 1)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// the first batch is rejected and must not block the next one
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := WriteToHTTP(ctx, config.HTTP{URL: srv.URL, BatchSize: 1, FlushInterval: time.Hour, Timeout: time.Second},
+		config.Buffer{Size: 10, RetryBackoff: time.Hour, MaxAttempts: 10}, "json", log.NopLogger())
+	l([]byte(`{"a":1}`))
+	l([]byte(`{"b":2}`))
+
+	select {
+	case body := <-bodies:
+		require.Equal(t, "{\"b\":2}\n", body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch was not delivered")
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/store"
 	"github.com/opencloud-eu/opencloud/services/audit/pkg/types"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 )
@@ -18,20 +20,105 @@ type Log func([]byte)
 // Marshaller is used to marshal events
 type Marshaller func(interface{}) ([]byte, error)
 
-// AuditLoggerFromConfig will start a new AuditLogger generated from the config
-func AuditLoggerFromConfig(ctx context.Context, cfg config.Auditlog, ch <-chan events.Event, log log.Logger) {
+// AuditLoggerFromConfig will start a new AuditLogger generated from the config. The audit events are also added to the store if it is not nil.
+func AuditLoggerFromConfig(ctx context.Context, cfg config.Auditlog, st *store.Store, ch <-chan events.Event, log log.Logger) {
 	var logs []Log
 
 	if cfg.LogToConsole {
 		logs = append(logs, WriteToStdout())
 	}
 
-	if cfg.LogToFile {
+	if cfg.LogToFile && cfg.HashChain.Enabled {
+		chain := NewChain(cfg.HashChain.HMACKey, cfg.HashChain.CheckpointInterval)
+		if err := chain.Resume(cfg.FilePath); err != nil {
+			// continue with a new chain, the verification will report the broken link
+			log.Error().Err(err).Msg("cannot resume the audit hash chain")
+		}
+		logs = append(logs, WriteToChainedFile(cfg.FilePath, chain, log))
+	} else if cfg.LogToFile {
 		logs = append(logs, WriteToFile(cfg.FilePath, log))
 	}
 
-	StartAuditLogger(ctx, ch, log, Marshal(cfg.Format, log), logs...)
+	if cfg.LogToSyslog {
+		l, err := WriteToSyslog(ctx, cfg.Syslog, cfg.Buffer, log)
+		if err != nil {
+			log.Error().Err(err).Msg("cannot log to syslog")
+		} else {
+			logs = append(logs, l)
+		}
+	}
+
+	if cfg.LogToHTTP {
+		logs = append(logs, WriteToHTTP(ctx, cfg.HTTP, cfg.Buffer, cfg.Format, log))
+	}
+
+	marshaller := Marshal(cfg.Format, log)
+	if marshaller == nil {
+		// the format is validated when parsing the config, never drop the audit events
+		log.Error().Str("format", cfg.Format).Msg("falling back to the json format")
+		marshaller = json.Marshal
+	}
+
+	if st == nil {
+		StartAuditLogger(ctx, ch, log, marshaller, logs...)
+		return
+	}
+
+	// the store needs json, the other outputs get the events converted to their format
+	StartAuditLogger(ctx, ch, log, json.Marshal, WriteToStore(st, log), Remarshal(marshaller, log, logs...))
+}
+
+// WriteToStore returns a Log function adding json audit events to the store
+func WriteToStore(st *store.Store, log log.Logger) Log {
+	return func(content []byte) {
+		if err := st.Add(content); err != nil {
+			log.Error().Err(err).Msg("error adding the event to the audit store")
+		}
+	}
+}
+
+// ExpireEvents deletes the audit events older than the retention from the store once an hour. It blocks until the context is done.
+func ExpireEvents(ctx context.Context, st *store.Store, retention time.Duration, log log.Logger) {
+	if retention <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		n, err := st.Purge(time.Now().Add(-retention))
+		if err != nil {
+			log.Error().Err(err).Msg("error purging expired audit events")
+		} else if n > 0 {
+			log.Debug().Int("count", n).Msg("purged expired audit events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
 
+// Remarshal returns a Log function converting json audit events with the marshaller before passing them to the logs
+func Remarshal(marshaller Marshaller, log log.Logger, logto ...Log) Log {
+	return func(content []byte) {
+		if len(logto) == 0 {
+			return
+		}
+
+		b, err := marshaller(json.RawMessage(content))
+		if err != nil {
+			log.Error().Err(err).Msg("error marshaling the event")
+			return
+		}
+
+		for _, l := range logto {
+			l(b)
+		}
+	}
 }
 
 // StartAuditLogger will block. run in separate go routine
@@ -161,7 +248,11 @@ func Marshal(format string, log log.Logger) Marshaller {
 		return nil
 	case "json":
 		return json.Marshal
-	case "minimal":
+	case "cef":
+		return MarshalCEF
+	case "leef":
+		return MarshalLEEF
+	case "", "minimal":
 		return func(ev interface{}) ([]byte, error) {
 			b, err := json.Marshal(ev)
 			if err != nil {
@@ -0,0 +1,129 @@
+package svc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/audit/pkg/config"
+)
+
+const (
+	// syslogSeverity is the severity of the audit events, 5 is "notice"
+	syslogSeverity = 5
+	// syslogWriteTimeout keeps a stalled syslog server from blocking the audit events forever
+	syslogWriteTimeout = 10 * time.Second
+)
+
+var syslogFacilities = map[string]int{
+	"user":     1,
+	"auth":     4,
+	"authpriv": 10,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// WriteToSyslog returns a Log function sending the audit events to a syslog server in the RFC 5424 format
+func WriteToSyslog(ctx context.Context, cfg config.Syslog, buf config.Buffer, log log.Logger) (Log, error) {
+	facility, ok := syslogFacilities[cfg.Facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility '%s'", cfg.Facility)
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.Network == "tls" {
+		tlsConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.TLSInsecure, //nolint:gosec
+		}
+		if cfg.TLSRootCACertificate != "" {
+			pem, err := os.ReadFile(cfg.TLSRootCACertificate)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in '%s'", cfg.TLSRootCACertificate)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	w := &syslogWriter{
+		network:   cfg.Network,
+		address:   cfg.Address,
+		tlsConfig: tlsConfig,
+		header: func(t time.Time) string {
+			return fmt.Sprintf("<%d>1 %s %s %s %d audit - ", facility*8+syslogSeverity, t.Format(time.RFC3339Nano), hostname, cfg.AppName, os.Getpid())
+		},
+	}
+
+	return Buffered(ctx, "syslog", buf, 1, time.Second, w.send, log), nil
+}
+
+// syslogWriter keeps the connection to the syslog server, it is only used by one go routine
+type syslogWriter struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+	header    func(time.Time) string
+	conn      net.Conn
+}
+
+func (w *syslogWriter) send(ctx context.Context, batch [][]byte) error {
+	if w.conn == nil {
+		conn, err := w.dial(ctx)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+
+	for _, content := range batch {
+		msg := w.header(time.Now()) + string(content)
+		if w.network != "udp" {
+			// octet counting framing as defined in RFC 6587
+			msg = fmt.Sprintf("%d %s", len(msg), msg)
+		}
+
+		if err := w.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+			_ = w.conn.Close()
+			w.conn = nil
+			return err
+		}
+		if _, err := w.conn.Write([]byte(msg)); err != nil {
+			_ = w.conn.Close()
+			w.conn = nil
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *syslogWriter) dial(ctx context.Context) (net.Conn, error) {
+	d := &net.Dialer{Timeout: 10 * time.Second}
+	switch w.network {
+	case "tls":
+		td := &tls.Dialer{NetDialer: d, Config: w.tlsConfig}
+		return td.DialContext(ctx, "tcp", w.address)
+	default:
+		return d.DialContext(ctx, w.network, w.address)
+	}
+}
@@ -0,0 +1,365 @@
+// Package store persists audit events in an embedded bbolt database indexed by time, user, action, resource and space.
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// ErrInvalidCursor is returned for a malformed paging cursor
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	eventsBucket = []byte("events")
+	// the index buckets map the indexed value followed by the event key to nothing
+	indexBuckets = map[string][]byte{
+		"user":     []byte("user"),
+		"action":   []byte("action"),
+		"resource": []byte("resource"),
+		"space":    []byte("space"),
+	}
+)
+
+const (
+	// keyLen is the length of the event keys, the event time in unix nanoseconds followed by a sequence number
+	keyLen = 16
+	// purgeBatchSize limits the number of events deleted in one transaction
+	purgeBatchSize = 1000
+)
+
+// Record is an audit event stored in the store
+type Record struct {
+	ID         string          `json:"id"`
+	Time       time.Time       `json:"time"`
+	User       string          `json:"user,omitempty"`
+	Action     string          `json:"action"`
+	ResourceID string          `json:"resource_id,omitempty"`
+	SpaceID    string          `json:"space_id,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// Query filters the stored audit events. Empty fields match all events.
+type Query struct {
+	From       time.Time
+	To         time.Time
+	User       string
+	Action     string
+	ResourceID string
+	SpaceID    string
+	// Cursor continues a previous query after the event with this id
+	Cursor string
+	// Limit is the maximum number of events returned, 0 returns all matching events
+	Limit int
+}
+
+// Store is the audit event store
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the store at the given path
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(eventsBucket); err != nil {
+			return err
+		}
+		for _, b := range indexBuckets {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the store
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add stores an audit event marshalled to json
+func (s *Store) Add(event []byte) error {
+	var fields struct {
+		User    string
+		Time    string
+		Message string
+		Action  string
+		FileID  string
+		SpaceID string
+	}
+	if err := json.Unmarshal(event, &fields); err != nil {
+		return err
+	}
+
+	t, err := time.Parse(time.RFC3339, fields.Time)
+	if err != nil {
+		t = time.Now()
+	}
+
+	spaceID := fields.SpaceID
+	if spaceID == "" && fields.FileID != "" {
+		if rid, err := storagespace.ParseID(fields.FileID); err == nil && rid.GetSpaceId() != "" {
+			spaceID = storagespace.FormatStorageID(rid.GetStorageId(), rid.GetSpaceId())
+		}
+	}
+
+	r := Record{
+		Time:       t.UTC(),
+		User:       fields.User,
+		Action:     fields.Action,
+		ResourceID: fields.FileID,
+		SpaceID:    spaceID,
+		Message:    fields.Message,
+		Event:      event,
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		events := tx.Bucket(eventsBucket)
+		seq, err := events.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		key := eventKey(t, seq)
+		r.ID = hex.EncodeToString(key)
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+
+		if err := events.Put(key, b); err != nil {
+			return err
+		}
+		for name, value := range r.indexValues() {
+			if value == "" {
+				continue
+			}
+			if err := tx.Bucket(indexBuckets[name]).Put(indexKey(value, key), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Walk calls fn for the audit events matching the query, newest first, until the limit is reached or fn returns an error
+func (s *Store) Walk(q Query, fn func(Record) error) error {
+	var hi []byte
+	switch {
+	case q.Cursor != "":
+		c, err := hex.DecodeString(q.Cursor)
+		if err != nil || len(c) != keyLen {
+			return ErrInvalidCursor
+		}
+		hi = c
+	case !q.To.IsZero():
+		hi = eventKey(q.To.Add(time.Nanosecond), 0)
+	}
+
+	var lo []byte
+	if !q.From.IsZero() {
+		lo = eventKey(q.From, 0)
+	}
+
+	// use the index of the most selective filter
+	index, prefix := q.index()
+
+	return s.db.View(func(tx *bolt.Tx) error {
+		events := tx.Bucket(eventsBucket)
+
+		b := events
+		if index != nil {
+			b = tx.Bucket(index)
+		}
+		c := b.Cursor()
+
+		var k []byte
+		if hi != nil {
+			k, _ = c.Seek(append(append([]byte{}, prefix...), hi...))
+		} else {
+			k, _ = c.Seek(append(append([]byte{}, prefix...), bytes.Repeat([]byte{0xff}, keyLen+1)...))
+		}
+		if k == nil {
+			k, _ = c.Last()
+		} else {
+			k, _ = c.Prev()
+		}
+
+		n := 0
+		for ; k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Prev() {
+			key := k[len(prefix):]
+			if len(key) != keyLen {
+				continue
+			}
+			if lo != nil && bytes.Compare(key, lo) < 0 {
+				break
+			}
+
+			v := events.Get(key)
+			if v == nil {
+				continue
+			}
+
+			var r Record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if !q.matches(r) {
+				continue
+			}
+
+			if err := fn(r); err != nil {
+				return err
+			}
+
+			n++
+			if q.Limit > 0 && n >= q.Limit {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Find returns the audit events matching the query and the cursor of the next page, which is empty for the last page
+func (s *Store) Find(q Query) ([]Record, string, error) {
+	records := make([]Record, 0)
+	limit := q.Limit
+	if limit > 0 {
+		// fetch one more event to know if there is a next page
+		q.Limit++
+	}
+
+	err := s.Walk(q, func(r Record) error {
+		records = append(records, r)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+		return records, records[limit-1].ID, nil
+	}
+	return records, "", nil
+}
+
+// Purge deletes the audit events older than the given time and returns the number of deleted events
+func (s *Store) Purge(before time.Time) (int, error) {
+	limit := eventKey(before, 0)
+	total := 0
+	for {
+		n := 0
+		err := s.db.Update(func(tx *bolt.Tx) error {
+			events := tx.Bucket(eventsBucket)
+
+			// deleting while iterating skips keys, so collect them first
+			var keys [][]byte
+			c := events.Cursor()
+			for k, _ := c.First(); k != nil && bytes.Compare(k, limit) < 0 && len(keys) < purgeBatchSize; k, _ = c.Next() {
+				keys = append(keys, append([]byte{}, k...))
+			}
+
+			for _, k := range keys {
+				var r Record
+				if err := json.Unmarshal(events.Get(k), &r); err == nil {
+					for name, value := range r.indexValues() {
+						if value == "" {
+							continue
+						}
+						if err := tx.Bucket(indexBuckets[name]).Delete(indexKey(value, k)); err != nil {
+							return err
+						}
+					}
+				}
+				if err := events.Delete(k); err != nil {
+					return err
+				}
+			}
+			n = len(keys)
+			return nil
+		})
+		total += n
+		if err != nil || n < purgeBatchSize {
+			return total, err
+		}
+	}
+}
+
+func (r Record) indexValues() map[string]string {
+	return map[string]string{
+		"user":     r.User,
+		"action":   r.Action,
+		"resource": r.ResourceID,
+		"space":    r.SpaceID,
+	}
+}
+
+func (q Query) index() ([]byte, []byte) {
+	switch {
+	case q.ResourceID != "":
+		return indexBuckets["resource"], indexPrefix(q.ResourceID)
+	case q.User != "":
+		return indexBuckets["user"], indexPrefix(q.User)
+	case q.SpaceID != "":
+		return indexBuckets["space"], indexPrefix(q.SpaceID)
+	case q.Action != "":
+		return indexBuckets["action"], indexPrefix(q.Action)
+	default:
+		return nil, nil
+	}
+}
+
+func (q Query) matches(r Record) bool {
+	switch {
+	case q.User != "" && q.User != r.User,
+		q.Action != "" && q.Action != r.Action,
+		q.ResourceID != "" && q.ResourceID != r.ResourceID,
+		q.SpaceID != "" && q.SpaceID != r.SpaceID,
+		!q.From.IsZero() && r.Time.Before(q.From),
+		!q.To.IsZero() && r.Time.After(q.To):
+		return false
+	}
+	return true
+}
+
+func eventKey(t time.Time, seq uint64) []byte {
+	k := make([]byte, keyLen)
+	binary.BigEndian.PutUint64(k, uint64(max(t.UnixNano(), 0)))
+	binary.BigEndian.PutUint64(k[8:], seq)
+	return k
+}
+
+func indexPrefix(value string) []byte {
+	return append([]byte(value), 0)
+}
+
+func indexKey(value string, key []byte) []byte {
+	return append(indexPrefix(value), key...)
+}
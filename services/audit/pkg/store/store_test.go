@@ -0,0 +1,78 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func event(user, action, fileID string, t time.Time) []byte {
+	return []byte(fmt.Sprintf(`{"User":%q,"Action":%q,"FileID":%q,"Time":%q,"Message":"test"}`, user, action, fileID, t.Format(time.RFC3339)))
+}
+
+func TestStore(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "audit.db"))
+	require.NoError(t, err)
+	defer st.Close()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		user := "alice"
+		if i%2 == 1 {
+			user = "bob"
+		}
+		require.NoError(t, st.Add(event(user, "file_delete", fmt.Sprintf("storage$space%d!item%d", i%3, i), start.Add(time.Duration(i)*time.Hour))))
+	}
+
+	// newest first
+	all, next, err := st.Find(Query{})
+	require.NoError(t, err)
+	require.Len(t, all, 10)
+	require.Empty(t, next)
+	require.Equal(t, start.Add(9*time.Hour), all[0].Time)
+
+	// filter by index and time
+	recs, _, err := st.Find(Query{User: "bob", From: start.Add(2 * time.Hour), To: start.Add(7 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+	for _, r := range recs {
+		require.Equal(t, "bob", r.User)
+	}
+
+	recs, _, err = st.Find(Query{SpaceID: "storage$space1"})
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+
+	recs, _, err = st.Find(Query{ResourceID: "storage$space0!item3", User: "bob"})
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+
+	// paging
+	var paged []Record
+	q := Query{Limit: 4}
+	for {
+		recs, next, err := st.Find(q)
+		require.NoError(t, err)
+		paged = append(paged, recs...)
+		if next == "" {
+			break
+		}
+		q.Cursor = next
+	}
+	require.Equal(t, all, paged)
+
+	_, _, err = st.Find(Query{Cursor: "invalid"})
+	require.ErrorIs(t, err, ErrInvalidCursor)
+
+	// retention
+	n, err := st.Purge(start.Add(5 * time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	recs, _, err = st.Find(Query{User: "alice"})
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/opencloud-eu/opencloud/services/notifications/pkg/logging"
 	"github.com/opencloud-eu/opencloud/services/notifications/pkg/server/debug"
 	"github.com/opencloud-eu/opencloud/services/notifications/pkg/service"
+	searchevent "github.com/opencloud-eu/opencloud/services/search/pkg/event"
 )
 
 // Server is the entrypoint for the server command.
@@ -87,6 +88,7 @@ func Server(cfg *config.Config) *cli.Command {
 				events.SpaceMembershipExpired{},
 				events.ScienceMeshInviteTokenGenerated{},
 				events.SendEmailsEvent{},
+				searchevent.SavedSearchMatched{},
 			}
 			registeredEvents := make(map[string]events.Unmarshaller)
 			for _, e := range evs {
@@ -109,6 +109,20 @@ Please visit your federation settings and use the following details:
   ProviderDomain: {ProviderDomain}`),
 	}
 
+	// Search templates
+	SavedSearchMatched = MessageTemplate{
+		textTemplate: _textTemplate,
+		htmlTemplate: _htmlTemplate,
+		// SavedSearchMatched email template, Subject field (resolves directly)
+		Subject: l10n.Template(`New result for your saved search '{SearchName}'`),
+		// SavedSearchMatched email template, resolves via {{ .Greeting }}
+		Greeting: l10n.Template(`Hello {SearchOwner},`),
+		// SavedSearchMatched email template, resolves via {{ .MessageBody }}
+		MessageBody: l10n.Template(`"{ResourceName}" is a new result of your saved search "{SearchName}".`),
+		// SavedSearchMatched email template, resolves via {{ .CallToAction }}
+		CallToAction: l10n.Template(`Click here to view it: {ResourceLink}`),
+	}
+
 	Grouped = GroupedMessageTemplate{
 		textTemplate: _textTemplate,
 		htmlTemplate: _htmlTemplate,
@@ -134,6 +148,10 @@ var _placeholders = map[string]string{
 	"{ProviderDomain}":  "{{ .ProviderDomain }}",
 	"{Token}":           "{{ .Token }}",
 	"{DisplayName}":     "{{ .DisplayName }}",
+	"{SearchName}":      "{{ .SearchName }}",
+	"{SearchOwner}":     "{{ .SearchOwner }}",
+	"{ResourceName}":    "{{ .ResourceName }}",
+	"{ResourceLink}":    "{{ .ResourceLink }}",
 }
 
 // MessageTemplate is the data structure for the email
@@ -7,6 +7,7 @@ import (
 	ehmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/eventhistory/v0"
 	"github.com/opencloud-eu/opencloud/services/notifications/pkg/channels"
 	"github.com/opencloud-eu/opencloud/services/notifications/pkg/email"
+	searchevent "github.com/opencloud-eu/opencloud/services/search/pkg/event"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 	"github.com/rs/zerolog"
 )
@@ -130,6 +131,23 @@ func (s eventsNotifier) createGroupedMail(ctx context.Context, logger zerolog.Lo
 				"ShareFolder": shareFolder,
 				"ExpiredAt":   te.ExpiredAt.Format("2006-01-02 15:04:05"),
 			})
+		case searchevent.SavedSearchMatched:
+			logger := logger.With().
+				Str("event", "SavedSearchMatched").
+				Str("eventId", te.ResourceID.GetOpaqueId()).
+				Logger()
+
+			resourceName, resourceLink, _, err := s.prepareSavedSearchMatched(logger, te)
+			if err != nil {
+				logger.Error().Err(err).Msg("could not prepare vars for grouped email")
+				continue
+			}
+			mts = append(mts, email.SavedSearchMatched)
+			mtsVars = append(mtsVars, map[string]string{
+				"SearchName":   te.SavedSearchName,
+				"ResourceName": resourceName,
+				"ResourceLink": resourceLink,
+			})
 		}
 	}
 
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	"github.com/opencloud-eu/reva/v2/pkg/utils"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/opencloud-eu/opencloud/services/notifications/pkg/email"
+	searchevent "github.com/opencloud-eu/opencloud/services/search/pkg/event"
+)
+
+func (s eventsNotifier) handleSavedSearchMatched(e searchevent.SavedSearchMatched, eventId string) {
+	if !e.Email {
+		// the user only wants to be alerted in-app
+		return
+	}
+
+	logger := s.logger.With().
+		Str("event", "SavedSearchMatched").
+		Str("itemid", e.ResourceID.GetOpaqueId()).
+		Logger()
+
+	resourceName, resourceLink, ctx, err := s.prepareSavedSearchMatched(logger, e)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not prepare vars for email")
+		return
+	}
+
+	granteeList := s.ensureGranteeList(ctx, nil, e.UserID, nil)
+
+	recipientsInstant, recipientsDaily, recipientsInstantWeekly := s.splitter.execute(ctx, granteeList)
+	recipientsInstant = append(recipientsInstant, s.userEventStore.persist(_intervalDaily, eventId, recipientsDaily)...)
+	recipientsInstant = append(recipientsInstant, s.userEventStore.persist(_intervalWeekly, eventId, recipientsInstantWeekly)...)
+	if recipientsInstant == nil {
+		return
+	}
+
+	emails, err := s.render(ctx, email.SavedSearchMatched,
+		"SearchOwner",
+		map[string]string{
+			"SearchName":   e.SavedSearchName,
+			"ResourceName": resourceName,
+			"ResourceLink": resourceLink,
+		}, recipientsInstant, s.defaultEmailSender)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get render the email")
+		return
+	}
+	s.send(ctx, emails)
+}
+
+func (s eventsNotifier) prepareSavedSearchMatched(logger zerolog.Logger, e searchevent.SavedSearchMatched) (resourceName, resourceLink string, ctx context.Context, err error) {
+	gatewayClient, err := s.gatewaySelector.Next()
+	if err != nil {
+		logger.Error().Err(err).Msg("could not select next gateway client")
+		return resourceName, resourceLink, ctx, err
+	}
+
+	ctx, err = utils.GetServiceUserContextWithContext(context.Background(), gatewayClient, s.serviceAccountID, s.serviceAccountSecret)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get service user context")
+		return resourceName, resourceLink, ctx, err
+	}
+
+	resourceInfo, err := s.getResourceInfo(ctx, e.ResourceID, &fieldmaskpb.FieldMask{Paths: []string{"name"}})
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("could not stat resource")
+		return resourceName, resourceLink, ctx, err
+	}
+	resourceName = resourceInfo.GetName()
+
+	resourceLink, err = urlJoinPath(s.openCloudURL, "f", storagespace.FormatResourceID(e.ResourceID))
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("could not create link to the resource")
+		return resourceName, resourceLink, ctx, err
+	}
+
+	return resourceName, resourceLink, ctx, err
+}
@@ -29,6 +29,7 @@ import (
 	settingssvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/settings/v0"
 	"github.com/opencloud-eu/opencloud/services/notifications/pkg/channels"
 	"github.com/opencloud-eu/opencloud/services/notifications/pkg/email"
+	searchevent "github.com/opencloud-eu/opencloud/services/search/pkg/event"
 	"github.com/opencloud-eu/opencloud/services/settings/pkg/store/defaults"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
@@ -122,6 +123,8 @@ func (s eventsNotifier) Run() error {
 					s.handleScienceMeshInviteTokenGenerated(e)
 				case events.SendEmailsEvent:
 					s.sendGroupedEmailsJob(e, evt.ID)
+				case searchevent.SavedSearchMatched:
+					s.handleSavedSearchMatched(e, evt.ID)
 				}
 			}()
 		case <-s.signals:
@@ -20,6 +20,7 @@ import (
 	"github.com/opencloud-eu/opencloud/services/graph/pkg/config/defaults"
 	"github.com/opencloud-eu/opencloud/services/notifications/pkg/channels"
 	"github.com/opencloud-eu/opencloud/services/notifications/pkg/service"
+	searchevent "github.com/opencloud-eu/opencloud/services/search/pkg/event"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	"github.com/opencloud-eu/reva/v2/pkg/utils"
@@ -233,6 +234,32 @@ https://opencloud.eu
 				ExpiredAt:     time.Date(2023, 4, 17, 16, 42, 0, 0, time.UTC),
 			},
 		}),
+
+		Entry("Saved Search Matched", testChannel{
+			expectedReceipients: []string{sharer.GetMail()},
+			expectedSubject:     "New result for your saved search 'board papers'",
+			expectedTextBody: `Hello Dr. S. Harer,
+
+"secrets of the board" is a new result of your saved search "board papers".
+
+Click here to view it: f/storageid$spaceid%21itemid
+
+
+---
+OpenCloud - a safe home for all your data
+https://opencloud.eu
+`,
+			expectedSender: "",
+			done:           make(chan struct{}),
+		}, events.Event{
+			Event: searchevent.SavedSearchMatched{
+				SavedSearchID:   "searchid",
+				SavedSearchName: "board papers",
+				UserID:          sharer.GetId(),
+				ResourceID:      resourceid,
+				Email:           true,
+			},
+		}),
 	)
 })
 
@@ -6,16 +6,23 @@ import (
 	"os"
 
 	"github.com/oklog/run"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/events/stream"
 	"github.com/opencloud-eu/reva/v2/pkg/store"
 	"github.com/urfave/cli/v2"
 	microstore "go-micro.dev/v4/store"
 
+	ogrpc "github.com/opencloud-eu/opencloud/pkg/service/grpc"
 	"github.com/opencloud-eu/opencloud/pkg/tracing"
+	settingssvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/settings/v0"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config/parser"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/logging"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/server/debug"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/server/grpc"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/server/http"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/service"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/webhook"
 )
 
 // Server is the entrypoint for the server command.
@@ -83,6 +90,78 @@ func Server(cfg *config.Config) *cli.Command {
 
 					cancel()
 				})
+
+				var worker *webhook.Worker
+				if len(cfg.Postprocessing.Webhooks) > 0 {
+					natsStream, err := stream.NatsFromConfig(cfg.Service.Name+"-webhook", false, stream.NatsConfig{
+						Endpoint:             cfg.Postprocessing.Events.Endpoint,
+						Cluster:              cfg.Postprocessing.Events.Cluster,
+						EnableTLS:            cfg.Postprocessing.Events.EnableTLS,
+						TLSInsecure:          cfg.Postprocessing.Events.TLSInsecure,
+						TLSRootCACertificate: cfg.Postprocessing.Events.TLSRootCACertificate,
+						AuthUsername:         cfg.Postprocessing.Events.AuthUsername,
+						AuthPassword:         cfg.Postprocessing.Events.AuthPassword,
+					})
+					if err != nil {
+						return err
+					}
+
+					ch, err := events.Consume(natsStream, "postprocessing-webhook", events.StartPostprocessingStep{})
+					if err != nil {
+						return err
+					}
+
+					worker = webhook.NewWorker(cfg.Postprocessing, natsStream, st, logger)
+
+					gr.Add(func() error {
+						return worker.Run(ch)
+					}, func(_ error) {
+						cancel()
+					})
+				}
+
+				cfg.GrpcClient, err = ogrpc.NewClient(
+					append(ogrpc.GetClientOptions(cfg.GRPCClientTLS), ogrpc.WithTraceProvider(traceProvider))...,
+				)
+				if err != nil {
+					return err
+				}
+				roleClient := settingssvc.NewRoleService("eu.opencloud.api.settings", cfg.GrpcClient)
+
+				server, err := http.Server(
+					http.Logger(logger),
+					http.Context(ctx),
+					http.Config(cfg),
+					http.TracerProvider(traceProvider),
+					http.Service(svc),
+					http.Role(roleClient),
+					http.Worker(worker),
+				)
+				if err != nil {
+					logger.Info().Err(err).Str("transport", "http").Msg("Failed to initialize server")
+					return err
+				}
+
+				gr.Add(server.Run, func(_ error) {
+					cancel()
+				})
+
+				grpcServer, err := grpc.Server(
+					grpc.Logger(logger),
+					grpc.Context(ctx),
+					grpc.Config(cfg),
+					grpc.TracerProvider(traceProvider),
+					grpc.Service(svc),
+					grpc.Role(roleClient),
+				)
+				if err != nil {
+					logger.Info().Err(err).Str("transport", "grpc").Msg("Failed to initialize server")
+					return err
+				}
+
+				gr.Add(grpcServer.Run, func(_ error) {
+					cancel()
+				})
 			}
 
 			{
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/opencloud-eu/opencloud/pkg/shared"
+	"go-micro.dev/v4/client"
 )
 
 // Config combines all available configuration parts.
@@ -19,6 +20,12 @@ type Config struct {
 
 	Store          Store          `yaml:"store"`
 	Postprocessing Postprocessing `yaml:"postprocessing"`
+	HTTP           HTTP           `yaml:"http"`
+	GRPC           GRPCConfig     `yaml:"grpc"`
+
+	GRPCClientTLS *shared.GRPCClientTLS `yaml:"grpc_client_tls"`
+	GrpcClient    client.Client         `yaml:"-"`
+	TokenManager  *TokenManager         `yaml:"token_manager"`
 
 	Context context.Context `yaml:"-"`
 }
@@ -33,6 +40,48 @@ type Postprocessing struct {
 
 	RetryBackoffDuration time.Duration `yaml:"retry_backoff_duration" env:"POSTPROCESSING_RETRY_BACKOFF_DURATION" desc:"The base for the exponential backoff duration before retrying a failed postprocessing step. See the Environment Variable Types description for more details." introductionVersion:"1.0.0"`
 	MaxRetries           int           `yaml:"max_retries" env:"POSTPROCESSING_MAX_RETRIES" desc:"The maximum number of retries for a failed postprocessing step." introductionVersion:"1.0.0"`
+
+	Dependencies map[string][]string `yaml:"dependencies"`
+	Rules        Rules               `yaml:"rules"`
+
+	Webhooks       []Webhook `yaml:"webhooks"`
+	WebhookBaseURL string    `yaml:"webhook_base_url" env:"OC_URL;POSTPROCESSING_WEBHOOK_BASE_URL" desc:"The public base URL of OpenCloud. Webhook endpoints use it to download the file and to report the outcome of the step." introductionVersion:"%%NEXT%%"`
+}
+
+// Rules configures the selection of the postprocessing steps per upload.
+type Rules struct {
+	Policies []string      `yaml:"policies"`
+	Query    string        `yaml:"query" env:"POSTPROCESSING_RULES_QUERY" desc:"The rego query returning the list of postprocessing steps for an upload. The rules are only evaluated if policy files are configured. If the query is undefined for an upload, the steps configured in 'POSTPROCESSING_STEPS' are used." introductionVersion:"%%NEXT%%"`
+	Steps    []string      `yaml:"steps" env:"POSTPROCESSING_RULES_STEPS" desc:"A list of additional postprocessing steps the rules may select which are not part of 'POSTPROCESSING_STEPS'. Steps returned by the rules which are neither listed here, in 'POSTPROCESSING_STEPS', nor are known to the system or configured as webhook are dropped. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	Timeout  time.Duration `yaml:"timeout" env:"POSTPROCESSING_RULES_TIMEOUT" desc:"Sets the timeout the evaluation of the rules can take. The steps configured in 'POSTPROCESSING_STEPS' are used if the timeout was reached. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+}
+
+// Webhook defines a postprocessing step which is processed by an external http endpoint.
+type Webhook struct {
+	Step    string        `yaml:"step"`
+	URL     string        `yaml:"url"`
+	Secret  string        `yaml:"secret"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// HTTP defines the available http configuration.
+type HTTP struct {
+	Addr      string                `yaml:"addr" env:"POSTPROCESSING_HTTP_ADDR" desc:"The bind address of the HTTP service. It serves the admin API and the webhook callbacks." introductionVersion:"%%NEXT%%"`
+	Namespace string                `yaml:"-"`
+	Root      string                `yaml:"root" env:"POSTPROCESSING_HTTP_ROOT" desc:"Subdirectory that serves as the root for this HTTP service." introductionVersion:"%%NEXT%%"`
+	TLS       shared.HTTPServiceTLS `yaml:"tls"`
+}
+
+// GRPCConfig defines the available grpc configuration.
+type GRPCConfig struct {
+	Addr      string                 `yaml:"addr" env:"POSTPROCESSING_GRPC_ADDR" desc:"The bind address of the GRPC service. It serves the admin API." introductionVersion:"%%NEXT%%"`
+	Namespace string                 `yaml:"-"`
+	TLS       *shared.GRPCServiceTLS `yaml:"tls"`
+}
+
+// TokenManager is the config for using the reva token manager
+type TokenManager struct {
+	JWTSecret string `yaml:"jwt_secret" env:"OC_JWT_SECRET;POSTPROCESSING_JWT_SECRET" desc:"The secret to mint and validate jwt tokens." introductionVersion:"%%NEXT%%"`
 }
 
 // Events combines the configuration options for the event bus.
@@ -1,8 +1,10 @@
 package defaults
 
 import (
+	"strings"
 	"time"
 
+	"github.com/opencloud-eu/opencloud/pkg/structs"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
 )
 
@@ -36,6 +38,20 @@ func DefaultConfig() *config.Config {
 			Workers:              3,
 			RetryBackoffDuration: 5 * time.Second,
 			MaxRetries:           14,
+			Rules: config.Rules{
+				Query:   "data.postprocessing.steps",
+				Timeout: 10 * time.Second,
+			},
+			WebhookBaseURL: "https://localhost:9200",
+		},
+		HTTP: config.HTTP{
+			Addr:      "127.0.0.1:9256",
+			Root:      "/",
+			Namespace: "eu.opencloud.web",
+		},
+		GRPC: config.GRPCConfig{
+			Addr:      "127.0.0.1:9257",
+			Namespace: "eu.opencloud.api",
 		},
 		Store: config.Store{
 			Store:    "nats-js-kv",
@@ -71,8 +87,35 @@ func EnsureDefaults(cfg *config.Config) {
 	} else if cfg.Tracing == nil {
 		cfg.Tracing = &config.Tracing{}
 	}
+
+	if cfg.GRPCClientTLS == nil && cfg.Commons != nil {
+		cfg.GRPCClientTLS = structs.CopyOrZeroValue(cfg.Commons.GRPCClientTLS)
+	}
+
+	if cfg.GRPC.TLS == nil && cfg.Commons != nil {
+		cfg.GRPC.TLS = structs.CopyOrZeroValue(cfg.Commons.GRPCServiceTLS)
+	}
+
+	if cfg.TokenManager == nil && cfg.Commons != nil && cfg.Commons.TokenManager != nil {
+		cfg.TokenManager = &config.TokenManager{
+			JWTSecret: cfg.Commons.TokenManager.JWTSecret,
+		}
+	} else if cfg.TokenManager == nil {
+		cfg.TokenManager = &config.TokenManager{}
+	}
+
+	if cfg.Commons != nil {
+		cfg.HTTP.TLS = cfg.Commons.HTTPServiceTLS
+	}
 }
 
-// Sanitize does nothing atm
+// Sanitize sanitizes the webhook configuration
 func Sanitize(cfg *config.Config) {
+	cfg.Postprocessing.WebhookBaseURL = strings.TrimRight(cfg.Postprocessing.WebhookBaseURL, "/")
+
+	for i := range cfg.Postprocessing.Webhooks {
+		if cfg.Postprocessing.Webhooks[i].Timeout == 0 {
+			cfg.Postprocessing.Webhooks[i].Timeout = 5 * time.Minute
+		}
+	}
 }
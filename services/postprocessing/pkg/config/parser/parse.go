@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	occfg "github.com/opencloud-eu/opencloud/pkg/config"
+	"github.com/opencloud-eu/opencloud/pkg/shared"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config/defaults"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
@@ -37,6 +38,10 @@ func ParseConfig(cfg *config.Config) error {
 
 // Validate validates the config
 func Validate(cfg *config.Config) error {
+	if cfg.TokenManager.JWTSecret == "" {
+		return shared.MissingJWTTokenError(cfg.Service.Name)
+	}
+
 	if cfg.Postprocessing.Delayprocessing != 0 {
 		if !contains(cfg.Postprocessing.Steps, events.PPStepDelay) {
 			if len(cfg.Postprocessing.Steps) > 0 {
@@ -47,6 +52,75 @@ func Validate(cfg *config.Config) error {
 			cfg.Postprocessing.Steps = append(cfg.Postprocessing.Steps, string(events.PPStepDelay))
 		}
 	}
+
+	if err := validateDependencies(cfg.Postprocessing.Dependencies); err != nil {
+		return err
+	}
+
+	if len(cfg.Postprocessing.Dependencies) > 0 {
+		switch cfg.Store.Store {
+		case "nats-js-kv", "memory", "ocmem":
+			// the nats-js-kv store locks the uploads for all instances, the memory stores only serve a single instance anyway
+		default:
+			return fmt.Errorf("parallel postprocessing steps are not supported with the '%s' store, use the 'nats-js-kv' store", cfg.Store.Store)
+		}
+	}
+
+	seen := make(map[string]bool, len(cfg.Postprocessing.Webhooks))
+	for _, w := range cfg.Postprocessing.Webhooks {
+		switch {
+		case w.Step == "":
+			return errors.New("postprocessing webhook without step name")
+		case seen[w.Step]:
+			return fmt.Errorf("duplicate postprocessing webhook for step '%s'", w.Step)
+		case w.URL == "":
+			return fmt.Errorf("postprocessing webhook for step '%s' has no url", w.Step)
+		case w.Secret == "":
+			return fmt.Errorf("postprocessing webhook for step '%s' has no secret", w.Step)
+		}
+		seen[w.Step] = true
+	}
+
+	return nil
+}
+
+// validateDependencies makes sure the dependencies of the postprocessing steps don't contain a cycle
+func validateDependencies(deps map[string][]string) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[string]int, len(deps))
+	var visit func(step string, path []string) error
+	visit = func(step string, path []string) error {
+		switch state[step] {
+		case visiting:
+			return fmt.Errorf("cyclic postprocessing step dependencies: %s", strings.Join(append(path, step), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[step] = visiting
+		for _, d := range deps[step] {
+			if d == step {
+				return fmt.Errorf("postprocessing step '%s' depends on itself", step)
+			}
+			if err := visit(d, append(path, step)); err != nil {
+				return err
+			}
+		}
+		state[step] = visited
+
+		return nil
+	}
+
+	for step := range deps {
+		if err := visit(step, nil); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -55,6 +55,19 @@ var (
 		Help:      "Duration of postprocessing operations in seconds",
 		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1200},
 	}, []string{"status"})
+	stepDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "step_duration_seconds",
+		Help:      "Duration of postprocessing steps in seconds",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1200},
+	}, []string{"step", "outcome"})
+	queueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: Subsystem,
+		Name:      "queue_depth",
+		Help:      "Number of uploads waiting for a postprocessing step",
+	}, []string{"step"})
 )
 
 // Metrics defines the available metrics of this service.
@@ -67,6 +80,8 @@ type Metrics struct {
 	InProgress            prometheus.Gauge
 	Finished              *prometheus.CounterVec
 	Duration              *prometheus.HistogramVec
+	StepDuration          *prometheus.HistogramVec
+	QueueDepth            *prometheus.GaugeVec
 }
 
 // New initializes the available metrics.
@@ -79,6 +94,8 @@ func New() *Metrics {
 		InProgress:            inProgress,
 		Finished:              finished,
 		Duration:              duration,
+		StepDuration:          stepDuration,
+		QueueDepth:            queueDepth,
 	}
 
 	return m
@@ -2,10 +2,12 @@ package postprocessing
 
 import (
 	"math"
+	"slices"
 	"time"
 
 	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/opencloud/pkg/quarantine"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 )
@@ -20,19 +22,25 @@ type Postprocessing struct {
 	Filesize          uint64
 	ResourceID        *provider.ResourceId
 	Steps             []events.Postprocessingstep
-	Status            Status
-	Failures          int
-	InitiatorID       string
-	Finished          bool
-	StartTime         time.Time
+	// Dependencies of the steps. If nil, the steps are processed one after another in the order of Steps.
+	Dependencies map[events.Postprocessingstep][]events.Postprocessingstep
+	Status       Status
+	Failures     int
+	InitiatorID  string
+	Finished     bool
+	StartTime    time.Time
 
 	config config.Postprocessing
 }
 
 // Status is helper struct to show current postprocessing status
 type Status struct {
-	CurrentStep events.Postprocessingstep
-	Outcome     events.PostprocessingOutcome
+	CurrentStep    events.Postprocessingstep
+	Outcome        events.PostprocessingOutcome
+	StepStartTimes map[events.Postprocessingstep]time.Time
+	// Running and Done are only used when the steps are processed in parallel
+	Running []events.Postprocessingstep
+	Done    []events.Postprocessingstep
 }
 
 // New returns a new postprocessing instance
@@ -43,41 +51,105 @@ func New(config config.Postprocessing) *Postprocessing {
 }
 
 // Init is the first step of the postprocessing
-func (pp *Postprocessing) Init(_ events.BytesReceived) interface{} {
+func (pp *Postprocessing) Init(_ events.BytesReceived) []interface{} {
 	if len(pp.Steps) == 0 {
-		return pp.finished(events.PPOutcomeContinue)
+		return []interface{}{pp.finished(events.PPOutcomeContinue)}
 	}
 
-	return pp.step(pp.Steps[0])
+	if pp.parallel() {
+		return pp.ready()
+	}
+
+	return []interface{}{pp.step(pp.Steps[0])}
 }
 
-// NextStep returns the next postprocessing step
-func (pp *Postprocessing) NextStep(ev events.PostprocessingStepFinished) interface{} {
+// NextStep returns the next postprocessing steps. When the steps are processed in parallel
+// it is empty as long as other steps the remaining steps depend on are still running.
+func (pp *Postprocessing) NextStep(ev events.PostprocessingStepFinished) []interface{} {
+	if !slices.Contains(pp.RunningSteps(), ev.FinishedStep) {
+		// the step finished after the postprocessing has already been aborted or its outcome was forced
+		return nil
+	}
+
 	switch ev.Outcome {
 	case events.PPOutcomeContinue:
-		return pp.next(ev.FinishedStep)
+		if pp.parallel() {
+			return pp.done(ev.FinishedStep)
+		}
+		return []interface{}{pp.next(ev.FinishedStep)}
 	case events.PPOutcomeRetry:
 		pp.Failures++
 		if pp.Failures > pp.config.MaxRetries {
-			return pp.finished(events.PPOutcomeAbort)
+			return []interface{}{pp.finished(events.PPOutcomeAbort)}
 		}
-		return pp.retry()
+		return []interface{}{pp.retry()}
+	case quarantine.PPOutcomeQuarantine:
+		// the file has been moved to the quarantine space, the upload is not needed anymore
+		return []interface{}{pp.finished(events.PPOutcomeDelete)}
 	default:
-		return pp.finished(ev.Outcome)
+		return []interface{}{pp.finished(ev.Outcome)}
+	}
+}
+
+// RetryStep restarts the postprocessing at the given step
+func (pp *Postprocessing) RetryStep(step events.Postprocessingstep) events.StartPostprocessingStep {
+	pp.Status.Outcome = ""
+	pp.Failures = 0
+	pp.Status.Done = slices.DeleteFunc(pp.Status.Done, func(s events.Postprocessingstep) bool { return s == step })
+	return pp.step(step)
+}
+
+// Force finishes the running steps with the given outcome. The steps keep running,
+// their results are ignored once they finish.
+func (pp *Postprocessing) Force(outcome events.PostprocessingOutcome) []interface{} {
+	switch {
+	case outcome != events.PPOutcomeContinue:
+		return []interface{}{pp.finished(outcome)}
+	case !pp.parallel():
+		return []interface{}{pp.next(pp.Status.CurrentStep)}
 	}
+
+	var evs []interface{}
+	for _, s := range pp.RunningSteps() {
+		evs = append(evs, pp.done(s)...)
+	}
+	return evs
 }
 
-// CurrentStep returns the current postprocessing step
-func (pp *Postprocessing) CurrentStep() interface{} {
+// CurrentStep returns the events to resume the current postprocessing steps
+func (pp *Postprocessing) CurrentStep() []interface{} {
 	if pp.Status.CurrentStep == events.PPStepFinished {
-		return pp.finished(pp.Status.Outcome)
+		return []interface{}{pp.finished(pp.Status.Outcome)}
+	}
+
+	var evs []interface{}
+	for _, s := range pp.RunningSteps() {
+		evs = append(evs, pp.step(s))
+	}
+	return evs
+}
+
+// RunningSteps returns the steps the postprocessing is currently waiting for
+func (pp *Postprocessing) RunningSteps() []events.Postprocessingstep {
+	switch {
+	case pp.Status.CurrentStep == events.PPStepFinished:
+		return nil
+	case pp.parallel():
+		return slices.Clone(pp.Status.Running)
+	default:
+		return []events.Postprocessingstep{pp.Status.CurrentStep}
 	}
-	return pp.step(pp.Status.CurrentStep)
 }
 
-// Delay will sleep the configured time then continue
+// Delay will sleep the configured time then finish the delay step
 func (pp *Postprocessing) Delay(f func(next interface{})) {
-	next := pp.next(events.PPStepDelay)
+	next := events.PostprocessingStepFinished{
+		UploadID:      pp.ID,
+		ExecutingUser: pp.User,
+		Filename:      pp.Filename,
+		FinishedStep:  events.PPStepDelay,
+		Outcome:       events.PPOutcomeContinue,
+	}
 	go func() {
 		time.Sleep(pp.config.Delayprocessing)
 		f(next)
@@ -89,6 +161,10 @@ func (pp *Postprocessing) BackoffDuration() time.Duration {
 	return pp.config.RetryBackoffDuration * time.Duration(math.Pow(2, float64(pp.Failures-1)))
 }
 
+func (pp *Postprocessing) parallel() bool {
+	return pp.Dependencies != nil
+}
+
 func (pp *Postprocessing) next(current events.Postprocessingstep) interface{} {
 	l := len(pp.Steps)
 	for i, s := range pp.Steps {
@@ -99,8 +175,58 @@ func (pp *Postprocessing) next(current events.Postprocessingstep) interface{} {
 	return pp.finished(events.PPOutcomeContinue)
 }
 
+// done marks the step as successfully finished and starts the steps which are ready now
+func (pp *Postprocessing) done(step events.Postprocessingstep) []interface{} {
+	if !slices.Contains(pp.Status.Running, step) {
+		// duplicate or unexpected event
+		return nil
+	}
+
+	pp.Status.Running = slices.DeleteFunc(pp.Status.Running, func(s events.Postprocessingstep) bool { return s == step })
+	pp.Status.Done = append(pp.Status.Done, step)
+
+	return pp.ready()
+}
+
+// ready starts all steps whose dependencies are done and finishes the postprocessing if all steps are done
+func (pp *Postprocessing) ready() []interface{} {
+	var evs []interface{}
+	for _, s := range pp.Steps {
+		if slices.Contains(pp.Status.Done, s) || slices.Contains(pp.Status.Running, s) {
+			continue
+		}
+
+		ready := true
+		for _, d := range pp.Dependencies[s] {
+			// dependencies which are not part of this postprocessing are ignored
+			if slices.Contains(pp.Steps, d) && !slices.Contains(pp.Status.Done, d) {
+				ready = false
+				break
+			}
+		}
+
+		if ready {
+			evs = append(evs, pp.step(s))
+		}
+	}
+
+	if len(evs) == 0 && len(pp.Status.Running) == 0 {
+		return []interface{}{pp.finished(events.PPOutcomeContinue)}
+	}
+
+	return evs
+}
+
 func (pp *Postprocessing) step(next events.Postprocessingstep) events.StartPostprocessingStep {
 	pp.Status.CurrentStep = next
+	if pp.Status.StepStartTimes == nil {
+		pp.Status.StepStartTimes = make(map[events.Postprocessingstep]time.Time)
+	}
+	pp.Status.StepStartTimes[next] = time.Now()
+	if pp.parallel() && !slices.Contains(pp.Status.Running, next) {
+		pp.Status.Running = append(pp.Status.Running, next)
+	}
+
 	return events.StartPostprocessingStep{
 		UploadID:          pp.ID,
 		URL:               pp.URL,
@@ -116,6 +242,7 @@ func (pp *Postprocessing) step(next events.Postprocessingstep) events.StartPostp
 func (pp *Postprocessing) finished(outcome events.PostprocessingOutcome) events.PostprocessingFinished {
 	pp.Status.CurrentStep = events.PPStepFinished
 	pp.Status.Outcome = outcome
+	pp.Status.Running = nil
 	return events.PostprocessingFinished{
 		UploadID:          pp.ID,
 		ExecutingUser:     pp.User,
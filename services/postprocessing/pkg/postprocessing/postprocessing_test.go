@@ -0,0 +1,73 @@
+package postprocessing_test
+
+import (
+	"testing"
+
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/postprocessing"
+)
+
+func started(t *testing.T, evs []interface{}) []events.Postprocessingstep {
+	var steps []events.Postprocessingstep
+	for _, ev := range evs {
+		s, ok := ev.(events.StartPostprocessingStep)
+		require.True(t, ok, "expected StartPostprocessingStep, got %T", ev)
+		steps = append(steps, s.StepToStart)
+	}
+	return steps
+}
+
+func finish(step events.Postprocessingstep, outcome events.PostprocessingOutcome) events.PostprocessingStepFinished {
+	return events.PostprocessingStepFinished{FinishedStep: step, Outcome: outcome}
+}
+
+func TestSequentialSteps(t *testing.T) {
+	pp := postprocessing.New(config.Postprocessing{})
+	pp.Steps = []events.Postprocessingstep{"policies", "virusscan"}
+
+	require.Equal(t, []events.Postprocessingstep{"policies"}, started(t, pp.Init(events.BytesReceived{})))
+	require.Equal(t, []events.Postprocessingstep{"virusscan"}, started(t, pp.NextStep(finish("policies", events.PPOutcomeContinue))))
+
+	evs := pp.NextStep(finish("virusscan", events.PPOutcomeContinue))
+	require.Len(t, evs, 1)
+	require.Equal(t, events.PPOutcomeContinue, evs[0].(events.PostprocessingFinished).Outcome)
+}
+
+func TestParallelSteps(t *testing.T) {
+	pp := postprocessing.New(config.Postprocessing{})
+	pp.Steps = []events.Postprocessingstep{"policies", "virusscan", "classification"}
+	pp.Dependencies = map[events.Postprocessingstep][]events.Postprocessingstep{
+		"classification": {"virusscan"},
+	}
+
+	require.Equal(t, []events.Postprocessingstep{"policies", "virusscan"}, started(t, pp.Init(events.BytesReceived{})))
+
+	// classification still waits for virusscan
+	require.Empty(t, pp.NextStep(finish("policies", events.PPOutcomeContinue)))
+	require.Equal(t, []events.Postprocessingstep{"virusscan"}, pp.RunningSteps())
+
+	require.Equal(t, []events.Postprocessingstep{"classification"}, started(t, pp.NextStep(finish("virusscan", events.PPOutcomeContinue))))
+
+	evs := pp.NextStep(finish("classification", events.PPOutcomeContinue))
+	require.Len(t, evs, 1)
+	require.Equal(t, events.PPOutcomeContinue, evs[0].(events.PostprocessingFinished).Outcome)
+}
+
+func TestParallelStepsAbort(t *testing.T) {
+	pp := postprocessing.New(config.Postprocessing{})
+	pp.Steps = []events.Postprocessingstep{"policies", "virusscan"}
+	pp.Dependencies = map[events.Postprocessingstep][]events.Postprocessingstep{}
+
+	require.Len(t, pp.Init(events.BytesReceived{}), 2)
+
+	evs := pp.NextStep(finish("virusscan", events.PPOutcomeDelete))
+	require.Len(t, evs, 1)
+	require.Equal(t, events.PPOutcomeDelete, evs[0].(events.PostprocessingFinished).Outcome)
+
+	// the result of the other step is ignored
+	require.Empty(t, pp.NextStep(finish("policies", events.PPOutcomeContinue)))
+	require.Empty(t, pp.RunningSteps())
+}
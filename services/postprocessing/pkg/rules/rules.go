@@ -0,0 +1,188 @@
+// Package rules selects the postprocessing steps of an upload by evaluating rego policies.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/mime"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
+)
+
+// publicIDP is the identity provider of the users uploading via public links
+const publicIDP = "public"
+
+// Resource contains the information about the uploaded file.
+type Resource struct {
+	ID       *provider.ResourceId `json:"resource_id"`
+	Name     string               `json:"name"`
+	Size     uint64               `json:"size"`
+	MimeType string               `json:"mimetype"`
+}
+
+// Upload contains the information about the upload itself.
+type Upload struct {
+	SpaceID    string       `json:"space_id"`
+	SpaceOwner *user.UserId `json:"space_owner"`
+	PublicLink bool         `json:"public_link"`
+}
+
+// Environment is the input of the rules
+type Environment struct {
+	User     *user.User `json:"user"`
+	Resource Resource   `json:"resource"`
+	Upload   Upload     `json:"upload"`
+	Steps    []string   `json:"steps"`
+}
+
+// Evaluator computes the postprocessing steps of an upload.
+type Evaluator interface {
+	Steps(ctx context.Context, ev events.BytesReceived) []events.Postprocessingstep
+}
+
+// NewEvaluator returns an evaluator for the configured rules.
+// Without policies every upload gets the configured default steps.
+func NewEvaluator(ctx context.Context, cfg config.Postprocessing, logger log.Logger) (Evaluator, error) {
+	steps := make([]events.Postprocessingstep, 0, len(cfg.Steps))
+	for _, s := range cfg.Steps {
+		steps = append(steps, events.Postprocessingstep(s))
+	}
+
+	if len(cfg.Rules.Policies) == 0 {
+		return static{steps: steps}, nil
+	}
+
+	q, err := rego.New(
+		rego.Query(cfg.Rules.Query),
+		rego.Load(cfg.Rules.Policies, nil),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot prepare postprocessing rules: %w", err)
+	}
+
+	return OPA{
+		query:   q,
+		timeout: cfg.Rules.Timeout,
+		steps:   steps,
+		known:   knownSteps(cfg),
+		log:     logger,
+	}, nil
+}
+
+// knownSteps returns the steps which are processed by a known target. The rules must not
+// select any other step, postprocessing would wait forever for it to finish.
+func knownSteps(cfg config.Postprocessing) []events.Postprocessingstep {
+	known := []events.Postprocessingstep{events.PPStepAntivirus, events.PPStepPolicies, events.PPStepDelay}
+	for _, s := range slices.Concat(cfg.Steps, cfg.Rules.Steps) {
+		known = append(known, events.Postprocessingstep(s))
+	}
+	for _, w := range cfg.Webhooks {
+		known = append(known, events.Postprocessingstep(w.Step))
+	}
+	return known
+}
+
+// static always returns the same steps
+type static struct {
+	steps []events.Postprocessingstep
+}
+
+// Steps fulfills the Evaluator interface
+func (s static) Steps(_ context.Context, _ events.BytesReceived) []events.Postprocessingstep {
+	return s.steps
+}
+
+// OPA evaluates rego policies to compute the postprocessing steps.
+type OPA struct {
+	query   rego.PreparedEvalQuery
+	timeout time.Duration
+	steps   []events.Postprocessingstep
+	known   []events.Postprocessingstep
+	log     log.Logger
+}
+
+// Steps returns the steps computed by the rules. If the rules are undefined for the upload
+// or can't be evaluated the default steps are returned, skipping a step must never happen by accident.
+// Unknown steps returned by the rules are dropped.
+func (o OPA) Steps(ctx context.Context, ev events.BytesReceived) []events.Postprocessingstep {
+	steps, err := o.Evaluate(ctx, NewEnvironment(ev, o.steps))
+	switch {
+	case err != nil:
+		o.log.Error().Err(err).Str("uploadID", ev.UploadID).Msg("cannot evaluate postprocessing rules, using the default steps")
+		return o.steps
+	case steps == nil:
+		return o.steps
+	}
+
+	return slices.DeleteFunc(steps, func(s events.Postprocessingstep) bool {
+		if slices.Contains(o.known, s) {
+			return false
+		}
+		o.log.Warn().Str("uploadID", ev.UploadID).Str("step", string(s)).Msg("postprocessing rules returned an unknown step, skipping it")
+		return true
+	})
+}
+
+// Evaluate evaluates the rules for the environment, it returns nil if the query is undefined.
+func (o OPA) Evaluate(ctx context.Context, env Environment) ([]events.Postprocessingstep, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	rs, err := o.query.Eval(ctx, rego.EvalInput(env))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return nil, nil
+	}
+
+	values, ok := rs[0].Expressions[0].Value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("postprocessing rules must return a list of steps, got %T", rs[0].Expressions[0].Value)
+	}
+
+	steps := make([]events.Postprocessingstep, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("postprocessing step must be a string, got %T", v)
+		}
+		steps = append(steps, events.Postprocessingstep(s))
+	}
+
+	return steps, nil
+}
+
+// NewEnvironment returns the environment of the upload
+func NewEnvironment(ev events.BytesReceived, defaults []events.Postprocessingstep) Environment {
+	env := Environment{
+		User: ev.ExecutingUser,
+		Resource: Resource{
+			ID:       ev.ResourceID,
+			Name:     ev.Filename,
+			Size:     ev.Filesize,
+			MimeType: mime.Detect(false, ev.Filename),
+		},
+		Upload: Upload{
+			SpaceID:    ev.ResourceID.GetSpaceId(),
+			SpaceOwner: ev.SpaceOwner,
+			PublicLink: ev.ImpersonatingUser.GetId().GetIdp() == publicIDP,
+		},
+		Steps: make([]string, 0, len(defaults)),
+	}
+
+	for _, s := range defaults {
+		env.Steps = append(env.Steps, string(s))
+	}
+
+	return env
+}
@@ -0,0 +1,113 @@
+package rules_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/rules"
+)
+
+const policy = `
+package postprocessing
+
+import future.keywords.if
+import future.keywords.in
+
+steps := [s | some s in input.steps; s != "virusscan"] if {
+	input.upload.space_id == "trusted"
+}
+
+steps := array.concat(input.steps, ["classification"]) if {
+	input.resource.mimetype == "application/pdf"
+	input.resource.size > 1024
+}
+
+steps := array.concat(input.steps, ["review"]) if {
+	input.upload.public_link
+}
+
+steps := array.concat(input.steps, ["virusscna"]) if {
+	input.resource.name == "typo.txt"
+}
+`
+
+func TestSteps(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "postprocessing.rego")
+	require.NoError(t, os.WriteFile(file, []byte(policy), 0600))
+
+	evaluator, err := rules.NewEvaluator(context.Background(), config.Postprocessing{
+		Steps: []string{"policies", "virusscan"},
+		Rules: config.Rules{
+			Policies: []string{file},
+			Steps:    []string{"classification"},
+			Query:    "data.postprocessing.steps",
+			Timeout:  10 * time.Second,
+		},
+		Webhooks: []config.Webhook{{Step: "review"}},
+	}, log.NopLogger())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		event    events.BytesReceived
+		expected []events.Postprocessingstep
+	}{
+		{
+			name:     "undefined rules use the default steps",
+			event:    events.BytesReceived{Filename: "file.txt", Filesize: 10},
+			expected: []events.Postprocessingstep{"policies", "virusscan"},
+		},
+		{
+			name: "skip virusscan for the trusted space",
+			event: events.BytesReceived{
+				Filename:   "file.txt",
+				ResourceID: &provider.ResourceId{SpaceId: "trusted"},
+			},
+			expected: []events.Postprocessingstep{"policies"},
+		},
+		{
+			name:     "classify large pdf files",
+			event:    events.BytesReceived{Filename: "file.pdf", Filesize: 2048},
+			expected: []events.Postprocessingstep{"policies", "virusscan", "classification"},
+		},
+		{
+			name: "review public link uploads",
+			event: events.BytesReceived{
+				Filename:          "file.txt",
+				ImpersonatingUser: &user.User{Id: &user.UserId{Idp: "public"}},
+			},
+			expected: []events.Postprocessingstep{"policies", "virusscan", "review"},
+		},
+		{
+			name:     "unknown steps are dropped",
+			event:    events.BytesReceived{Filename: "typo.txt"},
+			expected: []events.Postprocessingstep{"policies", "virusscan"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, evaluator.Steps(context.Background(), tt.event))
+		})
+	}
+}
+
+func TestStepsWithoutPolicies(t *testing.T) {
+	evaluator, err := rules.NewEvaluator(context.Background(), config.Postprocessing{
+		Steps: []string{"virusscan"},
+	}, log.NopLogger())
+	require.NoError(t, err)
+
+	require.Equal(t, []events.Postprocessingstep{"virusscan"}, evaluator.Steps(context.Background(), events.BytesReceived{}))
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"fmt"
+
+	"github.com/opencloud-eu/opencloud/pkg/roles"
+	"github.com/opencloud-eu/opencloud/pkg/service/grpc"
+	"github.com/opencloud-eu/opencloud/pkg/version"
+	ppsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/postprocessing/v0"
+	svc "github.com/opencloud-eu/opencloud/services/postprocessing/pkg/service"
+)
+
+// Server initializes the grpc service and server serving the admin api.
+func Server(opts ...Option) (grpc.Service, error) {
+	options := newOptions(opts...)
+
+	service, err := grpc.NewServiceWithClient(
+		options.Config.GrpcClient,
+		grpc.TLSEnabled(options.Config.GRPC.TLS.Enabled),
+		grpc.TLSCert(
+			options.Config.GRPC.TLS.Cert,
+			options.Config.GRPC.TLS.Key,
+		),
+		grpc.Logger(options.Logger),
+		grpc.Namespace(options.Config.GRPC.Namespace),
+		grpc.Name(options.Config.Service.Name),
+		grpc.Version(version.GetString()),
+		grpc.Address(options.Config.GRPC.Addr),
+		grpc.Context(options.Context),
+		grpc.TraceProvider(options.TracerProvider),
+	)
+	if err != nil {
+		options.Logger.Error().
+			Err(err).
+			Msg("Error initializing grpc service")
+		return grpc.Service{}, fmt.Errorf("could not initialize grpc service: %w", err)
+	}
+
+	rm := roles.NewManager(
+		roles.Logger(options.Logger),
+		roles.RoleService(options.RoleClient),
+	)
+
+	if err := ppsvc.RegisterPostprocessingServiceHandler(
+		service.Server(),
+		svc.NewGRPCHandler(options.Service, &rm),
+	); err != nil {
+		options.Logger.Error().
+			Err(err).
+			Msg("Error registering postprocessing handler")
+		return grpc.Service{}, err
+	}
+
+	return service, nil
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	settingssvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/settings/v0"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/service"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/webhook"
+)
+
+// Option defines a single option function.
+type Option func(o *Options)
+
+// Options defines the available options for this package.
+type Options struct {
+	Logger         log.Logger
+	Context        context.Context
+	Config         *config.Config
+	TracerProvider trace.TracerProvider
+	Service        *service.PostprocessingService
+	RoleClient     settingssvc.RoleService
+	Worker         *webhook.Worker
+}
+
+// newOptions initializes the available default options.
+func newOptions(opts ...Option) Options {
+	opt := Options{}
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return opt
+}
+
+// Logger provides a function to set the logger option.
+func Logger(val log.Logger) Option {
+	return func(o *Options) {
+		o.Logger = val
+	}
+}
+
+// Context provides a function to set the context option.
+func Context(val context.Context) Option {
+	return func(o *Options) {
+		o.Context = val
+	}
+}
+
+// Config provides a function to set the config option.
+func Config(val *config.Config) Option {
+	return func(o *Options) {
+		o.Config = val
+	}
+}
+
+// TracerProvider provides a function to set the TracerProvider option
+func TracerProvider(val trace.TracerProvider) Option {
+	return func(o *Options) {
+		o.TracerProvider = val
+	}
+}
+
+// Service provides a function to set the postprocessing service option
+func Service(val *service.PostprocessingService) Option {
+	return func(o *Options) {
+		o.Service = val
+	}
+}
+
+// Role provides a function to set the RoleClient option
+func Role(val settingssvc.RoleService) Option {
+	return func(o *Options) {
+		o.RoleClient = val
+	}
+}
+
+// Worker provides a function to set the webhook worker option
+func Worker(val *webhook.Worker) Option {
+	return func(o *Options) {
+		o.Worker = val
+	}
+}
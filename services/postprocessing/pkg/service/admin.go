@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/postprocessing"
+)
+
+var (
+	// ErrFinished is returned when the postprocessing of an upload is already finished.
+	ErrFinished = errors.New("postprocessing already finished")
+	// ErrInvalidStep is returned when a step is not part of the postprocessing of an upload.
+	ErrInvalidStep = errors.New("invalid postprocessing step")
+	// ErrInvalidOutcome is returned when an outcome can't be forced.
+	ErrInvalidOutcome = errors.New("invalid postprocessing outcome")
+)
+
+// Upload is the postprocessing status of an upload
+type Upload struct {
+	ID          string                       `json:"id"`
+	Filename    string                       `json:"filename"`
+	Filesize    uint64                       `json:"filesize"`
+	ResourceID  *provider.ResourceId         `json:"resourceId,omitempty"`
+	UserID      string                       `json:"userId,omitempty"`
+	InitiatorID string                       `json:"initiatorId,omitempty"`
+	Steps       []events.Postprocessingstep  `json:"steps"`
+	CurrentStep events.Postprocessingstep    `json:"currentStep"`
+	Running     []events.Postprocessingstep  `json:"running"`
+	Outcome     events.PostprocessingOutcome `json:"outcome,omitempty"`
+	Failures    int                          `json:"failures"`
+	Finished    bool                         `json:"finished"`
+	StartTime   time.Time                    `json:"startTime"`
+	StepStarted time.Time                    `json:"stepStartTime"`
+}
+
+func newUpload(pp *postprocessing.Postprocessing) Upload {
+	return Upload{
+		ID:          pp.ID,
+		Filename:    pp.Filename,
+		Filesize:    pp.Filesize,
+		ResourceID:  pp.ResourceID,
+		UserID:      pp.User.GetId().GetOpaqueId(),
+		InitiatorID: pp.InitiatorID,
+		Steps:       pp.Steps,
+		CurrentStep: pp.Status.CurrentStep,
+		Running:     pp.RunningSteps(),
+		Outcome:     pp.Status.Outcome,
+		Failures:    pp.Failures,
+		Finished:    pp.Finished || pp.Status.CurrentStep == events.PPStepFinished,
+		StartTime:   pp.StartTime,
+		StepStarted: pp.Status.StepStartTimes[pp.Status.CurrentStep],
+	}
+}
+
+// ListUploads returns the uploads in postprocessing, optionally only those waiting for the given step
+func (pps *PostprocessingService) ListUploads(step events.Postprocessingstep) []Upload {
+	uploads := make([]Upload, 0)
+	for _, pp := range pps.listPP() {
+		if step != "" && !slices.Contains(pp.RunningSteps(), step) {
+			continue
+		}
+		uploads = append(uploads, newUpload(pp))
+	}
+
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].StartTime.Before(uploads[j].StartTime)
+	})
+
+	return uploads
+}
+
+// GetUpload returns the postprocessing status of an upload
+func (pps *PostprocessingService) GetUpload(uploadID string) (Upload, error) {
+	pp, err := pps.getPP(pps.store, uploadID)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	return newUpload(pp), nil
+}
+
+// CancelUpload stops the postprocessing of an upload and deletes the upload
+func (pps *PostprocessingService) CancelUpload(ctx context.Context, uploadID string) error {
+	return pps.ForceOutcome(ctx, uploadID, events.PPOutcomeDelete)
+}
+
+// ForceOutcome finishes the running steps of an upload with the given outcome
+func (pps *PostprocessingService) ForceOutcome(ctx context.Context, uploadID string, outcome events.PostprocessingOutcome) error {
+	switch outcome {
+	case events.PPOutcomeContinue, events.PPOutcomeAbort, events.PPOutcomeDelete:
+	default:
+		return fmt.Errorf("%w: '%s'", ErrInvalidOutcome, outcome)
+	}
+
+	unlock, err := pps.locks.lock(uploadID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pp, err := pps.unfinishedPP(uploadID)
+	if err != nil {
+		return err
+	}
+
+	running := pp.RunningSteps()
+	next := pp.Force(outcome)
+	if err := storePP(pps.store, pp); err != nil {
+		return err
+	}
+	pps.updateQueueDepth(running, pp.RunningSteps())
+
+	pps.log.Info().Str("uploadID", uploadID).Strs("steps", toStrings(running)).Str("outcome", string(outcome)).Msg("forced postprocessing outcome")
+	for _, ev := range next {
+		if err := events.Publish(ctx, pps.pub, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RetryStep restarts the postprocessing of an upload at the given step, the current step if empty
+func (pps *PostprocessingService) RetryStep(ctx context.Context, uploadID string, step events.Postprocessingstep) error {
+	unlock, err := pps.locks.lock(uploadID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pp, err := pps.unfinishedPP(uploadID)
+	if err != nil {
+		return err
+	}
+
+	if step == "" {
+		step = pp.Status.CurrentStep
+	}
+
+	if !slices.Contains(pp.Steps, step) {
+		return fmt.Errorf("%w: '%s'", ErrInvalidStep, step)
+	}
+
+	running := pp.RunningSteps()
+	next := pp.RetryStep(step)
+	if err := storePP(pps.store, pp); err != nil {
+		return err
+	}
+	pps.updateQueueDepth(running, pp.RunningSteps())
+
+	pps.log.Info().Str("uploadID", uploadID).Str("step", string(step)).Msg("retrying postprocessing step")
+	return events.Publish(ctx, pps.pub, next)
+}
+
+func (pps *PostprocessingService) unfinishedPP(uploadID string) (*postprocessing.Postprocessing, error) {
+	pp, err := pps.getPP(pps.store, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pp.Finished || pp.Status.CurrentStep == events.PPStepFinished {
+		return nil, ErrFinished
+	}
+
+	return pp, nil
+}
+
+func toStrings(steps []events.Postprocessingstep) []string {
+	s := make([]string, 0, len(steps))
+	for _, step := range steps {
+		s = append(s, string(step))
+	}
+	return s
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/go-chi/chi/v5"
+	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/events/raw"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	microclient "go-micro.dev/v4/client"
+	merrors "go-micro.dev/v4/errors"
+	microevents "go-micro.dev/v4/events"
+	"go-micro.dev/v4/metadata"
+	"go-micro.dev/v4/store"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/pkg/middleware"
+	"github.com/opencloud-eu/opencloud/pkg/roles"
+	settingsmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/settings/v0"
+	ppsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/postprocessing/v0"
+	settingssvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/settings/v0"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/metrics"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/rules"
+	settings "github.com/opencloud-eu/opencloud/services/settings/pkg/service/v0"
+)
+
+type testPublisher struct {
+	mu        sync.Mutex
+	published []interface{}
+}
+
+func (p *testPublisher) Publish(_ string, ev interface{}, _ ...microevents.PublishOption) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = append(p.published, ev)
+	return nil
+}
+
+// take returns the events published since the last call
+func (p *testPublisher) take() []interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	evs := p.published
+	p.published = nil
+	return evs
+}
+
+// roleService assigns the admin role to the admin user
+type roleService struct {
+	settingssvc.RoleService
+}
+
+func (roleService) ListRoleAssignments(_ context.Context, req *settingssvc.ListRoleAssignmentsRequest, _ ...microclient.CallOption) (*settingssvc.ListRoleAssignmentsResponse, error) {
+	roleID := "user-role"
+	if req.GetAccountUuid() == "admin" {
+		roleID = "admin-role"
+	}
+	return &settingssvc.ListRoleAssignmentsResponse{
+		Assignments: []*settingsmsg.UserRoleAssignment{{AccountUuid: req.GetAccountUuid(), RoleId: roleID}},
+	}, nil
+}
+
+func (roleService) ListRoles(_ context.Context, req *settingssvc.ListBundlesRequest, _ ...microclient.CallOption) (*settingssvc.ListBundlesResponse, error) {
+	var bundles []*settingsmsg.Bundle
+	for _, id := range req.GetBundleIds() {
+		b := &settingsmsg.Bundle{Id: id}
+		if id == "admin-role" {
+			b.Settings = []*settingsmsg.Setting{{Id: settings.AccountManagementPermissionID}}
+		}
+		bundles = append(bundles, b)
+	}
+	return &settingssvc.ListBundlesResponse{Bundles: bundles}, nil
+}
+
+func newTestService(t *testing.T, cfg config.Postprocessing) (*PostprocessingService, *testPublisher) {
+	evaluator, err := rules.NewEvaluator(context.Background(), cfg, log.NopLogger())
+	require.NoError(t, err)
+
+	pub := &testPublisher{}
+	pps := &PostprocessingService{
+		ctx:     context.Background(),
+		log:     log.NopLogger(),
+		pub:     pub,
+		rules:   evaluator,
+		deps:    getDependencies(cfg),
+		locks:   &localLocks{locks: map[string]*localLock{}},
+		store:   store.NewMemoryStore(),
+		c:       cfg,
+		tp:      noop.NewTracerProvider(),
+		metrics: metrics.New(),
+	}
+	pps.metrics.QueueDepth.Reset()
+
+	return pps, pub
+}
+
+// upload starts the postprocessing of an upload
+func upload(t *testing.T, pps *PostprocessingService, id string) {
+	require.NoError(t, pps.processEvent(raw.Event{Event: events.Event{Event: events.BytesReceived{
+		UploadID:      id,
+		Filename:      id + ".txt",
+		ExecutingUser: &user.User{Id: &user.UserId{OpaqueId: "uploader"}},
+	}}}))
+}
+
+func finishStep(t *testing.T, pps *PostprocessingService, id string, step events.Postprocessingstep) {
+	require.NoError(t, pps.processEvent(raw.Event{Event: events.Event{Event: events.PostprocessingStepFinished{
+		UploadID:     id,
+		FinishedStep: step,
+		Outcome:      events.PPOutcomeContinue,
+	}}}))
+}
+
+func queueDepth(t *testing.T, pps *PostprocessingService, step events.Postprocessingstep) float64 {
+	var m dto.Metric
+	require.NoError(t, pps.metrics.QueueDepth.WithLabelValues(string(step)).Write(&m))
+	return m.GetGauge().GetValue()
+}
+
+func startedSteps(evs []interface{}) []events.Postprocessingstep {
+	var steps []events.Postprocessingstep
+	for _, ev := range evs {
+		if s, ok := ev.(events.StartPostprocessingStep); ok {
+			steps = append(steps, s.StepToStart)
+		}
+	}
+	return steps
+}
+
+func TestListUploads(t *testing.T) {
+	pps, _ := newTestService(t, config.Postprocessing{Steps: []string{"policies", "virusscan"}})
+	upload(t, pps, "upload-1")
+	upload(t, pps, "upload-2")
+	finishStep(t, pps, "upload-2", "policies")
+
+	assert.Len(t, pps.ListUploads(""), 2)
+
+	uploads := pps.ListUploads("virusscan")
+	require.Len(t, uploads, 1)
+	assert.Equal(t, "upload-2", uploads[0].ID)
+	assert.Equal(t, "uploader", uploads[0].UserID)
+	assert.Equal(t, []events.Postprocessingstep{"virusscan"}, uploads[0].Running)
+
+	_, err := pps.GetUpload("unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestForceOutcome(t *testing.T) {
+	t.Run("continue starts the next step and ignores the late result", func(t *testing.T) {
+		pps, pub := newTestService(t, config.Postprocessing{Steps: []string{"policies", "virusscan"}})
+		upload(t, pps, "upload-1")
+		pub.take()
+
+		require.NoError(t, pps.ForceOutcome(context.Background(), "upload-1", events.PPOutcomeContinue))
+		evs := pub.take()
+		require.Len(t, evs, 1)
+		assert.Equal(t, []events.Postprocessingstep{"virusscan"}, startedSteps(evs), "no synthetic step result must be published")
+
+		finishStep(t, pps, "upload-1", "policies")
+		assert.Empty(t, pub.take())
+
+		u, err := pps.GetUpload("upload-1")
+		require.NoError(t, err)
+		assert.Equal(t, events.Postprocessingstep("virusscan"), u.CurrentStep)
+		assert.Equal(t, float64(0), queueDepth(t, pps, "policies"))
+		assert.Equal(t, float64(1), queueDepth(t, pps, "virusscan"))
+	})
+
+	t.Run("continue finishes the running parallel steps", func(t *testing.T) {
+		pps, pub := newTestService(t, config.Postprocessing{
+			Steps:        []string{"policies", "virusscan", "classification"},
+			Dependencies: map[string][]string{"classification": {"virusscan"}},
+		})
+		upload(t, pps, "upload-1")
+		pub.take()
+
+		require.NoError(t, pps.ForceOutcome(context.Background(), "upload-1", events.PPOutcomeContinue))
+		assert.Equal(t, []events.Postprocessingstep{"classification"}, startedSteps(pub.take()))
+		assert.Equal(t, float64(1), queueDepth(t, pps, "classification"))
+		assert.Equal(t, float64(0), queueDepth(t, pps, "virusscan"))
+	})
+
+	t.Run("abort finishes the postprocessing", func(t *testing.T) {
+		pps, pub := newTestService(t, config.Postprocessing{Steps: []string{"policies", "virusscan"}})
+		upload(t, pps, "upload-1")
+		pub.take()
+
+		require.NoError(t, pps.CancelUpload(context.Background(), "upload-1"))
+		evs := pub.take()
+		require.Len(t, evs, 1)
+		assert.Equal(t, events.PPOutcomeDelete, evs[0].(events.PostprocessingFinished).Outcome)
+		assert.Equal(t, float64(0), queueDepth(t, pps, "policies"))
+
+		assert.ErrorIs(t, pps.ForceOutcome(context.Background(), "upload-1", events.PPOutcomeAbort), ErrFinished)
+	})
+
+	t.Run("retry can't be forced", func(t *testing.T) {
+		pps, _ := newTestService(t, config.Postprocessing{Steps: []string{"policies"}})
+		upload(t, pps, "upload-1")
+
+		assert.ErrorIs(t, pps.ForceOutcome(context.Background(), "upload-1", events.PPOutcomeRetry), ErrInvalidOutcome)
+	})
+}
+
+func TestRetryStep(t *testing.T) {
+	pps, pub := newTestService(t, config.Postprocessing{Steps: []string{"policies", "virusscan"}})
+	upload(t, pps, "upload-1")
+	finishStep(t, pps, "upload-1", "policies")
+	pub.take()
+
+	assert.ErrorIs(t, pps.RetryStep(context.Background(), "upload-1", "unknown"), ErrInvalidStep)
+
+	require.NoError(t, pps.RetryStep(context.Background(), "upload-1", "policies"))
+	assert.Equal(t, []events.Postprocessingstep{"policies"}, startedSteps(pub.take()))
+	assert.Equal(t, float64(1), queueDepth(t, pps, "policies"))
+	assert.Equal(t, float64(0), queueDepth(t, pps, "virusscan"))
+}
+
+func TestAdminHandler(t *testing.T) {
+	pps, _ := newTestService(t, config.Postprocessing{Steps: []string{"policies"}})
+	upload(t, pps, "upload-1")
+	rm := roles.NewManager(roles.RoleService(roleService{}))
+	h := NewAdminHandler(pps, &rm, chi.NewMux())
+
+	request := func(userID, method, path, body string) int {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if userID != "" {
+			r = r.WithContext(revactx.ContextSetUser(r.Context(), &user.User{Id: &user.UserId{OpaqueId: userID}}))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request("", http.MethodGet, "/postprocessing/v1/uploads", ""))
+	assert.Equal(t, http.StatusForbidden, request("user", http.MethodGet, "/postprocessing/v1/uploads", ""))
+	assert.Equal(t, http.StatusOK, request("admin", http.MethodGet, "/postprocessing/v1/uploads", ""))
+	assert.Equal(t, http.StatusNotFound, request("admin", http.MethodGet, "/postprocessing/v1/uploads/unknown", ""))
+	assert.Equal(t, http.StatusBadRequest, request("admin", http.MethodPost, "/postprocessing/v1/uploads/upload-1/force", `{"outcome":"retry"}`))
+	assert.Equal(t, http.StatusAccepted, request("admin", http.MethodPost, "/postprocessing/v1/uploads/upload-1/force", `{"outcome":"abort"}`))
+	assert.Equal(t, http.StatusConflict, request("admin", http.MethodPost, "/postprocessing/v1/uploads/upload-1/cancel", ""))
+}
+
+func TestGRPCHandler(t *testing.T) {
+	pps, _ := newTestService(t, config.Postprocessing{Steps: []string{"policies"}})
+	upload(t, pps, "upload-1")
+	rm := roles.NewManager(roles.RoleService(roleService{}))
+	g := NewGRPCHandler(pps, &rm)
+
+	as := func(userID string) context.Context {
+		return metadata.Set(context.Background(), middleware.AccountID, userID)
+	}
+	code := func(err error) int32 {
+		return merrors.FromError(err).GetCode()
+	}
+
+	res := &ppsvc.ListUploadsResponse{}
+	assert.Equal(t, int32(http.StatusUnauthorized), code(g.ListUploads(context.Background(), &ppsvc.ListUploadsRequest{}, res)))
+	assert.Equal(t, int32(http.StatusForbidden), code(g.ListUploads(as("user"), &ppsvc.ListUploadsRequest{}, res)))
+
+	require.NoError(t, g.ListUploads(as("admin"), &ppsvc.ListUploadsRequest{Step: "policies"}, res))
+	require.Len(t, res.GetUploads(), 1)
+	assert.Equal(t, "upload-1", res.GetUploads()[0].GetId())
+	assert.Equal(t, []string{"policies"}, res.GetUploads()[0].GetRunning())
+	assert.NotNil(t, res.GetUploads()[0].GetStartTime())
+
+	err := g.GetUpload(as("admin"), &ppsvc.GetUploadRequest{Id: "unknown"}, &ppsvc.GetUploadResponse{})
+	assert.Equal(t, int32(http.StatusNotFound), code(err))
+
+	require.NoError(t, g.ForceOutcome(as("admin"), &ppsvc.ForceOutcomeRequest{Id: "upload-1", Outcome: "abort"}, &ppsvc.ForceOutcomeResponse{}))
+	err = g.RetryStep(as("admin"), &ppsvc.RetryStepRequest{Id: "upload-1"}, &ppsvc.RetryStepResponse{})
+	assert.Equal(t, int32(http.StatusConflict), code(err))
+}
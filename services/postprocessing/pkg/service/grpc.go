@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	merrors "go-micro.dev/v4/errors"
+	"go-micro.dev/v4/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/opencloud-eu/opencloud/pkg/middleware"
+	"github.com/opencloud-eu/opencloud/pkg/roles"
+	ppmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/postprocessing/v0"
+	ppsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/postprocessing/v0"
+	settings "github.com/opencloud-eu/opencloud/services/settings/pkg/service/v0"
+)
+
+// GRPCHandler serves the grpc api to inspect and manage the postprocessing of uploads
+type GRPCHandler struct {
+	id  string
+	pps *PostprocessingService
+	rm  *roles.Manager
+}
+
+// NewGRPCHandler returns the grpc handler of the admin api
+func NewGRPCHandler(pps *PostprocessingService, rm *roles.Manager) *GRPCHandler {
+	return &GRPCHandler{
+		id:  "opencloud-postprocessing",
+		pps: pps,
+		rm:  rm,
+	}
+}
+
+// ListUploads lists the uploads in postprocessing
+func (g *GRPCHandler) ListUploads(ctx context.Context, req *ppsvc.ListUploadsRequest, res *ppsvc.ListUploadsResponse) error {
+	if err := g.requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	for _, u := range g.pps.ListUploads(events.Postprocessingstep(req.GetStep())) {
+		res.Uploads = append(res.Uploads, u.toProto())
+	}
+	return nil
+}
+
+// GetUpload returns the postprocessing status of an upload
+func (g *GRPCHandler) GetUpload(ctx context.Context, req *ppsvc.GetUploadRequest, res *ppsvc.GetUploadResponse) error {
+	if err := g.requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	u, err := g.pps.GetUpload(req.GetId())
+	if err != nil {
+		return g.error(err)
+	}
+
+	res.Upload = u.toProto()
+	return nil
+}
+
+// CancelUpload stops the postprocessing of an upload and deletes the upload
+func (g *GRPCHandler) CancelUpload(ctx context.Context, req *ppsvc.CancelUploadRequest, _ *ppsvc.CancelUploadResponse) error {
+	if err := g.requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	return g.error(g.pps.CancelUpload(ctx, req.GetId()))
+}
+
+// RetryStep restarts the postprocessing of an upload at the given step
+func (g *GRPCHandler) RetryStep(ctx context.Context, req *ppsvc.RetryStepRequest, _ *ppsvc.RetryStepResponse) error {
+	if err := g.requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	return g.error(g.pps.RetryStep(ctx, req.GetId(), events.Postprocessingstep(req.GetStep())))
+}
+
+// ForceOutcome finishes the running steps of an upload with the given outcome
+func (g *GRPCHandler) ForceOutcome(ctx context.Context, req *ppsvc.ForceOutcomeRequest, _ *ppsvc.ForceOutcomeResponse) error {
+	if err := g.requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	return g.error(g.pps.ForceOutcome(ctx, req.GetId(), events.PostprocessingOutcome(req.GetOutcome())))
+}
+
+// requireAdmin allows only requests of users with the account management permission
+func (g *GRPCHandler) requireAdmin(ctx context.Context) error {
+	accountID, _ := metadata.Get(ctx, middleware.AccountID)
+
+	switch err := g.rm.CheckPermission(ctx, accountID, settings.AccountManagementPermissionID); {
+	case err == nil:
+		return nil
+	case errors.Is(err, roles.ErrUnauthenticated):
+		return merrors.Unauthorized(g.id, "%s", err)
+	case errors.Is(err, roles.ErrPermissionDenied):
+		return merrors.Forbidden(g.id, "%s", err)
+	default:
+		g.pps.log.Error().Err(err).Msg("cannot check the permissions of the user")
+		return merrors.InternalServerError(g.id, "%s", err)
+	}
+}
+
+func (g *GRPCHandler) error(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNotFound):
+		return merrors.NotFound(g.id, "%s", err)
+	case errors.Is(err, ErrFinished):
+		return merrors.Conflict(g.id, "%s", err)
+	case errors.Is(err, ErrInvalidStep), errors.Is(err, ErrInvalidOutcome):
+		return merrors.BadRequest(g.id, "%s", err)
+	default:
+		g.pps.log.Error().Err(err).Msg("postprocessing admin request failed")
+		return merrors.InternalServerError(g.id, "%s", err)
+	}
+}
+
+func (u Upload) toProto() *ppmsg.Upload {
+	p := &ppmsg.Upload{
+		Id:          u.ID,
+		Filename:    u.Filename,
+		Filesize:    u.Filesize,
+		UserId:      u.UserID,
+		InitiatorId: u.InitiatorID,
+		Steps:       toStrings(u.Steps),
+		CurrentStep: string(u.CurrentStep),
+		Running:     toStrings(u.Running),
+		Outcome:     string(u.Outcome),
+		Failures:    int32(u.Failures),
+		Finished:    u.Finished,
+	}
+
+	if u.ResourceID != nil {
+		p.ResourceId = storagespace.FormatResourceID(u.ResourceID)
+	}
+	if !u.StartTime.IsZero() {
+		p.StartTime = timestamppb.New(u.StartTime)
+	}
+	if !u.StepStarted.IsZero() {
+		p.StepStartTime = timestamppb.New(u.StepStarted)
+	}
+
+	return p
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+
+	"github.com/opencloud-eu/opencloud/pkg/roles"
+	settings "github.com/opencloud-eu/opencloud/services/settings/pkg/service/v0"
+)
+
+// AdminHandler serves the api to inspect and manage the postprocessing of uploads
+type AdminHandler struct {
+	pps *PostprocessingService
+	mux *chi.Mux
+}
+
+// RetryRequest is the expected body of the retry request
+type RetryRequest struct {
+	// the step to restart, defaults to the current step
+	Step events.Postprocessingstep `json:"step"`
+}
+
+// ForceRequest is the expected body of the force request
+type ForceRequest struct {
+	Outcome events.PostprocessingOutcome `json:"outcome"`
+}
+
+// NewAdminHandler returns the http handler of the admin api
+func NewAdminHandler(pps *PostprocessingService, rm *roles.Manager, mux *chi.Mux) *AdminHandler {
+	h := &AdminHandler{pps: pps, mux: mux}
+
+	mux.Route("/postprocessing/v1/uploads", func(r chi.Router) {
+		r.Use(roles.RequirePermission(rm, settings.AccountManagementPermissionID, pps.log))
+		r.Get("/", h.HandleList)
+		r.Get("/{id}", h.HandleGet)
+		r.Post("/{id}/cancel", h.HandleCancel)
+		r.Post("/{id}/retry", h.HandleRetry)
+		r.Post("/{id}/force", h.HandleForce)
+	})
+
+	return h
+}
+
+// ServeHTTP fulfills Handler interface
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// HandleList is the GET handler listing the uploads in postprocessing
+func (h *AdminHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	uploads := h.pps.ListUploads(events.Postprocessingstep(r.URL.Query().Get("step")))
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, uploads)
+}
+
+// HandleGet is the GET handler returning the postprocessing status of an upload
+func (h *AdminHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	upload, err := h.pps.GetUpload(chi.URLParam(r, "id"))
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, upload)
+}
+
+// HandleCancel is the POST handler cancelling the postprocessing of an upload
+func (h *AdminHandler) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	if err := h.pps.CancelUpload(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// HandleRetry is the POST handler restarting a postprocessing step of an upload
+func (h *AdminHandler) HandleRetry(w http.ResponseWriter, r *http.Request) {
+	var req RetryRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.pps.RetryStep(r.Context(), chi.URLParam(r, "id"), req.Step); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// HandleForce is the POST handler finishing the current step of an upload with the given outcome
+func (h *AdminHandler) HandleForce(w http.ResponseWriter, r *http.Request) {
+	var req ForceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.pps.ForceOutcome(r.Context(), chi.URLParam(r, "id"), req.Outcome); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AdminHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, ErrFinished):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, ErrInvalidStep), errors.Is(err, ErrInvalidOutcome):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		h.pps.log.Error().Err(err).Str("path", r.URL.Path).Msg("postprocessing admin request failed")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
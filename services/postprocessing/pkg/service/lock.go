@@ -0,0 +1,127 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
+)
+
+const (
+	// _lockTTL is the time after which the lock of a crashed instance expires
+	_lockTTL = time.Minute
+	// _lockRetryInterval is the time to wait before trying to acquire a held lock again
+	_lockRetryInterval = 20 * time.Millisecond
+)
+
+// locker serialises the updates of an upload, the step results of an upload may be processed concurrently
+// when steps run in parallel
+type locker interface {
+	// lock locks the upload and returns the function to unlock it
+	lock(uploadID string) (func(), error)
+}
+
+// newLocker returns a locker shared by all instances of the service if the nats-js-kv store is used.
+// The other stores are only safe with a single instance of the service.
+func newLocker(cfg config.Store, logger log.Logger) (locker, error) {
+	if cfg.Store != "nats-js-kv" {
+		return &localLocks{locks: map[string]*localLock{}}, nil
+	}
+
+	conn, err := nats.Connect(strings.Join(cfg.Nodes, ","), nats.UserInfo(cfg.AuthUsername, cfg.AuthPassword))
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the store: %w", err)
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := cfg.Database + "-locks"
+	kv, err := js.KeyValue(bucket)
+	if err != nil {
+		if !errors.Is(err, nats.ErrBucketNotFound) {
+			return nil, fmt.Errorf("failed to get bucket (%s): %w", bucket, err)
+		}
+
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket: bucket,
+			TTL:    _lockTTL,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create bucket (%s): %w", bucket, err)
+		}
+	}
+
+	return &kvLocks{kv: kv, log: logger}, nil
+}
+
+// kvLocks locks the uploads with keys in a nats key value bucket, creating a key fails while it exists
+type kvLocks struct {
+	kv  nats.KeyValue
+	log log.Logger
+}
+
+func (l *kvLocks) lock(uploadID string) (func(), error) {
+	key := base64.RawURLEncoding.EncodeToString([]byte(uploadID))
+	deadline := time.Now().Add(_lockTTL)
+	for {
+		rev, err := l.kv.Create(key, []byte{})
+		switch {
+		case err == nil:
+			return func() {
+				// only delete the lock if it did not expire and was taken by someone else
+				if err := l.kv.Delete(key, nats.LastRevision(rev)); err != nil {
+					l.log.Error().Err(err).Str("uploadID", uploadID).Msg("cannot unlock upload")
+				}
+			}, nil
+		case !errors.Is(err, nats.ErrKeyExists):
+			return nil, err
+		case time.Now().After(deadline):
+			return nil, fmt.Errorf("timeout waiting for the lock of upload '%s'", uploadID)
+		}
+		time.Sleep(_lockRetryInterval)
+	}
+}
+
+// localLocks locks the uploads within the instance
+type localLocks struct {
+	mu    sync.Mutex
+	locks map[string]*localLock
+}
+
+type localLock struct {
+	sync.Mutex
+	// refs counts the holders and waiters, the lock is removed when it drops to zero
+	refs int
+}
+
+func (l *localLocks) lock(uploadID string) (func(), error) {
+	l.mu.Lock()
+	ul, ok := l.locks[uploadID]
+	if !ok {
+		ul = &localLock{}
+		l.locks[uploadID] = ul
+	}
+	ul.refs++
+	l.mu.Unlock()
+
+	ul.Lock()
+	return func() {
+		ul.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		ul.refs--
+		if ul.refs == 0 {
+			delete(l.locks, uploadID)
+		}
+	}, nil
+}
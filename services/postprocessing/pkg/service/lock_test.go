@@ -0,0 +1,45 @@
+package service
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalLocks(t *testing.T) {
+	l := &localLocks{locks: map[string]*localLock{}}
+
+	var (
+		wg      sync.WaitGroup
+		running int
+		maxRun  int
+		mu      sync.Mutex
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := l.lock("upload")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer unlock()
+
+			mu.Lock()
+			running++
+			maxRun = max(maxRun, running)
+			mu.Unlock()
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 1, maxRun)
+	// the lock is removed once nobody holds or waits for it
+	require.Empty(t, l.locks)
+}
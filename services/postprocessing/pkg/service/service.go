@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,6 +14,8 @@ import (
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/metrics"
 	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/postprocessing"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/rules"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/webhook"
 	ctxpkg "github.com/opencloud-eu/reva/v2/pkg/ctx"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 	"github.com/opencloud-eu/reva/v2/pkg/events/raw"
@@ -28,7 +31,9 @@ type PostprocessingService struct {
 	log     log.Logger
 	events  <-chan raw.Event
 	pub     events.Publisher
-	steps   []events.Postprocessingstep
+	rules   rules.Evaluator
+	deps    map[events.Postprocessingstep][]events.Postprocessingstep
+	locks   locker
 	store   store.Store
 	c       config.Postprocessing
 	tp      trace.TracerProvider
@@ -81,21 +86,36 @@ func NewPostprocessingService(ctx context.Context, logger log.Logger, sto store.
 		return nil, err
 	}
 
+	evaluator, err := rules.NewEvaluator(ctx, cfg.Postprocessing, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	locks, err := newLocker(cfg.Store, logger)
+	if err != nil {
+		return nil, err
+	}
+
 	m := metrics.New()
 	m.BuildInfo.WithLabelValues(version.GetString()).Set(1)
 	monitorMetrics(raw, "postprocessing-pull", m, logger)
 
-	return &PostprocessingService{
+	pps := &PostprocessingService{
 		ctx:     ctx,
 		log:     logger,
 		events:  evs,
 		pub:     pub,
-		steps:   getSteps(cfg.Postprocessing),
+		rules:   evaluator,
+		deps:    getDependencies(cfg.Postprocessing),
+		locks:   locks,
 		store:   sto,
 		c:       cfg.Postprocessing,
 		tp:      tp,
 		metrics: m,
-	}, nil
+	}
+	pps.initQueueDepth()
+
+	return pps, nil
 }
 
 // Run to fulfil Runner interface
@@ -127,9 +147,10 @@ func (pps *PostprocessingService) Run() error {
 
 func (pps *PostprocessingService) processEvent(e raw.Event) error {
 	var (
-		next interface{}
-		pp   *postprocessing.Postprocessing
-		err  error
+		next    []interface{}
+		pp      *postprocessing.Postprocessing
+		running []events.Postprocessingstep
+		err     error
 	)
 
 	ctx := e.GetTraceContext(pps.ctx)
@@ -145,6 +166,17 @@ func (pps *PostprocessingService) processEvent(e raw.Event) error {
 		}
 	}()
 
+	if id := uploadID(e.Event.Event); id != "" {
+		// steps running in parallel must not update the same upload concurrently, not even on different instances
+		unlock, err := pps.locks.lock(id)
+		if err != nil {
+			ackEvent = false
+			pps.log.Error().Str("uploadID", id).Err(err).Msg("cannot lock upload")
+			return fmt.Errorf("%w: cannot lock upload", ErrEvent)
+		}
+		defer unlock()
+	}
+
 	switch ev := e.Event.Event.(type) {
 	case events.BytesReceived:
 		pp = &postprocessing.Postprocessing{
@@ -154,7 +186,8 @@ func (pps *PostprocessingService) processEvent(e raw.Event) error {
 			Filename:          ev.Filename,
 			Filesize:          ev.Filesize,
 			ResourceID:        ev.ResourceID,
-			Steps:             pps.steps,
+			Steps:             pps.rules.Steps(ctx, ev),
+			Dependencies:      pps.deps,
 			InitiatorID:       e.InitiatorID,
 			ImpersonatingUser: ev.ImpersonatingUser,
 			StartTime:         time.Now(),
@@ -171,10 +204,13 @@ func (pps *PostprocessingService) processEvent(e raw.Event) error {
 			pps.log.Error().Str("uploadID", ev.UploadID).Err(err).Msg("cannot get upload")
 			return fmt.Errorf("%w: cannot get upload", ErrEvent)
 		}
+		running = pp.RunningSteps()
+		if start, ok := pp.Status.StepStartTimes[ev.FinishedStep]; ok {
+			pps.metrics.StepDuration.WithLabelValues(string(ev.FinishedStep), string(ev.Outcome)).Observe(time.Since(start).Seconds())
+		}
 		next = pp.NextStep(ev)
 
-		switch pp.Status.Outcome {
-		case events.PPOutcomeRetry:
+		if ev.Outcome == events.PPOutcomeRetry && pp.Status.Outcome == events.PPOutcomeRetry {
 			// schedule retry
 			backoff := pp.BackoffDuration()
 			go func() {
@@ -186,7 +222,7 @@ func (pps *PostprocessingService) processEvent(e raw.Event) error {
 					Filename:          pp.Filename,
 					Filesize:          pp.Filesize,
 					ResourceID:        pp.ResourceID,
-					StepToStart:       pp.Status.CurrentStep,
+					StepToStart:       ev.FinishedStep,
 					ImpersonatingUser: pp.ImpersonatingUser,
 				}
 				err := events.Publish(ctx, pps.pub, retryEvent)
@@ -248,10 +284,15 @@ func (pps *PostprocessingService) processEvent(e raw.Event) error {
 			pps.log.Error().Str("uploadID", pp.ID).Err(err).Msg("cannot store upload")
 			return fmt.Errorf("%w: cannot store upload", ErrEvent)
 		}
+
+		switch e.Event.Event.(type) {
+		case events.BytesReceived, events.PostprocessingStepFinished:
+			pps.updateQueueDepth(running, pp.RunningSteps())
+		}
 	}
 
-	if next != nil {
-		if err := events.Publish(ctx, pps.pub, next); err != nil {
+	for _, ev := range next {
+		if err := events.Publish(ctx, pps.pub, ev); err != nil {
 			pps.log.Error().Err(err).Msg("unable to publish event")
 			return fmt.Errorf("%w: unable to publish event", ErrFatal) // we can't publish -> we are screwed
 		}
@@ -259,6 +300,39 @@ func (pps *PostprocessingService) processEvent(e raw.Event) error {
 	return nil
 }
 
+func uploadID(ev interface{}) string {
+	switch ev := ev.(type) {
+	case events.BytesReceived:
+		return ev.UploadID
+	case events.PostprocessingStepFinished:
+		return ev.UploadID
+	case events.StartPostprocessingStep:
+		return ev.UploadID
+	case events.UploadReady:
+		return ev.UploadID
+	default:
+		return ""
+	}
+}
+
+// getDependencies returns the configured dependencies of the steps, nil if the steps are processed sequentially
+func getDependencies(c config.Postprocessing) map[events.Postprocessingstep][]events.Postprocessingstep {
+	if len(c.Dependencies) == 0 {
+		return nil
+	}
+
+	deps := make(map[events.Postprocessingstep][]events.Postprocessingstep, len(c.Dependencies))
+	for step, ds := range c.Dependencies {
+		s := events.Postprocessingstep(step)
+		deps[s] = make([]events.Postprocessingstep, 0, len(ds))
+		for _, d := range ds {
+			deps[s] = append(deps[s], events.Postprocessingstep(d))
+		}
+	}
+
+	return deps
+}
+
 func (pps *PostprocessingService) getPP(sto store.Store, uploadID string) (*postprocessing.Postprocessing, error) {
 	recs, err := sto.Read(uploadID)
 	if err != nil {
@@ -285,18 +359,6 @@ func (pps *PostprocessingService) getPP(sto store.Store, uploadID string) (*post
 	return pp, nil
 }
 
-func getSteps(c config.Postprocessing) []events.Postprocessingstep {
-	// NOTE: improved version only allows configuring order of postprocessing steps
-	// But we aim for a system where postprocessing steps can be configured per space, ideally by the spaceadmin itself
-	// We need to iterate over configuring PP service when we see fit
-	steps := make([]events.Postprocessingstep, 0, len(c.Steps))
-	for _, s := range c.Steps {
-		steps = append(steps, events.Postprocessingstep(s))
-	}
-
-	return steps
-}
-
 func storePP(sto store.Store, pp *postprocessing.Postprocessing) error {
 	b, err := json.Marshal(pp)
 	if err != nil {
@@ -343,18 +405,40 @@ func (pps *PostprocessingService) resumePP(ctx context.Context, uploadID string)
 		return nil
 	}
 
-	return events.Publish(ctx, pps.pub, pp.CurrentStep())
+	for _, ev := range pp.CurrentStep() {
+		if err := events.Publish(ctx, pps.pub, ev); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (pps *PostprocessingService) findUploadsByStep(step events.Postprocessingstep) []string {
 	var ids []string
 
+	for _, pp := range pps.listPP() {
+		if slices.Contains(pp.RunningSteps(), step) {
+			ids = append(ids, pp.ID)
+		}
+	}
+
+	return ids
+}
+
+// listPP returns all postprocessings in the store
+func (pps *PostprocessingService) listPP() []*postprocessing.Postprocessing {
+	var all []*postprocessing.Postprocessing
+
 	keys, err := pps.store.List()
 	if err != nil {
 		pps.log.Error().Err(err).Msg("cannot list uploads")
 	}
 
 	for _, k := range keys {
+		if webhook.IsPendingKey(k) {
+			continue
+		}
+
 		rec, err := pps.store.Read(k)
 		if err != nil {
 			pps.log.Error().Err(err).Msg("cannot read upload")
@@ -366,19 +450,38 @@ func (pps *PostprocessingService) findUploadsByStep(step events.Postprocessingst
 			continue
 		}
 
-		pp := &postprocessing.Postprocessing{}
+		pp := postprocessing.New(pps.c)
 		err = json.Unmarshal(rec[0].Value, pp)
 		if err != nil {
 			pps.log.Error().Err(err).Msg("cannot unmarshal upload")
 			continue
 		}
 
-		if pp.Status.CurrentStep == step {
-			ids = append(ids, pp.ID)
+		all = append(all, pp)
+	}
+
+	return all
+}
+
+// initQueueDepth counts the unfinished uploads per step once, afterwards the queue depth
+// is updated whenever the running steps of an upload change
+func (pps *PostprocessingService) initQueueDepth() {
+	for _, pp := range pps.listPP() {
+		if pp.Finished {
+			continue
 		}
+		pps.updateQueueDepth(nil, pp.RunningSteps())
 	}
+}
 
-	return ids
+// updateQueueDepth moves an upload from the steps it was waiting for to the steps it is waiting for now
+func (pps *PostprocessingService) updateQueueDepth(before, after []events.Postprocessingstep) {
+	for _, s := range before {
+		pps.metrics.QueueDepth.WithLabelValues(string(s)).Dec()
+	}
+	for _, s := range after {
+		pps.metrics.QueueDepth.WithLabelValues(string(s)).Inc()
+	}
 }
 
 func monitorMetrics(stream raw.Stream, name string, m *metrics.Metrics, logger log.Logger) {
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opencloud-eu/reva/v2/pkg/rhttp"
+)
+
+// maxCallbackSize limits the size of the callback body
+const maxCallbackSize = 64 * 1024
+
+// Handler serves the callbacks and downloads of the webhook steps
+type Handler struct {
+	worker *Worker
+	mux    *chi.Mux
+	client *http.Client
+}
+
+// NewHandler returns the http handler of the webhook api
+func NewHandler(worker *Worker, mux *chi.Mux) *Handler {
+	h := &Handler{
+		worker: worker,
+		mux:    mux,
+		client: rhttp.GetHTTPClient(rhttp.Timeout(0)),
+	}
+
+	mux.Route("/postprocessing/v1/webhook/{id}", func(r chi.Router) {
+		r.Post("/", h.HandleCallback)
+		r.Get("/content", h.HandleContent)
+	})
+
+	return h
+}
+
+// ServeHTTP fulfills Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// HandleCallback is the POST handler receiving the outcome of a webhook step
+func (h *Handler) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackSize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.worker.Callback(r.Context(), chi.URLParam(r, "id"), r.Header.Get(SignatureHeader), body)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, ErrNotPending):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, ErrInvalidSignature):
+		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Is(err, ErrInvalidOutcome):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		h.worker.log.Error().Err(err).Str("path", r.URL.Path).Msg("webhook callback failed")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// HandleContent is the GET handler streaming the file of a pending webhook step
+func (h *Handler) HandleContent(w http.ResponseWriter, r *http.Request) {
+	req, err := h.worker.DownloadRequest(r.Context(), chi.URLParam(r, "id"))
+	switch {
+	case errors.Is(err, ErrNotPending):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
+		h.worker.log.Error().Err(err).Str("path", r.URL.Path).Msg("cannot read pending webhook step")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		h.worker.log.Error().Err(err).Msg("cannot download file")
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		h.worker.log.Error().Int("statuscode", res.StatusCode).Msg("cannot download file")
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	for _, k := range []string{"Content-Type", "Content-Length"} {
+		if v := res.Header.Get(k); v != "" {
+			w.Header().Set(k, v)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, res.Body)
+}
@@ -0,0 +1,346 @@
+// Package webhook processes postprocessing steps by calling external http endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	ctxpkg "github.com/opencloud-eu/reva/v2/pkg/ctx"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/rhttp"
+	"go-micro.dev/v4/store"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
+)
+
+// SignatureHeader carries the hmac signature of the request body
+const SignatureHeader = "X-OpenCloud-Signature"
+
+// pendingKeyPrefix prefixes the store keys of steps waiting for their callback
+const pendingKeyPrefix = "webhook-"
+
+var (
+	// ErrNotPending is returned when there is no step waiting for the callback
+	ErrNotPending = errors.New("no pending webhook step")
+	// ErrInvalidSignature is returned when the signature of the callback doesn't match
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidOutcome is returned when the callback carries an unknown outcome
+	ErrInvalidOutcome = errors.New("invalid outcome")
+)
+
+// Request is the payload sent to the webhook endpoint
+type Request struct {
+	ID          string                         `json:"id"`
+	Step        events.Postprocessingstep      `json:"step"`
+	Event       events.StartPostprocessingStep `json:"event"`
+	DownloadURL string                         `json:"downloadUrl"`
+	CallbackURL string                         `json:"callbackUrl"`
+	Expires     time.Time                      `json:"expires"`
+}
+
+// Callback is the payload the webhook endpoint sends back when it is done
+type Callback struct {
+	Outcome events.PostprocessingOutcome `json:"outcome"`
+	Message string                       `json:"message,omitempty"`
+}
+
+// pending is the persisted state of a step waiting for its callback
+type pending struct {
+	ID       string
+	Event    events.StartPostprocessingStep
+	Deadline time.Time
+}
+
+// Worker sends the configured postprocessing steps to their webhooks
+type Worker struct {
+	log      log.Logger
+	pub      events.Publisher
+	store    store.Store
+	webhooks map[events.Postprocessingstep]config.Webhook
+	baseURL  string
+	client   *http.Client
+}
+
+// NewWorker returns a worker for the configured webhooks
+func NewWorker(cfg config.Postprocessing, pub events.Publisher, st store.Store, logger log.Logger) *Worker {
+	webhooks := make(map[events.Postprocessingstep]config.Webhook, len(cfg.Webhooks))
+	for _, w := range cfg.Webhooks {
+		webhooks[events.Postprocessingstep(w.Step)] = w
+
+		if !slices.Contains(cfg.Steps, w.Step) {
+			logger.Warn().Str("step", w.Step).Msg("postprocessing webhook is configured, but the step is not part of POSTPROCESSING_STEPS")
+		}
+	}
+
+	return &Worker{
+		log:      logger,
+		pub:      pub,
+		store:    st,
+		webhooks: webhooks,
+		baseURL:  cfg.WebhookBaseURL,
+		client:   rhttp.GetHTTPClient(rhttp.Timeout(30 * time.Second)),
+	}
+}
+
+// Run dispatches the webhook steps until the channel is closed,
+// the steps which were pending when the service stopped expire at their original deadline
+func (w *Worker) Run(ch <-chan events.Event) error {
+	if err := w.rearm(); err != nil {
+		return err
+	}
+
+	for e := range ch {
+		ev, ok := e.Event.(events.StartPostprocessingStep)
+		if !ok {
+			continue
+		}
+
+		if _, ok := w.webhooks[ev.StepToStart]; !ok {
+			continue
+		}
+
+		ctx := e.GetTraceContext(context.Background())
+		if err := w.dispatch(ctx, ev); err != nil {
+			w.log.Error().Err(err).Str("uploadID", ev.UploadID).Str("step", string(ev.StepToStart)).Msg("webhook failed, retrying")
+			w.finish(ctx, ev, events.PPOutcomeRetry)
+		}
+	}
+
+	return nil
+}
+
+// IsPending tells if the id belongs to a step waiting for its callback
+func (w *Worker) IsPending(id string) bool {
+	_, err := w.pending(id)
+	return err == nil
+}
+
+// dispatch posts the step to the webhook and waits for the callback in the background
+func (w *Worker) dispatch(ctx context.Context, ev events.StartPostprocessingStep) error {
+	wh := w.webhooks[ev.StepToStart]
+
+	p := pending{
+		ID:       uuid.New().String(),
+		Event:    ev,
+		Deadline: time.Now().Add(wh.Timeout),
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	if err := w.store.Write(&store.Record{Key: pendingKeyPrefix + p.ID, Value: b}); err != nil {
+		return err
+	}
+
+	// the endpoint downloads the file via the short-lived download url, don't leak the internal one
+	public := ev
+	public.URL, public.Token, public.RevaToken = "", "", ""
+
+	body, err := json.Marshal(Request{
+		ID:          p.ID,
+		Step:        ev.StepToStart,
+		Event:       public,
+		DownloadURL: w.baseURL + "/postprocessing/v1/webhook/" + p.ID + "/content",
+		CallbackURL: w.baseURL + "/postprocessing/v1/webhook/" + p.ID,
+		Expires:     p.Deadline,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(wh.Secret, body))
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		_ = w.store.Delete(pendingKeyPrefix + p.ID)
+		return err
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		_ = w.store.Delete(pendingKeyPrefix + p.ID)
+		return fmt.Errorf("unexpected status code from webhook %v", res.StatusCode)
+	}
+
+	w.schedule(p)
+
+	return nil
+}
+
+// schedule expires the step at its deadline unless the webhook endpoint calls back before
+func (w *Worker) schedule(p pending) {
+	time.AfterFunc(time.Until(p.Deadline), func() {
+		w.expire(p.ID)
+	})
+}
+
+// rearm schedules the expiry of the steps which are still waiting for their callback,
+// the timers don't survive a restart of the service
+func (w *Worker) rearm() error {
+	keys, err := w.store.List()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if !IsPendingKey(k) {
+			continue
+		}
+
+		p, err := w.load(strings.TrimPrefix(k, pendingKeyPrefix))
+		if err != nil {
+			w.log.Error().Err(err).Str("key", k).Msg("cannot read pending webhook step")
+			continue
+		}
+
+		w.schedule(p)
+	}
+
+	return nil
+}
+
+// Callback finishes the step with the outcome reported by the webhook endpoint
+func (w *Worker) Callback(ctx context.Context, id string, signature string, body []byte) error {
+	p, err := w.pending(id)
+	if err != nil {
+		return err
+	}
+
+	if !Verify(w.webhooks[p.Event.StepToStart].Secret, body, signature) {
+		return ErrInvalidSignature
+	}
+
+	var cb Callback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidOutcome, err)
+	}
+
+	switch cb.Outcome {
+	case events.PPOutcomeContinue, events.PPOutcomeAbort, events.PPOutcomeDelete, events.PPOutcomeRetry:
+	default:
+		return fmt.Errorf("%w: '%s'", ErrInvalidOutcome, cb.Outcome)
+	}
+
+	if err := w.store.Delete(pendingKeyPrefix + id); err != nil {
+		return err
+	}
+
+	w.log.Info().Str("uploadID", p.Event.UploadID).Str("step", string(p.Event.StepToStart)).Str("outcome", string(cb.Outcome)).Str("message", cb.Message).Msg("webhook step finished")
+	w.finish(ctx, p.Event, cb.Outcome)
+
+	return nil
+}
+
+// DownloadRequest returns the request downloading the file processed by the pending step
+func (w *Worker) DownloadRequest(ctx context.Context, id string) (*http.Request, error) {
+	p, err := w.pending(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Event.UploadID != "" {
+		return http.NewRequestWithContext(ctx, http.MethodGet, p.Event.URL, nil)
+	}
+
+	// on demand steps process already stored files, they are downloaded via the data gateway
+	req, err := rhttp.NewRequest(ctxpkg.ContextSetToken(ctx, p.Event.RevaToken), http.MethodGet, p.Event.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Reva-Transfer", p.Event.Token)
+
+	return req, nil
+}
+
+// expire retries the step if the webhook endpoint didn't call back in time
+func (w *Worker) expire(id string) {
+	p, err := w.load(id)
+	if err != nil {
+		// the callback already arrived
+		return
+	}
+
+	if err := w.store.Delete(pendingKeyPrefix + id); err != nil {
+		w.log.Error().Err(err).Str("id", id).Msg("cannot delete pending webhook step")
+	}
+
+	w.log.Error().Str("uploadID", p.Event.UploadID).Str("step", string(p.Event.StepToStart)).Msg("webhook did not call back in time, retrying")
+	w.finish(context.Background(), p.Event, events.PPOutcomeRetry)
+}
+
+func (w *Worker) finish(ctx context.Context, ev events.StartPostprocessingStep, outcome events.PostprocessingOutcome) {
+	if err := events.Publish(ctx, w.pub, events.PostprocessingStepFinished{
+		FinishedStep:  ev.StepToStart,
+		Outcome:       outcome,
+		UploadID:      ev.UploadID,
+		ExecutingUser: ev.ExecutingUser,
+		Filename:      ev.Filename,
+	}); err != nil {
+		w.log.Error().Err(err).Str("uploadID", ev.UploadID).Msg("cannot publish PostprocessingStepFinished event")
+	}
+}
+
+// pending returns the step waiting for its callback, expired steps are no longer pending
+func (w *Worker) pending(id string) (pending, error) {
+	p, err := w.load(id)
+	if err != nil {
+		return p, err
+	}
+
+	if time.Now().After(p.Deadline) {
+		return p, ErrNotPending
+	}
+
+	return p, nil
+}
+
+// load reads the persisted state of the step
+func (w *Worker) load(id string) (pending, error) {
+	var p pending
+
+	recs, err := w.store.Read(pendingKeyPrefix + id)
+	switch {
+	case errors.Is(err, store.ErrNotFound), err == nil && len(recs) == 0:
+		return p, ErrNotPending
+	case err != nil:
+		return p, err
+	}
+
+	return p, json.Unmarshal(recs[0].Value, &p)
+}
+
+// IsPendingKey tells if the store key belongs to a pending webhook step
+func IsPendingKey(key string) bool {
+	return strings.HasPrefix(key, pendingKeyPrefix)
+}
+
+// Sign returns the signature of the body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of the body
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
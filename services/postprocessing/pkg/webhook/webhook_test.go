@@ -0,0 +1,243 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	microevents "go-micro.dev/v4/events"
+	"go-micro.dev/v4/store"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/postprocessing/pkg/webhook"
+)
+
+const secret = "secret"
+
+type testPublisher struct {
+	mu       sync.Mutex
+	finished []events.PostprocessingStepFinished
+}
+
+func (p *testPublisher) Publish(_ string, ev interface{}, _ ...microevents.PublishOption) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished = append(p.finished, ev.(events.PostprocessingStepFinished))
+	return nil
+}
+
+func (p *testPublisher) outcomes() []events.PostprocessingOutcome {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	outcomes := make([]events.PostprocessingOutcome, 0, len(p.finished))
+	for _, f := range p.finished {
+		outcomes = append(outcomes, f.Outcome)
+	}
+	return outcomes
+}
+
+// endpoint is a webhook endpoint recording the received requests
+type endpoint struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []webhook.Request
+}
+
+func newEndpoint(t *testing.T) *endpoint {
+	e := &endpoint{}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify(secret, body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req webhook.Request
+		_ = json.Unmarshal(body, &req)
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.requests = append(e.requests, req)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(e.Close)
+
+	return e
+}
+
+func newWorker(url string, timeout time.Duration, st store.Store, pub events.Publisher) *webhook.Worker {
+	return webhook.NewWorker(config.Postprocessing{
+		Steps:    []string{"classification"},
+		Webhooks: []config.Webhook{{Step: "classification", URL: url, Secret: secret, Timeout: timeout}},
+	}, pub, st, log.NopLogger())
+}
+
+// dispatch runs the worker for the given steps and returns the requests the endpoint received
+func dispatch(t *testing.T, w *webhook.Worker, e *endpoint, evs ...events.StartPostprocessingStep) []webhook.Request {
+	ch := make(chan events.Event, len(evs))
+	for _, ev := range evs {
+		ch <- events.Event{Event: ev}
+	}
+	close(ch)
+
+	require.NoError(t, w.Run(ch))
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.requests
+}
+
+func step(uploadID string) events.StartPostprocessingStep {
+	return events.StartPostprocessingStep{
+		UploadID:    uploadID,
+		URL:         "http://internal/data/" + uploadID,
+		Filename:    "file.txt",
+		StepToStart: "classification",
+	}
+}
+
+func callback(t *testing.T, outcome events.PostprocessingOutcome) ([]byte, string) {
+	body, err := json.Marshal(webhook.Callback{Outcome: outcome})
+	require.NoError(t, err)
+	return body, webhook.Sign(secret, body)
+}
+
+func TestWorker(t *testing.T) {
+	t.Run("steps are registered and sent to the endpoint", func(t *testing.T) {
+		e := newEndpoint(t)
+		pub := &testPublisher{}
+		w := newWorker(e.URL, time.Hour, store.NewMemoryStore(), pub)
+
+		requests := dispatch(t, w, e, step("upload-1"), events.StartPostprocessingStep{UploadID: "upload-2", StepToStart: "virusscan"})
+
+		require.Len(t, requests, 1, "only the webhook steps are sent")
+		assert.Equal(t, "upload-1", requests[0].Event.UploadID)
+		assert.Empty(t, requests[0].Event.URL, "the internal download url must not leak")
+		assert.True(t, w.IsPending(requests[0].ID))
+		assert.Empty(t, pub.outcomes())
+	})
+
+	t.Run("the callback finishes the step", func(t *testing.T) {
+		e := newEndpoint(t)
+		pub := &testPublisher{}
+		w := newWorker(e.URL, time.Hour, store.NewMemoryStore(), pub)
+		requests := dispatch(t, w, e, step("upload-1"))
+		require.Len(t, requests, 1)
+
+		body, signature := callback(t, events.PPOutcomeAbort)
+		assert.ErrorIs(t, w.Callback(context.Background(), requests[0].ID, "sha256=invalid", body), webhook.ErrInvalidSignature)
+
+		require.NoError(t, w.Callback(context.Background(), requests[0].ID, signature, body))
+		assert.Equal(t, []events.PostprocessingOutcome{events.PPOutcomeAbort}, pub.outcomes())
+		assert.False(t, w.IsPending(requests[0].ID))
+
+		assert.ErrorIs(t, w.Callback(context.Background(), requests[0].ID, signature, body), webhook.ErrNotPending)
+	})
+
+	t.Run("steps without callback expire and are retried", func(t *testing.T) {
+		e := newEndpoint(t)
+		pub := &testPublisher{}
+		w := newWorker(e.URL, 50*time.Millisecond, store.NewMemoryStore(), pub)
+		requests := dispatch(t, w, e, step("upload-1"))
+		require.Len(t, requests, 1)
+
+		assert.Eventually(t, func() bool {
+			return len(pub.outcomes()) == 1
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []events.PostprocessingOutcome{events.PPOutcomeRetry}, pub.outcomes())
+		assert.False(t, w.IsPending(requests[0].ID))
+
+		body, signature := callback(t, events.PPOutcomeContinue)
+		assert.ErrorIs(t, w.Callback(context.Background(), requests[0].ID, signature, body), webhook.ErrNotPending)
+	})
+
+	t.Run("pending steps expire after a restart", func(t *testing.T) {
+		e := newEndpoint(t)
+		st := store.NewMemoryStore()
+		requests := dispatch(t, newWorker(e.URL, 100*time.Millisecond, st, &testPublisher{}), e, step("upload-1"))
+		require.Len(t, requests, 1)
+
+		// the restarted worker only knows the persisted state, not the timers of the previous one
+		persisted := store.NewMemoryStore()
+		keys, err := st.List()
+		require.NoError(t, err)
+		for _, k := range keys {
+			recs, err := st.Read(k)
+			require.NoError(t, err)
+			require.NoError(t, persisted.Write(recs[0]))
+		}
+
+		pub := &testPublisher{}
+		restarted := newWorker(e.URL, 100*time.Millisecond, persisted, pub)
+		assert.True(t, restarted.IsPending(requests[0].ID))
+		dispatch(t, restarted, e)
+
+		assert.Eventually(t, func() bool {
+			return len(pub.outcomes()) == 1
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []events.PostprocessingOutcome{events.PPOutcomeRetry}, pub.outcomes())
+		assert.False(t, restarted.IsPending(requests[0].ID))
+	})
+
+	t.Run("endpoints which are not reachable are retried", func(t *testing.T) {
+		e := newEndpoint(t)
+		e.Close()
+		pub := &testPublisher{}
+		w := newWorker(e.URL, time.Hour, store.NewMemoryStore(), pub)
+
+		dispatch(t, w, e, step("upload-1"))
+		assert.Equal(t, []events.PostprocessingOutcome{events.PPOutcomeRetry}, pub.outcomes())
+	})
+}
+
+func TestHandler_HandleContent(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		headers []http.Header
+	)
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+		_, _ = io.WriteString(w, "content")
+	}))
+	t.Cleanup(storage.Close)
+
+	e := newEndpoint(t)
+	w := newWorker(e.URL, time.Hour, store.NewMemoryStore(), &testPublisher{})
+
+	upload := step("upload-1")
+	upload.URL = storage.URL
+	onDemand := step("")
+	onDemand.URL, onDemand.Token, onDemand.RevaToken = storage.URL, "transfer-token", "reva-token"
+
+	requests := dispatch(t, w, e, upload, onDemand)
+	require.Len(t, requests, 2)
+
+	h := webhook.NewHandler(w, chi.NewMux())
+	for _, req := range requests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/postprocessing/v1/webhook/"+req.ID+"/content", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "content", rec.Body.String())
+	}
+
+	require.Len(t, headers, 2)
+	assert.Empty(t, headers[0].Get("X-Reva-Transfer"))
+	assert.Equal(t, "transfer-token", headers[1].Get("X-Reva-Transfer"))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/postprocessing/v1/webhook/unknown/content", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -252,6 +252,23 @@ func DefaultPolicies() []config.Policy {
 					Endpoint: "/app/", // /app or /apps? ocdav only handles /apps
 					Service:  "eu.opencloud.web.frontend",
 				},
+				{
+					Endpoint: "/antivirus/",
+					Service:  "eu.opencloud.web.antivirus",
+				},
+				{
+					Endpoint:    "/postprocessing/v1/webhook/",
+					Service:     "eu.opencloud.web.postprocessing",
+					Unprotected: true,
+				},
+				{
+					Endpoint: "/postprocessing/",
+					Service:  "eu.opencloud.web.postprocessing",
+				},
+				{
+					Endpoint: "/audit/",
+					Service:  "eu.opencloud.web.audit",
+				},
 				{
 					Endpoint: "/graph/v1beta1/extensions/org.libregraph/activities",
 					Service:  "eu.opencloud.web.activitylog",
@@ -27,8 +27,11 @@ type Config struct {
 	Events                     Events                `yaml:"events"`
 	Engine                     Engine                `yaml:"engine"`
 	Extractor                  Extractor             `yaml:"extractor"`
+	Embedding                  Embedding             `yaml:"embedding"`
+	SavedSearches              SavedSearches         `yaml:"saved_searches"`
 	ContentExtractionSizeLimit uint64                `yaml:"content_extraction_size_limit" env:"SEARCH_CONTENT_EXTRACTION_SIZE_LIMIT" desc:"Maximum file size in bytes that is allowed for content extraction." introductionVersion:"1.0.0"`
 	BatchSize                  int                   `yaml:"batch_size" env:"SEARCH_BATCH_SIZE" desc:"The number of documents to process in a single batch. Defaults to 500." introductionVersion:"1.0.0"`
+	IndexVersions              bool                  `yaml:"index_versions" env:"SEARCH_INDEX_VERSIONS" desc:"Index the previous versions of the files to make them searchable with 'version:any'. The content of every version is extracted, which needs additional resources." introductionVersion:"%%NEXT%%"`
 
 	ServiceAccount ServiceAccount `yaml:"service_account"`
 
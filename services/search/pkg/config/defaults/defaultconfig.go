@@ -52,6 +52,24 @@ func DefaultConfig() *config.Config {
 				TikaURL:        "http://127.0.0.1:9998",
 				CleanStopWords: true,
 			},
+			Native: config.ExtractorNative{
+				MaxContentLength: 1024 * 1024,
+			},
+		},
+		Embedding: config.Embedding{
+			Dimensions:     384,
+			MaxInputLength: 8 * 1024,
+			HTTP: config.EmbeddingHTTP{
+				URL:     "http://127.0.0.1:11434/v1/embeddings",
+				Model:   "all-minilm",
+				Timeout: 30 * time.Second,
+			},
+		},
+		SavedSearches: config.SavedSearches{
+			MaxPerUser:     50,
+			GatewayAddress: "eu.opencloud.api.storage-system",
+			StorageAddress: "eu.opencloud.api.storage-system",
+			SystemUserIDP:  "internal",
 		},
 		Events: config.Events{
 			Endpoint:         "127.0.0.1:9233",
@@ -109,6 +127,18 @@ func EnsureDefaults(cfg *config.Config) {
 	if cfg.GRPC.TLS == nil && cfg.Commons != nil {
 		cfg.GRPC.TLS = structs.CopyOrZeroValue(cfg.Commons.GRPCServiceTLS)
 	}
+
+	if cfg.SavedSearches.SystemUserAPIKey == "" && cfg.Commons != nil && cfg.Commons.SystemUserAPIKey != "" {
+		cfg.SavedSearches.SystemUserAPIKey = cfg.Commons.SystemUserAPIKey
+	}
+
+	if cfg.SavedSearches.SystemUserID == "" && cfg.Commons != nil && cfg.Commons.SystemUserID != "" {
+		cfg.SavedSearches.SystemUserID = cfg.Commons.SystemUserID
+	}
+
+	if cfg.SavedSearches.MachineAuthAPIKey == "" && cfg.Commons != nil && cfg.Commons.MachineAuthAPIKey != "" {
+		cfg.SavedSearches.MachineAuthAPIKey = cfg.Commons.MachineAuthAPIKey
+	}
 }
 
 // Sanitize sanitizes the configuration
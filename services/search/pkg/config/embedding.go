@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// Embedding defines which embedding provider to use for semantic search
+type Embedding struct {
+	Type           string        `yaml:"type" env:"SEARCH_EMBEDDING_TYPE" desc:"Defines the provider which turns resources and queries into embedding vectors for semantic search. Semantic search is disabled if empty. Supported values are: '', 'http' and 'hash'. See the documentation for more details." introductionVersion:"%%NEXT%%"`
+	Dimensions     int           `yaml:"dimensions" env:"SEARCH_EMBEDDING_DIMENSIONS" desc:"The number of dimensions of the embedding vectors. It must match the model of the provider, changing it requires to rebuild the index." introductionVersion:"%%NEXT%%"`
+	MaxInputLength int           `yaml:"max_input_length" env:"SEARCH_EMBEDDING_MAX_INPUT_LENGTH" desc:"Maximum number of bytes of the name, title and content of a resource which are embedded. The rest is ignored." introductionVersion:"%%NEXT%%"`
+	HTTP           EmbeddingHTTP `yaml:"http"`
+}
+
+// EmbeddingHTTP configures the http embedding provider
+type EmbeddingHTTP struct {
+	URL     string        `yaml:"url" env:"SEARCH_EMBEDDING_HTTP_URL" desc:"URL of an OpenAI compatible embeddings endpoint." introductionVersion:"%%NEXT%%"`
+	Model   string        `yaml:"model" env:"SEARCH_EMBEDDING_HTTP_MODEL" desc:"The name of the embedding model." introductionVersion:"%%NEXT%%"`
+	APIKey  string        `yaml:"api_key" env:"SEARCH_EMBEDDING_HTTP_API_KEY" desc:"The key which is sent as bearer token to the embeddings endpoint." introductionVersion:"%%NEXT%%"`
+	Timeout time.Duration `yaml:"timeout" env:"SEARCH_EMBEDDING_HTTP_TIMEOUT" desc:"The timeout of a request to the embeddings endpoint. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+}
@@ -45,5 +45,14 @@ func Validate(cfg *config.Config) error {
 		return shared.MissingServiceAccountSecret(cfg.Service.Name)
 	}
 
+	if cfg.SavedSearches.Enabled {
+		if cfg.SavedSearches.SystemUserAPIKey == "" {
+			return shared.MissingSystemUserApiKeyError(cfg.Service.Name)
+		}
+		if cfg.SavedSearches.MachineAuthAPIKey == "" {
+			return shared.MissingMachineAuthApiKeyError(cfg.Service.Name)
+		}
+	}
+
 	return nil
 }
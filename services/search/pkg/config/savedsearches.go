@@ -0,0 +1,16 @@
+package config
+
+// SavedSearches configures the storage of the saved searches
+type SavedSearches struct {
+	Enabled    bool `yaml:"enabled" env:"SEARCH_SAVED_SEARCHES_ENABLED" desc:"Enables the saved searches of the users and the alerts about new resources matching them. Defaults to 'false'." introductionVersion:"%%NEXT%%"`
+	MaxPerUser int  `yaml:"max_per_user" env:"SEARCH_SAVED_SEARCHES_MAX_PER_USER" desc:"The maximum number of searches a user can save. Defaults to 50." introductionVersion:"%%NEXT%%"`
+
+	GatewayAddress string `yaml:"gateway_addr" env:"SEARCH_SAVED_SEARCHES_STORAGE_GATEWAY_GRPC_ADDR;STORAGE_GATEWAY_GRPC_ADDR" desc:"GRPC address of the STORAGE-SYSTEM service." introductionVersion:"%%NEXT%%"`
+	StorageAddress string `yaml:"storage_addr" env:"SEARCH_SAVED_SEARCHES_STORAGE_GRPC_ADDR;STORAGE_GRPC_ADDR" desc:"GRPC address of the STORAGE-SYSTEM service." introductionVersion:"%%NEXT%%"`
+
+	SystemUserID     string `yaml:"system_user_id" env:"OC_SYSTEM_USER_ID;SEARCH_SAVED_SEARCHES_SYSTEM_USER_ID" desc:"ID of the OpenCloud STORAGE-SYSTEM system user. Admins need to set the ID for the STORAGE-SYSTEM system user in this config option which is then used to reference the user. Any reasonable long string is possible, preferably this would be an UUIDv4 format." introductionVersion:"%%NEXT%%"`
+	SystemUserIDP    string `yaml:"system_user_idp" env:"OC_SYSTEM_USER_IDP;SEARCH_SAVED_SEARCHES_SYSTEM_USER_IDP" desc:"IDP of the OpenCloud STORAGE-SYSTEM system user." introductionVersion:"%%NEXT%%"`
+	SystemUserAPIKey string `yaml:"system_user_api_key" env:"OC_SYSTEM_USER_API_KEY" desc:"API key for the STORAGE-SYSTEM system user." introductionVersion:"%%NEXT%%"`
+
+	MachineAuthAPIKey string `yaml:"machine_auth_api_key" env:"OC_MACHINE_AUTH_API_KEY;SEARCH_SAVED_SEARCHES_MACHINE_AUTH_API_KEY" desc:"Machine auth API key used to check the access of the users to the resources they are alerted about." introductionVersion:"%%NEXT%%"`
+}
@@ -0,0 +1,290 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"golang.org/x/net/html"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+)
+
+// nativeParser extracts the content and meta information of a file into the document
+type nativeParser func(data []byte, doc *Document, w *contentWriter) error
+
+var (
+	// nativeParsersByMimeType selects the parser of a file by its mime type
+	nativeParsersByMimeType = map[string]nativeParser{
+		"text/html":             parseHTML,
+		"application/xhtml+xml": parseHTML,
+		"text/markdown":         parseMarkdown,
+		"text/x-markdown":       parseMarkdown,
+		"application/epub+zip":  parseEPUB,
+		"image/jpeg":            parseImage,
+		"image/png":             parseImage,
+		"image/gif":             parseImage,
+		"image/tiff":            parseImage,
+		"image/bmp":             parseImage,
+
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   parseDocx,
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         parseXlsx,
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation": parsePptx,
+		"application/vnd.oasis.opendocument.text":                                   parseODF,
+		"application/vnd.oasis.opendocument.spreadsheet":                            parseODF,
+		"application/vnd.oasis.opendocument.presentation":                           parseODF,
+	}
+	// nativeParsersByExtension selects the parser of files with a generic mime type by their extension
+	nativeParsersByExtension = map[string]nativeParser{
+		"txt": parseText, "csv": parseText, "log": parseText,
+		"md": parseMarkdown, "markdown": parseMarkdown,
+		"htm": parseHTML, "html": parseHTML, "xhtml": parseHTML,
+		"docx": parseDocx,
+		"xlsx": parseXlsx,
+		"pptx": parsePptx,
+		"odt":  parseODF, "ods": parseODF, "odp": parseODF,
+		"epub": parseEPUB,
+		"mp3":  parseAudio, "m4a": parseAudio, "flac": parseAudio, "ogg": parseAudio,
+	}
+	// genericMimeTypes are the mime types of files which are selected by their extension
+	genericMimeTypes = map[string]bool{"": true, "application/octet-stream": true, "application/zip": true, "text/plain": true}
+)
+
+// Native is used to extract content from a resource,
+// it parses common document, image and audio formats itself and needs no external services.
+type Native struct {
+	*Basic
+	Retriever
+	ContentExtractionSizeLimit uint64
+	MaxContentLength           uint64
+}
+
+// NewNativeExtractor creates a new Native instance.
+func NewNativeExtractor(gatewaySelector pool.Selectable[gateway.GatewayAPIClient], logger log.Logger, cfg *config.Config) (*Native, error) {
+	basic, err := NewBasicExtractor(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Native{
+		Basic:                      basic,
+		Retriever:                  newCS3Retriever(gatewaySelector, logger, cfg.Extractor.CS3AllowInsecure),
+		ContentExtractionSizeLimit: cfg.ContentExtractionSizeLimit,
+		MaxContentLength:           cfg.Extractor.Native.MaxContentLength,
+	}, nil
+}
+
+// Extract loads a resource from its underlying storage, parses it and processes the result into a Document.
+// Files which can not be parsed are indexed like the Basic extractor does.
+func (n Native) Extract(ctx context.Context, ri *provider.ResourceInfo) (Document, error) {
+	doc, err := n.Basic.Extract(ctx, ri)
+	if err != nil {
+		return doc, err
+	}
+
+	if ri.Size == 0 {
+		return doc, nil
+	}
+
+	if ri.Size > n.ContentExtractionSizeLimit {
+		n.logger.Info().Interface("ResourceID", ri.Id).Str("Name", ri.Name).Msg("file exceeds content extraction size limit. skipping.")
+		return doc, nil
+	}
+
+	if ri.Type != provider.ResourceType_RESOURCE_TYPE_FILE {
+		return doc, nil
+	}
+
+	parse := nativeParserFor(ri.MimeType, ri.Name)
+	if parse == nil {
+		return doc, nil
+	}
+
+	data, err := n.Retrieve(ctx, ri.Id)
+	if err != nil {
+		return doc, err
+	}
+	defer data.Close()
+
+	b, err := io.ReadAll(io.LimitReader(data, int64(n.ContentExtractionSizeLimit)))
+	if err != nil {
+		return doc, err
+	}
+
+	w := &contentWriter{limit: int(n.MaxContentLength)}
+	if err := parse(b, &doc, w); err != nil {
+		n.logger.Debug().Err(err).Interface("ResourceID", ri.Id).Str("Name", ri.Name).Msg("could not parse file content")
+	}
+	doc.Title = strings.TrimSpace(doc.Title)
+	doc.Content = strings.TrimSpace(w.String())
+
+	return doc, nil
+}
+
+// nativeParserFor selects the parser by the mime type or, for generic mime types, by the extension of the file name
+func nativeParserFor(mimeType, name string) nativeParser {
+	mimeType, _, _ = mime.ParseMediaType(mimeType)
+	if p, ok := nativeParsersByMimeType[mimeType]; ok {
+		return p
+	}
+	switch {
+	case strings.HasPrefix(mimeType, "audio/"):
+		return parseAudio
+	case genericMimeTypes[mimeType]:
+		return nativeParsersByExtension[strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))]
+	case strings.HasPrefix(mimeType, "text/"):
+		return parseText
+	}
+	return nil
+}
+
+// contentWriter collects the extracted text until the limit is reached
+type contentWriter struct {
+	b     strings.Builder
+	limit int
+}
+
+// full returns true if the limit is reached
+func (w *contentWriter) full() bool {
+	return w.b.Len() >= w.limit
+}
+
+// write adds the text, it is cut at the limit
+func (w *contentWriter) write(s string) {
+	if w.full() {
+		return
+	}
+	if w.b.Len()+len(s) > w.limit {
+		s = s[:w.limit-w.b.Len()]
+		// do not cut a multibyte character
+		for len(s) > 0 && !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+		w.b.WriteString(s)
+		// the text is not continued after a cut
+		w.limit = w.b.Len()
+		return
+	}
+	w.b.WriteString(s)
+}
+
+// separate ends the current word or paragraph with the separator unless the text already ends with whitespace
+func (w *contentWriter) separate(separator string) {
+	s := w.b.String()
+	if s == "" {
+		return
+	}
+	if r, _ := utf8.DecodeLastRuneInString(s); !unicode.IsSpace(r) {
+		w.write(separator)
+	}
+}
+
+func (w *contentWriter) String() string {
+	return w.b.String()
+}
+
+// parseText extracts plain text files, binary files are ignored
+func parseText(data []byte, _ *Document, w *contentWriter) error {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil
+	}
+	w.write(strings.ToValidUTF8(string(data), " "))
+	return nil
+}
+
+// parseMarkdown extracts markdown files, the first heading is used as title
+func parseMarkdown(data []byte, doc *Document, w *contentWriter) error {
+	for _, line := range strings.Split(string(data), "\n") {
+		if title, ok := strings.CutPrefix(strings.TrimSpace(line), "# "); ok {
+			doc.Title = title
+			break
+		}
+	}
+	return parseText(data, doc, w)
+}
+
+var (
+	// htmlBlockElements end a paragraph of text
+	htmlBlockElements = map[string]bool{
+		"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true, "div": true,
+		"dl": true, "dt": true, "figcaption": true, "footer": true, "h1": true, "h2": true, "h3": true, "h4": true,
+		"h5": true, "h6": true, "header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true,
+		"p": true, "pre": true, "section": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+	}
+	// htmlSkipElements are not part of the text
+	htmlSkipElements = map[string]bool{"head": true, "script": true, "style": true, "template": true, "noscript": true, "svg": true}
+)
+
+// parseHTML extracts the text of html documents
+func parseHTML(data []byte, doc *Document, w *contentWriter) error {
+	title, err := htmlText(bytes.NewReader(data), w)
+	if doc.Title == "" {
+		doc.Title = title
+	}
+	return err
+}
+
+// htmlText writes the text of the html document and returns its title
+func htmlText(r io.Reader, w *contentWriter) (string, error) {
+	var (
+		title    strings.Builder
+		inTitle  bool
+		skipping int
+	)
+	z := html.NewTokenizer(r)
+	for !w.full() {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return title.String(), nil
+			}
+			return title.String(), z.Err()
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case tag == "title":
+				inTitle = true
+			case htmlSkipElements[tag]:
+				// void elements have no end tag, so only the skipped elements are counted
+				skipping++
+			}
+			if htmlBlockElements[tag] {
+				w.separate("\n")
+			}
+		case html.SelfClosingTagToken:
+			if name, _ := z.TagName(); htmlBlockElements[string(name)] {
+				w.separate("\n")
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case tag == "title":
+				inTitle = false
+			case skipping > 0 && htmlSkipElements[tag]:
+				skipping--
+			}
+			if htmlBlockElements[tag] {
+				w.separate("\n")
+			}
+		case html.TextToken:
+			switch {
+			case inTitle:
+				title.Write(z.Text())
+			case skipping == 0:
+				w.write(string(z.Text()))
+			}
+		}
+	}
+	return title.String(), nil
+}
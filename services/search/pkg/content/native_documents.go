@@ -0,0 +1,330 @@
+package content
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// nativeMaxUncompressedSize limits the data decompressed from a single archive to protect against zip bombs
+	nativeMaxUncompressedSize = 100 << 20
+)
+
+// archive reads the entries of zip based document formats
+type archive struct {
+	files map[string]*zip.File
+	// budget is the remaining number of bytes which may be decompressed
+	budget int64
+}
+
+func openArchive(data []byte) (*archive, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	a := &archive{files: make(map[string]*zip.File, len(zr.File)), budget: nativeMaxUncompressedSize}
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+	}
+	return a, nil
+}
+
+// open opens the entry of the archive, the reader fails if the archive exceeds the decompression limit
+func (a *archive) open(name string) (io.ReadCloser, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("missing archive entry: %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &budgetReader{ReadCloser: rc, archive: a}, nil
+}
+
+// names returns the sorted names of the entries matching the pattern, numbers in the names are sorted numerically
+func (a *archive) names(pattern string) []string {
+	var names []string
+	for name := range a.files {
+		if ok, _ := path.Match(pattern, name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ni, nj := trailingNumber(names[i]), trailingNumber(names[j])
+		if ni != nj {
+			return ni < nj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// trailingNumber returns the number at the end of the file name, like 2 in slide2.xml
+func trailingNumber(name string) int {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	i := len(base)
+	for i > 0 && base[i-1] >= '0' && base[i-1] <= '9' {
+		i--
+	}
+	n, _ := strconv.Atoi(base[i:])
+	return n
+}
+
+// xmlText writes the text of the archive entry, see writeXMLText
+func (a *archive) xmlText(name string, w *contentWriter, opts xmlTextOptions) error {
+	rc, err := a.open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeXMLText(rc, w, opts)
+}
+
+// xmlElement returns the text of the first element with the local name in the archive entry
+func (a *archive) xmlElement(name, local string) string {
+	rc, err := a.open(name)
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+
+	d := xml.NewDecoder(rc)
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		if se, ok := t.(xml.StartElement); ok && se.Name.Local == local {
+			var text string
+			if err := d.DecodeElement(&text, &se); err != nil {
+				return ""
+			}
+			return strings.TrimSpace(text)
+		}
+	}
+}
+
+var errUncompressedSizeLimit = errors.New("archive exceeds the uncompressed size limit")
+
+type budgetReader struct {
+	io.ReadCloser
+	archive *archive
+}
+
+func (r *budgetReader) Read(p []byte) (int, error) {
+	if r.archive.budget <= 0 {
+		return 0, errUncompressedSizeLimit
+	}
+	if int64(len(p)) > r.archive.budget {
+		p = p[:r.archive.budget]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.archive.budget -= int64(n)
+	return n, err
+}
+
+// xmlTextOptions select the text of xml documents
+type xmlTextOptions struct {
+	// text are the elements containing the text, if empty all character data is text
+	text map[string]bool
+	// separators are the elements separating the text, like paragraphs or tabs, with the separator to insert
+	separators map[string]string
+	// skip are the elements which are ignored with all their children
+	skip map[string]bool
+}
+
+// writeXMLText writes the text of the xml document selected by the options, the elements are matched by their local names
+func writeXMLText(r io.Reader, w *contentWriter, opts xmlTextOptions) error {
+	d := xml.NewDecoder(r)
+	var inText, skipping int
+	for !w.full() {
+		t, err := d.Token()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			switch {
+			case skipping > 0 || opts.skip[t.Name.Local]:
+				skipping++
+			case opts.text[t.Name.Local]:
+				inText++
+			}
+		case xml.EndElement:
+			switch {
+			case skipping > 0:
+				skipping--
+				continue
+			case opts.text[t.Name.Local]:
+				inText--
+			}
+			if sep, ok := opts.separators[t.Name.Local]; ok {
+				w.separate(sep)
+			}
+		case xml.CharData:
+			if skipping == 0 && (len(opts.text) == 0 || inText > 0) {
+				w.write(string(t))
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	// ooxmlText selects the text of word processing, spreadsheet and presentation documents
+	ooxmlText = xmlTextOptions{
+		text:       map[string]bool{"t": true},
+		separators: map[string]string{"p": "\n", "br": "\n", "tab": " ", "si": "\n", "c": " ", "row": "\n"},
+		// the phonetic reading of east asian text repeats the text
+		skip: map[string]bool{"rPh": true},
+	}
+	// odfText selects the text of open document files
+	odfText = xmlTextOptions{
+		separators: map[string]string{"p": "\n", "h": "\n", "line-break": "\n", "tab": " ", "s": " ", "table-cell": " ", "table-row": "\n"},
+		skip:       map[string]bool{"annotation": true, "tracked-changes": true, "forms": true},
+	}
+)
+
+// parseDocx extracts word processing documents
+func parseDocx(data []byte, doc *Document, w *contentWriter) error {
+	a, err := openArchive(data)
+	if err != nil {
+		return err
+	}
+	doc.Title = a.xmlElement("docProps/core.xml", "title")
+	return a.xmlText("word/document.xml", w, ooxmlText)
+}
+
+// parseXlsx extracts spreadsheets, only the text of cells is indexed, numbers and formulas are ignored
+func parseXlsx(data []byte, doc *Document, w *contentWriter) error {
+	a, err := openArchive(data)
+	if err != nil {
+		return err
+	}
+	doc.Title = a.xmlElement("docProps/core.xml", "title")
+	if _, ok := a.files["xl/sharedStrings.xml"]; ok {
+		if err := a.xmlText("xl/sharedStrings.xml", w, ooxmlText); err != nil {
+			return err
+		}
+	}
+	// inline strings are stored in the sheets
+	for _, name := range a.names("xl/worksheets/sheet*.xml") {
+		w.separate("\n")
+		if err := a.xmlText(name, w, ooxmlText); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePptx extracts presentations slide by slide
+func parsePptx(data []byte, doc *Document, w *contentWriter) error {
+	a, err := openArchive(data)
+	if err != nil {
+		return err
+	}
+	doc.Title = a.xmlElement("docProps/core.xml", "title")
+	for _, name := range append(a.names("ppt/slides/slide*.xml"), a.names("ppt/notesSlides/notesSlide*.xml")...) {
+		w.separate("\n")
+		if err := a.xmlText(name, w, ooxmlText); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseODF extracts open document texts, spreadsheets and presentations
+func parseODF(data []byte, doc *Document, w *contentWriter) error {
+	a, err := openArchive(data)
+	if err != nil {
+		return err
+	}
+	doc.Title = a.xmlElement("meta.xml", "title")
+	return a.xmlText("content.xml", w, odfText)
+}
+
+// parseEPUB extracts the chapters of e-books in reading order
+func parseEPUB(data []byte, doc *Document, w *contentWriter) error {
+	a, err := openArchive(data)
+	if err != nil {
+		return err
+	}
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := a.decodeXML("META-INF/container.xml", &container); err != nil {
+		return err
+	}
+	if len(container.Rootfiles) == 0 {
+		return errors.New("epub without package document")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+
+	var pkg struct {
+		Title    []string `xml:"metadata>title"`
+		Manifest []struct {
+			ID        string `xml:"id,attr"`
+			Href      string `xml:"href,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := a.decodeXML(opfPath, &pkg); err != nil {
+		return err
+	}
+	if len(pkg.Title) > 0 {
+		doc.Title = pkg.Title[0]
+	}
+
+	items := map[string]string{}
+	for _, item := range pkg.Manifest {
+		if item.MediaType == "application/xhtml+xml" || item.MediaType == "text/html" {
+			items[item.ID] = path.Join(path.Dir(opfPath), item.Href)
+		}
+	}
+	for _, ref := range pkg.Spine {
+		name, ok := items[ref.IDRef]
+		if !ok || w.full() {
+			continue
+		}
+		rc, err := a.open(name)
+		if err != nil {
+			return err
+		}
+		w.separate("\n")
+		_, err = htmlText(rc, w)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeXML unmarshals the xml archive entry
+func (a *archive) decodeXML(name string, v interface{}) error {
+	rc, err := a.open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
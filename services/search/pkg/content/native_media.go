@@ -0,0 +1,182 @@
+package content
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"  // register the gif decoder
+	_ "image/jpeg" // register the jpeg decoder
+	_ "image/png"  // register the png decoder
+	"time"
+
+	"github.com/dhowden/tag"
+	libregraph "github.com/opencloud-eu/libre-graph-api-go"
+	_ "golang.org/x/image/bmp"  // register the bmp decoder
+	_ "golang.org/x/image/tiff" // register the tiff decoder
+
+	"github.com/opencloud-eu/opencloud/pkg/exif"
+)
+
+// parseImage extracts the dimensions of images and the EXIF metadata of photos
+func parseImage(data []byte, doc *Document, _ *contentWriter) error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	doc.Image = libregraph.NewImage()
+	doc.Image.SetWidth(int32(cfg.Width))
+	doc.Image.SetHeight(int32(cfg.Height))
+
+	tiff := exif.Parse(data)
+	if tiff == nil {
+		return nil
+	}
+	ifd0, ok := tiff.IFD(tiff.FirstIFD())
+	if !ok {
+		return nil
+	}
+	exifIFD, _ := tiff.IFD(ifd0.Uint(exif.TagExifIFD))
+	gps, _ := tiff.IFD(ifd0.Uint(exif.TagGPSIFD))
+	doc.Photo = exifPhoto(ifd0, exifIFD)
+	doc.Location = exifLocation(gps)
+	return nil
+}
+
+func exifPhoto(ifd0, exifIFD exif.IFD) *libregraph.Photo {
+	var photo *libregraph.Photo
+	initPhoto := func() {
+		if photo == nil {
+			photo = libregraph.NewPhoto()
+		}
+	}
+
+	if v := ifd0.String(exif.TagMake); v != "" {
+		initPhoto()
+		photo.SetCameraMake(v)
+	}
+	if v := ifd0.String(exif.TagModel); v != "" {
+		initPhoto()
+		photo.SetCameraModel(v)
+	}
+	if v := ifd0.Uint(exif.TagOrientation); v > 0 {
+		initPhoto()
+		photo.SetOrientation(int32(v))
+	}
+	if num, den, ok := exifIFD.Rational(exif.TagExposureTime, 0); ok {
+		initPhoto()
+		photo.SetExposureNumerator(num)
+		photo.SetExposureDenominator(den)
+	}
+	if num, den, ok := exifIFD.Rational(exif.TagFNumber, 0); ok {
+		initPhoto()
+		photo.SetFNumber(num / den)
+	}
+	if num, den, ok := exifIFD.Rational(exif.TagFocalLength, 0); ok {
+		initPhoto()
+		photo.SetFocalLength(num / den)
+	}
+	if v := exifIFD.Uint(exif.TagISO); v > 0 {
+		initPhoto()
+		photo.SetIso(int32(v))
+	}
+	if t, err := time.Parse("2006:01:02 15:04:05", exifIFD.String(exif.TagDateTimeOriginal)); err == nil {
+		initPhoto()
+		photo.SetTakenDateTime(t)
+	}
+	return photo
+}
+
+func exifLocation(gps exif.IFD) *libregraph.GeoCoordinates {
+	coordinate := func(tag uint16, ref uint16, negative string) (float64, bool) {
+		var v float64
+		for i, unit := range []float64{1, 60, 3600} {
+			num, den, ok := gps.Rational(tag, i)
+			if !ok {
+				return 0, false
+			}
+			v += num / den / unit
+		}
+		if gps.String(ref) == negative {
+			v = -v
+		}
+		return v, true
+	}
+
+	lat, okLat := coordinate(exif.TagGPSLatitude, exif.TagGPSLatitudeRef, "S")
+	long, okLong := coordinate(exif.TagGPSLongitude, exif.TagGPSLongitudeRef, "W")
+	if !okLat || !okLong {
+		return nil
+	}
+	location := libregraph.NewGeoCoordinates()
+	location.SetLatitude(lat)
+	location.SetLongitude(long)
+	if num, den, ok := gps.Rational(exif.TagGPSAltitude, 0); ok {
+		altitude := num / den
+		if gps.Uint(exif.TagGPSAltitudeRef) == 1 {
+			// below sea level
+			altitude = -altitude
+		}
+		location.SetAltitude(altitude)
+	}
+	return location
+}
+
+// parseAudio extracts the tags of mp3, mp4, flac and ogg files
+func parseAudio(data []byte, doc *Document, _ *contentWriter) error {
+	m, err := tag.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	var audio *libregraph.Audio
+	initAudio := func() {
+		if audio == nil {
+			audio = libregraph.NewAudio()
+		}
+	}
+
+	if v := m.Title(); v != "" {
+		initAudio()
+		audio.SetTitle(v)
+		doc.Title = v
+	}
+	if v := m.Album(); v != "" {
+		initAudio()
+		audio.SetAlbum(v)
+	}
+	if v := m.Artist(); v != "" {
+		initAudio()
+		audio.SetArtist(v)
+	}
+	if v := m.AlbumArtist(); v != "" {
+		initAudio()
+		audio.SetAlbumArtist(v)
+	}
+	if v := m.Composer(); v != "" {
+		initAudio()
+		audio.SetComposers(v)
+	}
+	if v := m.Genre(); v != "" {
+		initAudio()
+		audio.SetGenre(v)
+	}
+	if v := m.Year(); v > 0 {
+		initAudio()
+		audio.SetYear(int32(v))
+	}
+	if track, count := m.Track(); track > 0 {
+		initAudio()
+		audio.SetTrack(int32(track))
+		if count > 0 {
+			audio.SetTrackCount(int32(count))
+		}
+	}
+	if disc, count := m.Disc(); disc > 0 {
+		initAudio()
+		audio.SetDisc(int32(disc))
+		if count > 0 {
+			audio.SetDiscCount(int32(count))
+		}
+	}
+	doc.Audio = audio
+	return nil
+}
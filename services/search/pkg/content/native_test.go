@@ -0,0 +1,274 @@
+package content_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io"
+	"strings"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	libregraph "github.com/opencloud-eu/libre-graph-api-go"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/opencloud-eu/opencloud/pkg/exif"
+	"github.com/opencloud-eu/opencloud/pkg/exif/exiftest"
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	conf "github.com/opencloud-eu/opencloud/services/search/pkg/config/defaults"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+	contentMocks "github.com/opencloud-eu/opencloud/services/search/pkg/content/mocks"
+)
+
+// zipFile returns a zip archive with the given files
+func zipFile(files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, data := range files {
+		f, err := zw.Create(name)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.Write([]byte(data))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(zw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+// exifJPEG returns a jpeg image with the EXIF data of a photo
+func exifJPEG() []byte {
+	var buf bytes.Buffer
+	Expect(jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 40, 30)), nil)).To(Succeed())
+
+	return exiftest.JPEG(buf.Bytes(), exiftest.TIFF(binary.BigEndian,
+		exiftest.ASCII(exif.TagMake, "Canon"),
+		exiftest.ASCII(exif.TagModel, "Canon EOS 5D"),
+		exiftest.Short(exif.TagOrientation, 6),
+		exiftest.Pointer(exif.TagExifIFD,
+			exiftest.Rational(exif.TagExposureTime, 1, 1000),
+			exiftest.Rational(exif.TagFNumber, 18, 10),
+			exiftest.Short(exif.TagISO, 100),
+			exiftest.ASCII(exif.TagDateTimeOriginal, "2018:01:01 12:34:56"),
+			exiftest.Rational(exif.TagFocalLength, 50, 1),
+		),
+		exiftest.Pointer(exif.TagGPSIFD,
+			exiftest.ASCII(exif.TagGPSLatitudeRef, "N"),
+			exiftest.Rational(exif.TagGPSLatitude, 49, 1, 29, 1, 1215, 100),
+			exiftest.ASCII(exif.TagGPSLongitudeRef, "W"),
+			exiftest.Rational(exif.TagGPSLongitude, 11, 1, 6, 1, 1395, 100),
+			exiftest.Rational(exif.TagGPSAltitude, 2274, 10),
+		),
+	))
+}
+
+// id3File returns an mp3 file with ID3v2.3 tags
+func id3File(frames map[string]string) []byte {
+	var body []byte
+	for id, text := range frames {
+		body = append(body, id...)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(text)+1))
+		body = append(body, 0, 0, 0)
+		body = append(body, text...)
+	}
+	size := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(append(header, body...), make([]byte, 128)...)
+}
+
+var _ = Describe("Native", func() {
+	Describe("extract", func() {
+		var (
+			data   []byte
+			native *content.Native
+		)
+
+		BeforeEach(func() {
+			data = nil
+
+			cfg := conf.DefaultConfig()
+			cfg.Extractor.Native.MaxContentLength = 64
+
+			var err error
+			native, err = content.NewNativeExtractor(nil, log.NewLogger(), cfg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(native).ToNot(BeNil())
+
+			retriever := &contentMocks.Retriever{}
+			retriever.On("Retrieve", mock.Anything, mock.Anything).Return(func(context.Context, *provider.ResourceId) io.ReadCloser {
+				return io.NopCloser(bytes.NewReader(data))
+			}, nil)
+			native.Retriever = retriever
+		})
+
+		extract := func(name, mimeType string) content.Document {
+			doc, err := native.Extract(context.TODO(), &provider.ResourceInfo{
+				Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+				Name:     name,
+				MimeType: mimeType,
+				Size:     uint64(len(data)),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			return doc
+		}
+
+		It("skips non file resources", func() {
+			doc, err := native.Extract(context.TODO(), &provider.ResourceInfo{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(doc.Content).To(Equal(""))
+		})
+
+		It("adds the content of text files", func() {
+			data = []byte("any body")
+			Expect(extract("notes.txt", "text/plain").Content).To(Equal("any body"))
+		})
+
+		It("ignores binary files", func() {
+			data = []byte("any\x00body")
+			Expect(extract("notes.txt", "text/plain").Content).To(Equal(""))
+			Expect(extract("data.bin", "application/octet-stream").Content).To(Equal(""))
+		})
+
+		It("limits the content length", func() {
+			data = []byte(strings.Repeat("ä", 100))
+			doc := extract("notes.txt", "text/plain")
+			Expect(doc.Content).To(Equal(strings.Repeat("ä", 32)))
+		})
+
+		It("adds the title of markdown files", func() {
+			data = []byte("some intro\n\n# The Title\n\ntext")
+			doc := extract("README.md", "text/markdown")
+			Expect(doc.Title).To(Equal("The Title"))
+			Expect(doc.Content).To(ContainSubstring("text"))
+		})
+
+		It("adds the text of html files", func() {
+			data = []byte(`<html><head><title>Page &amp; Title</title><meta charset="utf-8"><style>p {}</style></head>
+				<body><p>first&nbsp;paragraph</p><script>var x;</script><div>second<br>line</div></body></html>`)
+			doc := extract("page.html", "text/html")
+			Expect(doc.Title).To(Equal("Page & Title"))
+			Expect(doc.Content).To(Equal("first paragraph\nsecond\nline"))
+		})
+
+		It("adds the text of docx files", func() {
+			data = zipFile(map[string]string{
+				"docProps/core.xml": `<cp:coreProperties xmlns:cp="cp" xmlns:dc="dc"><dc:title>Report</dc:title></cp:coreProperties>`,
+				"word/document.xml": `<w:document xmlns:w="w"><w:body>
+					<w:p><w:r><w:t>Hel</w:t></w:r><w:r><w:t>lo</w:t></w:r></w:p>
+					<w:p><w:r><w:instrText>PAGE</w:instrText><w:t>world</w:t></w:r></w:p>
+				</w:body></w:document>`,
+			})
+			doc := extract("report.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+			Expect(doc.Title).To(Equal("Report"))
+			Expect(doc.Content).To(Equal("Hello\nworld"))
+		})
+
+		It("adds the text of xlsx files", func() {
+			data = zipFile(map[string]string{
+				"xl/sharedStrings.xml":     `<sst><si><t>Name</t></si><si><r><t>Fir</t></r><r><t>st</t></r></si></sst>`,
+				"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row><c t="s"><v>0</v></c><c t="inlineStr"><is><t>inline</t></is></c><c><f>SUM(A1)</f><v>3</v></c></row></sheetData></worksheet>`,
+			})
+			doc := extract("table.xlsx", "application/octet-stream")
+			Expect(doc.Content).To(Equal("Name\nFirst\ninline"))
+		})
+
+		It("adds the text of pptx files in the order of the slides", func() {
+			data = zipFile(map[string]string{
+				"ppt/slides/slide10.xml": `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>ten</a:t></a:r></a:p></p:sld>`,
+				"ppt/slides/slide2.xml":  `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>two</a:t></a:r></a:p></p:sld>`,
+				"ppt/slides/slide1.xml":  `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>one</a:t></a:r></a:p></p:sld>`,
+			})
+			doc := extract("slides.pptx", "application/vnd.openxmlformats-officedocument.presentationml.presentation")
+			Expect(doc.Content).To(Equal("one\ntwo\nten"))
+		})
+
+		It("adds the text of odf files", func() {
+			data = zipFile(map[string]string{
+				"meta.xml":    `<office:document-meta xmlns:office="o" xmlns:dc="dc"><office:meta><dc:title>Letter</dc:title></office:meta></office:document-meta>`,
+				"content.xml": `<office:document-content xmlns:office="o" xmlns:text="t"><office:body><office:text><text:h>Dear</text:h><text:p>you<text:s/>and<office:annotation><text:p>note</text:p></office:annotation> me</text:p></office:text></office:body></office:document-content>`,
+			})
+			doc := extract("letter.odt", "application/vnd.oasis.opendocument.text")
+			Expect(doc.Title).To(Equal("Letter"))
+			Expect(doc.Content).To(Equal("Dear\nyou and me"))
+		})
+
+		It("adds the text of epub files in reading order", func() {
+			data = zipFile(map[string]string{
+				"META-INF/container.xml": `<container><rootfiles><rootfile full-path="OEBPS/content.opf"/></rootfiles></container>`,
+				"OEBPS/content.opf": `<package xmlns:dc="dc"><metadata><dc:title>The Book</dc:title></metadata>
+					<manifest><item id="c1" href="one.xhtml" media-type="application/xhtml+xml"/><item id="c2" href="text/two.xhtml" media-type="application/xhtml+xml"/></manifest>
+					<spine><itemref idref="c2"/><itemref idref="c1"/></spine></package>`,
+				"OEBPS/one.xhtml":      `<html><head><title>One</title></head><body><p>chapter one</p></body></html>`,
+				"OEBPS/text/two.xhtml": `<html><body><h1>chapter two</h1></body></html>`,
+			})
+			doc := extract("book.epub", "application/epub+zip")
+			Expect(doc.Title).To(Equal("The Book"))
+			Expect(doc.Content).To(Equal("chapter two\nchapter one"))
+		})
+
+		It("indexes broken documents without content", func() {
+			data = []byte("no zip file")
+			doc := extract("report.docx", "")
+			Expect(doc.Name).To(Equal("report.docx"))
+			Expect(doc.Content).To(Equal(""))
+		})
+
+		It("adds image and photo content", func() {
+			data = exifJPEG()
+			doc := extract("photo.jpg", "image/jpeg")
+
+			Expect(doc.Image).ToNot(BeNil())
+			Expect(doc.Image.Width).To(Equal(libregraph.PtrInt32(40)))
+			Expect(doc.Image.Height).To(Equal(libregraph.PtrInt32(30)))
+
+			photo := doc.Photo
+			Expect(photo).ToNot(BeNil())
+			Expect(photo.CameraMake).To(Equal(libregraph.PtrString("Canon")))
+			Expect(photo.CameraModel).To(Equal(libregraph.PtrString("Canon EOS 5D")))
+			Expect(photo.ExposureNumerator).To(Equal(libregraph.PtrFloat64(1)))
+			Expect(photo.ExposureDenominator).To(Equal(libregraph.PtrFloat64(1000)))
+			Expect(photo.FNumber).To(Equal(libregraph.PtrFloat64(1.8)))
+			Expect(photo.FocalLength).To(Equal(libregraph.PtrFloat64(50)))
+			Expect(photo.Iso).To(Equal(libregraph.PtrInt32(100)))
+			Expect(photo.Orientation).To(Equal(libregraph.PtrInt32(6)))
+			Expect(photo.TakenDateTime).To(Equal(libregraph.PtrTime(time.Date(2018, 1, 1, 12, 34, 56, 0, time.UTC))))
+
+			location := doc.Location
+			Expect(location).ToNot(BeNil())
+			Expect(*location.Latitude).To(BeNumerically("~", 49.48671, 0.00001))
+			Expect(*location.Longitude).To(BeNumerically("~", -11.10387, 0.00001))
+			Expect(location.Altitude).To(Equal(libregraph.PtrFloat64(227.4)))
+		})
+
+		It("adds audio content", func() {
+			data = id3File(map[string]string{
+				"TIT2": "Some Title",
+				"TPE1": "Some Artist",
+				"TPE2": "Some AlbumArtist",
+				"TALB": "Some Album",
+				"TCON": "Some Genre",
+				"TRCK": "7/9",
+				"TPOS": "4/5",
+				"TYER": "2004",
+			})
+			doc := extract("song.mp3", "audio/mpeg")
+
+			audio := doc.Audio
+			Expect(audio).ToNot(BeNil())
+			Expect(doc.Title).To(Equal("Some Title"))
+			Expect(audio.Title).To(Equal(libregraph.PtrString("Some Title")))
+			Expect(audio.Artist).To(Equal(libregraph.PtrString("Some Artist")))
+			Expect(audio.AlbumArtist).To(Equal(libregraph.PtrString("Some AlbumArtist")))
+			Expect(audio.Album).To(Equal(libregraph.PtrString("Some Album")))
+			Expect(audio.Genre).To(Equal(libregraph.PtrString("Some Genre")))
+			Expect(audio.Track).To(Equal(libregraph.PtrInt32(7)))
+			Expect(audio.TrackCount).To(Equal(libregraph.PtrInt32(9)))
+			Expect(audio.Disc).To(Equal(libregraph.PtrInt32(4)))
+			Expect(audio.DiscCount).To(Equal(libregraph.PtrInt32(5)))
+			Expect(audio.Year).To(Equal(libregraph.PtrInt32(2004)))
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package embedding
+
+import (
+	"context"
+	"math"
+)
+
+// Embedder is the interface to the embedding providers, they turn texts into vectors
+// which are close to each other if the texts have a similar meaning.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// CosineSimilarity returns the cosine of the angle between both vectors, 1 means same direction.
+// Vectors of different length or without length are not similar at all.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Normalize scales the vector to the length of 1
+func Normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+
+	if norm == 0 {
+		return v
+	}
+
+	norm = math.Sqrt(norm)
+	for i := range v {
+		v[i] = float32(float64(v[i]) / norm)
+	}
+
+	return v
+}
@@ -0,0 +1,13 @@
+package embedding_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestEmbedding(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Embedding Suite")
+}
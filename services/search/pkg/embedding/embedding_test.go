@@ -0,0 +1,40 @@
+package embedding_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/embedding"
+)
+
+var _ = Describe("Embedding", func() {
+	Describe("CosineSimilarity", func() {
+		It("is 1 for vectors with the same direction", func() {
+			Expect(embedding.CosineSimilarity([]float32{1, 2, 3}, []float32{2, 4, 6})).To(BeNumerically("~", 1, 1e-6))
+		})
+
+		It("is 0 for orthogonal vectors", func() {
+			Expect(embedding.CosineSimilarity([]float32{1, 0}, []float32{0, 1})).To(BeNumerically("~", 0, 1e-6))
+		})
+
+		It("is -1 for opposite vectors", func() {
+			Expect(embedding.CosineSimilarity([]float32{1, 1}, []float32{-1, -1})).To(BeNumerically("~", -1, 1e-6))
+		})
+
+		It("is 0 for vectors which can not be compared", func() {
+			Expect(embedding.CosineSimilarity([]float32{1, 1}, []float32{1, 1, 1})).To(BeZero())
+			Expect(embedding.CosineSimilarity(nil, nil)).To(BeZero())
+			Expect(embedding.CosineSimilarity([]float32{0, 0}, []float32{1, 1})).To(BeZero())
+		})
+	})
+
+	Describe("Normalize", func() {
+		It("scales the vector to the length of 1", func() {
+			Expect(embedding.Normalize([]float32{3, 4})).To(Equal([]float32{0.6, 0.8}))
+		})
+
+		It("keeps zero vectors", func() {
+			Expect(embedding.Normalize([]float32{0, 0})).To(Equal([]float32{0, 0}))
+		})
+	})
+})
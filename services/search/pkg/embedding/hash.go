@@ -0,0 +1,50 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strings"
+	"unicode"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+)
+
+// Hash is a local embedding provider which hashes the words of a text into the vector.
+// It does not understand the meaning of words, texts are only similar if they share words,
+// but it is deterministic and needs no model which makes it a stand-in for tests and development.
+type Hash struct {
+	dimensions int
+}
+
+// NewHashEmbedder creates a new Hash instance
+func NewHashEmbedder(cfg *config.Config) (*Hash, error) {
+	if cfg.Embedding.Dimensions <= 0 {
+		return nil, errors.New("embedding dimensions must be greater than 0")
+	}
+
+	return &Hash{dimensions: cfg.Embedding.Dimensions}, nil
+}
+
+// Embed adds every lower cased word of the text to the vector, the sign and dimension of a word
+// are taken from its hash.
+func (h Hash) Embed(_ context.Context, text string) ([]float32, error) {
+	vector := make([]float32, h.dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, word := range words {
+		hasher := fnv.New64a()
+		_, _ = hasher.Write([]byte(word))
+		sum := hasher.Sum64()
+
+		if sum>>63 == 1 {
+			vector[sum%uint64(h.dimensions)]--
+		} else {
+			vector[sum%uint64(h.dimensions)]++
+		}
+	}
+
+	return Normalize(vector), nil
+}
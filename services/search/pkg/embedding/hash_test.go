@@ -0,0 +1,54 @@
+package embedding_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/embedding"
+)
+
+var _ = Describe("Hash", func() {
+	var (
+		ctx context.Context
+		h   *embedding.Hash
+	)
+
+	BeforeEach(func() {
+		var err error
+		ctx = context.Background()
+		h, err = embedding.NewHashEmbedder(&config.Config{Embedding: config.Embedding{Dimensions: 64}})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("needs dimensions", func() {
+		_, err := embedding.NewHashEmbedder(&config.Config{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("is deterministic", func() {
+		a, err := h.Embed(ctx, "quarterly report 2024")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(a).To(HaveLen(64))
+
+		b, err := h.Embed(ctx, "Quarterly Report, 2024!")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(embedding.CosineSimilarity(a, b)).To(BeNumerically("~", 1, 1e-6))
+	})
+
+	It("scores texts with shared words as more similar", func() {
+		query, _ := h.Embed(ctx, "holiday photos from italy")
+		similar, _ := h.Embed(ctx, "photos of the holiday in rome, italy")
+		other, _ := h.Embed(ctx, "tax declaration")
+
+		Expect(embedding.CosineSimilarity(query, similar)).To(BeNumerically(">", embedding.CosineSimilarity(query, other)))
+	})
+
+	It("returns a zero vector for texts without words", func() {
+		v, err := h.Embed(ctx, " ,.! ")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(v).To(Equal(make([]float32, 64)))
+	})
+})
@@ -0,0 +1,92 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+)
+
+// HTTP is an embedding provider which requests the vectors from an OpenAI compatible
+// embeddings endpoint, like the ones of Ollama, LocalAI or vLLM.
+type HTTP struct {
+	client     *http.Client
+	url        string
+	model      string
+	apiKey     string
+	dimensions int
+}
+
+type httpEmbeddingRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type httpEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// NewHTTPEmbedder creates a new HTTP instance
+func NewHTTPEmbedder(cfg *config.Config) (*HTTP, error) {
+	switch {
+	case cfg.Embedding.Dimensions <= 0:
+		return nil, errors.New("embedding dimensions must be greater than 0")
+	case cfg.Embedding.HTTP.URL == "":
+		return nil, errors.New("embedding url must not be empty")
+	}
+
+	return &HTTP{
+		client:     &http.Client{Timeout: cfg.Embedding.HTTP.Timeout},
+		url:        cfg.Embedding.HTTP.URL,
+		model:      cfg.Embedding.HTTP.Model,
+		apiKey:     cfg.Embedding.HTTP.APIKey,
+		dimensions: cfg.Embedding.Dimensions,
+	}, nil
+}
+
+// Embed requests the vector of the text from the endpoint
+func (h HTTP) Embed(ctx context.Context, text string) ([]float32, error) {
+	body, err := json.Marshal(httpEmbeddingRequest{Model: h.model, Input: text})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding request failed with status %d", res.StatusCode)
+	}
+
+	var embeddings httpEmbeddingResponse
+	if err := json.NewDecoder(res.Body).Decode(&embeddings); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+
+	switch {
+	case len(embeddings.Data) == 0:
+		return nil, errors.New("embedding response contains no vector")
+	case len(embeddings.Data[0].Embedding) != h.dimensions:
+		return nil, fmt.Errorf("embedding has %d dimensions, expected %d", len(embeddings.Data[0].Embedding), h.dimensions)
+	}
+
+	return embeddings.Data[0].Embedding, nil
+}
@@ -0,0 +1,79 @@
+package embedding_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/embedding"
+)
+
+var _ = Describe("HTTP", func() {
+	var (
+		srv      *httptest.Server
+		cfg      *config.Config
+		status   int
+		response string
+		request  map[string]string
+		header   http.Header
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		response = `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2,0.3]}]}`
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(response))
+		}))
+		DeferCleanup(srv.Close)
+
+		cfg = &config.Config{Embedding: config.Embedding{
+			Dimensions: 3,
+			HTTP: config.EmbeddingHTTP{
+				URL:    srv.URL,
+				Model:  "all-minilm",
+				APIKey: "secret",
+			},
+		}}
+	})
+
+	It("needs an url", func() {
+		cfg.Embedding.HTTP.URL = ""
+		_, err := embedding.NewHTTPEmbedder(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("requests the vector of the text", func() {
+		h, err := embedding.NewHTTPEmbedder(cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		v, err := h.Embed(context.Background(), "holiday photos")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(v).To(Equal([]float32{0.1, 0.2, 0.3}))
+		Expect(request).To(Equal(map[string]string{"model": "all-minilm", "input": "holiday photos"}))
+		Expect(header.Get("Authorization")).To(Equal("Bearer secret"))
+	})
+
+	It("fails if the endpoint fails", func() {
+		status = http.StatusInternalServerError
+		h, _ := embedding.NewHTTPEmbedder(cfg)
+
+		_, err := h.Embed(context.Background(), "holiday photos")
+		Expect(err).To(MatchError(ContainSubstring("500")))
+	})
+
+	It("fails if the dimensions do not match", func() {
+		cfg.Embedding.Dimensions = 384
+		h, _ := embedding.NewHTTPEmbedder(cfg)
+
+		_, err := h.Embed(context.Background(), "holiday photos")
+		Expect(err).To(MatchError(ContainSubstring("expected 384")))
+	})
+})
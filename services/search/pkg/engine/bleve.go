@@ -7,6 +7,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,9 +20,11 @@ import (
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/search/query"
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	libregraph "github.com/opencloud-eu/libre-graph-api-go"
+	"github.com/opencloud-eu/opencloud/pkg/kql"
 	"github.com/opencloud-eu/opencloud/pkg/log"
 	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
 	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
@@ -31,6 +34,7 @@ import (
 	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
 	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/embedding"
 	searchQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query"
 )
 
@@ -48,11 +52,12 @@ type Bleve struct {
 
 // NewBleveIndex returns a new bleve index
 // given path must exist.
-func NewBleveIndex(root string) (bleve.Index, error) {
+// The embedding dimensions are only used for new indexes, 0 disables the vector index.
+func NewBleveIndex(root string, embeddingDimensions int) (bleve.Index, error) {
 	destination := filepath.Join(root, "bleve")
 	index, err := bleve.Open(destination)
 	if errors.Is(bleve.ErrorIndexPathDoesNotExist, err) {
-		m, err := BuildBleveMapping()
+		m, err := BuildBleveMapping(embeddingDimensions)
 		if err != nil {
 			return nil, err
 		}
@@ -77,7 +82,7 @@ func NewBleveEngine(index bleve.Index, queryCreator searchQuery.Creator[query.Qu
 }
 
 // BuildBleveMapping builds a bleve index mapping which can be used for indexing
-func BuildBleveMapping() (mapping.IndexMapping, error) {
+func BuildBleveMapping(embeddingDimensions int) (mapping.IndexMapping, error) {
 	nameMapping := bleve.NewTextFieldMapping()
 	nameMapping.Analyzer = "lowercaseKeyword"
 
@@ -93,6 +98,7 @@ func BuildBleveMapping() (mapping.IndexMapping, error) {
 	docMapping.AddFieldMappingsAt("Name", nameMapping)
 	docMapping.AddFieldMappingsAt("Tags", lowercaseMapping)
 	docMapping.AddFieldMappingsAt("Content", fulltextFieldMapping)
+	docMapping.AddFieldMappingsAt("Embedding", embeddingFieldMappings(embeddingDimensions)...)
 
 	indexMapping := bleve.NewIndexMapping()
 	indexMapping.DefaultAnalyzer = keyword.Name
@@ -127,29 +133,63 @@ func BuildBleveMapping() (mapping.IndexMapping, error) {
 	return indexMapping, nil
 }
 
+// storedEmbeddingMapping stores the embedding without indexing it, the vector is needed
+// to update the resource and to compare it with other vectors
+func storedEmbeddingMapping() *mapping.FieldMapping {
+	embeddingMapping := bleve.NewNumericFieldMapping()
+	embeddingMapping.Index = false
+	embeddingMapping.IncludeInAll = false
+	embeddingMapping.DocValues = false
+
+	return embeddingMapping
+}
+
 // Search executes a search request operation within the index.
 // Returns a SearchIndexResponse object or an error.
 func (b *Bleve) Search(ctx context.Context, sir *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error) {
-	createdQuery, err := b.queryCreator.Create(sir.Query)
+	if err := ValidateFacets(sir.GetFacets()); err != nil {
+		return nil, err
+	}
+	if err := ValidateSimilarity(sir.GetSimilarity()); err != nil {
+		return nil, err
+	}
+
+	vector, err := b.similarityVector(sir.GetSimilarity())
 	if err != nil {
-		if searchQuery.IsValidationError(err) {
-			return nil, errtypes.BadRequest(err.Error())
-		}
 		return nil, err
 	}
 
-	q := bleve.NewConjunctionQuery(
-		// Skip documents that have been marked as deleted
+	// the query is optional if the resources are searched by their similarity
+	var createdQuery query.Query = bleve.NewMatchNoneQuery()
+	if sir.Query != "" || vector == nil {
+		createdQuery, err = b.queryCreator.Create(sir.Query)
+		if err != nil {
+			if searchQuery.IsValidationError(err) {
+				return nil, errtypes.BadRequest(err.Error())
+			}
+			return nil, err
+		}
+	}
+
+	filter := bleve.NewConjunctionQuery(
+		// Skip documents that have been marked as deleted, unless the trash is searched
 		&query.BoolFieldQuery{
-			Bool:     false,
+			Bool:     sir.GetTrashed(),
 			FieldVal: "Deleted",
 		},
-		createdQuery,
 	)
 
+	if !sir.GetVersions() {
+		// the previous versions of the files are only searched on request
+		currentVersions := bleve.NewBooleanQuery()
+		currentVersions.AddMust(bleve.NewMatchAllQuery())
+		currentVersions.AddMustNot(versionsQuery())
+		filter.Conjuncts = append(filter.Conjuncts, currentVersions)
+	}
+
 	if sir.Ref != nil {
-		q.Conjuncts = append(
-			q.Conjuncts,
+		filter.Conjuncts = append(
+			filter.Conjuncts,
 			&query.TermQuery{
 				FieldVal: "RootID",
 				Term: storagespace.FormatResourceID(
@@ -161,11 +201,54 @@ func (b *Bleve) Search(ctx context.Context, sir *searchService.SearchIndexReques
 				),
 			},
 		)
+
+		// limit the query to the requested path so that the facets only count resources below it
+		if requestedPath := utils.MakeRelativePath(sir.Ref.Path); requestedPath != "." {
+			filter.Conjuncts = append(
+				filter.Conjuncts,
+				bleve.NewDisjunctionQuery(
+					&query.TermQuery{FieldVal: "Path", Term: requestedPath},
+					&query.PrefixQuery{FieldVal: "Path", Prefix: requestedPath + "/"},
+				),
+			)
+		}
+	}
+
+	if id := sir.GetSimilarity().GetResourceId(); id != nil {
+		// a resource is no result of the search for similar resources
+		notSimilarResource := bleve.NewBooleanQuery()
+		notSimilarResource.AddMustNot(bleve.NewDocIDQuery([]string{searchIDtoString(id)}))
+		filter.Conjuncts = append(filter.Conjuncts, notSimilarResource)
+	}
+
+	queryMatches := bleve.NewConjunctionQuery(filter, createdQuery)
+	q := queryMatches
+
+	var neighbours search.DocumentMatchCollection
+	if vector != nil {
+		neighbours, err = b.nearestNeighbours(filter, vector, Neighbours(sir.PageSize))
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]string, 0, len(neighbours))
+		for _, neighbour := range neighbours {
+			ids = append(ids, neighbour.ID)
+		}
+
+		// the neighbours are results, whether they match the query or not
+		q = bleve.NewConjunctionQuery(filter, bleve.NewDisjunctionQuery(createdQuery, bleve.NewDocIDQuery(ids)))
 	}
 
 	bleveReq := bleve.NewSearchRequest(q)
 	bleveReq.Highlight = bleve.NewHighlight()
 
+	facetsReq, err := bleveFacetsRequest(sir.GetFacets())
+	if err != nil {
+		return nil, err
+	}
+	bleveReq.Facets = facetsReq
+
 	switch {
 	case sir.PageSize == -1:
 		bleveReq.Size = math.MaxInt
@@ -176,14 +259,29 @@ func (b *Bleve) Search(ctx context.Context, sir *searchService.SearchIndexReques
 	}
 
 	bleveReq.Fields = []string{"*"}
+
+	size := bleveReq.Size
+	if vector != nil {
+		// the request only counts and aggregates the results, they are ranked by rankBySimilarity
+		bleveReq.Size = 0
+	}
+
 	res, err := b.index.Search(bleveReq)
 	if err != nil {
 		return nil, err
 	}
 
-	matches := make([]*searchMessage.Match, 0, len(res.Hits))
+	hits := res.Hits
+	if vector != nil {
+		hits, err = b.rankBySimilarity(queryMatches, neighbours, vector, SimilarityWeight(sir.GetSimilarity()), size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	matches := make([]*searchMessage.Match, 0, len(hits))
 	totalMatches := res.Total
-	for _, hit := range res.Hits {
+	for _, hit := range hits {
 		if sir.Ref != nil {
 			hitPath := strings.TrimSuffix(getFieldValue[string](hit.Fields, "Path"), "/")
 			requestedPath := utils.MakeRelativePath(sir.Ref.Path)
@@ -226,6 +324,7 @@ func (b *Bleve) Search(ctx context.Context, sir *searchService.SearchIndexReques
 				Image:      getImageValue[searchMessage.Image](hit.Fields),
 				Location:   getLocationValue[searchMessage.GeoCoordinates](hit.Fields),
 				Photo:      getPhotoValue[searchMessage.Photo](hit.Fields),
+				VersionKey: getFieldValue[string](hit.Fields, "VersionKey"),
 			},
 		}
 
@@ -236,12 +335,174 @@ func (b *Bleve) Search(ctx context.Context, sir *searchService.SearchIndexReques
 		matches = append(matches, match)
 	}
 
+	facets, err := b.facets(sir.GetFacets(), q, res.Facets)
+	if err != nil {
+		return nil, err
+	}
+
 	return &searchService.SearchIndexResponse{
 		Matches:      matches,
 		TotalMatches: int32(totalMatches),
+		Facets:       facets,
 	}, nil
 }
 
+// similarityVector returns the vector of the similarity, which is the embedding of the resource if
+// the search service did not provide a vector. It is nil if the search is not by similarity.
+func (b *Bleve) similarityVector(s *searchMessage.Similarity) ([]float32, error) {
+	switch {
+	case s == nil:
+		return nil, nil
+	case len(s.GetVector()) > 0:
+		return s.GetVector(), nil
+	}
+
+	r, err := b.getResource(searchIDtoString(s.GetResourceId()))
+	switch {
+	case err != nil:
+		return nil, errtypes.NotFound("similar resource not found")
+	case len(r.Embedding) == 0:
+		return nil, errtypes.BadRequest("similar resource has no embedding")
+	}
+
+	return r.Embedding, nil
+}
+
+// rankBySimilarity merges the best matches of the query with the neighbours of the vector
+// and returns the best of them by their hybrid score
+func (b *Bleve) rankBySimilarity(q query.Query, neighbours search.DocumentMatchCollection, vector []float32, weight float64, size int) (search.DocumentMatchCollection, error) {
+	req := bleve.NewSearchRequest(q)
+	req.Highlight = bleve.NewHighlight()
+	req.Size = size
+	req.Fields = []string{"*"}
+
+	res, err := b.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make(search.DocumentMatchCollection, 0, len(res.Hits)+len(neighbours))
+	seen := make(map[string]bool, len(res.Hits))
+	for _, hit := range res.Hits {
+		seen[hit.ID] = true
+		hit.Score = HybridScore(hit.Score, res.MaxScore, embedding.CosineSimilarity(vector, getVectorValue(hit.Fields, "Embedding")), weight)
+		hits = append(hits, hit)
+	}
+
+	for _, hit := range neighbours {
+		if seen[hit.ID] {
+			continue
+		}
+		hit.Score = HybridScore(0, res.MaxScore, embedding.CosineSimilarity(vector, getVectorValue(hit.Fields, "Embedding")), weight)
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if len(hits) > size {
+		hits = hits[:size]
+	}
+
+	return hits, nil
+}
+
+// bleveFacetsRequest builds the bleve facets of the term and range facets,
+// the media type facet has no bleve equivalent and is counted by facets
+func bleveFacetsRequest(frs []*searchMessage.FacetRequest) (bleve.FacetsRequest, error) {
+	var facetsReq bleve.FacetsRequest
+	add := func(field string, fr *bleve.FacetRequest) {
+		if facetsReq == nil {
+			facetsReq = bleve.FacetsRequest{}
+		}
+		facetsReq[field] = fr
+	}
+
+	for _, fr := range frs {
+		switch fr.GetField() {
+		case FacetTags:
+			add(FacetTags, bleve.NewFacetRequest("Tags", TermFacetSize(fr)))
+		case FacetSpace:
+			add(FacetSpace, bleve.NewFacetRequest("RootID", TermFacetSize(fr)))
+		case FacetSize:
+			facet := bleve.NewFacetRequest("Size", len(SizeFacetRanges))
+			for _, r := range SizeFacetRanges {
+				facet.AddNumericRange(r.Name, r.From, r.To)
+			}
+			add(FacetSize, facet)
+		case FacetMtime:
+			ranges, err := MtimeFacetRanges()
+			if err != nil {
+				return nil, err
+			}
+			facet := bleve.NewFacetRequest("Mtime", len(ranges))
+			for _, r := range ranges {
+				facet.AddDateTimeRange(r.Name, *r.From, *r.To)
+			}
+			add(FacetMtime, facet)
+		}
+	}
+
+	return facetsReq, nil
+}
+
+// facets converts the bleve facet results in the requested order, the media type groups are
+// compiled by the query creator and counted by searching them within the query
+func (b *Bleve) facets(frs []*searchMessage.FacetRequest, q query.Query, results search.FacetResults) ([]*searchMessage.Facet, error) {
+	facets := make([]*searchMessage.Facet, 0, len(frs))
+	for _, fr := range frs {
+		facet := &searchMessage.Facet{Field: fr.GetField()}
+		result := results[fr.GetField()]
+
+		switch fr.GetField() {
+		case FacetTags, FacetSpace:
+			if result == nil {
+				break
+			}
+			for _, term := range result.Terms.Terms() {
+				facet.Buckets = append(facet.Buckets, &searchMessage.FacetBucket{Key: term.Term, Count: int64(term.Count)})
+			}
+			facet.Other = int64(result.Other)
+		case FacetSize:
+			counts := map[string]int{}
+			if result != nil {
+				for _, r := range result.NumericRanges {
+					counts[r.Name] = r.Count
+				}
+			}
+			for _, r := range SizeFacetRanges {
+				facet.Buckets = append(facet.Buckets, &searchMessage.FacetBucket{Key: r.Name, Count: int64(counts[r.Name])})
+			}
+		case FacetMtime:
+			counts := map[string]int{}
+			if result != nil {
+				for _, r := range result.DateRanges {
+					counts[r.Name] = r.Count
+				}
+			}
+			for _, name := range kql.TimeRanges {
+				facet.Buckets = append(facet.Buckets, &searchMessage.FacetBucket{Key: name, Count: int64(counts[name])})
+			}
+		case FacetMediaType:
+			for _, group := range MediaTypeFacetBuckets {
+				groupQuery, err := b.queryCreator.Create(FacetMediaType + ":" + group)
+				if err != nil {
+					return nil, err
+				}
+				res, err := b.index.Search(bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(q, groupQuery), 0, 0, false))
+				if err != nil {
+					return nil, err
+				}
+				facet.Buckets = append(facet.Buckets, &searchMessage.FacetBucket{Key: group, Count: int64(res.Total)})
+			}
+		}
+
+		facets = append(facets, facet)
+	}
+
+	return facets, nil
+}
+
 func (b *Bleve) StartBatch(batchSize int) error {
 	b.m.Lock()
 	defer b.m.Unlock()
@@ -317,6 +578,21 @@ func (b *Bleve) Move(id string, parentid string, target string) error {
 		return err
 	}
 
+	versionIDs, err := b.versionDocumentIDs(id)
+	if err != nil {
+		return err
+	}
+	for _, versionID := range versionIDs {
+		_, err := b.updateEntity(versionID, func(r *Resource) {
+			r.Path = nextPath
+			r.Name = path.Base(nextPath)
+			r.ParentID = parentid
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	if r.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
 		q := bleve.NewConjunctionQuery(
 			bleve.NewQueryStringQuery("RootID:"+r.RootID),
@@ -357,22 +633,42 @@ func (b *Bleve) Restore(id string) error {
 	return b.setDeleted(id, false)
 }
 
-// Purge removes a resource from the index, irreversible operation.
+// Purge removes a resource and the previous versions of it from the index, irreversible operation.
 func (b *Bleve) Purge(id string) error {
+	versionIDs, err := b.versionDocumentIDs(id)
+	if err != nil {
+		return err
+	}
+
 	b.m.Lock()
 	defer b.m.Unlock()
 
-	if b.batch != nil {
-		b.batch.Delete(id)
+	for _, docID := range append(versionIDs, id) {
+		if b.batch == nil {
+			if err := b.index.Delete(docID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b.batch.Delete(docID)
 		if b.batch.Size() >= b.batchSize {
 			if err := b.index.Batch(b.batch); err != nil {
 				return err
 			}
 			b.batch = b.index.NewBatch()
 		}
-		return nil
 	}
-	return b.index.Delete(id)
+	return nil
+}
+
+// Exists reports whether the resource is part of the index, resources which are only part of the pending batch are not.
+func (b *Bleve) Exists(id string) (bool, error) {
+	doc, err := b.index.Document(id)
+	if err != nil {
+		return false, err
+	}
+	return doc != nil, nil
 }
 
 // DocCount returns the number of resources in the index.
@@ -413,6 +709,8 @@ func (b *Bleve) getResource(id string) (*Resource, error) {
 			Location: getLocationValue[libregraph.GeoCoordinates](fields),
 			Photo:    getPhotoValue[libregraph.Photo](fields),
 		},
+		Embedding:  getVectorValue(fields, "Embedding"),
+		VersionKey: getFieldValue[string](fields, "VersionKey"),
 	}, nil
 }
 
@@ -537,6 +835,19 @@ func (b *Bleve) setDeleted(id string, deleted bool) error {
 		return err
 	}
 
+	versionIDs, err := b.versionDocumentIDs(id)
+	if err != nil {
+		return err
+	}
+	for _, versionID := range versionIDs {
+		_, err := b.updateEntity(versionID, func(r *Resource) {
+			r.Deleted = deleted
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	if it.Type == uint64(storageProvider.ResourceType_RESOURCE_TYPE_CONTAINER) {
 		q := bleve.NewConjunctionQuery(
 			bleve.NewQueryStringQuery("RootID:"+it.RootID),
@@ -566,3 +877,31 @@ func (b *Bleve) setDeleted(id string, deleted bool) error {
 
 	return nil
 }
+
+// versionsQuery matches the documents of the previous versions of the files,
+// the current versions are indexed with an empty version key
+func versionsQuery() query.Query {
+	q := bleve.NewRegexpQuery(".+")
+	q.SetField("VersionKey")
+	return q
+}
+
+// versionDocumentIDs returns the ids of the documents of the previous versions of a file
+func (b *Bleve) versionDocumentIDs(id string) ([]string, error) {
+	req := bleve.NewSearchRequest(bleve.NewConjunctionQuery(
+		&query.TermQuery{FieldVal: "ID", Term: id},
+		versionsQuery(),
+	))
+	req.Size = math.MaxInt
+	res, err := b.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+
+	return ids, nil
+}
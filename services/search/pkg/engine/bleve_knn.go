@@ -0,0 +1,44 @@
+//go:build vectors
+
+package engine
+
+import (
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// _embeddingVectorField is the field of the vector index, the embedding field only stores the vector
+const _embeddingVectorField = "EmbeddingVector"
+
+// embeddingFieldMappings stores the embedding and adds it to the vector index of bleve
+func embeddingFieldMappings(dimensions int) []*mapping.FieldMapping {
+	mappings := []*mapping.FieldMapping{storedEmbeddingMapping()}
+	if dimensions <= 0 {
+		return mappings
+	}
+
+	vectorMapping := bleve.NewVectorFieldMapping()
+	vectorMapping.Name = _embeddingVectorField
+	vectorMapping.Dims = dimensions
+	vectorMapping.Similarity = "cosine"
+
+	return append(mappings, vectorMapping)
+}
+
+// nearestNeighbours returns the k resources matching the filter which are the most similar to the vector,
+// they are looked up in the vector index of bleve
+func (b *Bleve) nearestNeighbours(filter query.Query, vector []float32, k int) (search.DocumentMatchCollection, error) {
+	req := bleve.NewSearchRequest(bleve.NewMatchNoneQuery())
+	req.AddKNNWithFilter(_embeddingVectorField, vector, int64(k), 1, filter)
+	req.Size = k
+	req.Fields = []string{"*"}
+
+	res, err := b.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Hits, nil
+}
@@ -0,0 +1,78 @@
+//go:build !vectors
+
+package engine
+
+import (
+	"sort"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/embedding"
+)
+
+// embeddingFieldMappings stores the embedding, bleve is built without its vector index
+func embeddingFieldMappings(_ int) []*mapping.FieldMapping {
+	return []*mapping.FieldMapping{storedEmbeddingMapping()}
+}
+
+// _maxComparedEmbeddings is the number of resources matching the filter whose embeddings are compared at most
+const _maxComparedEmbeddings = 10000
+
+// nearestNeighbours returns the k resources matching the filter which are the most similar to the vector.
+// Without the vector index of bleve the stored vectors of the resources matching the filter are compared,
+// they are read in batches and only the first _maxComparedEmbeddings resources are considered.
+func (b *Bleve) nearestNeighbours(filter query.Query, vector []float32, k int) (search.DocumentMatchCollection, error) {
+	var ids []string
+	similarities := map[string]float64{}
+	for from := 0; from < _maxComparedEmbeddings; from += _batchSize {
+		req := bleve.NewSearchRequestOptions(filter, _batchSize, from, false)
+		req.SortBy([]string{"_id"})
+		req.Fields = []string{"Embedding"}
+
+		res, err := b.index.Search(req)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hit := range res.Hits {
+			v := getVectorValue(hit.Fields, "Embedding")
+			if v == nil {
+				continue
+			}
+
+			ids = append(ids, hit.ID)
+			similarities[hit.ID] = embedding.CosineSimilarity(vector, v)
+		}
+
+		if len(res.Hits) < _batchSize {
+			break
+		}
+		if from+_batchSize >= _maxComparedEmbeddings && res.Total > uint64(_maxComparedEmbeddings) {
+			b.log.Warn().Uint64("resources", res.Total).Int("compared", _maxComparedEmbeddings).Msg("too many resources to compare their embeddings, build with the vectors tag to use the vector index")
+		}
+	}
+
+	sort.SliceStable(ids, func(i, j int) bool {
+		return similarities[ids[i]] > similarities[ids[j]]
+	})
+	if len(ids) > k {
+		ids = ids[:k]
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	req := bleve.NewSearchRequest(bleve.NewDocIDQuery(ids))
+	req.Size = len(ids)
+	req.Fields = []string{"*"}
+
+	res, err := b.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Hits, nil
+}
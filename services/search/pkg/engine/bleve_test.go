@@ -3,6 +3,7 @@ package engine_test
 import (
 	"context"
 	"fmt"
+	"time"
 
 	bleveSearch "github.com/blevesearch/bleve/v2"
 	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
@@ -58,7 +59,7 @@ var _ = Describe("Bleve", func() {
 	)
 
 	BeforeEach(func() {
-		mapping, err := engine.BuildBleveMapping()
+		mapping, err := engine.BuildBleveMapping(3)
 		Expect(err).ToNot(HaveOccurred())
 
 		idx, err = bleveSearch.NewMemOnly(mapping)
@@ -365,6 +366,276 @@ var _ = Describe("Bleve", func() {
 			})
 		})
 
+		Context("with facets", func() {
+			var (
+				facetSearch = func(query, path string, facets ...*searchmsg.FacetRequest) map[string]*searchmsg.Facet {
+					rID, err := storagespace.ParseID(rootResource.ID)
+					ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+					res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+						Query: query,
+						Ref: &searchmsg.Reference{
+							ResourceId: &searchmsg.ResourceID{
+								StorageId: rID.StorageId,
+								SpaceId:   rID.SpaceId,
+								OpaqueId:  rID.OpaqueId,
+							},
+							Path: path,
+						},
+						Facets: facets,
+					})
+					ExpectWithOffset(1, err).ToNot(HaveOccurred())
+					ExpectWithOffset(1, res.Facets).To(HaveLen(len(facets)))
+
+					byField := map[string]*searchmsg.Facet{}
+					for _, facet := range res.Facets {
+						byField[facet.Field] = facet
+					}
+					return byField
+				}
+
+				bucketCounts = func(facet *searchmsg.Facet) map[string]int64 {
+					counts := map[string]int64{}
+					for _, bucket := range facet.Buckets {
+						counts[bucket.Key] = bucket.Count
+					}
+					return counts
+				}
+			)
+
+			BeforeEach(func() {
+				parentResource.Document.MimeType = "httpd/unix-directory"
+				parentResource.Document.Size = 3 << 20
+				parentResource.Document.Mtime = time.Now().UTC().Format(time.RFC3339Nano)
+
+				childResource.Document.MimeType = "application/pdf"
+				childResource.Document.Size = 3 << 20
+				childResource.Document.Tags = []string{"foo", "bar"}
+				childResource.Document.Mtime = time.Now().UTC().Format(time.RFC3339Nano)
+
+				childResource2.Document.MimeType = "application/pdf"
+				childResource2.Document.Tags = []string{"foo"}
+				childResource2.Document.Mtime = time.Now().AddDate(-3, 0, 0).UTC().Format(time.RFC3339Nano)
+
+				otherResource := engine.Resource{
+					ID:       "1$2!6",
+					ParentID: rootResource.ID,
+					RootID:   rootResource.ID,
+					Path:     "./other.png",
+					Type:     uint64(sprovider.ResourceType_RESOURCE_TYPE_FILE),
+					Document: content.Document{Name: "other.png", MimeType: "image/png", Size: 1024, Tags: []string{"baz"}},
+				}
+
+				for _, r := range []engine.Resource{parentResource, childResource, childResource2, otherResource} {
+					Expect(eng.Upsert(r.ID, r)).To(Succeed())
+				}
+			})
+
+			It("counts the media type groups", func() {
+				facets := facetSearch("*", "", &searchmsg.FacetRequest{Field: "mediatype"})
+				counts := bucketCounts(facets["mediatype"])
+				Expect(facets["mediatype"].Buckets).To(HaveLen(len(engine.MediaTypeFacetBuckets)))
+				Expect(counts["file"]).To(Equal(int64(3)))
+				Expect(counts["folder"]).To(Equal(int64(1)))
+				Expect(counts["pdf"]).To(Equal(int64(2)))
+				Expect(counts["image"]).To(Equal(int64(1)))
+				Expect(counts["video"]).To(Equal(int64(0)))
+			})
+
+			It("counts the most frequent tags", func() {
+				facets := facetSearch("*", "", &searchmsg.FacetRequest{Field: "tags", Size: 2})
+				Expect(facets["tags"].Buckets).To(HaveLen(2))
+				Expect(facets["tags"].Buckets[0]).To(HaveField("Key", "foo"))
+				Expect(facets["tags"].Buckets[0]).To(HaveField("Count", int64(2)))
+				Expect(facets["tags"].Other).To(Equal(int64(1)))
+			})
+
+			It("counts the size and mtime ranges", func() {
+				facets := facetSearch("*", "", &searchmsg.FacetRequest{Field: "size"}, &searchmsg.FacetRequest{Field: "mtime"})
+
+				sizes := bucketCounts(facets["size"])
+				Expect(facets["size"].Buckets[0].Key).To(Equal("empty"))
+				Expect(sizes["empty"]).To(Equal(int64(1)))
+				Expect(sizes["tiny"]).To(Equal(int64(1)))
+				Expect(sizes["medium"]).To(Equal(int64(2)))
+
+				mtimes := bucketCounts(facets["mtime"])
+				Expect(facets["mtime"].Buckets[0].Key).To(Equal("today"))
+				Expect(mtimes["today"]).To(Equal(int64(2)))
+				Expect(mtimes["last year"]).To(Equal(int64(0)))
+			})
+
+			It("counts the spaces", func() {
+				facets := facetSearch("*", "", &searchmsg.FacetRequest{Field: "space"})
+				Expect(facets["space"].Buckets).To(HaveLen(1))
+				Expect(facets["space"].Buckets[0].Key).To(Equal(rootResource.ID))
+				Expect(facets["space"].Buckets[0].Count).To(Equal(int64(4)))
+			})
+
+			It("only counts the matches of the query below the path", func() {
+				facets := facetSearch("Name:child*", "", &searchmsg.FacetRequest{Field: "tags"})
+				Expect(bucketCounts(facets["tags"])).To(Equal(map[string]int64{"foo": 2, "bar": 1}))
+
+				facets = facetSearch("*", "./parent d!r", &searchmsg.FacetRequest{Field: "mediatype"})
+				counts := bucketCounts(facets["mediatype"])
+				Expect(counts["image"]).To(Equal(int64(0)))
+				Expect(counts["pdf"]).To(Equal(int64(2)))
+			})
+
+			It("rejects unknown facets", func() {
+				_, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+					Query:  "*",
+					Facets: []*searchmsg.FacetRequest{{Field: "owner"}},
+				})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("with similarity", func() {
+			var (
+				similaritySearch = func(query string, similarity *searchmsg.Similarity) []*searchmsg.Match {
+					rID, err := storagespace.ParseID(rootResource.ID)
+					ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+					res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+						Query: query,
+						Ref: &searchmsg.Reference{
+							ResourceId: &searchmsg.ResourceID{
+								StorageId: rID.StorageId,
+								SpaceId:   rID.SpaceId,
+								OpaqueId:  rID.OpaqueId,
+							},
+						},
+						Similarity: similarity,
+					})
+					ExpectWithOffset(1, err).ToNot(HaveOccurred())
+					return res.Matches
+				}
+
+				matchNames = func(matches []*searchmsg.Match) []string {
+					names := make([]string, 0, len(matches))
+					for _, match := range matches {
+						names = append(names, match.Entity.Name)
+					}
+					return names
+				}
+			)
+
+			BeforeEach(func() {
+				parentResource.Embedding = []float32{0, 0, 1}
+				childResource.Embedding = []float32{1, 0, 0}
+				childResource2.Embedding = []float32{0.8, 0.6, 0}
+
+				for _, r := range []engine.Resource{parentResource, childResource, childResource2} {
+					Expect(eng.Upsert(r.ID, r)).To(Succeed())
+				}
+			})
+
+			It("ranks the resources by the similarity to the vector", func() {
+				matches := similaritySearch("", &searchmsg.Similarity{Vector: []float32{1, 0.1, 0}})
+				Expect(matchNames(matches)).To(Equal([]string{"child.pdf", "child2.pdf", "parent d!r"}))
+				Expect(matches[0].Score).To(BeNumerically(">", matches[1].Score))
+			})
+
+			It("ranks the resources by the similarity to another resource and excludes it", func() {
+				rID, err := storagespace.ParseID(childResource.ID)
+				Expect(err).ToNot(HaveOccurred())
+
+				matches := similaritySearch("", &searchmsg.Similarity{ResourceId: &searchmsg.ResourceID{
+					StorageId: rID.StorageId,
+					SpaceId:   rID.SpaceId,
+					OpaqueId:  rID.OpaqueId,
+				}})
+				Expect(matchNames(matches)).To(Equal([]string{"child2.pdf", "parent d!r"}))
+			})
+
+			It("mixes the similarity with the score of the query", func() {
+				matches := similaritySearch("Name:parent*", &searchmsg.Similarity{Vector: []float32{1, 0, 0}, Weight: 0.1})
+				Expect(matches[0].Entity.Name).To(Equal("parent d!r"))
+
+				matches = similaritySearch("Name:parent*", &searchmsg.Similarity{Vector: []float32{1, 0, 0}, Weight: 1})
+				Expect(matches[0].Entity.Name).To(Equal("child.pdf"))
+			})
+
+			It("rejects invalid weights", func() {
+				_, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+					Similarity: &searchmsg.Similarity{Vector: []float32{1, 0, 0}, Weight: 2},
+				})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("in the trash and the previous versions of the files", func() {
+			var (
+				searchWith = func(query string, trashed, versions bool) []*searchmsg.Match {
+					res, err := eng.Search(context.Background(), &searchsvc.SearchIndexRequest{
+						Query:    query,
+						Trashed:  trashed,
+						Versions: versions,
+					})
+					ExpectWithOffset(1, err).ToNot(HaveOccurred())
+					return res.Matches
+				}
+			)
+
+			BeforeEach(func() {
+				version := childResource
+				version.VersionKey = "4.REV.2024-01-01T00:00:00Z"
+				version.Document.Content = "quarterly numbers"
+
+				for id, r := range map[string]engine.Resource{
+					parentResource.ID: parentResource,
+					childResource.ID:  childResource,
+					childResource2.ID: childResource2,
+					engine.VersionDocumentID(version.ID, version.VersionKey): version,
+				} {
+					Expect(eng.Upsert(id, r)).To(Succeed())
+				}
+			})
+
+			It("finds the trashed resources only in the trash", func() {
+				Expect(eng.Delete(parentResource.ID)).To(Succeed())
+
+				Expect(searchWith("Name:child*", false, false)).To(BeEmpty())
+
+				matches := searchWith("Name:child*", true, false)
+				Expect(matches).To(HaveLen(2))
+				for _, match := range matches {
+					Expect(match.Entity.Deleted).To(BeTrue())
+					Expect(match.Entity.Ref.Path).To(HavePrefix("./parent d!r/"))
+				}
+			})
+
+			It("finds the previous versions of the files on request", func() {
+				Expect(searchWith("Content:quarterly", false, false)).To(BeEmpty())
+
+				matches := searchWith("Content:quarterly", false, true)
+				Expect(matches).To(HaveLen(1))
+				Expect(matches[0].Entity.Id.OpaqueId).To(Equal("4"))
+				Expect(matches[0].Entity.VersionKey).To(Equal("4.REV.2024-01-01T00:00:00Z"))
+
+				Expect(searchWith("Name:child.pdf", false, true)).To(HaveLen(2))
+			})
+
+			It("trashes, restores, moves and purges the versions with the file", func() {
+				Expect(eng.Delete(childResource.ID)).To(Succeed())
+				Expect(searchWith("Content:quarterly", false, true)).To(BeEmpty())
+				Expect(searchWith("Content:quarterly", true, true)).To(HaveLen(1))
+
+				Expect(eng.Restore(childResource.ID)).To(Succeed())
+				Expect(searchWith("Content:quarterly", false, true)).To(HaveLen(1))
+
+				Expect(eng.Move(childResource.ID, rootResource.ID, "./renamed.pdf")).To(Succeed())
+				matches := searchWith("Name:renamed.pdf", false, true)
+				Expect(matches).To(HaveLen(2))
+				for _, match := range matches {
+					Expect(match.Entity.Ref.Path).To(Equal("./renamed.pdf"))
+				}
+
+				Expect(eng.Purge(childResource.ID)).To(Succeed())
+				Expect(searchWith("Name:renamed.pdf", false, true)).To(BeEmpty())
+			})
+		})
 	})
 
 	Describe("Upsert", func() {
@@ -400,6 +671,21 @@ var _ = Describe("Bleve", func() {
 		})
 	})
 
+	Describe("Exists", func() {
+		It("reports whether a resource is indexed", func() {
+			exists, err := eng.Exists(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+
+			err = eng.Upsert(childResource.ID, childResource)
+			Expect(err).ToNot(HaveOccurred())
+
+			exists, err = eng.Exists(childResource.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+	})
+
 	Describe("Delete", func() {
 		It("marks a resource as deleted", func() {
 			err := eng.Upsert(childResource.ID, childResource)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/blevesearch/bleve/v2/search"
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
 
 	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
 	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
@@ -18,6 +19,8 @@ var queryEscape = regexp.MustCompile(`([` + regexp.QuoteMeta(`+=&|><!(){}[]^\"~*
 type Engine interface {
 	Search(ctx context.Context, req *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error)
 	Upsert(id string, r Resource) error
+	// Exists reports whether the resource is part of the index
+	Exists(id string) (bool, error)
 	Move(id string, parentid string, target string) error
 	Delete(id string) error
 	Restore(id string) error
@@ -39,6 +42,18 @@ type Resource struct {
 	Type     uint64
 	Deleted  bool
 	Hidden   bool
+
+	// Embedding is the vector of the resource for semantic search
+	Embedding []float32 `json:",omitempty"`
+
+	// VersionKey is the key of the file version the document indexes, the current version has none
+	VersionKey string `json:",omitempty"`
+}
+
+// VersionDocumentID returns the id of the document of a file version, the versions
+// are stored next to the current version of the file which is stored by its id
+func VersionDocumentID(id, key string) string {
+	return id + "/v/" + key
 }
 
 func resourceIDtoSearchID(id storageProvider.ResourceId) *searchMessage.ResourceID {
@@ -48,6 +63,14 @@ func resourceIDtoSearchID(id storageProvider.ResourceId) *searchMessage.Resource
 		OpaqueId:  id.GetOpaqueId()}
 }
 
+func searchIDtoString(id *searchMessage.ResourceID) string {
+	return storagespace.FormatResourceID(&storageProvider.ResourceId{
+		StorageId: id.GetStorageId(),
+		SpaceId:   id.GetSpaceId(),
+		OpaqueId:  id.GetOpaqueId(),
+	})
+}
+
 func escapeQuery(s string) string {
 	return queryEscape.ReplaceAllString(s, "\\$1")
 }
@@ -101,3 +124,18 @@ func getFieldSliceValue[T any](m map[string]interface{}, key string) (out []T) {
 
 	return
 }
+
+// getVectorValue returns the vector of the stored numeric field
+func getVectorValue(m map[string]interface{}, key string) []float32 {
+	values := getFieldSliceValue[float64](m, key)
+	if len(values) == 0 {
+		return nil
+	}
+
+	vector := make([]float32, len(values))
+	for i, v := range values {
+		vector[i] = float32(v)
+	}
+
+	return vector
+}
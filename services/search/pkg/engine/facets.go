@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+
+	"github.com/opencloud-eu/opencloud/pkg/conversions"
+	"github.com/opencloud-eu/opencloud/pkg/kql"
+	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+)
+
+// The fields which can be aggregated by the engines
+const (
+	FacetMediaType = "mediatype"
+	FacetTags      = "tags"
+	FacetMtime     = "mtime"
+	FacetSpace     = "space"
+	FacetSize      = "size"
+)
+
+const (
+	_defaultFacetSize = 10
+	_maxFacetSize     = 100
+)
+
+// MediaTypeFacetBuckets are the media type groups of the kql mediatype property,
+// the key of every bucket refines the query like mediatype:document
+var MediaTypeFacetBuckets = []string{"file", "folder", "document", "spreadsheet", "presentation", "pdf", "image", "video", "audio", "archive"}
+
+// FacetRange is a bucket of a range facet, From is inclusive, To is exclusive and nil bounds are open
+type FacetRange[T any] struct {
+	Name string
+	From *T
+	To   *T
+}
+
+// SizeFacetRanges are the buckets of the size facet in bytes
+var SizeFacetRanges = []FacetRange[float64]{
+	{Name: "empty", From: conversions.ToPointer[float64](0), To: conversions.ToPointer[float64](1)},
+	{Name: "tiny", From: conversions.ToPointer[float64](1), To: conversions.ToPointer[float64](16 << 10)},
+	{Name: "small", From: conversions.ToPointer[float64](16 << 10), To: conversions.ToPointer[float64](1 << 20)},
+	{Name: "medium", From: conversions.ToPointer[float64](1 << 20), To: conversions.ToPointer[float64](128 << 20)},
+	{Name: "large", From: conversions.ToPointer[float64](128 << 20), To: conversions.ToPointer[float64](1 << 30)},
+	{Name: "huge", From: conversions.ToPointer[float64](1 << 30), To: conversions.ToPointer[float64](4 << 30)},
+	{Name: "gigantic", From: conversions.ToPointer[float64](4 << 30)},
+}
+
+// MtimeFacetRanges returns the buckets of the mtime facet, they are the natural language
+// time ranges of kql so the key of every bucket refines the query like mtime:"last 7 days"
+func MtimeFacetRanges() ([]FacetRange[time.Time], error) {
+	ranges := make([]FacetRange[time.Time], 0, len(kql.TimeRanges))
+	for _, name := range kql.TimeRanges {
+		from, to, err := kql.TimeRange(name)
+		if err != nil {
+			return nil, err
+		}
+
+		// the kql ranges include the last nanosecond of the range
+		to = to.Add(time.Nanosecond)
+		ranges = append(ranges, FacetRange[time.Time]{Name: name, From: &from, To: &to})
+	}
+
+	return ranges, nil
+}
+
+// IsTermFacet returns true if the buckets of the facet are the most frequent terms of the field,
+// the buckets of all other facets are predefined
+func IsTermFacet(field string) bool {
+	return field == FacetTags || field == FacetSpace
+}
+
+// TermFacetSize returns the maximum number of buckets of the term facet
+func TermFacetSize(fr *searchMessage.FacetRequest) int {
+	switch {
+	case fr.GetSize() <= 0:
+		return _defaultFacetSize
+	case fr.GetSize() > _maxFacetSize:
+		return _maxFacetSize
+	default:
+		return int(fr.GetSize())
+	}
+}
+
+// ValidateFacets checks that all requested facets are known and requested once
+func ValidateFacets(frs []*searchMessage.FacetRequest) error {
+	seen := make(map[string]bool, len(frs))
+	for _, fr := range frs {
+		switch fr.GetField() {
+		case FacetMediaType, FacetTags, FacetMtime, FacetSpace, FacetSize:
+		default:
+			return errtypes.BadRequest(fmt.Sprintf("unsupported facet: %s", fr.GetField()))
+		}
+
+		if seen[fr.GetField()] {
+			return errtypes.BadRequest(fmt.Sprintf("duplicate facet: %s", fr.GetField()))
+		}
+		seen[fr.GetField()] = true
+	}
+
+	return nil
+}
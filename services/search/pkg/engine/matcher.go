@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	searchQuery "github.com/opencloud-eu/opencloud/services/search/pkg/query"
+)
+
+// Matcher decides if a single resource matches queries. The resource is held in an in-memory index
+// of its own, so it can be matched before a batch is committed and regardless of the configured engine.
+type Matcher struct {
+	index  bleve.Index
+	engine *Bleve
+}
+
+// NewMatcher creates a Matcher for the resource, it must be closed after use
+func NewMatcher(r Resource, queryCreator searchQuery.Creator[query.Query]) (*Matcher, error) {
+	m, err := BuildBleveMapping(0)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := bleve.NewMemOnly(m)
+	if err != nil {
+		return nil, err
+	}
+
+	// the similarity of the resource is not part of a query
+	r.Embedding = nil
+	if err := index.Index(r.ID, r); err != nil {
+		_ = index.Close()
+		return nil, err
+	}
+
+	return &Matcher{
+		index:  index,
+		engine: NewBleveEngine(index, queryCreator, log.NopLogger()),
+	}, nil
+}
+
+// Match reports whether the resource matches the query, the reference limits the match to the resources below it
+func (m *Matcher) Match(ctx context.Context, q string, ref *searchMessage.Reference) (bool, error) {
+	res, err := m.engine.Search(ctx, &searchService.SearchIndexRequest{
+		Query:    q,
+		Ref:      ref,
+		PageSize: 1,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return res.GetTotalMatches() > 0, nil
+}
+
+// Close releases the index of the resource
+func (m *Matcher) Close() error {
+	return m.index.Close()
+}
@@ -0,0 +1,66 @@
+package engine_test
+
+import (
+	"context"
+
+	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/engine"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
+)
+
+var _ = Describe("Matcher", func() {
+	var (
+		matcher *engine.Matcher
+		ctx     = context.Background()
+	)
+
+	BeforeEach(func() {
+		var err error
+		matcher, err = engine.NewMatcher(engine.Resource{
+			ID:        "1$2!4",
+			ParentID:  "1$2!3",
+			RootID:    "1$2!2",
+			Path:      "./invoices/march.pdf",
+			Type:      uint64(sprovider.ResourceType_RESOURCE_TYPE_FILE),
+			Embedding: []float32{1, 0, 0},
+			Document: content.Document{
+				Name:     "march.pdf",
+				MimeType: "application/pdf",
+				Tags:     []string{"invoice"},
+			},
+		}, bleve.DefaultCreator)
+		Expect(err).ToNot(HaveOccurred())
+
+		DeferCleanup(func() {
+			Expect(matcher.Close()).To(Succeed())
+		})
+	})
+
+	DescribeTable("matches the queries",
+		func(query string, ref *searchMessage.Reference, expected bool) {
+			matched, err := matcher.Match(ctx, query, ref)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matched).To(Equal(expected))
+		},
+		Entry("by tag", "tag:invoice", nil, true),
+		Entry("by media type and tag", "mediatype:pdf tag:invoice", nil, true),
+		Entry("by another tag", "tag:report", nil, false),
+		Entry("by name", "name:march*", nil, true),
+		Entry("below the referenced folder", "tag:invoice", &searchMessage.Reference{
+			ResourceId: &searchMessage.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+			Path:       "/invoices",
+		}, true),
+		Entry("outside of the referenced folder", "tag:invoice", &searchMessage.Reference{
+			ResourceId: &searchMessage.ResourceID{StorageId: "1", SpaceId: "2", OpaqueId: "2"},
+			Path:       "/reports",
+		}, false),
+		Entry("in another space", "tag:invoice", &searchMessage.Reference{
+			ResourceId: &searchMessage.ResourceID{StorageId: "1", SpaceId: "3", OpaqueId: "3"},
+		}, false),
+	)
+})
@@ -187,6 +187,66 @@ func (_c *Engine_EndBatch_Call) RunAndReturn(run func() error) *Engine_EndBatch_
 	return _c
 }
 
+// Exists provides a mock function for the type Engine
+func (_mock *Engine) Exists(id string) (bool, error) {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Exists")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return returnFunc(id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = returnFunc(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Engine_Exists_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exists'
+type Engine_Exists_Call struct {
+	*mock.Call
+}
+
+// Exists is a helper method to define mock.On call
+//   - id string
+func (_e *Engine_Expecter) Exists(id interface{}) *Engine_Exists_Call {
+	return &Engine_Exists_Call{Call: _e.mock.On("Exists", id)}
+}
+
+func (_c *Engine_Exists_Call) Run(run func(id string)) *Engine_Exists_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Engine_Exists_Call) Return(b bool, err error) *Engine_Exists_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *Engine_Exists_Call) RunAndReturn(run func(id string) (bool, error)) *Engine_Exists_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Move provides a mock function for the type Engine
 func (_mock *Engine) Move(id string, parentid string, target string) error {
 	ret := _mock.Called(id, parentid, target)
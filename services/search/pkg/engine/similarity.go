@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+
+	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+)
+
+const (
+	_defaultSimilarityWeight = 0.5
+	_defaultNeighbours       = 200
+	_maxNeighbours           = 1000
+)
+
+// ValidateSimilarity checks that the similarity refers to a vector or resource and that its weight is a share
+func ValidateSimilarity(s *searchMessage.Similarity) error {
+	switch {
+	case s == nil:
+		return nil
+	case len(s.GetVector()) == 0 && s.GetResourceId() == nil:
+		return errtypes.BadRequest("similarity needs a vector or a resource")
+	case s.GetWeight() < 0 || s.GetWeight() > 1:
+		return errtypes.BadRequest(fmt.Sprintf("similarity weight must be between 0 and 1: %v", s.GetWeight()))
+	}
+
+	return nil
+}
+
+// SimilarityWeight returns the share of the vector similarity in the score of a match
+func SimilarityWeight(s *searchMessage.Similarity) float64 {
+	if s.GetWeight() == 0 {
+		return _defaultSimilarityWeight
+	}
+
+	return float64(s.GetWeight())
+}
+
+// Neighbours returns how many nearest neighbours of the vector are searched for the page size
+func Neighbours(pageSize int32) int {
+	switch {
+	case pageSize == 0:
+		return _defaultNeighbours
+	case pageSize < 0 || pageSize > _maxNeighbours:
+		return _maxNeighbours
+	default:
+		return int(pageSize)
+	}
+}
+
+// HybridScore mixes the score of the query, relative to the best score of the query, with the
+// similarity of the vectors. Neighbours which do not match the query have no query score.
+func HybridScore(queryScore, maxQueryScore, similarity, weight float64) float64 {
+	relativeScore := 0.0
+	if maxQueryScore > 0 {
+		relativeScore = queryScore / maxQueryScore
+	}
+
+	return (1-weight)*relativeScore + weight*similarity
+}
@@ -0,0 +1,30 @@
+// Package event contains the events the search service emits.
+package event
+
+import (
+	"encoding/json"
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// SavedSearchMatched is emitted when a newly indexed resource matches a saved search with an alert
+type SavedSearchMatched struct {
+	SavedSearchID   string
+	SavedSearchName string
+	Query           string
+	UserID          *user.UserId
+	ResourceID      *provider.ResourceId
+	ResourceName    string
+	// Email is set if the user also wants to be alerted by email
+	Email     bool
+	Timestamp time.Time
+}
+
+// Unmarshal to fulfill umarshaller interface
+func (SavedSearchMatched) Unmarshal(v []byte) (interface{}, error) {
+	e := SavedSearchMatched{}
+	err := json.Unmarshal(v, &e)
+	return e, err
+}
@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
 	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
 	"github.com/opencloud-eu/reva/v2/pkg/utils"
 	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
@@ -17,6 +20,7 @@ import (
 	"github.com/opencloud-eu/opencloud/pkg/conversions"
 	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
 	searchService "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/embedding"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/engine"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/convert"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
@@ -31,7 +35,8 @@ type Backend struct {
 	client *opensearchgoAPI.Client
 }
 
-func NewBackend(index string, client *opensearchgoAPI.Client) (*Backend, error) {
+// NewBackend creates a new Backend instance, the index stores embeddings if the dimensions are greater than 0
+func NewBackend(index string, client *opensearchgoAPI.Client, embeddingDimensions int) (*Backend, error) {
 	pingResp, err := client.Ping(context.TODO(), &opensearchgoAPI.PingReq{})
 	switch {
 	case err != nil:
@@ -41,8 +46,12 @@ func NewBackend(index string, client *opensearchgoAPI.Client) (*Backend, error)
 	}
 
 	// apply the index template
-	if err := IndexManagerLatest.Apply(context.TODO(), index, client); err != nil {
-		return nil, fmt.Errorf("failed to apply index template: %w", err)
+	applyErr := IndexManagerLatest.Apply(context.TODO(), index, client)
+	if embeddingDimensions > 0 {
+		applyErr = IndexManagerLatest.ApplyWithEmbedding(context.TODO(), index, client, embeddingDimensions)
+	}
+	if applyErr != nil {
+		return nil, fmt.Errorf("failed to apply index template: %w", applyErr)
 	}
 
 	// first check if the cluster is healthy
@@ -67,19 +76,41 @@ func NewBackend(index string, client *opensearchgoAPI.Client) (*Backend, error)
 }
 
 func (be *Backend) Search(ctx context.Context, sir *searchService.SearchIndexRequest) (*searchService.SearchIndexResponse, error) {
-	boolQuery, err := convert.KQLToOpenSearchBoolQuery(sir.Query)
+	if err := engine.ValidateFacets(sir.GetFacets()); err != nil {
+		return nil, err
+	}
+	if err := engine.ValidateSimilarity(sir.GetSimilarity()); err != nil {
+		return nil, err
+	}
+
+	vector, err := be.similarityVector(sir.GetSimilarity())
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert KQL query to OpenSearch bool query: %w", err)
+		return nil, err
 	}
 
-	// filter out deleted resources
-	boolQuery.Filter(
-		osu.NewTermQuery[bool]("Deleted").Value(false),
-	)
+	// the query is optional if the resources are searched by their similarity
+	hasQuery := sir.Query != "" || vector == nil
+	boolQuery := osu.NewBoolQuery()
+	if hasQuery {
+		boolQuery, err = convert.KQLToOpenSearchBoolQuery(sir.Query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert KQL query to OpenSearch bool query: %w", err)
+		}
+	}
+
+	// filter out deleted resources, unless the trash is searched
+	filters := []osu.Builder{
+		osu.NewTermQuery[bool]("Deleted").Value(sir.GetTrashed()),
+	}
+
+	if !sir.GetVersions() {
+		// the previous versions of the files are only searched on request
+		filters = append(filters, osu.NewBoolQuery().MustNot(osu.NewExistsQuery("VersionKey")))
+	}
 
 	if sir.Ref != nil {
 		// if a reference is provided, filter by the root ID
-		boolQuery.Filter(
+		filters = append(filters,
 			osu.NewTermQuery[string]("RootID").Value(
 				storagespace.FormatResourceID(
 					&storageProvider.ResourceId{
@@ -90,6 +121,28 @@ func (be *Backend) Search(ctx context.Context, sir *searchService.SearchIndexReq
 				),
 			),
 		)
+
+		// limit the query to the requested path so that the aggregations only count resources below it,
+		// the path hierarchy analyzer indexes every parent path of a resource in lowercase
+		if requestedPath := utils.MakeRelativePath(sir.Ref.Path); requestedPath != "." {
+			filters = append(filters,
+				osu.NewTermQuery[string]("Path").Value(strings.ToLower(requestedPath)),
+			)
+		}
+	}
+
+	var notSimilarResource osu.Builder
+	if id := sir.GetSimilarity().GetResourceId(); id != nil {
+		// a resource is no result of the search for similar resources
+		notSimilarResource = osu.NewIDsQuery(similarityResourceID(sir.GetSimilarity()))
+		boolQuery.MustNot(notSimilarResource)
+	}
+
+	boolQuery.Filter(filters...)
+
+	aggregations, err := convert.FacetsToOpenSearchAggregations(sir.GetFacets())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build aggregations: %w", err)
 	}
 
 	searchParams := opensearchgoAPI.SearchParams{}
@@ -103,6 +156,15 @@ func (be *Backend) Search(ctx context.Context, sir *searchService.SearchIndexReq
 		searchParams.Size = conversions.ToPointer(int(sir.PageSize))
 	}
 
+	if vector != nil {
+		filter := osu.NewBoolQuery().Filter(filters...)
+		if notSimilarResource != nil {
+			filter.MustNot(notSimilarResource)
+		}
+
+		return be.searchBySimilarity(ctx, sir, boolQuery, hasQuery, filter, vector, aggregations, *searchParams.Size)
+	}
+
 	req, err := osu.BuildSearchReq(&opensearchgoAPI.SearchReq{
 		Indices: []string{be.index},
 		Params:  searchParams,
@@ -116,6 +178,7 @@ func (be *Backend) Search(ctx context.Context, sir *searchService.SearchIndexReq
 					"Content": {},
 				},
 			},
+			Aggregations: aggregations,
 		},
 	)
 	if err != nil {
@@ -135,26 +198,193 @@ func (be *Backend) Search(ctx context.Context, sir *searchService.SearchIndexReq
 			return nil, fmt.Errorf("failed to convert hit to match: %w", err)
 		}
 
-		if sir.Ref != nil {
-			hitPath := strings.TrimSuffix(match.GetEntity().GetRef().GetPath(), "/")
-			requestedPath := utils.MakeRelativePath(sir.Ref.Path)
-			isRoot := hitPath == requestedPath
+		if isOutsideRequestedPath(sir, match) {
+			totalMatches--
+			continue
+		}
+
+		matches = append(matches, match)
+	}
+
+	facets, err := convert.OpenSearchAggregationsToFacets(sir.GetFacets(), resp.Aggregations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert aggregations to facets: %w", err)
+	}
+
+	return &searchService.SearchIndexResponse{
+		Matches:      matches,
+		TotalMatches: int32(totalMatches),
+		Facets:       facets,
+	}, nil
+}
+
+// searchBySimilarity merges the best matches of the query with the nearest neighbours of the vector
+// and ranks them by their hybrid score, the neighbours are results whether they match the query or not
+func (be *Backend) searchBySimilarity(ctx context.Context, sir *searchService.SearchIndexRequest, boolQuery *osu.BoolQuery, hasQuery bool, filter osu.Builder, vector []float32, aggregations map[string]any, size int) (*searchService.SearchIndexResponse, error) {
+	k := engine.Neighbours(sir.PageSize)
+	neighboursResp, err := be.search(ctx, osu.NewKNNQuery("Embedding").Vector(vector).K(k).Filter(filter), osu.SearchBodyParams{}, k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the nearest neighbours: %w", err)
+	}
+
+	ids := make([]string, 0, len(neighboursResp.Hits.Hits))
+	for _, hit := range neighboursResp.Hits.Hits {
+		ids = append(ids, hit.ID)
+	}
+
+	if len(ids) == 0 && !hasQuery {
+		facets, err := convert.OpenSearchAggregationsToFacets(sir.GetFacets(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert aggregations to facets: %w", err)
+		}
+
+		return &searchService.SearchIndexResponse{Facets: facets}, nil
+	}
 
-			if !isRoot && requestedPath != "." && !strings.HasPrefix(hitPath, requestedPath+"/") {
-				totalMatches--
-				continue
-			}
+	var queryHits opensearchgoAPI.SearchHits
+	union := osu.NewBoolQuery().Should(osu.NewIDsQuery(ids...))
+	if hasQuery {
+		queryResp, err := be.search(ctx, boolQuery, osu.SearchBodyParams{
+			Highlight: &osu.BodyParamHighlight{
+				PreTags:  []string{"<mark>"},
+				PostTags: []string{"</mark>"},
+				Fields: map[string]osu.BodyParamHighlight{
+					"Content": {},
+				},
+			},
+		}, size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search: %w", err)
 		}
 
+		queryHits = queryResp.Hits
+		union.Should(boolQuery)
+	}
+
+	// count and aggregate the matches of the query together with the neighbours
+	unionResp, err := be.search(ctx, union, osu.SearchBodyParams{Aggregations: aggregations}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count the matches: %w", err)
+	}
+
+	weight := engine.SimilarityWeight(sir.GetSimilarity())
+	matches := make([]*searchMessage.Match, 0, len(queryHits.Hits)+len(neighboursResp.Hits.Hits))
+	totalMatches := unionResp.Hits.Total.Value
+	seen := make(map[string]bool, len(queryHits.Hits))
+	rank := func(hit opensearchgoAPI.SearchHit, queryScore float32) error {
+		seen[hit.ID] = true
+
+		match, err := convert.OpenSearchHitToMatch(hit)
+		if err != nil {
+			return fmt.Errorf("failed to convert hit to match: %w", err)
+		}
+
+		if isOutsideRequestedPath(sir, match) {
+			totalMatches--
+			return nil
+		}
+
+		resource, err := conversions.To[engine.Resource](hit.Source)
+		if err != nil {
+			return fmt.Errorf("failed to convert hit source: %w", err)
+		}
+
+		similarity := embedding.CosineSimilarity(vector, resource.Embedding)
+		match.Score = float32(engine.HybridScore(float64(queryScore), float64(queryHits.MaxScore), similarity, weight))
 		matches = append(matches, match)
+		return nil
+	}
+
+	for _, hit := range queryHits.Hits {
+		if err := rank(hit, hit.Score); err != nil {
+			return nil, err
+		}
+	}
+	for _, hit := range neighboursResp.Hits.Hits {
+		if seen[hit.ID] {
+			continue
+		}
+		if err := rank(hit, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].GetScore() > matches[j].GetScore()
+	})
+	if len(matches) > size {
+		matches = matches[:size]
+	}
+
+	facets, err := convert.OpenSearchAggregationsToFacets(sir.GetFacets(), unionResp.Aggregations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert aggregations to facets: %w", err)
 	}
 
 	return &searchService.SearchIndexResponse{
 		Matches:      matches,
 		TotalMatches: int32(totalMatches),
+		Facets:       facets,
 	}, nil
 }
 
+// similarityVector returns the vector of the similarity, which is the embedding of the resource if
+// the search service did not provide a vector. It is nil if the search is not by similarity.
+func (be *Backend) similarityVector(s *searchMessage.Similarity) ([]float32, error) {
+	switch {
+	case s == nil:
+		return nil, nil
+	case len(s.GetVector()) > 0:
+		return s.GetVector(), nil
+	}
+
+	resource, err := be.getResource(similarityResourceID(s))
+	switch {
+	case err != nil:
+		return nil, errtypes.NotFound("similar resource not found")
+	case len(resource.Embedding) == 0:
+		return nil, errtypes.BadRequest("similar resource has no embedding")
+	}
+
+	return resource.Embedding, nil
+}
+
+func (be *Backend) search(ctx context.Context, q osu.Builder, params osu.SearchBodyParams, size int) (*opensearchgoAPI.SearchResp, error) {
+	req, err := osu.BuildSearchReq(&opensearchgoAPI.SearchReq{
+		Indices: []string{be.index},
+		Params:  opensearchgoAPI.SearchParams{Size: conversions.ToPointer(size)},
+	},
+		q,
+		params,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build search request: %w", err)
+	}
+
+	return be.client.Search(ctx, req)
+}
+
+func similarityResourceID(s *searchMessage.Similarity) string {
+	return storagespace.FormatResourceID(&storageProvider.ResourceId{
+		StorageId: s.GetResourceId().GetStorageId(),
+		SpaceId:   s.GetResourceId().GetSpaceId(),
+		OpaqueId:  s.GetResourceId().GetOpaqueId(),
+	})
+}
+
+// isOutsideRequestedPath returns true if the match is not located below the path of the reference
+func isOutsideRequestedPath(sir *searchService.SearchIndexRequest, match *searchMessage.Match) bool {
+	if sir.Ref == nil {
+		return false
+	}
+
+	hitPath := strings.TrimSuffix(match.GetEntity().GetRef().GetPath(), "/")
+	requestedPath := utils.MakeRelativePath(sir.Ref.Path)
+	isRoot := hitPath == requestedPath
+
+	return !isRoot && requestedPath != "." && !strings.HasPrefix(hitPath, requestedPath+"/")
+}
+
 func (be *Backend) Upsert(id string, r engine.Resource) error {
 	body, err := json.Marshal(r)
 	if err != nil {
@@ -246,6 +476,21 @@ func (be *Backend) Purge(id string) error {
 	return nil
 }
 
+func (be *Backend) Exists(id string) (bool, error) {
+	resp, err := be.client.Document.Exists(context.TODO(), opensearchgoAPI.DocumentExistsReq{
+		Index:      be.index,
+		DocumentID: id,
+	})
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to check the document: %w", err)
+	}
+
+	return true, nil
+}
+
 func (be *Backend) DocCount() (uint64, error) {
 	req, err := osu.BuildIndicesCountReq(
 		&opensearchgoAPI.IndicesCountReq{
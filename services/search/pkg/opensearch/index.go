@@ -12,6 +12,7 @@ import (
 	"github.com/go-jose/go-jose/v3/json"
 	opensearchgoAPI "github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 var (
@@ -53,6 +54,43 @@ func (m IndexManager) Apply(ctx context.Context, name string, client *opensearch
 		return fmt.Errorf("failed to marshal index %s: %w", name, err)
 	}
 
+	return applyIndex(ctx, name, client, localIndexB)
+}
+
+// ApplyWithEmbedding applies the index extended by the k-NN vector field of the embeddings,
+// an index which was created without the field or with other dimensions has to be recreated.
+func (m IndexManager) ApplyWithEmbedding(ctx context.Context, name string, client *opensearchgoAPI.Client, dimensions int) error {
+	localIndexB, err := m.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal index %s: %w", name, err)
+	}
+
+	localIndexB, err = sjson.SetBytes(localIndexB, "settings.knn", "true")
+	if err != nil {
+		return fmt.Errorf("failed to enable k-NN for index %s: %w", name, err)
+	}
+
+	localIndexB, err = sjson.SetBytes(localIndexB, "mappings.properties.Embedding", map[string]any{
+		"type":      "knn_vector",
+		"dimension": dimensions,
+		"method": map[string]any{
+			"name":       "hnsw",
+			"space_type": "cosinesimil",
+			"engine":     "lucene",
+			"parameters": map[string]any{
+				"ef_construction": 100,
+				"m":               16,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add the embedding to index %s: %w", name, err)
+	}
+
+	return applyIndex(ctx, name, client, localIndexB)
+}
+
+func applyIndex(ctx context.Context, name string, client *opensearchgoAPI.Client, localIndexB []byte) error {
 	indicesExistsResp, err := client.Indices.Exists(ctx, opensearchgoAPI.IndicesExistsReq{
 		Indices: []string{name},
 	})
@@ -87,6 +87,7 @@ func OpenSearchHitToMatch(hit opensearchgoAPI.SearchHit) (*searchMessage.Match,
 				photo, _ := conversions.To[*searchMessage.Photo](resource.Photo)
 				return photo
 			}(),
+			VersionKey: resource.VersionKey,
 		},
 	}
 
@@ -0,0 +1,137 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+
+	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/engine"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
+)
+
+// FacetsToOpenSearchAggregations builds the aggregations of the requested facets,
+// the media type groups are filters compiled from their kql queries
+func FacetsToOpenSearchAggregations(frs []*searchMessage.FacetRequest) (map[string]any, error) {
+	if len(frs) == 0 {
+		return nil, nil
+	}
+
+	aggregations := make(map[string]any, len(frs))
+	for _, fr := range frs {
+		switch fr.GetField() {
+		case engine.FacetTags:
+			aggregations[fr.GetField()] = map[string]any{
+				"terms": map[string]any{"field": "Tags.keyword", "size": engine.TermFacetSize(fr)},
+			}
+		case engine.FacetSpace:
+			aggregations[fr.GetField()] = map[string]any{
+				"terms": map[string]any{"field": "RootID", "size": engine.TermFacetSize(fr)},
+			}
+		case engine.FacetSize:
+			ranges := make([]map[string]any, 0, len(engine.SizeFacetRanges))
+			for _, r := range engine.SizeFacetRanges {
+				ranges = append(ranges, rangeBounds(r.Name, r.From, r.To))
+			}
+			aggregations[fr.GetField()] = map[string]any{
+				"range": map[string]any{"field": "Size", "ranges": ranges},
+			}
+		case engine.FacetMtime:
+			mtimeRanges, err := engine.MtimeFacetRanges()
+			if err != nil {
+				return nil, err
+			}
+			ranges := make([]map[string]any, 0, len(mtimeRanges))
+			for _, r := range mtimeRanges {
+				// the bounds are passed as epoch milliseconds
+				from, to := r.From.UnixMilli(), r.To.UnixMilli()
+				ranges = append(ranges, rangeBounds(r.Name, &from, &to))
+			}
+			aggregations[fr.GetField()] = map[string]any{
+				"date_range": map[string]any{"field": "Mtime", "ranges": ranges},
+			}
+		case engine.FacetMediaType:
+			filters := make(map[string]osu.Builder, len(engine.MediaTypeFacetBuckets))
+			for _, group := range engine.MediaTypeFacetBuckets {
+				q, err := KQLToOpenSearchBoolQuery(engine.FacetMediaType + ":" + group)
+				if err != nil {
+					return nil, err
+				}
+				filters[group] = q
+			}
+			aggregations[fr.GetField()] = map[string]any{
+				"filters": map[string]any{"filters": filters},
+			}
+		default:
+			return nil, fmt.Errorf("unsupported facet: %s", fr.GetField())
+		}
+	}
+
+	return aggregations, nil
+}
+
+func rangeBounds[T any](key string, from, to *T) map[string]any {
+	bounds := map[string]any{"key": key}
+	if from != nil {
+		bounds["from"] = *from
+	}
+	if to != nil {
+		bounds["to"] = *to
+	}
+	return bounds
+}
+
+type openSearchAggregation struct {
+	Buckets          json.RawMessage `json:"buckets"`
+	SumOtherDocCount int64           `json:"sum_other_doc_count"`
+}
+
+type openSearchBucket struct {
+	Key      any   `json:"key"`
+	DocCount int64 `json:"doc_count"`
+}
+
+// OpenSearchAggregationsToFacets converts the aggregations of a search response into the requested facets
+func OpenSearchAggregationsToFacets(frs []*searchMessage.FacetRequest, raw json.RawMessage) ([]*searchMessage.Facet, error) {
+	if len(frs) == 0 {
+		return nil, nil
+	}
+
+	aggregations := map[string]openSearchAggregation{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &aggregations); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal aggregations: %w", err)
+		}
+	}
+
+	facets := make([]*searchMessage.Facet, 0, len(frs))
+	for _, fr := range frs {
+		facet := &searchMessage.Facet{Field: fr.GetField()}
+		aggregation, ok := aggregations[fr.GetField()]
+
+		switch {
+		case !ok:
+		case fr.GetField() == engine.FacetMediaType:
+			// the buckets of filters aggregations are keyed by the name of the filter
+			var buckets map[string]openSearchBucket
+			if err := json.Unmarshal(aggregation.Buckets, &buckets); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal %s buckets: %w", fr.GetField(), err)
+			}
+			for _, group := range engine.MediaTypeFacetBuckets {
+				facet.Buckets = append(facet.Buckets, &searchMessage.FacetBucket{Key: group, Count: buckets[group].DocCount})
+			}
+		default:
+			var buckets []openSearchBucket
+			if err := json.Unmarshal(aggregation.Buckets, &buckets); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal %s buckets: %w", fr.GetField(), err)
+			}
+			for _, bucket := range buckets {
+				facet.Buckets = append(facet.Buckets, &searchMessage.FacetBucket{Key: fmt.Sprint(bucket.Key), Count: bucket.DocCount})
+			}
+			facet.Other = aggregation.SumOtherDocCount
+		}
+
+		facets = append(facets, facet)
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,101 @@
+package convert_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	searchMessage "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/engine"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/convert"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/test"
+)
+
+func TestFacetsToOpenSearchAggregations(t *testing.T) {
+	t.Run("no facets", func(t *testing.T) {
+		aggregations, err := convert.FacetsToOpenSearchAggregations(nil)
+		require.NoError(t, err)
+		assert.Nil(t, aggregations)
+	})
+
+	t.Run("term and range facets", func(t *testing.T) {
+		aggregations, err := convert.FacetsToOpenSearchAggregations([]*searchMessage.FacetRequest{
+			{Field: "tags", Size: 5},
+			{Field: "space"},
+			{Field: "size"},
+		})
+		require.NoError(t, err)
+
+		got := opensearchtest.JSONMustMarshal(t, aggregations)
+		assert.JSONEq(t, `{"terms": {"field": "Tags.keyword", "size": 5}}`, opensearchtest.JSONMustMarshal(t, aggregations["tags"]))
+		assert.JSONEq(t, `{"terms": {"field": "RootID", "size": 10}}`, opensearchtest.JSONMustMarshal(t, aggregations["space"]))
+		assert.Contains(t, got, `{"from":1048576,"key":"medium","to":134217728}`)
+		assert.Contains(t, got, `{"from":4294967296,"key":"gigantic"}`)
+	})
+
+	t.Run("mtime facet", func(t *testing.T) {
+		aggregations, err := convert.FacetsToOpenSearchAggregations([]*searchMessage.FacetRequest{{Field: "mtime"}})
+		require.NoError(t, err)
+
+		var got struct {
+			DateRange struct {
+				Field  string `json:"field"`
+				Ranges []struct {
+					Key  string `json:"key"`
+					From int64  `json:"from"`
+					To   int64  `json:"to"`
+				} `json:"ranges"`
+			} `json:"date_range"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(opensearchtest.JSONMustMarshal(t, aggregations["mtime"])), &got))
+		assert.Equal(t, "Mtime", got.DateRange.Field)
+		require.Len(t, got.DateRange.Ranges, 10)
+		assert.Equal(t, "today", got.DateRange.Ranges[0].Key)
+		assert.Less(t, got.DateRange.Ranges[0].From, got.DateRange.Ranges[0].To)
+	})
+
+	t.Run("media type facet", func(t *testing.T) {
+		aggregations, err := convert.FacetsToOpenSearchAggregations([]*searchMessage.FacetRequest{{Field: "mediatype"}})
+		require.NoError(t, err)
+
+		pdf, err := convert.KQLToOpenSearchBoolQuery("mediatype:pdf")
+		require.NoError(t, err)
+
+		var got struct {
+			Filters struct {
+				Filters map[string]json.RawMessage `json:"filters"`
+			} `json:"filters"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(opensearchtest.JSONMustMarshal(t, aggregations["mediatype"])), &got))
+		assert.Len(t, got.Filters.Filters, len(engine.MediaTypeFacetBuckets))
+		assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, pdf), string(got.Filters.Filters["pdf"]))
+	})
+}
+
+func TestOpenSearchAggregationsToFacets(t *testing.T) {
+	frs := []*searchMessage.FacetRequest{{Field: "mediatype"}, {Field: "tags"}, {Field: "size"}, {Field: "space"}}
+	facets, err := convert.OpenSearchAggregationsToFacets(frs, json.RawMessage(`{
+		"mediatype": {"buckets": {"pdf": {"doc_count": 2}, "folder": {"doc_count": 1}}},
+		"tags": {"sum_other_doc_count": 3, "buckets": [{"key": "foo", "doc_count": 4}, {"key": "bar", "doc_count": 1}]},
+		"size": {"buckets": [{"key": "empty", "from": 0, "to": 1, "doc_count": 1}, {"key": "tiny", "from": 1, "to": 16384, "doc_count": 0}]}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, facets, 4)
+
+	assert.Equal(t, "mediatype", facets[0].Field)
+	require.Len(t, facets[0].Buckets, len(engine.MediaTypeFacetBuckets))
+	assert.Equal(t, "file", facets[0].Buckets[0].Key)
+	assert.Equal(t, int64(0), facets[0].Buckets[0].Count)
+	assert.Equal(t, int64(1), facets[0].Buckets[1].Count)
+
+	assert.Equal(t, []*searchMessage.FacetBucket{{Key: "foo", Count: 4}, {Key: "bar", Count: 1}}, facets[1].Buckets)
+	assert.Equal(t, int64(3), facets[1].Other)
+
+	assert.Equal(t, []*searchMessage.FacetBucket{{Key: "empty", Count: 1}, {Key: "tiny", Count: 0}}, facets[2].Buckets)
+
+	// missing aggregations result in empty facets
+	assert.Equal(t, "space", facets[3].Field)
+	assert.Empty(t, facets[3].Buckets)
+}
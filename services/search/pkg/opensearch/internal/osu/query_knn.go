@@ -0,0 +1,60 @@
+package osu
+
+import (
+	"encoding/json"
+)
+
+type KNNQuery struct {
+	field  string
+	vector []float32
+	k      int
+	filter Builder
+}
+
+func NewKNNQuery(field string) *KNNQuery {
+	return &KNNQuery{field: field}
+}
+
+func (q *KNNQuery) Vector(v []float32) *KNNQuery {
+	q.vector = v
+	return q
+}
+
+func (q *KNNQuery) K(v int) *KNNQuery {
+	q.k = v
+	return q
+}
+
+func (q *KNNQuery) Filter(v Builder) *KNNQuery {
+	q.filter = v
+	return q
+}
+
+func (q *KNNQuery) Map() (map[string]any, error) {
+	base := make(map[string]any)
+
+	applyValue(base, "vector", q.vector)
+	applyValue(base, "k", q.k)
+
+	if isEmpty(base) {
+		return nil, nil
+	}
+
+	if err := applyBuilder(base, "filter", q.filter); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"knn": map[string]any{
+			q.field: base,
+		},
+	}, nil
+}
+
+func (q *KNNQuery) MarshalJSON() ([]byte, error) {
+	data, err := q.Map()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
@@ -0,0 +1,59 @@
+package osu_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/test"
+)
+
+func TestKNNQuery(t *testing.T) {
+	tests := []opensearchtest.TableTest[osu.Builder, map[string]any]{
+		{
+			Name: "empty",
+			Got:  osu.NewKNNQuery("Embedding"),
+			Want: nil,
+		},
+		{
+			Name: "vector",
+			Got:  osu.NewKNNQuery("Embedding").Vector([]float32{0.5, 1}).K(10),
+			Want: map[string]any{
+				"knn": map[string]any{
+					"Embedding": map[string]any{
+						"vector": []float32{0.5, 1},
+						"k":      10,
+					},
+				},
+			},
+		},
+		{
+			Name: "with filter",
+			Got: osu.NewKNNQuery("Embedding").Vector([]float32{0.5, 1}).K(10).Filter(
+				osu.NewTermQuery[bool]("Deleted").Value(false),
+			),
+			Want: map[string]any{
+				"knn": map[string]any{
+					"Embedding": map[string]any{
+						"vector": []float32{0.5, 1},
+						"k":      10,
+						"filter": map[string]any{
+							"term": map[string]any{
+								"Deleted": map[string]any{
+									"value": false,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, test.Want), opensearchtest.JSONMustMarshal(t, test.Got))
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package osu
+
+import (
+	"encoding/json"
+)
+
+type ExistsQuery struct {
+	field  string
+	params *ExistsQueryParams
+}
+
+type ExistsQueryParams struct {
+	Boost float32 `json:"boost,omitempty"`
+	Name  string  `json:"_name,omitempty"`
+}
+
+func NewExistsQuery(field string) *ExistsQuery {
+	return &ExistsQuery{field: field}
+}
+
+func (q *ExistsQuery) Params(v *ExistsQueryParams) *ExistsQuery {
+	q.params = v
+	return q
+}
+
+func (q *ExistsQuery) Map() (map[string]any, error) {
+	base, err := newBase(q.params)
+	if err != nil {
+		return nil, err
+	}
+
+	applyValue(base, "field", q.field)
+
+	if isEmpty(base) {
+		return nil, nil
+	}
+
+	return map[string]any{
+		"exists": base,
+	}, nil
+}
+
+func (q *ExistsQuery) MarshalJSON() ([]byte, error) {
+	data, err := q.Map()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
@@ -0,0 +1,46 @@
+package osu_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/osu"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch/internal/test"
+)
+
+func TestExistsQuery(t *testing.T) {
+	tests := []opensearchtest.TableTest[osu.Builder, map[string]any]{
+		{
+			Name: "empty",
+			Got:  osu.NewExistsQuery(""),
+			Want: nil,
+		},
+		{
+			Name: "no params",
+			Got:  osu.NewExistsQuery("VersionKey"),
+			Want: map[string]any{
+				"exists": map[string]any{
+					"field": "VersionKey",
+				},
+			},
+		},
+		{
+			Name: "params",
+			Got:  osu.NewExistsQuery("VersionKey").Params(&osu.ExistsQueryParams{Boost: 1.0, Name: "is-version"}),
+			Want: map[string]any{
+				"exists": map[string]any{
+					"field": "VersionKey",
+					"boost": 1.0,
+					"_name": "is-version",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.JSONEq(t, opensearchtest.JSONMustMarshal(t, test.Want), opensearchtest.JSONMustMarshal(t, test.Got))
+		})
+	}
+}
@@ -64,7 +64,8 @@ func BuildSearchReq(req *opensearchgoAPI.SearchReq, q Builder, p ...SearchBodyPa
 }
 
 type SearchBodyParams struct {
-	Highlight *BodyParamHighlight `json:"highlight,omitempty"`
+	Highlight    *BodyParamHighlight `json:"highlight,omitempty"`
+	Aggregations map[string]any      `json:"aggs,omitempty"`
 }
 
 //----------------------------------------------------------------------------//
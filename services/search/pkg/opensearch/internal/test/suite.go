@@ -43,6 +43,7 @@ func setupDefaultConfig() (*config.Config, error) {
 		cfg.TokenManager.JWTSecret = "any-jwt"
 		cfg.ServiceAccount.ServiceAccountID = "any-service-account-id"
 		cfg.ServiceAccount.ServiceAccountSecret = "any-service-account-secret"
+		cfg.SavedSearches.SystemUserAPIKey = "any-system-user-api-key"
 	}
 
 	if err := parser.ParseConfig(cfg); err != nil {
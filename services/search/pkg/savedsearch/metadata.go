@@ -0,0 +1,305 @@
+package savedsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+	"github.com/opencloud-eu/reva/v2/pkg/storage/utils/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
+)
+
+var (
+	savedSearchesSpaceID = "5b2a8e4c-3c8d-4f0e-9a51-7d6f0c2e8b13" // uuid.New().String()
+	rootFolderLocation   = "saved-searches"
+	// the alerts of the other instances of the service are picked up after this time
+	alertsTTL = time.Minute
+)
+
+// MetadataStore stores the saved searches of each user in a json file of the metadata storage
+type MetadataStore struct {
+	storage    metadata.Storage
+	maxPerUser int
+	logger     log.Logger
+
+	initialized  bool
+	alerts       map[string][]*searchmsg.SavedSearch
+	alertsLoaded time.Time
+
+	m sync.Mutex
+}
+
+// NewMetadataStore creates a new MetadataStore, the storage is initialized on first use
+func NewMetadataStore(storage metadata.Storage, maxPerUser int, logger log.Logger) *MetadataStore {
+	return &MetadataStore{
+		storage:    storage,
+		maxPerUser: maxPerUser,
+		logger:     logger,
+	}
+}
+
+// NewCS3Store creates a MetadataStore which stores the saved searches in the system storage
+func NewCS3Store(cfg config.SavedSearches, logger log.Logger) (*MetadataStore, error) {
+	storage, err := metadata.NewCS3Storage(cfg.GatewayAddress, cfg.StorageAddress, cfg.SystemUserID, cfg.SystemUserIDP, cfg.SystemUserAPIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMetadataStore(storage, cfg.MaxPerUser, logger), nil
+}
+
+// List returns the saved searches of a user
+func (s *MetadataStore) List(ctx context.Context, userID string) ([]*searchmsg.SavedSearch, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if err := s.init(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.load(ctx, userID)
+}
+
+// Save creates or updates a saved search of a user, a search without id is created
+func (s *MetadataStore) Save(ctx context.Context, userID string, search *searchmsg.SavedSearch) (*searchmsg.SavedSearch, error) {
+	if err := Validate(search); err != nil {
+		return nil, err
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if err := s.init(ctx); err != nil {
+		return nil, err
+	}
+
+	searches, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := proto.Clone(search).(*searchmsg.SavedSearch)
+	if saved.GetId() == "" {
+		if s.maxPerUser > 0 && len(searches) >= s.maxPerUser {
+			return nil, errtypes.BadRequest("the maximum number of saved searches is reached")
+		}
+
+		saved.Id = uuid.New().String()
+		saved.Ctime = timestamppb.Now()
+		searches = append(searches, saved)
+	} else {
+		i := index(searches, saved.GetId())
+		if i < 0 {
+			return nil, errtypes.NotFound("saved search not found")
+		}
+
+		// the creation time can not be changed, it keeps the alerts from reporting older resources
+		saved.Ctime = searches[i].GetCtime()
+		searches[i] = saved
+	}
+
+	if err := s.write(ctx, userID, searches); err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+// Delete removes a saved search of a user
+func (s *MetadataStore) Delete(ctx context.Context, userID, id string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	searches, err := s.load(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	i := index(searches, id)
+	if i < 0 {
+		return errtypes.NotFound("saved search not found")
+	}
+
+	return s.write(ctx, userID, append(searches[:i], searches[i+1:]...))
+}
+
+// Alerts returns the saved searches with an alert grouped by the id of their user,
+// the searches are cached and reloaded from the storage from time to time
+func (s *MetadataStore) Alerts(ctx context.Context) (map[string][]*searchmsg.SavedSearch, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.alerts == nil || time.Since(s.alertsLoaded) > alertsTTL {
+		if err := s.loadAlerts(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	alerts := make(map[string][]*searchmsg.SavedSearch, len(s.alerts))
+	for userID, searches := range s.alerts {
+		alerts[userID] = searches
+	}
+
+	return alerts, nil
+}
+
+func (s *MetadataStore) loadAlerts(ctx context.Context) error {
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	entries, err := s.storage.ReadDir(ctx, rootFolderLocation)
+	if err != nil {
+		return err
+	}
+
+	alerts := map[string][]*searchmsg.SavedSearch{}
+	for _, entry := range entries {
+		name := path.Base(entry)
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		userID, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+
+		searches, err := s.load(ctx, userID)
+		if err != nil {
+			// a broken file must not keep the other users from being alerted
+			s.logger.Error().Err(err).Str("user", userID).Msg("failed to load the saved searches of the user")
+			continue
+		}
+
+		if withAlert := filterAlerts(searches); len(withAlert) > 0 {
+			alerts[userID] = withAlert
+		}
+	}
+
+	s.alerts = alerts
+	s.alertsLoaded = time.Now()
+	return nil
+}
+
+// we need to lazy initialize the storage because the storage-system service might not be ready
+func (s *MetadataStore) init(ctx context.Context) error {
+	if s.initialized {
+		return nil
+	}
+
+	if err := s.storage.Init(ctx, savedSearchesSpaceID); err != nil {
+		return err
+	}
+
+	if err := s.storage.MakeDirIfNotExist(ctx, rootFolderLocation); err != nil {
+		return err
+	}
+
+	s.initialized = true
+	return nil
+}
+
+func (s *MetadataStore) load(ctx context.Context, userID string) ([]*searchmsg.SavedSearch, error) {
+	res, err := s.storage.Download(ctx, metadata.DownloadRequest{Path: userPath(userID)})
+	switch err.(type) {
+	case nil:
+		// continue
+	case errtypes.NotFound:
+		return []*searchmsg.SavedSearch{}, nil
+	default:
+		return nil, err
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(res.Content, &raw); err != nil {
+		return nil, err
+	}
+
+	searches := make([]*searchmsg.SavedSearch, 0, len(raw))
+	for _, r := range raw {
+		search := &searchmsg.SavedSearch{}
+		if err := protojson.Unmarshal(r, search); err != nil {
+			return nil, err
+		}
+		searches = append(searches, search)
+	}
+
+	return searches, nil
+}
+
+func (s *MetadataStore) write(ctx context.Context, userID string, searches []*searchmsg.SavedSearch) error {
+	if len(searches) == 0 {
+		if err := s.storage.Delete(ctx, userPath(userID)); err != nil {
+			return err
+		}
+	} else {
+		raw := make([]json.RawMessage, 0, len(searches))
+		for _, search := range searches {
+			r, err := protojson.Marshal(search)
+			if err != nil {
+				return err
+			}
+			raw = append(raw, r)
+		}
+
+		content, err := json.Marshal(raw)
+		if err != nil {
+			return err
+		}
+
+		if err := s.storage.SimpleUpload(ctx, userPath(userID), content); err != nil {
+			return err
+		}
+	}
+
+	// keep the cached alerts in sync with the changes of this instance
+	if s.alerts != nil {
+		if withAlert := filterAlerts(searches); len(withAlert) > 0 {
+			s.alerts[userID] = withAlert
+		} else {
+			delete(s.alerts, userID)
+		}
+	}
+
+	return nil
+}
+
+func userPath(userID string) string {
+	return path.Join(rootFolderLocation, url.PathEscape(userID)+".json")
+}
+
+func index(searches []*searchmsg.SavedSearch, id string) int {
+	for i, search := range searches {
+		if search.GetId() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func filterAlerts(searches []*searchmsg.SavedSearch) []*searchmsg.SavedSearch {
+	var withAlert []*searchmsg.SavedSearch
+	for _, search := range searches {
+		if search.GetAlert() {
+			withAlert = append(withAlert, search)
+		}
+	}
+	return withAlert
+}
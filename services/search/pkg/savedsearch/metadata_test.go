@@ -0,0 +1,146 @@
+package savedsearch_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+	"github.com/opencloud-eu/reva/v2/pkg/storage/utils/metadata"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/savedsearch"
+)
+
+var _ = Describe("MetadataStore", func() {
+	var (
+		store   *savedsearch.MetadataStore
+		storage metadata.Storage
+		ctx     = context.Background()
+	)
+
+	BeforeEach(func() {
+		var err error
+		storage, err = metadata.NewDiskStorage(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		store = savedsearch.NewMetadataStore(storage, 2, log.NopLogger())
+	})
+
+	Describe("Save", func() {
+		It("creates a saved search", func() {
+			saved, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "invoices", Query: "mediatype:pdf tag:invoice"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved.Id).ToNot(BeEmpty())
+			Expect(saved.Ctime).ToNot(BeNil())
+
+			searches, err := store.List(ctx, "user")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(searches).To(HaveLen(1))
+			Expect(searches[0].Id).To(Equal(saved.Id))
+			Expect(searches[0].Query).To(Equal("mediatype:pdf tag:invoice"))
+
+			searches, err = store.List(ctx, "otheruser")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(searches).To(BeEmpty())
+		})
+
+		It("updates a saved search and keeps its creation time", func() {
+			saved, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "invoices", Query: "tag:invoice"})
+			Expect(err).ToNot(HaveOccurred())
+
+			updated, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Id: saved.Id, Name: "pdf invoices", Query: "mediatype:pdf tag:invoice", Alert: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.Ctime.AsTime()).To(Equal(saved.Ctime.AsTime()))
+
+			searches, err := store.List(ctx, "user")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(searches).To(HaveLen(1))
+			Expect(searches[0].Name).To(Equal("pdf invoices"))
+			Expect(searches[0].Alert).To(BeTrue())
+		})
+
+		It("fails for unknown saved searches", func() {
+			_, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Id: "unknown", Name: "invoices", Query: "tag:invoice"})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.NotFound("")))
+		})
+
+		It("validates the saved search", func() {
+			_, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Query: "tag:invoice"})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+
+			_, err = store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "invoices", Query: "scope:storageid$spaceid!opaqueid"})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+
+			_, err = store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "invoices", Query: "AND tag:invoice"})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+
+			_, err = store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "trash", Query: "is:trashed"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("limits the number of saved searches", func() {
+			for _, name := range []string{"a", "b"} {
+				_, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Name: name, Query: "tag:" + name})
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			_, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "c", Query: "tag:c"})
+			Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes a saved search", func() {
+			saved, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "invoices", Query: "tag:invoice"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(store.Delete(ctx, "user", saved.Id)).To(Succeed())
+
+			searches, err := store.List(ctx, "user")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(searches).To(BeEmpty())
+		})
+
+		It("fails for unknown saved searches", func() {
+			err := store.Delete(ctx, "user", "unknown")
+			Expect(err).To(BeAssignableToTypeOf(errtypes.NotFound("")))
+		})
+	})
+
+	Describe("Alerts", func() {
+		It("returns the saved searches with an alert of all users", func() {
+			_, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "invoices", Query: "tag:invoice", Alert: true})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "reports", Query: "tag:report"})
+			Expect(err).ToNot(HaveOccurred())
+			saved, err := store.Save(ctx, "otheruser", &searchmsg.SavedSearch{Name: "photos", Query: "mediatype:image", Alert: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			alerts, err := store.Alerts(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alerts).To(HaveLen(2))
+			Expect(alerts["user"]).To(HaveLen(1))
+			Expect(alerts["user"][0].Name).To(Equal("invoices"))
+			Expect(alerts["otheruser"]).To(HaveLen(1))
+
+			Expect(store.Delete(ctx, "otheruser", saved.Id)).To(Succeed())
+
+			alerts, err = store.Alerts(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alerts).To(HaveLen(1))
+			Expect(alerts).To(HaveKey("user"))
+		})
+
+		It("skips the users whose saved searches can't be read", func() {
+			_, err := store.Save(ctx, "user", &searchmsg.SavedSearch{Name: "invoices", Query: "tag:invoice", Alert: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(storage.SimpleUpload(ctx, "saved-searches/broken.json", []byte("{"))).To(Succeed())
+
+			alerts, err := store.Alerts(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alerts).To(HaveLen(1))
+			Expect(alerts).To(HaveKey("user"))
+		})
+	})
+})
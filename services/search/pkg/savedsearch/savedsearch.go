@@ -0,0 +1,47 @@
+// Package savedsearch persists the named searches of the users and the alerts they subscribed to.
+package savedsearch
+
+import (
+	"context"
+	"strings"
+
+	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
+)
+
+// Store is the interface to the saved searches of the users
+type Store interface {
+	// List returns the saved searches of a user
+	List(ctx context.Context, userID string) ([]*searchmsg.SavedSearch, error)
+	// Save creates or updates a saved search of a user, a search without id is created
+	Save(ctx context.Context, userID string, s *searchmsg.SavedSearch) (*searchmsg.SavedSearch, error)
+	// Delete removes a saved search of a user
+	Delete(ctx context.Context, userID, id string) error
+	// Alerts returns the saved searches with an alert grouped by the id of their user
+	Alerts(ctx context.Context) (map[string][]*searchmsg.SavedSearch, error)
+}
+
+// Validate checks that the saved search has a name and a query the engines understand
+func Validate(s *searchmsg.SavedSearch) error {
+	if strings.TrimSpace(s.GetName()) == "" {
+		return errtypes.BadRequest("the saved search has no name")
+	}
+
+	query, _ := search.ParseScope(s.GetQuery())
+	query, trashed, versions := search.ParseTrashAndVersions(query)
+	switch {
+	case query == "" && (trashed || versions):
+		return nil
+	case query == "":
+		return errtypes.BadRequest("the saved search has no query")
+	}
+
+	if _, err := bleve.DefaultCreator.Create(query); err != nil {
+		return errtypes.BadRequest("invalid query: " + err.Error())
+	}
+
+	return nil
+}
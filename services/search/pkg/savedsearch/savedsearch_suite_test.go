@@ -0,0 +1,13 @@
+package savedsearch_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSavedSearch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SavedSearch Suite")
+}
@@ -0,0 +1,181 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	"github.com/opencloud-eu/reva/v2/pkg/utils"
+	"google.golang.org/grpc/metadata"
+
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/engine"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/event"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
+)
+
+// SavedSearches provides the saved searches the users want to be alerted about
+type SavedSearches interface {
+	// Alerts returns the saved searches with an alert grouped by the id of their user
+	Alerts(ctx context.Context) (map[string][]*searchmsg.SavedSearch, error)
+}
+
+func (s *Service) alertsEnabled() bool {
+	return s.savedSearches != nil && s.publisher != nil
+}
+
+// alert emits an event for every saved search with an alert which matches a resource that is new to the index,
+// only the users who can access the resource are alerted
+func (s *Service) alert(ctx context.Context, r engine.Resource, info *provider.ResourceInfo) {
+	alerts, err := s.savedSearches.Alerts(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to load the saved searches with alerts")
+		return
+	}
+	if len(alerts) == 0 {
+		return
+	}
+
+	var matcher *engine.Matcher
+	defer func() {
+		if matcher != nil {
+			_ = matcher.Close()
+		}
+	}()
+
+	mtime := utils.TSToTime(info.GetMtime())
+	for userID, searches := range alerts {
+		// the access is only checked once a saved search of the user matches
+		var checked, granted bool
+		for _, search := range searches {
+			// the resources which were modified before the search was saved are no news to the user
+			if mtime.Before(search.GetCtime().AsTime()) {
+				continue
+			}
+
+			query, scope := ParseScope(search.GetQuery())
+			query, trashed, _ := ParseTrashAndVersions(query)
+			if trashed {
+				// new resources are never trashed
+				continue
+			}
+			if query == "" {
+				query = "*"
+			}
+
+			ref, ok := s.alertScope(ctx, scope, info)
+			if !ok {
+				continue
+			}
+
+			if matcher == nil {
+				if matcher, err = engine.NewMatcher(r, bleve.DefaultCreator); err != nil {
+					s.logger.Error().Err(err).Str("id", r.ID).Msg("failed to create the matcher for the resource")
+					return
+				}
+			}
+
+			matched, err := matcher.Match(ctx, query, ref)
+			if err != nil {
+				s.logger.Error().Err(err).Str("savedSearch", search.GetId()).Msg("failed to match the saved search")
+				continue
+			}
+			if !matched {
+				continue
+			}
+
+			if !checked {
+				checked, granted = true, s.canAccess(userID, info.GetId())
+			}
+			if !granted {
+				break
+			}
+
+			if err := events.Publish(ctx, s.publisher, event.SavedSearchMatched{
+				SavedSearchID:   search.GetId(),
+				SavedSearchName: search.GetName(),
+				Query:           search.GetQuery(),
+				UserID:          &user.UserId{OpaqueId: userID},
+				ResourceID:      info.GetId(),
+				ResourceName:    info.GetName(),
+				Email:           search.GetAlertEmail(),
+				Timestamp:       time.Now(),
+			}); err != nil {
+				s.logger.Error().Err(err).Str("savedSearch", search.GetId()).Msg("failed to publish the saved search alert")
+			}
+		}
+	}
+}
+
+// canAccess checks if the user can access the resource by stating it as the user,
+// which covers the members of the space as well as the recipients of shares
+func (s *Service) canAccess(userID string, id *provider.ResourceId) bool {
+	gatewayClient, err := s.gatewaySelector.Next()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not retrieve client to check the access of the user")
+		return false
+	}
+
+	authRes, err := gatewayClient.Authenticate(context.Background(), &gateway.AuthenticateRequest{
+		Type:         "machine",
+		ClientId:     "userid:" + userID,
+		ClientSecret: s.machineAuthAPIKey,
+	})
+	if err != nil || authRes.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		s.logger.Error().Err(err).Str("user", userID).Str("message", authRes.GetStatus().GetMessage()).Msg("failed to authenticate the user")
+		return false
+	}
+
+	userCtx := metadata.AppendToOutgoingContext(context.Background(), revactx.TokenHeader, authRes.GetToken())
+	statRes, err := gatewayClient.Stat(userCtx, &provider.StatRequest{Ref: &provider.Reference{ResourceId: id}})
+	if err != nil {
+		s.logger.Error().Err(err).Str("user", userID).Msg("failed to stat the resource as the user")
+		return false
+	}
+
+	return statRes.GetStatus().GetCode() == rpc.Code_CODE_OK
+}
+
+// alertScope resolves the scope of a saved search to the reference the resource must be below,
+// the resources of other spaces are out of scope
+func (s *Service) alertScope(ctx context.Context, scope string, info *provider.ResourceInfo) (*searchmsg.Reference, bool) {
+	if scope == "" {
+		return nil, true
+	}
+
+	scopedID, err := storagespace.ParseID(scope)
+	if err != nil {
+		s.logger.Error().Err(err).Str("scope", scope).Msg("failed to parse the scope of the saved search")
+		return nil, false
+	}
+	if scopedID.GetSpaceId() != info.GetId().GetSpaceId() {
+		return nil, false
+	}
+
+	gatewayClient, err := s.gatewaySelector.Next()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not retrieve client to resolve the scope")
+		return nil, false
+	}
+
+	gpRes, err := gatewayClient.GetPath(ctx, &provider.GetPathRequest{ResourceId: &scopedID})
+	if err != nil || gpRes.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		s.logger.Error().Err(err).Str("scope", scope).Msg("failed to get the path of the scope")
+		return nil, false
+	}
+
+	return &searchmsg.Reference{
+		ResourceId: &searchmsg.ResourceID{
+			StorageId: info.GetId().GetStorageId(),
+			SpaceId:   info.GetId().GetSpaceId(),
+			OpaqueId:  info.GetId().GetSpaceId(),
+		},
+		Path: gpRes.GetPath(),
+	}, true
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
@@ -17,10 +19,20 @@ import (
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
 	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/engine"
 )
 
-var scopeRegex = regexp.MustCompile(`scope:\s*([^" "\n\r]*)`)
+var (
+	scopeRegex    = regexp.MustCompile(`scope:\s*([^" "\n\r]*)`)
+	trashedRegex  = regexp.MustCompile(`(?i)\bis:\s*trashed\b`)
+	versionsRegex = regexp.MustCompile(`(?i)\bversion:\s*any\b`)
+	// the operators which are left over when a keyword is cut from the query
+	danglingOperatorRegex = regexp.MustCompile(`^\s*(?:AND|OR)\s+|\s+(?:AND|OR)\s*$|\b(?:AND|OR)\s+(AND|OR)\b`)
+	// the quoted phrases of a query, the keywords are only cut outside of them
+	quotedRegex      = regexp.MustCompile(`"[^"]*"`)
+	quotedIndexRegex = regexp.MustCompile("\x00([0-9]+)\x00")
+)
 
 // ResolveReference makes sure the path is relative to the space root
 func ResolveReference(ctx context.Context, ref *provider.Reference, ri *provider.ResourceInfo, gatewaySelector pool.Selectable[gateway.GatewayAPIClient]) (*provider.Reference, error) {
@@ -61,6 +73,56 @@ func (ma matchArray) Less(i, j int) bool {
 	return ma[i].GetScore() > ma[j].GetScore()
 }
 
+// mergeFacets sums up the facets of the searched spaces in the requested order,
+// the term facets are cut to the requested size again and the rest is counted as other
+func mergeFacets(frs []*searchmsg.FacetRequest, responses []*searchsvc.SearchIndexResponse) []*searchmsg.Facet {
+	if len(frs) == 0 {
+		return nil
+	}
+
+	facets := make([]*searchmsg.Facet, 0, len(frs))
+	for _, fr := range frs {
+		facet := &searchmsg.Facet{Field: fr.GetField()}
+		counts := map[string]int64{}
+		for _, res := range responses {
+			for _, f := range res.GetFacets() {
+				if f.GetField() != fr.GetField() {
+					continue
+				}
+				facet.Other += f.GetOther()
+				for _, bucket := range f.GetBuckets() {
+					if _, ok := counts[bucket.GetKey()]; !ok {
+						facet.Buckets = append(facet.Buckets, &searchmsg.FacetBucket{Key: bucket.GetKey()})
+					}
+					counts[bucket.GetKey()] += bucket.GetCount()
+				}
+			}
+		}
+		for _, bucket := range facet.Buckets {
+			bucket.Count = counts[bucket.Key]
+		}
+
+		if engine.IsTermFacet(fr.GetField()) {
+			sort.SliceStable(facet.Buckets, func(i, j int) bool {
+				if facet.Buckets[i].Count != facet.Buckets[j].Count {
+					return facet.Buckets[i].Count > facet.Buckets[j].Count
+				}
+				return facet.Buckets[i].Key < facet.Buckets[j].Key
+			})
+			if size := engine.TermFacetSize(fr); len(facet.Buckets) > size {
+				for _, bucket := range facet.Buckets[size:] {
+					facet.Other += bucket.Count
+				}
+				facet.Buckets = facet.Buckets[:size]
+			}
+		}
+
+		facets = append(facets, facet)
+	}
+
+	return facets
+}
+
 func logDocCount(engine engine.Engine, logger log.Logger) {
 	c, err := engine.DocCount()
 	if err != nil {
@@ -158,3 +220,38 @@ func ParseScope(query string) (string, string) {
 	}
 	return query, ""
 }
+
+// ParseTrashAndVersions extracts the is:trashed and version:any keywords from the query string and returns
+// the remaining query and whether the trash and the previous versions of the files are searched.
+// The keywords are only recognized outside of quoted phrases.
+func ParseTrashAndVersions(query string) (string, bool, bool) {
+	// the quoted phrases are replaced by their index while the keywords are cut
+	var quoted []string
+	masked := quotedRegex.ReplaceAllStringFunc(query, func(phrase string) string {
+		quoted = append(quoted, phrase)
+		return fmt.Sprintf("\x00%d\x00", len(quoted)-1)
+	})
+
+	trashed := trashedRegex.MatchString(masked)
+	versions := versionsRegex.MatchString(masked)
+	if !trashed && !versions {
+		return query, false, false
+	}
+
+	masked = versionsRegex.ReplaceAllString(trashedRegex.ReplaceAllString(masked, ""), "")
+	masked = strings.Join(strings.Fields(masked), " ")
+	for {
+		cleaned := danglingOperatorRegex.ReplaceAllString(masked, "$1")
+		if cleaned == masked {
+			break
+		}
+		masked = cleaned
+	}
+
+	query = quotedIndexRegex.ReplaceAllStringFunc(masked, func(index string) string {
+		i, _ := strconv.Atoi(strings.Trim(index, "\x00"))
+		return quoted[i]
+	})
+
+	return strings.TrimSpace(query), trashed, versions
+}
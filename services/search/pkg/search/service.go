@@ -8,21 +8,25 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	rpcv1beta1 "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	collaborationv1beta1 "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	libregraph "github.com/opencloud-eu/libre-graph-api-go"
 	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
 	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	sdk "github.com/opencloud-eu/reva/v2/pkg/sdk/common"
 	"github.com/opencloud-eu/reva/v2/pkg/storage/utils/walker"
 	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
 	"github.com/opencloud-eu/reva/v2/pkg/utils"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
@@ -30,6 +34,7 @@ import (
 	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/embedding"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/engine"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/metrics"
 )
@@ -60,18 +65,45 @@ type Service struct {
 	gatewaySelector pool.Selectable[gateway.GatewayAPIClient]
 	engine          engine.Engine
 	extractor       content.Extractor
+	embedder        embedding.Embedder
+	savedSearches   SavedSearches
+	publisher       events.Publisher
 	metrics         *metrics.Metrics
 
 	serviceAccountID     string
 	serviceAccountSecret string
+	machineAuthAPIKey    string
 
-	batchSize int
+	batchSize     int
+	indexVersions bool
+
+	maxEmbeddingInputLength int
 }
 
 var errSkipSpace error
 
+// ServiceOption configures optional features of the Service
+type ServiceOption func(s *Service)
+
+// WithEmbedder sets the embedding provider of the Service.
+// Without it the resources have no embedding and can not be searched by the meaning of a text.
+func WithEmbedder(embedder embedding.Embedder) ServiceOption {
+	return func(s *Service) {
+		s.embedder = embedder
+	}
+}
+
+// WithSavedSearches sets the saved searches and the publisher of their alerts.
+// Without them no alerts are emitted for new resources.
+func WithSavedSearches(savedSearches SavedSearches, publisher events.Publisher) ServiceOption {
+	return func(s *Service) {
+		s.savedSearches = savedSearches
+		s.publisher = publisher
+	}
+}
+
 // NewService creates a new Provider instance.
-func NewService(gatewaySelector pool.Selectable[gateway.GatewayAPIClient], eng engine.Engine, extractor content.Extractor, metrics *metrics.Metrics, logger log.Logger, cfg *config.Config) *Service {
+func NewService(gatewaySelector pool.Selectable[gateway.GatewayAPIClient], eng engine.Engine, extractor content.Extractor, metrics *metrics.Metrics, logger log.Logger, cfg *config.Config, opts ...ServiceOption) *Service {
 	var s = &Service{
 		gatewaySelector: gatewaySelector,
 		engine:          eng,
@@ -81,8 +113,16 @@ func NewService(gatewaySelector pool.Selectable[gateway.GatewayAPIClient], eng e
 
 		serviceAccountID:     cfg.ServiceAccount.ServiceAccountID,
 		serviceAccountSecret: cfg.ServiceAccount.ServiceAccountSecret,
+		machineAuthAPIKey:    cfg.SavedSearches.MachineAuthAPIKey,
+
+		batchSize:     cfg.BatchSize,
+		indexVersions: cfg.IndexVersions,
 
-		batchSize: cfg.BatchSize,
+		maxEmbeddingInputLength: cfg.Embedding.MaxInputLength,
+	}
+
+	for _, o := range opts {
+		o(s)
 	}
 
 	return s
@@ -115,10 +155,21 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 
 	// Extract scope from query if set
 	query, scope := ParseScope(req.Query)
-	if query == "" {
+	query, trashed, versions := ParseTrashAndVersions(query)
+	switch {
+	case query == "" && (trashed || versions):
+		// the keywords alone list all trashed resources or versions
+		query = "*"
+	case query == "" && req.Similarity == nil:
 		return nil, errtypes.BadRequest("empty query provided")
 	}
 	req.Query = query
+	if err := engine.ValidateFacets(req.Facets); err != nil {
+		return nil, err
+	}
+	if err := s.prepareSimilarity(ctx, gatewayClient, req.Similarity); err != nil {
+		return nil, err
+	}
 	if len(scope) > 0 {
 		scopedID, err := storagespace.ParseID(scope)
 		if err != nil {
@@ -225,7 +276,7 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 	for i := 0; i < numWorkers; i++ {
 		errg.Go(func() error {
 			for space := range work {
-				res, err := s.searchIndex(ctx, req, space, mountpointMap[space.Id.OpaqueId])
+				res, err := s.searchIndex(ctx, req, space, mountpointMap[space.Id.OpaqueId], trashed, versions)
 				if err != nil && err != errSkipSpace {
 					return err
 				}
@@ -281,15 +332,65 @@ func (s *Service) Search(ctx context.Context, req *searchsvc.SearchRequest) (*se
 	return &searchsvc.SearchResponse{
 		Matches:      matches,
 		TotalMatches: total,
+		Facets:       mergeFacets(req.Facets, responses),
 	}, nil
 }
 
-func (s *Service) searchIndex(ctx context.Context, req *searchsvc.SearchRequest, space *provider.StorageSpace, mountpointID string) (*searchsvc.SearchIndexResponse, error) {
+// prepareSimilarity turns the text of the similarity into the vector the engines search with,
+// resources are only compared with a similar resource if the user can access it
+func (s *Service) prepareSimilarity(ctx context.Context, gatewayClient gateway.GatewayAPIClient, similarity *searchmsg.Similarity) error {
+	if similarity == nil {
+		return nil
+	}
+
+	similarity.Vector = nil
+	switch {
+	case similarity.GetText() != "" && similarity.GetResourceId() != nil:
+		return errtypes.BadRequest("similarity needs either a text or a resource")
+	case similarity.GetText() != "":
+		if s.embedder == nil {
+			return errtypes.BadRequest("semantic search is not enabled")
+		}
+
+		vector, err := s.embedder.Embed(ctx, similarity.GetText())
+		if err != nil {
+			return err
+		}
+		similarity.Vector = vector
+	case similarity.GetResourceId() != nil:
+		statRes, err := gatewayClient.Stat(ctx, &provider.StatRequest{
+			Ref: &provider.Reference{
+				ResourceId: &provider.ResourceId{
+					StorageId: similarity.GetResourceId().GetStorageId(),
+					SpaceId:   similarity.GetResourceId().GetSpaceId(),
+					OpaqueId:  similarity.GetResourceId().GetOpaqueId(),
+				},
+			},
+			FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"id"}},
+		})
+		switch {
+		case err != nil:
+			return err
+		case statRes.GetStatus().GetCode() != rpc.Code_CODE_OK:
+			return errtypes.NotFound("similar resource not found")
+		}
+	}
+
+	return engine.ValidateSimilarity(similarity)
+}
+
+// searchIndex searches a space, the trash and the previous versions of the files are only searched if requested
+// and if the user is allowed to list them
+func (s *Service) searchIndex(ctx context.Context, req *searchsvc.SearchRequest, space *provider.StorageSpace, mountpointID string, trashed, versions bool) (*searchsvc.SearchIndexResponse, error) {
 	if req.Ref != nil &&
 		(req.Ref.ResourceId.StorageId != space.Root.StorageId ||
 			req.Ref.ResourceId.SpaceId != space.Root.SpaceId) {
 		return nil, errSkipSpace
 	}
+	if trashed && space.SpaceType != _spaceTypePersonal && space.SpaceType != _spaceTypeProject {
+		// the trash of a space can not be listed by the recipients of a share
+		return nil, errSkipSpace
+	}
 
 	searchRootID := &searchmsg.ResourceID{
 		StorageId: space.Root.StorageId,
@@ -380,13 +481,23 @@ func (s *Service) searchIndex(ctx context.Context, req *searchsvc.SearchRequest,
 		permissions = space.GetRootInfo().GetPermissionSet()
 	}
 
+	if trashed && !permissions.GetListRecycle() {
+		return nil, errSkipSpace
+	}
+
 	searchRequest := &searchsvc.SearchIndexRequest{
 		Query: req.Query,
 		Ref: &searchmsg.Reference{
 			ResourceId: searchRootID,
 			Path:       searchPathPrefix,
 		},
-		PageSize: req.PageSize,
+		PageSize:   req.PageSize,
+		Facets:     req.Facets,
+		Similarity: req.Similarity,
+		Trashed:    trashed,
+		// the versions are left out of the spaces in which the user can not list them,
+		// the versions of trashed files can not be addressed
+		Versions: versions && !trashed && permissions.GetListFileVersions(),
 	}
 	start := time.Now()
 	res, err := s.engine.Search(ctx, searchRequest)
@@ -401,6 +512,13 @@ func (s *Service) searchIndex(ctx context.Context, req *searchsvc.SearchRequest,
 		s.logger.Debug().Interface("searchRequest", searchRequest).Str("duration", fmt.Sprint(duration)).Str("space", space.Id.OpaqueId).Int("hits", len(res.Matches)).Msg("space search done")
 	}
 
+	if trashed {
+		if err := s.setTrashItemKeys(ctx, space, res); err != nil {
+			s.logger.Error().Err(err).Str("space", space.Id.OpaqueId).Msg("failed to list the trash of the space")
+			return nil, errSkipSpace
+		}
+	}
+
 	matches := make([]*searchmsg.Match, 0, len(res.Matches))
 
 	for _, match := range res.Matches {
@@ -427,9 +545,73 @@ func (s *Service) searchIndex(ctx context.Context, req *searchsvc.SearchRequest,
 
 	res.Matches = matches
 
+	if mountpointRootID != nil {
+		// the space facet counts the resources of grants in the mountpoint the matches are mapped to
+		for _, facet := range res.Facets {
+			if facet.Field != engine.FacetSpace {
+				continue
+			}
+			for _, bucket := range facet.Buckets {
+				bucket.Key = storagespace.FormatResourceID(&provider.ResourceId{
+					StorageId: mountpointRootID.StorageId,
+					SpaceId:   mountpointRootID.SpaceId,
+					OpaqueId:  mountpointRootID.OpaqueId,
+				})
+			}
+		}
+	}
+
 	return res, nil
 }
 
+// setTrashItemKeys sets the keys of the trashed matches in the trash of the space. The resources in trashed
+// folders are addressed relative to the key of the folder. Matches which are no longer in the trash are removed.
+func (s *Service) setTrashItemKeys(ctx context.Context, space *provider.StorageSpace, res *searchsvc.SearchIndexResponse) error {
+	gatewayClient, err := s.gatewaySelector.Next()
+	if err != nil {
+		return err
+	}
+
+	lrRes, err := gatewayClient.ListRecycle(ctx, &provider.ListRecycleRequest{
+		Ref: &provider.Reference{ResourceId: space.GetRoot(), Path: "."},
+	})
+	switch {
+	case err != nil:
+		return err
+	case lrRes.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return errtypes.NewErrtypeFromStatus(lrRes.GetStatus())
+	}
+
+	matches := make([]*searchmsg.Match, 0, len(res.GetMatches()))
+	for _, match := range res.GetMatches() {
+		matchPath := utils.MakeRelativePath(match.GetEntity().GetRef().GetPath())
+
+		var key, itemPath string
+		for _, item := range lrRes.GetRecycleItems() {
+			if item.GetKey() == match.GetEntity().GetId().GetOpaqueId() {
+				// the storage keys the trash items by the id of the trashed resource
+				key, itemPath = item.GetKey(), matchPath
+				break
+			}
+			// otherwise the match is the item with the same path or lies in the trashed folder with the longest path
+			p := utils.MakeRelativePath(item.GetRef().GetPath())
+			if (p == matchPath || strings.HasPrefix(matchPath, p+"/")) && len(p) > len(itemPath) {
+				key, itemPath = item.GetKey(), p
+			}
+		}
+		if key == "" {
+			continue
+		}
+
+		match.Entity.TrashItemKey = key + strings.TrimPrefix(matchPath, itemPath)
+		matches = append(matches, match)
+	}
+
+	res.TotalMatches -= int32(len(res.GetMatches()) - len(matches))
+	res.Matches = matches
+	return nil
+}
+
 // IndexSpace (re)indexes all resources of a given space.
 func (s *Service) IndexSpace(spaceID *provider.StorageSpaceId) error {
 	ownerCtx, err := getAuthContext(s.serviceAccountID, s.gatewaySelector, s.serviceAccountSecret, s.logger)
@@ -551,10 +733,30 @@ func (s *Service) UpsertItem(ref *provider.Reference) {
 		r.ParentID = storagespace.FormatResourceID(parentID)
 	}
 
-	if err = s.engine.Upsert(r.ID, r); err != nil {
+	r.Embedding = s.embed(ctx, doc)
+
+	// only the resources which are new to the index can trigger alerts, the index is only asked if alerts are enabled
+	alert := false
+	if s.alertsEnabled() {
+		exists, err := s.engine.Exists(r.ID)
+		if err != nil {
+			s.logger.Error().Err(err).Str("id", r.ID).Msg("could not check if the resource is indexed")
+		}
+		alert = err == nil && !exists
+	}
+
+	if err := s.engine.Upsert(r.ID, r); err != nil {
 		s.logger.Error().Err(err).Msg("error adding updating the resource in the index")
 	} else {
 		logDocCount(s.engine, s.logger)
+
+		if alert {
+			s.alert(ctx, r, stat.GetInfo())
+		}
+	}
+
+	if s.indexVersions && stat.GetInfo().GetType() == provider.ResourceType_RESOURCE_TYPE_FILE {
+		s.indexFileVersions(ctx, ref, stat.GetInfo(), r)
 	}
 
 	// determine if metadata needs to be stored in storage as well
@@ -587,6 +789,114 @@ func (s *Service) UpsertItem(ref *provider.Reference) {
 	}
 }
 
+// indexFileVersions indexes the previous versions of a file next to the file and removes the versions
+// which were restored or expired, the content of a version is only extracted once
+func (s *Service) indexFileVersions(ctx context.Context, ref *provider.Reference, info *provider.ResourceInfo, current engine.Resource) {
+	gatewayClient, err := s.gatewaySelector.Next()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("could not retrieve client to list the file versions")
+		return
+	}
+
+	res, err := gatewayClient.ListFileVersions(ctx, &provider.ListFileVersionsRequest{Ref: ref})
+	switch {
+	case err != nil:
+		s.logger.Error().Err(err).Msg("failed to list the file versions")
+		return
+	case res.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		s.logger.Error().Interface("status", res.GetStatus()).Msg("failed to list the file versions")
+		return
+	}
+
+	indexed, err := s.engine.Search(ctx, &searchsvc.SearchIndexRequest{
+		Query:    "id:" + current.ID,
+		PageSize: -1,
+		Versions: true,
+	})
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to search the indexed file versions")
+		return
+	}
+
+	outdated := map[string]bool{}
+	for _, match := range indexed.GetMatches() {
+		if key := match.GetEntity().GetVersionKey(); key != "" {
+			outdated[key] = true
+		}
+	}
+
+	for _, version := range res.GetVersions() {
+		if outdated[version.GetKey()] {
+			delete(outdated, version.GetKey())
+			continue
+		}
+
+		// the content of a version is downloaded by its key
+		versionInfo := proto.Clone(info).(*provider.ResourceInfo)
+		versionInfo.Id.OpaqueId = version.GetKey()
+		versionInfo.Size = version.GetSize()
+		versionInfo.Mtime = &types.Timestamp{Seconds: version.GetMtime()}
+		versionInfo.Etag = version.GetEtag()
+
+		doc, err := s.extractor.Extract(ctx, versionInfo)
+		if err != nil {
+			s.logger.Error().Err(err).Str("version", version.GetKey()).Msg("failed to extract the file version content")
+			continue
+		}
+
+		r := current
+		r.Document = doc
+		r.VersionKey = version.GetKey()
+		r.Embedding = s.embed(ctx, doc)
+		if err := s.engine.Upsert(engine.VersionDocumentID(r.ID, r.VersionKey), r); err != nil {
+			s.logger.Error().Err(err).Str("version", version.GetKey()).Msg("error adding the file version to the index")
+		}
+	}
+
+	for key := range outdated {
+		if err := s.engine.Purge(engine.VersionDocumentID(current.ID, key)); err != nil {
+			s.logger.Error().Err(err).Str("version", key).Msg("failed to remove the file version from the index")
+		}
+	}
+}
+
+// embed returns the embedding of the document, the resource stays searchable by its words if the embedding fails
+func (s *Service) embed(ctx context.Context, doc content.Document) []float32 {
+	if s.embedder == nil {
+		return nil
+	}
+
+	vector, err := s.embedder.Embed(ctx, embeddingInput(doc, s.maxEmbeddingInputLength))
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to embed the resource")
+	}
+
+	return vector
+}
+
+// embeddingInput returns the text of the document which is embedded, it is cut to the maximum length
+func embeddingInput(doc content.Document, maxLength int) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{doc.Name, doc.Title, doc.Content} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	input := strings.Join(parts, "\n")
+	if maxLength <= 0 || len(input) <= maxLength {
+		return input
+	}
+
+	// do not cut a multibyte character
+	cut := maxLength
+	for cut > 0 && !utf8.RuneStart(input[cut]) {
+		cut--
+	}
+
+	return input[:cut]
+}
+
 func addAudioMetadata(metadata map[string]string, audio *libregraph.Audio) {
 	if audio == nil {
 		return
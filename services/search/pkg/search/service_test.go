@@ -2,6 +2,8 @@ package search_test
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
@@ -16,14 +18,20 @@ import (
 	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
 	contentMocks "github.com/opencloud-eu/opencloud/services/search/pkg/content/mocks"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/embedding"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/engine"
 	engineMocks "github.com/opencloud-eu/opencloud/services/search/pkg/engine/mocks"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/event"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/status"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	cs3mocks "github.com/opencloud-eu/reva/v2/tests/cs3mocks/mocks"
 	"github.com/stretchr/testify/mock"
+	microevents "go-micro.dev/v4/events"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var _ = Describe("Searchprovider", func() {
@@ -135,6 +143,200 @@ var _ = Describe("Searchprovider", func() {
 		})
 	})
 
+	Describe("UpsertItem", func() {
+		It("indexes the previous versions of a file and removes the outdated ones", func() {
+			file := &sprovider.ResourceInfo{
+				Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "opaqueid"},
+				ParentId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"},
+				Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+				Path:     "foo.pdf",
+				Mtime:    &typesv1beta1.Timestamp{Seconds: 4000},
+			}
+			fileID := "storageid$spaceid!opaqueid"
+
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+				Status: status.NewOK(context.Background()),
+				Info:   file,
+			}, nil)
+			gatewayClient.On("ListFileVersions", mock.Anything, mock.Anything).Return(&sprovider.ListFileVersionsResponse{
+				Status: status.NewOK(context.Background()),
+				Versions: []*sprovider.FileVersion{
+					{Key: "opaqueid.REV.1", Mtime: 1000},
+					{Key: "opaqueid.REV.2", Mtime: 2000},
+				},
+			}, nil)
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "foo.pdf"}, nil)
+			indexClient.On("Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+				return req.Versions && req.Query == "id:"+fileID
+			})).Return(&searchsvc.SearchIndexResponse{
+				Matches: []*searchmsg.Match{
+					{Entity: &searchmsg.Entity{Name: "foo.pdf"}},
+					{Entity: &searchmsg.Entity{Name: "foo.pdf", VersionKey: "opaqueid.REV.1"}},
+					{Entity: &searchmsg.Entity{Name: "foo.pdf", VersionKey: "opaqueid.REV.0"}},
+				},
+			}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+			indexClient.On("Purge", mock.Anything).Return(nil)
+
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{IndexVersions: true})
+			s.UpsertItem(&sprovider.Reference{ResourceId: file.ParentId, Path: "./foo.pdf"})
+
+			indexClient.AssertNumberOfCalls(GinkgoT(), "Upsert", 2)
+			// without alerts the index is not asked whether the resource is new
+			indexClient.AssertNotCalled(GinkgoT(), "Exists", mock.Anything)
+			indexClient.AssertCalled(GinkgoT(), "Upsert", fileID, mock.Anything)
+			indexClient.AssertCalled(GinkgoT(), "Upsert", engine.VersionDocumentID(fileID, "opaqueid.REV.2"), mock.MatchedBy(func(r engine.Resource) bool {
+				return r.ID == fileID && r.VersionKey == "opaqueid.REV.2"
+			}))
+			extractor.AssertCalled(GinkgoT(), "Extract", mock.Anything, mock.MatchedBy(func(ri *sprovider.ResourceInfo) bool {
+				return ri.GetId().GetOpaqueId() == "opaqueid.REV.2" && ri.GetMtime().GetSeconds() == 2000
+			}))
+			indexClient.AssertCalled(GinkgoT(), "Purge", engine.VersionDocumentID(fileID, "opaqueid.REV.0"))
+			indexClient.AssertNumberOfCalls(GinkgoT(), "Purge", 1)
+		})
+
+		It("does not index the previous versions of a file by default", func() {
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&sprovider.StatResponse{
+				Status: status.NewOK(context.Background()),
+				Info: &sprovider.ResourceInfo{
+					Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "opaqueid"},
+					ParentId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"},
+					Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+					Path:     "foo.pdf",
+					Mtime:    &typesv1beta1.Timestamp{Seconds: 4000},
+				},
+			}, nil)
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "foo.pdf"}, nil)
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+			s.UpsertItem(&sprovider.Reference{ResourceId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "spaceid"}, Path: "./foo.pdf"})
+
+			indexClient.AssertNumberOfCalls(GinkgoT(), "Upsert", 1)
+			gatewayClient.AssertNotCalled(GinkgoT(), "ListFileVersions", mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("UpsertItem with saved searches", func() {
+		var (
+			file = &sprovider.ResourceInfo{
+				Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "opaqueid"},
+				ParentId: &sprovider.ResourceId{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "personalspace"},
+				Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+				Path:     "invoice.pdf",
+				Name:     "invoice.pdf",
+				Mtime:    &typesv1beta1.Timestamp{Seconds: 4000},
+			}
+			fileID    = "storageid$personalspace!opaqueid"
+			pub       *testPublisher
+			indexed   bool
+			granted   map[string]bool
+			savedTime = timestamppb.New(time.Unix(1000, 0))
+			alerts    savedSearches
+		)
+
+		BeforeEach(func() {
+			pub = &testPublisher{}
+			indexed = false
+			granted = map[string]bool{"user": true}
+			alerts = savedSearches{
+				"user": {
+					{Id: "invoices", Name: "Invoices", Query: "tag:invoice", Alert: true, AlertEmail: true, Ctime: savedTime},
+					{Id: "reports", Name: "Reports", Query: "tag:report", Alert: true, Ctime: savedTime},
+					{Id: "trash", Name: "Trash", Query: "is:trashed tag:invoice", Alert: true, Ctime: savedTime},
+				},
+				"otheruser": {
+					{Id: "others", Name: "Invoices", Query: "tag:invoice", Alert: true, Ctime: savedTime},
+				},
+			}
+
+			gatewayClient.On("GetUserByClaim", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserByClaimResponse{
+				Status: status.NewOK(context.Background()),
+				User:   user,
+			}, nil)
+			// the users are authenticated by their id, the token tells them apart when they stat the resource
+			for _, c := range gatewayClient.ExpectedCalls {
+				if c.Method == "Authenticate" {
+					c.Unset()
+				}
+			}
+			gatewayClient.On("Authenticate", mock.Anything, mock.Anything).Return(func(_ context.Context, req *gateway.AuthenticateRequest, _ ...grpc.CallOption) (*gateway.AuthenticateResponse, error) {
+				token := "authtoken"
+				if req.GetType() == "machine" {
+					token = req.GetClientId()
+				}
+				return &gateway.AuthenticateResponse{Status: status.NewOK(ctx), Token: token}, nil
+			})
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(func(ctx context.Context, _ *sprovider.StatRequest, _ ...grpc.CallOption) (*sprovider.StatResponse, error) {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				if userID, ok := strings.CutPrefix(strings.Join(md.Get(revactx.TokenHeader), ""), "userid:"); ok && !granted[userID] {
+					return &sprovider.StatResponse{Status: status.NewNotFound(ctx, "not found")}, nil
+				}
+				return &sprovider.StatResponse{Status: status.NewOK(ctx), Info: file}, nil
+			})
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{Name: "invoice.pdf", Tags: []string{"invoice"}}, nil)
+			indexClient.On("Exists", fileID).Return(func(_ string) (bool, error) {
+				return indexed, nil
+			})
+			indexClient.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+		})
+
+		It("alerts the users who can access a new resource and whose saved searches match it", func() {
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{}, search.WithSavedSearches(alerts, pub))
+			s.UpsertItem(&sprovider.Reference{ResourceId: file.ParentId, Path: "./invoice.pdf"})
+
+			Expect(pub.published).To(HaveLen(1))
+			ev, ok := pub.published[0].(event.SavedSearchMatched)
+			Expect(ok).To(BeTrue())
+			Expect(ev.SavedSearchID).To(Equal("invoices"))
+			Expect(ev.UserID.GetOpaqueId()).To(Equal("user"))
+			Expect(ev.ResourceID.GetOpaqueId()).To(Equal("opaqueid"))
+			Expect(ev.ResourceName).To(Equal("invoice.pdf"))
+			Expect(ev.Email).To(BeTrue())
+		})
+
+		It("alerts the recipients of the shares of a new resource", func() {
+			granted["otheruser"] = true
+
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{}, search.WithSavedSearches(alerts, pub))
+			s.UpsertItem(&sprovider.Reference{ResourceId: file.ParentId, Path: "./invoice.pdf"})
+
+			Expect(pub.published).To(HaveLen(2))
+			alerted := map[string]string{}
+			for _, p := range pub.published {
+				ev := p.(event.SavedSearchMatched)
+				alerted[ev.UserID.GetOpaqueId()] = ev.SavedSearchID
+			}
+			Expect(alerted).To(Equal(map[string]string{"user": "invoices", "otheruser": "others"}))
+		})
+
+		It("does not alert for resources which are already indexed", func() {
+			indexed = true
+
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{}, search.WithSavedSearches(alerts, pub))
+			s.UpsertItem(&sprovider.Reference{ResourceId: file.ParentId, Path: "./invoice.pdf"})
+
+			Expect(pub.published).To(BeEmpty())
+			indexClient.AssertCalled(GinkgoT(), "Upsert", fileID, mock.Anything)
+		})
+
+		It("does not alert for resources which were modified before the search was saved", func() {
+			alerts["user"][0].Ctime = timestamppb.New(time.Unix(5000, 0))
+
+			s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{}, search.WithSavedSearches(alerts, pub))
+			s.UpsertItem(&sprovider.Reference{ResourceId: file.ParentId, Path: "./invoice.pdf"})
+
+			Expect(pub.published).To(BeEmpty())
+		})
+	})
+
 	Describe("Search", func() {
 		It("fails when an empty query is given", func() {
 			res, err := s.Search(ctx, &searchsvc.SearchRequest{
@@ -203,6 +405,117 @@ var _ = Describe("Searchprovider", func() {
 				Expect(match.Entity.Ref.ResourceId.OpaqueId).To(Equal(personalSpace.Root.OpaqueId))
 				Expect(match.Entity.Ref.Path).To(Equal("./path/to/Foo.pdf"))
 			})
+
+			It("embeds the text of the similarity", func() {
+				embedder, err := embedding.NewHashEmbedder(&config.Config{Embedding: config.Embedding{Dimensions: 8}})
+				Expect(err).ToNot(HaveOccurred())
+				s := search.NewService(gatewaySelector, indexClient, extractor, nil, logger, &config.Config{}, search.WithEmbedder(embedder))
+
+				_, err = s.Search(ctx, &searchsvc.SearchRequest{
+					Similarity: &searchmsg.Similarity{Text: "holiday photos"},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == "" && len(req.Similarity.GetVector()) == 8
+				}))
+			})
+
+			It("rejects similarity texts when semantic search is not enabled", func() {
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Similarity: &searchmsg.Similarity{Text: "holiday photos"},
+				})
+				Expect(err).To(HaveOccurred())
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+		})
+
+		Context("in the trash and the previous versions of the files", func() {
+			BeforeEach(func() {
+				gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status: status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{
+						{
+							Id:        &sprovider.StorageSpaceId{OpaqueId: "storageid$personalspace!personalspace"},
+							Root:      &sprovider.ResourceId{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "personalspace"},
+							SpaceType: "personal",
+							RootInfo: &sprovider.ResourceInfo{
+								PermissionSet: &sprovider.ResourcePermissions{ListRecycle: true},
+							},
+						},
+						{
+							Id:        &sprovider.StorageSpaceId{OpaqueId: "storageid$projectspace!projectspace"},
+							Root:      &sprovider.ResourceId{StorageId: "storageid", SpaceId: "projectspace", OpaqueId: "projectspace"},
+							SpaceType: "project",
+							RootInfo: &sprovider.ResourceInfo{
+								PermissionSet: &sprovider.ResourcePermissions{ListFileVersions: true},
+							},
+						},
+					},
+				}, nil)
+				gatewayClient.On("ListRecycle", mock.Anything, mock.Anything).Return(&sprovider.ListRecycleResponse{
+					Status: status.NewOK(ctx),
+					RecycleItems: []*sprovider.RecycleItem{
+						{Key: "fileid", Ref: &sprovider.Reference{Path: "/file.txt"}},
+						{Key: "folderid", Ref: &sprovider.Reference{Path: "/folder"}},
+						{Key: "subfolderid", Ref: &sprovider.Reference{Path: "/folder/sub"}},
+					},
+				}, nil)
+			})
+
+			It("only searches the trash of the spaces in which the user can list it", func() {
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "is:trashed foo",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertNumberOfCalls(GinkgoT(), "Search", 1)
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == "foo" && req.Trashed && req.Ref.ResourceId.SpaceId == "personalspace"
+				}))
+			})
+
+			It("only searches the versions in the spaces in which the user can list them", func() {
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+				_, err := s.Search(ctx, &searchsvc.SearchRequest{
+					Query: "version:any",
+				})
+				Expect(err).ToNot(HaveOccurred())
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == "*" && !req.Versions && req.Ref.ResourceId.SpaceId == "personalspace"
+				}))
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Query == "*" && req.Versions && req.Ref.ResourceId.SpaceId == "projectspace"
+				}))
+			})
+
+			It("addresses the trashed matches by their key in the trash", func() {
+				trashed := func(id, path string) *searchmsg.Match {
+					return &searchmsg.Match{Entity: &searchmsg.Entity{
+						Ref:     &searchmsg.Reference{ResourceId: &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "personalspace"}, Path: path},
+						Id:      &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: id},
+						Deleted: true,
+					}}
+				}
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{
+					TotalMatches: 4,
+					Matches: []*searchmsg.Match{
+						trashed("fileid", "./file.txt"),
+						trashed("childid", "./folder/sub/child.txt"),
+						trashed("otherid", "./folder/other.txt"),
+						trashed("purgedid", "./purged.txt"),
+					},
+				}, nil)
+
+				res, err := s.Search(ctx, &searchsvc.SearchRequest{Query: "is:trashed"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.TotalMatches).To(Equal(int32(3)))
+				keys := []string{}
+				for _, match := range res.Matches {
+					keys = append(keys, match.GetEntity().GetTrashItemKey())
+				}
+				Expect(keys).To(ConsistOf("fileid", "subfolderid/child.txt", "folderid/other.txt"))
+			})
 		})
 
 		Context("with a personal space with a filter", func() {
@@ -376,6 +689,10 @@ var _ = Describe("Searchprovider", func() {
 							req.Ref.ResourceId.SpaceId == grantSpace.Root.SpaceId
 					})).Return(&searchsvc.SearchIndexResponse{
 						TotalMatches: 2,
+						Facets: []*searchmsg.Facet{
+							{Field: "tags", Buckets: []*searchmsg.FacetBucket{{Key: "foo", Count: 2}}},
+							{Field: "space", Buckets: []*searchmsg.FacetBucket{{Key: "storageproviderid$spaceid!spaceid", Count: 2}}},
+						},
 						Matches: []*searchmsg.Match{
 							{
 								Score: 2,
@@ -420,6 +737,10 @@ var _ = Describe("Searchprovider", func() {
 							req.Ref.ResourceId.SpaceId == personalSpace.Root.SpaceId
 					})).Return(&searchsvc.SearchIndexResponse{
 						TotalMatches: 1,
+						Facets: []*searchmsg.Facet{
+							{Field: "tags", Buckets: []*searchmsg.FacetBucket{{Key: "bar", Count: 1}, {Key: "foo", Count: 1}}},
+							{Field: "space", Buckets: []*searchmsg.FacetBucket{{Key: "storageid$personalspace!personalspace", Count: 1}}},
+						},
 						Matches: []*searchmsg.Match{
 							{
 								Score: 1,
@@ -482,6 +803,39 @@ var _ = Describe("Searchprovider", func() {
 					ids := []string{res.Matches[0].Entity.Id.OpaqueId, res.Matches[1].Entity.Id.OpaqueId}
 					Expect(ids).To(Equal([]string{"grant-shared-id", "foo-id"}))
 				})
+
+				It("merges the facets of all spaces", func() {
+					res, err := s.Search(ctx, &searchsvc.SearchRequest{
+						Query:  "foo",
+						Facets: []*searchmsg.FacetRequest{{Field: "tags", Size: 1}, {Field: "space"}},
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Facets).To(HaveLen(2))
+
+					Expect(res.Facets[0].Field).To(Equal("tags"))
+					Expect(res.Facets[0].Buckets).To(HaveLen(1))
+					Expect(res.Facets[0].Buckets[0].Key).To(Equal("foo"))
+					Expect(res.Facets[0].Buckets[0].Count).To(Equal(int64(3)))
+					Expect(res.Facets[0].Other).To(Equal(int64(1)))
+
+					// the grant is counted as the mountpoint its matches are mapped to
+					keys := map[string]int64{}
+					for _, bucket := range res.Facets[1].Buckets {
+						keys[bucket.Key] = bucket.Count
+					}
+					Expect(keys).To(Equal(map[string]int64{
+						"storageproviderid$spaceid!otherspacemountpoint": 2,
+						"storageid$personalspace!personalspace":          1,
+					}))
+				})
+
+				It("rejects unknown facets", func() {
+					_, err := s.Search(ctx, &searchsvc.SearchRequest{
+						Query:  "foo",
+						Facets: []*searchmsg.FacetRequest{{Field: "owner"}},
+					})
+					Expect(err).To(HaveOccurred())
+				})
 			})
 		})
 	})
@@ -534,3 +888,35 @@ var _ = DescribeTable("Parse Scope",
 		``,
 	),
 )
+
+var _ = DescribeTable("Parse Trash And Versions",
+	func(pattern, wantSearch string, wantTrashed, wantVersions bool) {
+		gotSearch, gotTrashed, gotVersions := search.ParseTrashAndVersions(pattern)
+		Expect(gotSearch).To(Equal(wantSearch))
+		Expect(gotTrashed).To(Equal(wantTrashed))
+		Expect(gotVersions).To(Equal(wantVersions))
+	},
+	Entry("When the keywords are the only terms", `is:trashed version:any`, ``, true, true),
+	Entry("When the trash is searched", `Name:*file* is:trashed`, `Name:*file*`, true, false),
+	Entry("When the versions are searched", `version:any Content:budget`, `Content:budget`, false, true),
+	Entry("When a keyword is joined by an operator", `Name:*file* AND is:trashed AND mediatype:document`, `Name:*file* AND mediatype:document`, true, false),
+	Entry("When a keyword is written in upper case", `IS:Trashed file`, `file`, true, false),
+	Entry("When no keyword", `+Name:*file* +Tags:&quot;foo&quot;`, `+Name:*file* +Tags:&quot;foo&quot;`, false, false),
+	Entry("When a keyword is quoted", `Content:"is:trashed version:any"`, `Content:"is:trashed version:any"`, false, false),
+	Entry("When a keyword is next to a quoted phrase", `Content:"is:trashed  AND  moved" AND is:trashed`, `Content:"is:trashed  AND  moved"`, true, false),
+)
+
+type savedSearches map[string][]*searchmsg.SavedSearch
+
+func (s savedSearches) Alerts(_ context.Context) (map[string][]*searchmsg.SavedSearch, error) {
+	return s, nil
+}
+
+type testPublisher struct {
+	published []interface{}
+}
+
+func (p *testPublisher) Publish(_ string, ev interface{}, _ ...microevents.PublishOption) error {
+	p.published = append(p.published, ev)
+	return nil
+}
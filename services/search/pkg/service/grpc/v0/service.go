@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
@@ -14,6 +15,7 @@ import (
 	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
 	"github.com/opencloud-eu/reva/v2/pkg/errtypes"
 	"github.com/opencloud-eu/reva/v2/pkg/events/raw"
+	"github.com/opencloud-eu/reva/v2/pkg/events/stream"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	"github.com/opencloud-eu/reva/v2/pkg/token"
 	"github.com/opencloud-eu/reva/v2/pkg/token/manager/jwt"
@@ -30,9 +32,11 @@ import (
 	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/content"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/embedding"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/engine"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/opensearch"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/query/bleve"
+	"github.com/opencloud-eu/opencloud/services/search/pkg/savedsearch"
 	"github.com/opencloud-eu/opencloud/services/search/pkg/search"
 )
 
@@ -43,11 +47,17 @@ func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, func(), error)
 	logger := options.Logger
 	cfg := options.Config
 
+	// the engines only store embeddings if an embedding provider is configured
+	embeddingDimensions := 0
+	if cfg.Embedding.Type != "" {
+		embeddingDimensions = cfg.Embedding.Dimensions
+	}
+
 	// initialize search engine
 	var eng engine.Engine
 	switch cfg.Engine.Type {
 	case "bleve":
-		idx, err := engine.NewBleveIndex(cfg.Engine.Bleve.Datapath)
+		idx, err := engine.NewBleveIndex(cfg.Engine.Bleve.Datapath, embeddingDimensions)
 		if err != nil {
 			return nil, teardown, err
 		}
@@ -80,7 +90,7 @@ func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, func(), error)
 			return nil, teardown, fmt.Errorf("failed to create OpenSearch client: %w", err)
 		}
 
-		openSearchBackend, err := opensearch.NewBackend(cfg.Engine.OpenSearch.ResourceIndex.Name, client)
+		openSearchBackend, err := opensearch.NewBackend(cfg.Engine.OpenSearch.ResourceIndex.Name, client, embeddingDimensions)
 		if err != nil {
 			return nil, teardown, fmt.Errorf("failed to create OpenSearch backend: %w", err)
 		}
@@ -107,11 +117,55 @@ func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, func(), error)
 		if extractor, err = content.NewTikaExtractor(selector, logger, cfg); err != nil {
 			return nil, teardown, err
 		}
+	case "native":
+		if extractor, err = content.NewNativeExtractor(selector, logger, cfg); err != nil {
+			return nil, teardown, err
+		}
 	default:
 		return nil, teardown, fmt.Errorf("unknown search extractor: %s", cfg.Extractor.Type)
 	}
 
-	ss := search.NewService(selector, eng, extractor, options.Metrics, logger, cfg)
+	// initialize the embedding provider for semantic search
+	var embedder embedding.Embedder
+	switch cfg.Embedding.Type {
+	case "":
+	case "http":
+		if embedder, err = embedding.NewHTTPEmbedder(cfg); err != nil {
+			return nil, teardown, err
+		}
+	case "hash":
+		if embedder, err = embedding.NewHashEmbedder(cfg); err != nil {
+			return nil, teardown, err
+		}
+	default:
+		return nil, teardown, fmt.Errorf("unknown embedding provider: %s", cfg.Embedding.Type)
+	}
+
+	// initialize the saved searches and the publisher of their alerts if the feature is enabled
+	ssOpts := []search.ServiceOption{search.WithEmbedder(embedder)}
+	var savedSearches savedsearch.Store
+	if cfg.SavedSearches.Enabled {
+		if savedSearches, err = savedsearch.NewCS3Store(cfg.SavedSearches, logger); err != nil {
+			return nil, teardown, err
+		}
+
+		publisher, err := stream.NatsFromConfig(cfg.Service.Name, false, stream.NatsConfig{
+			Endpoint:             cfg.Events.Endpoint,
+			Cluster:              cfg.Events.Cluster,
+			EnableTLS:            cfg.Events.EnableTLS,
+			TLSInsecure:          cfg.Events.TLSInsecure,
+			TLSRootCACertificate: cfg.Events.TLSRootCACertificate,
+			AuthUsername:         cfg.Events.AuthUsername,
+			AuthPassword:         cfg.Events.AuthPassword,
+		})
+		if err != nil {
+			return nil, teardown, err
+		}
+
+		ssOpts = append(ssOpts, search.WithSavedSearches(savedSearches, publisher))
+	}
+
+	ss := search.NewService(selector, eng, extractor, options.Metrics, logger, cfg, ssOpts...)
 
 	// setup event handling
 
@@ -148,25 +202,27 @@ func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, func(), error)
 	}
 
 	return &Service{
-		id:           cfg.GRPC.Namespace + "." + cfg.Service.Name,
-		log:          logger,
-		searcher:     ss,
-		cache:        cache,
-		tokenManager: tokenManager,
-		gws:          selector,
-		cfg:          cfg,
+		id:            cfg.GRPC.Namespace + "." + cfg.Service.Name,
+		log:           logger,
+		searcher:      ss,
+		savedSearches: savedSearches,
+		cache:         cache,
+		tokenManager:  tokenManager,
+		gws:           selector,
+		cfg:           cfg,
 	}, teardown, nil
 }
 
 // Service implements the searchServiceHandler interface
 type Service struct {
-	id           string
-	log          log.Logger
-	searcher     search.Searcher
-	cache        *ttlcache.Cache
-	tokenManager token.Manager
-	gws          *pool.Selector[gateway.GatewayAPIClient]
-	cfg          *config.Config
+	id            string
+	log           log.Logger
+	searcher      search.Searcher
+	savedSearches savedsearch.Store
+	cache         *ttlcache.Cache
+	tokenManager  token.Manager
+	gws           *pool.Selector[gateway.GatewayAPIClient]
+	cfg           *config.Config
 }
 
 // Search handles the search
@@ -248,6 +304,92 @@ func (s Service) IndexSpace(_ context.Context, in *searchsvc.IndexSpaceRequest,
 	return nil
 }
 
+// SaveSearch creates or updates a saved search of the current user
+func (s Service) SaveSearch(ctx context.Context, in *searchsvc.SaveSearchRequest, out *searchsvc.SaveSearchResponse) error {
+	if s.savedSearches == nil {
+		return s.savedSearchesDisabled()
+	}
+
+	u, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	saved, err := s.savedSearches.Save(ctx, u.GetId().GetOpaqueId(), in.GetSavedSearch())
+	if err != nil {
+		return s.savedSearchError(err)
+	}
+
+	out.SavedSearch = saved
+	return nil
+}
+
+// ListSavedSearches lists the saved searches of the current user
+func (s Service) ListSavedSearches(ctx context.Context, _ *searchsvc.ListSavedSearchesRequest, out *searchsvc.ListSavedSearchesResponse) error {
+	if s.savedSearches == nil {
+		return s.savedSearchesDisabled()
+	}
+
+	u, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	searches, err := s.savedSearches.List(ctx, u.GetId().GetOpaqueId())
+	if err != nil {
+		return s.savedSearchError(err)
+	}
+
+	out.SavedSearches = searches
+	return nil
+}
+
+// DeleteSavedSearch deletes a saved search of the current user
+func (s Service) DeleteSavedSearch(ctx context.Context, in *searchsvc.DeleteSavedSearchRequest, _ *searchsvc.DeleteSavedSearchResponse) error {
+	if s.savedSearches == nil {
+		return s.savedSearchesDisabled()
+	}
+
+	u, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.savedSearches.Delete(ctx, u.GetId().GetOpaqueId(), in.GetId()); err != nil {
+		return s.savedSearchError(err)
+	}
+
+	return nil
+}
+
+// currentUser unpacks the user from the token of the request
+func (s Service) currentUser(ctx context.Context) (*user.User, error) {
+	t, ok := metadata.Get(ctx, revactx.TokenHeader)
+	if !ok {
+		s.log.Error().Msg("Could not get token from context")
+		return nil, errors.New("could not get token from context")
+	}
+
+	u, _, err := s.tokenManager.DismantleToken(ctx, t)
+	return u, err
+}
+
+// savedSearchesDisabled is returned by the saved search endpoints if the feature is not enabled
+func (s Service) savedSearchesDisabled() error {
+	return merrors.New(s.id, "saved searches are not enabled", http.StatusNotImplemented)
+}
+
+func (s Service) savedSearchError(err error) error {
+	switch err.(type) {
+	case errtypes.BadRequest:
+		return merrors.BadRequest(s.id, "%s", err.Error())
+	case errtypes.NotFound:
+		return merrors.NotFound(s.id, "%s", err.Error())
+	default:
+		return merrors.InternalServerError(s.id, "%s", err.Error())
+	}
+}
+
 // FromCache pulls a search result from cache
 func (s Service) FromCache(key string) (*searchsvc.SearchResponse, bool) {
 	v, err := s.cache.Get(key)
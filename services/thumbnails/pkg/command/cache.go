@@ -0,0 +1,110 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/opencloud-eu/reva/v2/pkg/bytesize"
+	"github.com/urfave/cli/v2"
+
+	"github.com/opencloud-eu/opencloud/pkg/config/configlog"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config/parser"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/logging"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail/storage"
+)
+
+// Cache wraps the commands managing the thumbnail cache of the filesystem storage.
+func Cache(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "manage the thumbnails in the filesystem storage",
+		Before: func(c *cli.Context) error {
+			if err := configlog.ReturnFatal(parser.ParseConfig(cfg)); err != nil {
+				return err
+			}
+			if cfg.Thumbnail.Storage != "filesystem" {
+				return errors.New("the cache commands only support the filesystem storage")
+			}
+			return nil
+		},
+		Subcommands: []*cli.Command{
+			cacheUsage(cfg),
+			cachePurge(cfg),
+		},
+	}
+}
+
+func cacheUsage(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "usage",
+		Usage: "show the number and size of the stored thumbnails",
+		Action: func(c *cli.Context) error {
+			fs := storage.NewFileSystemStorage(cfg.Thumbnail.FileSystemStorage, logging.Configure(cfg.Service.Name, cfg.Log))
+			u, err := fs.Usage()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("thumbnails:  %d\n", u.Files)
+			fmt.Printf("size:        %s\n", humanSize(u.Size))
+			if u.Files > 0 {
+				fmt.Printf("oldest used: %s\n", u.Oldest.Format(time.RFC3339))
+				fmt.Printf("newest used: %s\n", u.Newest.Format(time.RFC3339))
+			}
+			return nil
+		},
+	}
+}
+
+func cachePurge(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "purge",
+		Usage: "delete thumbnails by age or until the storage is below a size",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "max-age",
+				Usage: "delete the thumbnails which were not used for this duration, e.g. '720h'",
+			},
+			&cli.StringFlag{
+				Name:  "max-size",
+				Usage: "delete the least recently used thumbnails until the storage is below this size, e.g. '10GB'",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			var maxSize bytesize.ByteSize
+			if s := c.String("max-size"); s != "" {
+				var err error
+				if maxSize, err = bytesize.Parse(s); err != nil {
+					return fmt.Errorf("invalid max size '%s': %w", s, err)
+				}
+			}
+			if c.Duration("max-age") <= 0 && maxSize == 0 {
+				return errors.New("either --max-age or --max-size is required")
+			}
+
+			fs := storage.NewFileSystemStorage(cfg.Thumbnail.FileSystemStorage, logging.Configure(cfg.Service.Name, cfg.Log))
+			purged, err := fs.Purge(c.Duration("max-age"), int64(maxSize))
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("deleted %d thumbnails, %s\n", purged.Files, humanSize(purged.Size))
+			return nil
+		},
+	}
+}
+
+func humanSize(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
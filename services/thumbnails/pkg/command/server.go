@@ -5,7 +5,15 @@ import (
 	"fmt"
 
 	"github.com/oklog/run"
+	"github.com/opencloud-eu/reva/v2/pkg/bytesize"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/events/stream"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/opencloud-eu/opencloud/pkg/config/configlog"
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/pkg/registry"
 	ogrpc "github.com/opencloud-eu/opencloud/pkg/service/grpc"
 	"github.com/opencloud-eu/opencloud/pkg/tracing"
 	"github.com/opencloud-eu/opencloud/pkg/version"
@@ -13,9 +21,13 @@ import (
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config/parser"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/logging"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/metrics"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/pregenerate"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/server/debug"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/server/grpc"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/server/http"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail/imgsource"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail/storage"
 	"github.com/urfave/cli/v2"
 )
 
@@ -50,6 +62,45 @@ func Server(cfg *config.Config) *cli.Command {
 
 			m.BuildInfo.WithLabelValues(version.GetString()).Set(1)
 
+			thumbnailStorage, err := storage.New(cfg.Thumbnail, logger)
+			if err != nil {
+				return err
+			}
+
+			if fs, ok := thumbnailStorage.(storage.FileSystem); ok {
+				gr.Add(func() error {
+					fs.RunJanitor(ctx)
+					<-ctx.Done()
+					return nil
+				}, func(_ error) {
+					cancel()
+				})
+			}
+
+			if cfg.Pregenerate.Enabled {
+				pregenerator, err := newPregenerator(cfg, thumbnailStorage, traceProvider, logger)
+				if err != nil {
+					return err
+				}
+
+				natsStream, err := stream.NatsFromConfig(cfg.Service.Name, false, stream.NatsConfig(cfg.Events))
+				if err != nil {
+					return err
+				}
+
+				ch, err := events.Consume(natsStream, "thumbnails", pregenerate.Events...)
+				if err != nil {
+					return err
+				}
+
+				gr.Add(func() error {
+					pregenerator.Run(ctx, ch)
+					return nil
+				}, func(_ error) {
+					cancel()
+				})
+			}
+
 			service := grpc.NewService(
 				grpc.Logger(logger),
 				grpc.Context(ctx),
@@ -60,6 +111,7 @@ func Server(cfg *config.Config) *cli.Command {
 				grpc.Metrics(m),
 				grpc.TraceProvider(traceProvider),
 				grpc.MaxConcurrentRequests(cfg.GRPC.MaxConcurrentRequests),
+				grpc.Storage(thumbnailStorage),
 			)
 
 			gr.Add(service.Run, func(_ error) {
@@ -100,6 +152,7 @@ func Server(cfg *config.Config) *cli.Command {
 				http.Metrics(m),
 				http.Namespace(cfg.HTTP.Namespace),
 				http.TraceProvider(traceProvider),
+				http.Storage(thumbnailStorage),
 			)
 			if err != nil {
 				logger.Info().
@@ -130,3 +183,40 @@ func Server(cfg *config.Config) *cli.Command {
 		},
 	}
 }
+
+func newPregenerator(cfg *config.Config, st storage.Storage, tp trace.TracerProvider, logger log.Logger) (*pregenerate.Pregenerator, error) {
+	tm, err := pool.StringToTLSMode(cfg.GRPCClientTLS.Mode)
+	if err != nil {
+		return nil, err
+	}
+	gatewaySelector, err := pool.GatewaySelector(cfg.Thumbnail.RevaGateway,
+		pool.WithTLSCACert(cfg.GRPCClientTLS.CACert),
+		pool.WithTLSMode(tm),
+		pool.WithRegistry(registry.GetRegistry()),
+		pool.WithTracerProvider(tp),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resolutions, err := thumbnail.ParseResolutions(cfg.Thumbnail.Resolutions)
+	if err != nil {
+		return nil, err
+	}
+	pregenerateResolutions, err := pregenerate.Resolutions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	maxInputSize, err := bytesize.Parse(cfg.Thumbnail.MaxInputImageFileSize)
+	if err != nil {
+		return nil, err
+	}
+	maxFileSize, err := bytesize.Parse(cfg.Pregenerate.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := thumbnail.NewSimpleManager(resolutions, st, logger, cfg.Thumbnail.MaxInputWidth, cfg.Thumbnail.MaxInputHeight)
+	source := imgsource.NewCS3Source(cfg.Thumbnail, gatewaySelector, maxInputSize)
+	return pregenerate.New(manager, source, gatewaySelector, pregenerateResolutions, maxFileSize.Bytes(), cfg, logger), nil
+}
@@ -3,6 +3,7 @@ package config
 
 import (
 	"context"
+	"time"
 
 	"github.com/opencloud-eu/opencloud/pkg/shared"
 	"go-micro.dev/v4/client"
@@ -24,20 +25,77 @@ type Config struct {
 	GRPCClientTLS *shared.GRPCClientTLS `yaml:"grpc_client_tls"`
 	GrpcClient    client.Client         `yaml:"-"`
 
-	Thumbnail Thumbnail `yaml:"thumbnail"`
+	Thumbnail   Thumbnail   `yaml:"thumbnail"`
+	Pregenerate Pregenerate `yaml:"pregenerate"`
+
+	Events         Events         `yaml:"events"`
+	ServiceAccount ServiceAccount `yaml:"service_account"`
 
 	Context context.Context `yaml:"-"`
 }
 
+// Pregenerate defines the configuration of the thumbnail pre-generation for uploaded files.
+type Pregenerate struct {
+	Enabled     bool     `yaml:"enabled" env:"THUMBNAILS_PREGENERATE_ENABLED" desc:"Generate the thumbnails of uploaded files as soon as the postprocessing of the upload finished instead of on the first request." introductionVersion:"%%NEXT%%"`
+	Resolutions []string `yaml:"resolutions" env:"THUMBNAILS_PREGENERATE_RESOLUTIONS" desc:"The list of resolutions which are generated for uploaded files in the format WidthxHeight like 32x32. Every resolution must be contained in THUMBNAILS_RESOLUTIONS. If not set, all THUMBNAILS_RESOLUTIONS are generated. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	Workers     int      `yaml:"workers" env:"THUMBNAILS_PREGENERATE_WORKERS" desc:"The number of uploaded files for which thumbnails are generated concurrently." introductionVersion:"%%NEXT%%"`
+	MaxFileSize string   `yaml:"max_file_size" env:"THUMBNAILS_PREGENERATE_MAX_FILE_SIZE" desc:"Thumbnails are not pre-generated for files larger than this size. They are still generated on request. Usable common abbreviations: [KB, KiB, MB, MiB, GB, GiB, TB, TiB, PB, PiB, EB, EiB], example: 2GB." introductionVersion:"%%NEXT%%"`
+}
+
+// Events combines the configuration options for the event bus.
+type Events struct {
+	Endpoint             string `yaml:"endpoint" env:"OC_EVENTS_ENDPOINT;THUMBNAILS_EVENTS_ENDPOINT" desc:"The address of the event system. The event system is the message queuing service. It is used as message broker for the microservice architecture." introductionVersion:"%%NEXT%%"`
+	Cluster              string `yaml:"cluster" env:"OC_EVENTS_CLUSTER;THUMBNAILS_EVENTS_CLUSTER" desc:"The clusterID of the event system. The event system is the message queuing service. It is used as message broker for the microservice architecture. Mandatory when using NATS as event system." introductionVersion:"%%NEXT%%"`
+	TLSInsecure          bool   `yaml:"tls_insecure" env:"OC_INSECURE;THUMBNAILS_EVENTS_TLS_INSECURE" desc:"Whether to verify the server TLS certificates." introductionVersion:"%%NEXT%%"`
+	TLSRootCACertificate string `yaml:"tls_root_ca_certificate" env:"OC_EVENTS_TLS_ROOT_CA_CERTIFICATE;THUMBNAILS_EVENTS_TLS_ROOT_CA_CERTIFICATE" desc:"The root CA certificate used to validate the server's TLS certificate. If provided THUMBNAILS_EVENTS_TLS_INSECURE will be seen as false." introductionVersion:"%%NEXT%%"`
+	EnableTLS            bool   `yaml:"enable_tls" env:"OC_EVENTS_ENABLE_TLS;THUMBNAILS_EVENTS_ENABLE_TLS" desc:"Enable TLS for the connection to the events broker. The events broker is the OpenCloud service which receives and delivers events between the services." introductionVersion:"%%NEXT%%"`
+	AuthUsername         string `yaml:"username" env:"OC_EVENTS_AUTH_USERNAME;THUMBNAILS_EVENTS_AUTH_USERNAME" desc:"The username to authenticate with the events broker. The events broker is the OpenCloud service which receives and delivers events between the services." introductionVersion:"%%NEXT%%"`
+	AuthPassword         string `yaml:"password" env:"OC_EVENTS_AUTH_PASSWORD;THUMBNAILS_EVENTS_AUTH_PASSWORD" desc:"The password to authenticate with the events broker. The events broker is the OpenCloud service which receives and delivers events between the services." introductionVersion:"%%NEXT%%"`
+}
+
+// ServiceAccount is the configuration for the used service account
+type ServiceAccount struct {
+	ServiceAccountID     string `yaml:"service_account_id" env:"OC_SERVICE_ACCOUNT_ID;THUMBNAILS_SERVICE_ACCOUNT_ID" desc:"The ID of the service account the service should use. See the 'auth-service' service description for more details." introductionVersion:"%%NEXT%%"`
+	ServiceAccountSecret string `yaml:"service_account_secret" env:"OC_SERVICE_ACCOUNT_SECRET;THUMBNAILS_SERVICE_ACCOUNT_SECRET" desc:"The service account secret." introductionVersion:"%%NEXT%%"`
+}
+
 // FileSystemStorage defines the available filesystem storage configuration.
 type FileSystemStorage struct {
-	RootDirectory string `yaml:"root_directory" env:"THUMBNAILS_FILESYSTEMSTORAGE_ROOT" desc:"The directory where the filesystem storage will store the thumbnails. If not defined, the root directory derives from $OC_BASE_DATA_PATH/thumbnails." introductionVersion:"1.0.0"`
+	RootDirectory   string        `yaml:"root_directory" env:"THUMBNAILS_FILESYSTEMSTORAGE_ROOT" desc:"The directory where the filesystem storage will store the thumbnails. If not defined, the root directory derives from $OC_BASE_DATA_PATH/thumbnails." introductionVersion:"1.0.0"`
+	MaxSize         string        `yaml:"max_size" env:"THUMBNAILS_FILESYSTEMSTORAGE_MAX_SIZE" desc:"The maximum size of all thumbnails in the filesystem storage. If exceeded, the least recently used thumbnails are deleted. Usable common abbreviations: [KB, KiB, MB, MiB, GB, GiB, TB, TiB, PB, PiB, EB, EiB], example: 10GB. Set to '0' for no limit." introductionVersion:"%%NEXT%%"`
+	MaxAge          time.Duration `yaml:"max_age" env:"THUMBNAILS_FILESYSTEMSTORAGE_MAX_AGE" desc:"Thumbnails which were not used for this duration are deleted from the filesystem storage. Set to '0' to keep unused thumbnails. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env:"THUMBNAILS_FILESYSTEMSTORAGE_CLEANUP_INTERVAL" desc:"The interval in which thumbnails exceeding THUMBNAILS_FILESYSTEMSTORAGE_MAX_SIZE or THUMBNAILS_FILESYSTEMSTORAGE_MAX_AGE are deleted. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+}
+
+// S3Storage defines the available S3 storage configuration.
+type S3Storage struct {
+	Endpoint  string `yaml:"endpoint" env:"THUMBNAILS_S3STORAGE_ENDPOINT" desc:"Endpoint of the S3 compatible object storage, e.g. 'https://s3.example.com'." introductionVersion:"%%NEXT%%"`
+	Region    string `yaml:"region" env:"THUMBNAILS_S3STORAGE_REGION" desc:"Region of the S3 bucket." introductionVersion:"%%NEXT%%"`
+	AccessKey string `yaml:"access_key" env:"THUMBNAILS_S3STORAGE_ACCESS_KEY" desc:"Access key for the S3 bucket." introductionVersion:"%%NEXT%%"`
+	SecretKey string `yaml:"secret_key" env:"THUMBNAILS_S3STORAGE_SECRET_KEY" desc:"Secret key for the S3 bucket." introductionVersion:"%%NEXT%%"`
+	Bucket    string `yaml:"bucket" env:"THUMBNAILS_S3STORAGE_BUCKET" desc:"Name of the S3 bucket. The bucket must exist." introductionVersion:"%%NEXT%%"`
+	Prefix    string `yaml:"prefix" env:"THUMBNAILS_S3STORAGE_PREFIX" desc:"A prefix prepended to the keys of the thumbnails, which allows sharing the bucket with other applications." introductionVersion:"%%NEXT%%"`
+}
+
+// NATSStorage defines the available NATS JetStream object store configuration.
+type NATSStorage struct {
+	Nodes                []string      `yaml:"nodes" env:"OC_PERSISTENT_STORE_NODES;THUMBNAILS_NATSSTORAGE_NODES" desc:"A list of NATS nodes to connect to. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	Bucket               string        `yaml:"bucket" env:"THUMBNAILS_NATSSTORAGE_BUCKET" desc:"The name of the object store bucket. It is created if it does not exist." introductionVersion:"%%NEXT%%"`
+	TTL                  time.Duration `yaml:"ttl" env:"THUMBNAILS_NATSSTORAGE_TTL" desc:"Time to live of the thumbnails in the object store. Set to '0' to keep them forever. Only applies when the bucket is created. See the Environment Variable Types description for more details." introductionVersion:"%%NEXT%%"`
+	AuthUsername         string        `yaml:"username" env:"OC_PERSISTENT_STORE_AUTH_USERNAME;THUMBNAILS_NATSSTORAGE_AUTH_USERNAME" desc:"The username to authenticate with the NATS server." introductionVersion:"%%NEXT%%"`
+	AuthPassword         string        `yaml:"password" env:"OC_PERSISTENT_STORE_AUTH_PASSWORD;THUMBNAILS_NATSSTORAGE_AUTH_PASSWORD" desc:"The password to authenticate with the NATS server." introductionVersion:"%%NEXT%%"`
+	EnableTLS            bool          `yaml:"enable_tls" env:"OC_EVENTS_ENABLE_TLS;THUMBNAILS_NATSSTORAGE_ENABLE_TLS" desc:"Enable TLS for the connection to the NATS server." introductionVersion:"%%NEXT%%"`
+	TLSInsecure          bool          `yaml:"tls_insecure" env:"OC_INSECURE;THUMBNAILS_NATSSTORAGE_TLS_INSECURE" desc:"Whether to verify the server TLS certificates." introductionVersion:"%%NEXT%%"`
+	TLSRootCACertificate string        `yaml:"tls_root_ca_certificate" env:"OC_EVENTS_TLS_ROOT_CA_CERTIFICATE;THUMBNAILS_NATSSTORAGE_TLS_ROOT_CA_CERTIFICATE" desc:"The root CA certificate used to validate the server's TLS certificate. If provided THUMBNAILS_NATSSTORAGE_TLS_INSECURE will be seen as false." introductionVersion:"%%NEXT%%"`
 }
 
 // Thumbnail defines the available thumbnail related configuration.
 type Thumbnail struct {
 	Resolutions           []string          `yaml:"resolutions" env:"THUMBNAILS_RESOLUTIONS" desc:"The supported list of target resolutions in the format WidthxHeight like 32x32. You can define any resolution as required. See the Environment Variable Types description for more details." introductionVersion:"1.0.0"`
+	Storage               string            `yaml:"storage" env:"THUMBNAILS_STORAGE" desc:"The storage of the generated thumbnails. Supported values are 'filesystem', 's3' and 'nats-js-os'. Use 's3' or 'nats-js-os' to share the thumbnails between multiple instances of the thumbnails service without a shared volume." introductionVersion:"%%NEXT%%"`
 	FileSystemStorage     FileSystemStorage `yaml:"filesystem_storage"`
+	S3Storage             S3Storage         `yaml:"s3_storage"`
+	NATSStorage           NATSStorage       `yaml:"nats_storage"`
 	WebdavAllowInsecure   bool              `yaml:"webdav_allow_insecure" env:"OC_INSECURE;THUMBNAILS_WEBDAVSOURCE_INSECURE" desc:"Ignore untrusted SSL certificates when connecting to the webdav source." introductionVersion:"1.0.0"`
 	CS3AllowInsecure      bool              `yaml:"cs3_allow_insecure" env:"OC_INSECURE;THUMBNAILS_CS3SOURCE_INSECURE" desc:"Ignore untrusted SSL certificates when connecting to the CS3 source." introductionVersion:"1.0.0"`
 	RevaGateway           string            `yaml:"reva_gateway" env:"OC_REVA_GATEWAY" desc:"CS3 gateway used to look up user metadata" introductionVersion:"1.0.0"`
@@ -3,6 +3,7 @@ package defaults
 import (
 	"path"
 	"strings"
+	"time"
 
 	"github.com/opencloud-eu/opencloud/pkg/config/defaults"
 	"github.com/opencloud-eu/opencloud/pkg/shared"
@@ -48,8 +49,15 @@ func DefaultConfig() *config.Config {
 		},
 		Thumbnail: config.Thumbnail{
 			Resolutions: []string{"16x16", "32x32", "64x64", "128x128", "1080x1920", "1920x1080", "2160x3840", "3840x2160", "4320x7680", "7680x4320"},
+			Storage:     "filesystem",
 			FileSystemStorage: config.FileSystemStorage{
-				RootDirectory: path.Join(defaults.BaseDataPath(), "thumbnails"),
+				RootDirectory:   path.Join(defaults.BaseDataPath(), "thumbnails"),
+				MaxSize:         "0",
+				CleanupInterval: time.Hour,
+			},
+			NATSStorage: config.NATSStorage{
+				Nodes:  []string{"127.0.0.1:9233"},
+				Bucket: "thumbnails",
 			},
 			WebdavAllowInsecure:   false,
 			RevaGateway:           shared.DefaultRevaConfig().Address,
@@ -59,6 +67,16 @@ func DefaultConfig() *config.Config {
 			MaxInputHeight:        7680,
 			MaxInputImageFileSize: "50MB",
 		},
+		Pregenerate: config.Pregenerate{
+			Enabled:     false,
+			Workers:     2,
+			MaxFileSize: "50MB",
+		},
+		Events: config.Events{
+			Endpoint:  "127.0.0.1:9233",
+			Cluster:   "opencloud-cluster",
+			EnableTLS: false,
+		},
 	}
 }
 
@@ -105,4 +123,7 @@ func Sanitize(cfg *config.Config) {
 	if len(cfg.Thumbnail.Resolutions) == 1 && strings.Contains(cfg.Thumbnail.Resolutions[0], ",") {
 		cfg.Thumbnail.Resolutions = strings.Split(cfg.Thumbnail.Resolutions[0], ",")
 	}
+	if len(cfg.Pregenerate.Resolutions) == 1 && strings.Contains(cfg.Pregenerate.Resolutions[0], ",") {
+		cfg.Pregenerate.Resolutions = strings.Split(cfg.Pregenerate.Resolutions[0], ",")
+	}
 }
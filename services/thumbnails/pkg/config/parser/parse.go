@@ -2,12 +2,18 @@ package parser
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
+	"slices"
 
 	occfg "github.com/opencloud-eu/opencloud/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config/defaults"
 
 	"github.com/opencloud-eu/opencloud/pkg/config/envdecode"
+	"github.com/opencloud-eu/opencloud/pkg/shared"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail"
+	"github.com/opencloud-eu/reva/v2/pkg/bytesize"
 )
 
 // ParseConfig loads configuration from known paths.
@@ -34,6 +40,60 @@ func ParseConfig(cfg *config.Config) error {
 }
 
 // Validate can validate the configuration
-func Validate(_ *config.Config) error {
+func Validate(cfg *config.Config) error {
+	if _, err := bytesize.Parse(cfg.Thumbnail.FileSystemStorage.MaxSize); err != nil {
+		return fmt.Errorf("invalid max size of the thumbnail storage '%s': %w", cfg.Thumbnail.FileSystemStorage.MaxSize, err)
+	}
+
+	switch cfg.Thumbnail.Storage {
+	case "filesystem", "nats-js-os":
+	case "s3":
+		if cfg.Thumbnail.S3Storage.Endpoint == "" || cfg.Thumbnail.S3Storage.Bucket == "" {
+			return errors.New("the s3 endpoint and bucket are required for the s3 thumbnail storage")
+		}
+		u, err := url.Parse(cfg.Thumbnail.S3Storage.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("the s3 endpoint '%s' must be an absolute http or https url", cfg.Thumbnail.S3Storage.Endpoint)
+		}
+	default:
+		return fmt.Errorf("unsupported thumbnail storage '%s'", cfg.Thumbnail.Storage)
+	}
+
+	if cfg.Pregenerate.Enabled {
+		if err := validatePregenerate(cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validatePregenerate(cfg *config.Config) error {
+	if cfg.ServiceAccount.ServiceAccountID == "" {
+		return shared.MissingServiceAccountID(cfg.Service.Name)
+	}
+	if cfg.ServiceAccount.ServiceAccountSecret == "" {
+		return shared.MissingServiceAccountSecret(cfg.Service.Name)
+	}
+	if cfg.Pregenerate.Workers < 1 {
+		return errors.New("at least one worker is required to pre-generate thumbnails")
+	}
+	if _, err := bytesize.Parse(cfg.Pregenerate.MaxFileSize); err != nil {
+		return fmt.Errorf("invalid max file size for the pre-generation of thumbnails '%s': %w", cfg.Pregenerate.MaxFileSize, err)
+	}
+
+	resolutions, err := thumbnail.ParseResolutions(cfg.Thumbnail.Resolutions)
+	if err != nil {
+		return err
+	}
+	pregenerate, err := thumbnail.ParseResolutions(cfg.Pregenerate.Resolutions)
+	if err != nil {
+		return err
+	}
+	for _, r := range pregenerate {
+		if !slices.Contains(resolutions, r) {
+			return fmt.Errorf("the resolution %dx%d is not contained in the thumbnail resolutions", r.Dx(), r.Dy())
+		}
+	}
 	return nil
 }
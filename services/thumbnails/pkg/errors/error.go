@@ -5,6 +5,10 @@ import "errors"
 var (
 	// ErrImageTooLarge defines an error when an input image is too large
 	ErrImageTooLarge = errors.New("thumbnails: image is too large")
+	// ErrSVGTooComplex defines an error when an svg image exceeds the limits of the rasterizer
+	ErrSVGTooComplex = errors.New("thumbnails: svg image is too complex")
+	// ErrSVGUnsupported defines an error when an svg image uses features the rasterizer can't render
+	ErrSVGUnsupported = errors.New("thumbnails: svg image uses unsupported features")
 	// ErrInvalidType represents the error when a type can't be encoded.
 	ErrInvalidType = errors.New("thumbnails: can't encode this type")
 	// ErrNoEncoderForType represents the error when an encoder couldn't be found for a type.
@@ -0,0 +1,201 @@
+// Package pregenerate generates the thumbnails of uploaded files before they are requested.
+package pregenerate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
+	"github.com/opencloud-eu/reva/v2/pkg/events"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
+	"github.com/opencloud-eu/reva/v2/pkg/utils"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config"
+	terrors "github.com/opencloud-eu/opencloud/services/thumbnails/pkg/errors"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/preprocessor"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail/imgsource"
+)
+
+// Events are the events handled by the Pregenerator
+var Events = []events.Unmarshaller{
+	events.UploadReady{},
+}
+
+// Pregenerator generates the thumbnails of uploaded files
+type Pregenerator struct {
+	manager         thumbnail.Manager
+	source          imgsource.Source
+	gatewaySelector pool.Selectable[gateway.GatewayAPIClient]
+	resolutions     thumbnail.Resolutions
+	maxFileSize     uint64
+	workers         int
+	serviceAccount  config.ServiceAccount
+	fontMapFile     string
+	logger          log.Logger
+}
+
+// New returns a new Pregenerator
+func New(manager thumbnail.Manager, source imgsource.Source, gatewaySelector pool.Selectable[gateway.GatewayAPIClient], resolutions thumbnail.Resolutions, maxFileSize uint64, cfg *config.Config, logger log.Logger) *Pregenerator {
+	return &Pregenerator{
+		manager:         manager,
+		source:          source,
+		gatewaySelector: gatewaySelector,
+		resolutions:     resolutions,
+		maxFileSize:     maxFileSize,
+		workers:         max(cfg.Pregenerate.Workers, 1),
+		serviceAccount:  cfg.ServiceAccount,
+		fontMapFile:     cfg.Thumbnail.FontMapFile,
+		logger:          logger,
+	}
+}
+
+// Run handles the events from the channel with a fixed number of workers until the context is done
+func (p *Pregenerator) Run(ctx context.Context, ch <-chan events.Event) {
+	wg := sync.WaitGroup{}
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case e, ok := <-ch:
+					if !ok {
+						return
+					}
+					p.handle(ctx, e)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *Pregenerator) handle(ctx context.Context, e events.Event) {
+	ev, ok := e.Event.(events.UploadReady)
+	if !ok || ev.Failed || ev.FileRef == nil {
+		return
+	}
+
+	if err := p.Pregenerate(ctx, ev.FileRef); err != nil {
+		p.logger.Error().Err(err).Str("uploadid", ev.UploadID).Msg("could not pre-generate thumbnails")
+	}
+}
+
+// Pregenerate generates the missing thumbnails of the referenced file. Unsupported and too large files are skipped.
+func (p *Pregenerator) Pregenerate(ctx context.Context, ref *provider.Reference) error {
+	gwc, err := p.gatewaySelector.Next()
+	if err != nil {
+		return err
+	}
+	auth, err := utils.GetServiceUserToken(ctx, gwc, p.serviceAccount.ServiceAccountID, p.serviceAccount.ServiceAccountSecret)
+	if err != nil {
+		return fmt.Errorf("could not authenticate the service account: %w", err)
+	}
+
+	sRes, err := gwc.Stat(metadata.AppendToOutgoingContext(ctx, revactx.TokenHeader, auth), &provider.StatRequest{Ref: ref})
+	switch {
+	case err != nil:
+		return err
+	case sRes.GetStatus().GetCode() == rpc.Code_CODE_NOT_FOUND:
+		// the file was deleted in the meantime
+		return nil
+	case sRes.GetStatus().GetCode() != rpc.Code_CODE_OK:
+		return fmt.Errorf("could not stat file: %s", sRes.GetStatus().GetMessage())
+	}
+
+	info := sRes.GetInfo()
+	switch {
+	case info.GetType() != provider.ResourceType_RESOURCE_TYPE_FILE,
+		!thumbnail.IsFileSupported(info.GetMimeType(), info.GetName()),
+		info.GetChecksum().GetSum() == "":
+		return nil
+	case p.maxFileSize > 0 && info.GetSize() > p.maxFileSize:
+		p.logger.Debug().Str("resource", storagespace.FormatResourceID(info.GetId())).Uint64("size", info.GetSize()).Msg("file too large to pre-generate thumbnails")
+		return nil
+	}
+
+	requests, err := p.missing(info)
+	if err != nil {
+		return err
+	}
+	if _, cached := p.manager.GetBlurHash(info.GetChecksum().GetSum()); len(requests) == 0 && cached {
+		return nil
+	}
+
+	if err := p.generate(ctx, auth, info, requests); err != nil {
+		return err
+	}
+	p.logger.Debug().Str("resource", storagespace.FormatResourceID(info.GetId())).Int("thumbnails", len(requests)).Msg("pre-generated thumbnails")
+	return nil
+}
+
+// generate generates the thumbnails and the BlurHash placeholder of the file, both are stored by the checksum of the file
+func (p *Pregenerator) generate(ctx context.Context, auth string, info *provider.ResourceInfo, requests []thumbnail.Request) error {
+	r, err := p.source.Get(imgsource.ContextSetAuthorization(ctx, auth), storagespace.FormatResourceID(info.GetId()))
+	if err != nil {
+		return fmt.Errorf("could not get image from source: %w", err)
+	}
+	defer r.Close()
+
+	pp := preprocessor.ForType(info.GetMimeType(), map[string]interface{}{
+		"fontFileMap": p.fontMapFile,
+		"filename":    info.GetName(),
+	})
+	img, err := pp.Convert(r)
+	if img == nil || err != nil {
+		return fmt.Errorf("could not get image: %w", err)
+	}
+
+	for _, tr := range requests {
+		_, err := p.manager.Generate(tr, img)
+		switch {
+		case errors.Is(err, terrors.ErrImageTooLarge):
+			// the dimensions exceed the limits, they are the same for all resolutions
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+
+	if _, err := p.manager.GenerateBlurHash(info.GetChecksum().GetSum(), img); err != nil {
+		p.logger.Debug().Err(err).Str("resource", storagespace.FormatResourceID(info.GetId())).Msg("could not compute blurhash")
+	}
+	return nil
+}
+
+// missing returns the requests of the thumbnails which do not exist yet.
+// The requests match the requests of clients asking for the configured resolutions.
+func (p *Pregenerator) missing(info *provider.ResourceInfo) ([]thumbnail.Request, error) {
+	tType := thumbnail.GetExtForFile(info.GetMimeType(), info.GetName())
+	requests := make([]thumbnail.Request, 0, len(p.resolutions))
+	for _, res := range p.resolutions {
+		tr, err := thumbnail.PrepareRequest(res.Dx(), res.Dy(), tType, info.GetChecksum().GetSum(), "")
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := p.manager.CheckThumbnail(tr); exists {
+			continue
+		}
+		requests = append(requests, tr)
+	}
+	return requests, nil
+}
+
+// Resolutions returns the resolutions to pre-generate. All resolutions are used if none are selected.
+func Resolutions(cfg *config.Config) (thumbnail.Resolutions, error) {
+	if len(cfg.Pregenerate.Resolutions) == 0 {
+		return thumbnail.ParseResolutions(cfg.Thumbnail.Resolutions)
+	}
+	return thumbnail.ParseResolutions(cfg.Pregenerate.Resolutions)
+}
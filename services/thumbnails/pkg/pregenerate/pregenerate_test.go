@@ -0,0 +1,106 @@
+package pregenerate
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
+	cs3mocks "github.com/opencloud-eu/reva/v2/tests/cs3mocks/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail/imgsource"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail/storage"
+)
+
+type selector struct {
+	gwc gateway.GatewayAPIClient
+}
+
+func (s selector) Next(_ ...pool.Option) (gateway.GatewayAPIClient, error) {
+	return s.gwc, nil
+}
+
+type source struct {
+	img   []byte
+	calls int
+}
+
+func (s *source) Get(ctx context.Context, _ string) (io.ReadCloser, error) {
+	s.calls++
+	if auth, _ := imgsource.ContextGetAuthorization(ctx); auth != "token" {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return io.NopCloser(bytes.NewReader(s.img)), nil
+}
+
+func TestPregenerate(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 200, 100))))
+
+	ref := &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "file"}}
+	info := &provider.ResourceInfo{
+		Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+		Id:       ref.GetResourceId(),
+		MimeType: "image/png",
+		Size:     uint64(buf.Len()),
+		Checksum: &provider.ResourceChecksum{Sum: "checksum"},
+	}
+
+	gwc := &cs3mocks.GatewayAPIClient{}
+	gwc.On("Authenticate", mock.Anything, mock.Anything).Return(&gateway.AuthenticateResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Token:  "token",
+	}, nil)
+	gwc.On("Stat", mock.Anything, mock.Anything).Return(&provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info:   info,
+	}, nil)
+
+	resolutions, err := thumbnail.ParseResolutions([]string{"16x16", "32x32", "64x64"})
+	require.NoError(t, err)
+	warm := resolutions[:2]
+
+	st := storage.NewFileSystemStorage(config.FileSystemStorage{RootDirectory: t.TempDir(), MaxSize: "0"}, log.NopLogger())
+	manager := thumbnail.NewSimpleManager(resolutions, st, log.NopLogger(), 7680, 7680)
+	src := &source{img: buf.Bytes()}
+	cfg := &config.Config{Pregenerate: config.Pregenerate{Workers: 1}}
+
+	t.Run("generates the selected resolutions", func(t *testing.T) {
+		p := New(manager, src, selector{gwc}, warm, 0, cfg, log.NopLogger())
+		require.NoError(t, p.Pregenerate(context.Background(), ref))
+		require.Equal(t, 1, src.calls)
+
+		for i, res := range resolutions {
+			tr, err := thumbnail.PrepareRequest(res.Dx(), res.Dy(), "png", "checksum", "")
+			require.NoError(t, err)
+			_, exists := manager.CheckThumbnail(tr)
+			require.Equal(t, i < len(warm), exists, res.String())
+		}
+
+		_, exists := manager.GetBlurHash("checksum")
+		require.True(t, exists)
+	})
+
+	t.Run("skips existing thumbnails", func(t *testing.T) {
+		p := New(manager, src, selector{gwc}, warm, 0, cfg, log.NopLogger())
+		require.NoError(t, p.Pregenerate(context.Background(), ref))
+		require.Equal(t, 1, src.calls)
+	})
+
+	t.Run("skips large files", func(t *testing.T) {
+		p := New(manager, src, selector{gwc}, resolutions, uint64(buf.Len()-1), cfg, log.NopLogger())
+		require.NoError(t, p.Pregenerate(context.Background(), ref))
+		require.Equal(t, 1, src.calls)
+	})
+}
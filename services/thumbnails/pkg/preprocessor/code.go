@@ -0,0 +1,329 @@
+package preprocessor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/russross/blackfriday/v2"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// codeMaxRead limits the part of a source file read for the thumbnail, the rest would not be visible anyway
+	codeMaxRead = 64 << 10
+	// codeTabWidth is the number of spaces replacing a tab
+	codeTabWidth = 4
+)
+
+var (
+	codeBackground = color.RGBA{R: 0xf6, G: 0xf8, B: 0xfa, A: 0xff}
+	codeColors     = map[tokenKind]color.RGBA{
+		tokenText:    {R: 0x24, G: 0x29, B: 0x2e, A: 0xff},
+		tokenKeyword: {R: 0xd7, G: 0x3a, B: 0x49, A: 0xff},
+		tokenLiteral: {R: 0x00, G: 0x5c, B: 0xc5, A: 0xff},
+		tokenString:  {R: 0x03, G: 0x2f, B: 0x62, A: 0xff},
+		tokenNumber:  {R: 0x00, G: 0x5c, B: 0xc5, A: 0xff},
+		tokenComment: {R: 0x6a, G: 0x73, B: 0x7d, A: 0xff},
+		tokenKey:     {R: 0x6f, G: 0x42, B: 0xc1, A: 0xff},
+	}
+	markdownLinkColor  = color.RGBA{R: 0x03, G: 0x66, B: 0xd6, A: 0xff}
+	markdownQuoteColor = color.RGBA{R: 0x6a, G: 0x73, B: 0x7d, A: 0xff}
+	markdownRuleColor  = color.RGBA{R: 0xe1, G: 0xe4, B: 0xe8, A: 0xff}
+)
+
+// CodeToImageConverter is a converter for source code files, it renders the code with syntax highlighting
+type CodeToImageConverter struct {
+	fontLoader *FontLoader
+	language   *language
+}
+
+// Convert reads the source code and renders it into a thumbnail image
+func (c CodeToImageConverter) Convert(r io.Reader) (interface{}, error) {
+	src, err := readSource(r)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	draw.Draw(img, img.Bounds(), image.NewUniform(codeBackground), image.Point{}, draw.Src)
+
+	w := newTextWriter(img, c.fontLoader)
+	for _, t := range c.language.tokenize(src) {
+		lines := strings.Split(t.text, "\n")
+		for i, line := range lines {
+			if i > 0 && !w.newline(c.fontLoader, 1.4) {
+				return img, nil
+			}
+			w.write(line, c.fontLoader, codeColors[t.kind], false)
+		}
+	}
+	return img, nil
+}
+
+// MarkdownToImageConverter is a converter for markdown files, it renders the formatted document
+type MarkdownToImageConverter struct {
+	fontLoader    *FontLoader
+	headingLoader *FontLoader
+}
+
+// Convert reads the markdown file and renders it into a thumbnail image
+func (m MarkdownToImageConverter) Convert(r io.Reader) (interface{}, error) {
+	src, err := readSource(r)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	w := newTextWriter(img, m.fontLoader)
+	// the writer is at the start of a block and needs a new line before the next block
+	started := false
+	block := func(fl *FontLoader, spacing float64) bool {
+		if started && !w.newline(fl, spacing) {
+			return false
+		}
+		started = true
+		return true
+	}
+
+	doc := blackfriday.New(blackfriday.WithExtensions(blackfriday.CommonExtensions)).Parse([]byte(src))
+	doc.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if w.full {
+			return blackfriday.Terminate
+		}
+
+		switch n.Type {
+		case blackfriday.Heading:
+			if entering {
+				if !block(m.headingLoader, 1.8) {
+					return blackfriday.Terminate
+				}
+				w.write(plainText(n), m.headingLoader, codeColors[tokenText], true)
+			}
+			return blackfriday.SkipChildren
+		case blackfriday.Paragraph, blackfriday.TableRow:
+			// the first paragraph of a list item continues the line of the bullet
+			bullet := n.Parent != nil && n.Parent.Type == blackfriday.Item && n.Prev == nil
+			if entering && !bullet && !block(m.fontLoader, 1.6) {
+				return blackfriday.Terminate
+			}
+		case blackfriday.Item:
+			if entering {
+				if !block(m.fontLoader, 1.5) {
+					return blackfriday.Terminate
+				}
+				w.indent = depth(n) * 16
+				w.dot.X = w.minX + fixed.I(w.indent)
+				w.write("• ", m.fontLoader, codeColors[tokenText], false)
+			} else {
+				w.indent = 0
+			}
+		case blackfriday.CodeBlock:
+			if !entering {
+				return blackfriday.GoToNext
+			}
+			code := strings.TrimRight(string(n.Literal), "\n")
+			tokens := []token{{kind: tokenText, text: code}}
+			if info := strings.Fields(string(n.Info)); len(info) > 0 {
+				if l := languageFor("", "code."+info[0]); l != nil {
+					tokens = l.tokenize(code)
+				}
+			}
+			first := true
+			for _, t := range tokens {
+				for j, line := range strings.Split(t.text, "\n") {
+					if first || j > 0 {
+						if !block(m.fontLoader, 1.4) {
+							return blackfriday.Terminate
+						}
+						w.fillLine(codeBackground)
+						first = false
+					}
+					w.write(line, m.fontLoader, codeColors[t.kind], false)
+				}
+			}
+		case blackfriday.HorizontalRule:
+			if entering && block(m.fontLoader, 1.6) {
+				w.rule(markdownRuleColor)
+			}
+		case blackfriday.TableCell:
+			if entering && n.Prev != nil {
+				w.write(" | ", m.fontLoader, markdownQuoteColor, true)
+			}
+		case blackfriday.Text, blackfriday.Code:
+			if entering {
+				w.write(strings.ReplaceAll(string(n.Literal), "\n", " "), m.fontLoader, inlineColor(n), true)
+			}
+		case blackfriday.Softbreak, blackfriday.Hardbreak:
+			w.write(" ", m.fontLoader, codeColors[tokenText], true)
+		case blackfriday.Image:
+			if entering {
+				w.write("["+plainText(n)+"]", m.fontLoader, markdownQuoteColor, true)
+			}
+			return blackfriday.SkipChildren
+		case blackfriday.HTMLBlock, blackfriday.HTMLSpan:
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	return img, nil
+}
+
+// readSource reads the beginning of a text file, binary files are rejected
+func readSource(r io.Reader) (string, error) {
+	b, err := io.ReadAll(io.LimitReader(r, codeMaxRead))
+	if err != nil {
+		return "", err
+	}
+	if len(b) == codeMaxRead {
+		// do not cut a multibyte character
+		for i := 0; i < utf8.UTFMax && !utf8.Valid(b); i++ {
+			b = b[:len(b)-1]
+		}
+	}
+	if !utf8.Valid(b) || bytes.IndexByte(b, 0) >= 0 {
+		return "", errors.New("could not decode the file: no utf-8 text")
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(string(b), "\r\n", "\n"), "\t", strings.Repeat(" ", codeTabWidth)), nil
+}
+
+// inlineColor returns the color of inline text depending on the enclosing elements
+func inlineColor(n *blackfriday.Node) color.RGBA {
+	if n.Type == blackfriday.Code {
+		return codeColors[tokenKeyword]
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		switch p.Type {
+		case blackfriday.Link:
+			return markdownLinkColor
+		case blackfriday.BlockQuote:
+			return markdownQuoteColor
+		}
+	}
+	return codeColors[tokenText]
+}
+
+// plainText returns the text of the node and its children
+func plainText(n *blackfriday.Node) string {
+	var b strings.Builder
+	n.Walk(func(c *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && (c.Type == blackfriday.Text || c.Type == blackfriday.Code) {
+			b.Write(c.Literal)
+		}
+		return blackfriday.GoToNext
+	})
+	return b.String()
+}
+
+// depth returns the nesting depth of a list item
+func depth(n *blackfriday.Node) int {
+	d := 0
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == blackfriday.Item {
+			d++
+		}
+	}
+	return d
+}
+
+// textWriter draws text line by line, picking the font face for every script
+type textWriter struct {
+	img      *image.RGBA
+	analyzer TextAnalyzer
+	opts     AnalysisOpts
+
+	minX, maxX, maxY fixed.Int26_6
+	dot              fixed.Point26_6
+	indent           int
+	lineHeight       fixed.Int26_6
+	full             bool
+}
+
+func newTextWriter(img *image.RGBA, fl *FontLoader) *textWriter {
+	margin := 10
+	b := img.Bounds()
+	size := fixed.I(int(fl.GetFaceOptSize() + 0.5))
+	return &textWriter{
+		img:      img,
+		analyzer: NewTextAnalyzer(fl.GetScriptList()),
+		opts: AnalysisOpts{
+			UseMergeMap: true,
+			MergeMap:    DefaultMergeMap,
+		},
+		minX:       fixed.I(b.Min.X + margin),
+		maxX:       fixed.I(b.Max.X - margin),
+		maxY:       fixed.I(b.Max.Y - margin),
+		dot:        fixed.Point26_6{X: fixed.I(b.Min.X + margin), Y: fixed.I(b.Min.Y+margin) + size},
+		lineHeight: size,
+	}
+}
+
+// newline moves to the next line with the given line spacing relative to the font size, it returns false if the image is full
+func (w *textWriter) newline(fl *FontLoader, spacing float64) bool {
+	size := fixed.Int26_6(fl.GetFaceOptSize() * 64)
+	w.dot.X = w.minX + fixed.I(w.indent)
+	w.dot.Y += fixed.Int26_6(float64(max(w.lineHeight, size)) * spacing)
+	w.lineHeight = size
+	w.full = w.dot.Y > w.maxY
+	return !w.full
+}
+
+// write draws the text in the current line. If wrap is true, words not fitting into the line are moved to the next line,
+// otherwise the text is cut at the border of the image.
+func (w *textWriter) write(s string, fl *FontLoader, c color.Color, wrap bool) {
+	if w.full || s == "" {
+		return
+	}
+	size := fixed.Int26_6(fl.GetFaceOptSize() * 64)
+	w.lineHeight = max(w.lineHeight, size)
+
+	d := &font.Drawer{Dst: w.img, Src: image.NewUniform(c), Dot: w.dot}
+	res := w.analyzer.AnalyzeString(s, w.opts)
+	res.MergeCommon(DefaultMergeMap)
+	for _, sRange := range res.ScriptRanges {
+		face, err := fl.LoadFaceForScript(sRange.TargetScript)
+		if err != nil {
+			continue
+		}
+		d.Face = face.Face
+		text := res.Text[sRange.Low : sRange.High+1]
+		if !wrap {
+			d.DrawString(text)
+			continue
+		}
+		for _, word := range strings.SplitAfter(text, " ") {
+			if d.Dot.X+d.MeasureString(word) > w.maxX && d.Dot.X > w.minX+fixed.I(w.indent) {
+				w.dot = d.Dot
+				if !w.newline(fl, 1.5) {
+					return
+				}
+				d.Dot = w.dot
+				word = strings.TrimLeft(word, " ")
+			}
+			d.DrawString(word)
+		}
+	}
+	w.dot = d.Dot
+}
+
+// fillLine fills the background of the current line
+func (w *textWriter) fillLine(c color.Color) {
+	top := (w.dot.Y - w.lineHeight).Floor() - 2
+	bottom := w.dot.Y.Ceil() + w.lineHeight.Ceil()/2
+	r := image.Rect(w.minX.Floor()-4, top, w.maxX.Ceil()+4, bottom)
+	draw.Draw(w.img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// rule draws a horizontal line in the current line
+func (w *textWriter) rule(c color.Color) {
+	y := (w.dot.Y - w.lineHeight/2).Round()
+	draw.Draw(w.img, image.Rect(w.minX.Floor(), y, w.maxX.Ceil(), y+2), image.NewUniform(c), image.Point{}, draw.Src)
+}
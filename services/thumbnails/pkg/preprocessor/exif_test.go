@@ -0,0 +1,138 @@
+//go:build !enable_vips
+
+package preprocessor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/opencloud-eu/opencloud/pkg/exif"
+	"github.com/opencloud-eu/opencloud/pkg/exif/exiftest"
+)
+
+var (
+	quadrantColors = [2][2]color.RGBA{
+		{{R: 255, A: 255}, {G: 255, A: 255}},
+		{{B: 255, A: 255}, {R: 255, G: 255, B: 255, A: 255}},
+	}
+)
+
+// orientedImage returns the image data stored for a 64x32 image with colored quadrants
+// which is displayed correctly with the given EXIF orientation
+func orientedImage(orientation int) *image.RGBA {
+	const w, h = 64, 32
+	display := func(x, y int) color.RGBA {
+		return quadrantColors[y*2/h][x*2/w]
+	}
+
+	sw, sh := w, h
+	if orientation >= 5 {
+		sw, sh = h, w
+	}
+	stored := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// the position of the displayed pixel in the stored image as defined by the EXIF specification
+			var sx, sy int
+			switch orientation {
+			case 1:
+				sx, sy = x, y
+			case 2:
+				sx, sy = sw-1-x, y
+			case 3:
+				sx, sy = sw-1-x, sh-1-y
+			case 4:
+				sx, sy = x, sh-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, sh-1-x
+			case 7:
+				sx, sy = sw-1-y, sh-1-x
+			case 8:
+				sx, sy = sw-1-y, x
+			}
+			stored.Set(sx, sy, display(x, y))
+		}
+	}
+	return stored
+}
+
+// jpegWithOrientation encodes the image as jpeg with an XMP segment followed by the EXIF segment
+func jpegWithOrientation(img image.Image, orientation int) []byte {
+	var buf bytes.Buffer
+	Expect(jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100})).To(Succeed())
+
+	return exiftest.JPEG(buf.Bytes(), exiftest.TIFF(binary.BigEndian,
+		exiftest.Short(exif.TagOrientation, uint16(orientation)),
+		exiftest.Pointer(exif.TagGPSIFD),
+	))
+}
+
+// tiffWithOrientation encodes the image as uncompressed RGB tiff with the orientation tag
+func tiffWithOrientation(img *image.RGBA, orientation int) []byte {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	tiff := func(stripOffset uint32) []byte {
+		return exiftest.TIFF(binary.LittleEndian,
+			exiftest.Long(256, uint32(w)),
+			exiftest.Long(257, uint32(h)),
+			exiftest.Short(258, 8, 8, 8),
+			exiftest.Short(259, 1),
+			exiftest.Short(262, 2),
+			exiftest.Long(273, stripOffset),
+			exiftest.Short(exif.TagOrientation, uint16(orientation)),
+			exiftest.Short(277, 3),
+			exiftest.Long(278, uint32(h)),
+			exiftest.Long(279, uint32(w*h*3)),
+		)
+	}
+
+	// the pixels follow the TIFF structure, which has the same size with the final strip offset
+	b := tiff(0)
+	b = tiff(uint32(len(b)))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.RGBAAt(x, y)
+			b = append(b, c.R, c.G, c.B)
+		}
+	}
+	return b
+}
+
+// expectQuadrants checks the colors of the quadrants of the decoded image
+func expectQuadrants(img interface{}) {
+	m, ok := img.(image.Image)
+	Expect(ok).To(BeTrue())
+	Expect(m.Bounds().Size()).To(Equal(image.Pt(64, 32)))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			r, g, b, _ := m.At(m.Bounds().Min.X+x*32+16, m.Bounds().Min.Y+y*16+8).RGBA()
+			want := quadrantColors[y][x]
+			Expect([]bool{r > 0x8000, g > 0x8000, b > 0x8000}).To(Equal([]bool{want.R > 0, want.G > 0, want.B > 0}), "quadrant %d,%d", x, y)
+		}
+	}
+}
+
+var _ = Describe("EXIF orientation", func() {
+	for o := 1; o <= 8; o++ {
+		orientation := o
+		It(fmt.Sprintf("should apply the orientation %d to jpeg images", orientation), func() {
+			img, err := ImageDecoder{}.Convert(bytes.NewReader(jpegWithOrientation(orientedImage(orientation), orientation)))
+			Expect(err).ToNot(HaveOccurred())
+			expectQuadrants(img)
+		})
+
+		It(fmt.Sprintf("should apply the orientation %d to tiff images", orientation), func() {
+			img, err := ImageDecoder{}.Convert(bytes.NewReader(tiffWithOrientation(orientedImage(orientation), orientation)))
+			Expect(err).ToNot(HaveOccurred())
+			expectQuadrants(img)
+		})
+	}
+})
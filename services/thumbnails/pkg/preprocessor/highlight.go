@@ -0,0 +1,439 @@
+package preprocessor
+
+import (
+	"go/scanner"
+	gotoken "go/token"
+	"mime"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind classifies the tokens of source code for the syntax highlighting
+type tokenKind int
+
+const (
+	tokenText tokenKind = iota
+	tokenKeyword
+	tokenLiteral
+	tokenString
+	tokenNumber
+	tokenComment
+	tokenKey
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// language describes the syntax of a programming or markup language for the syntax highlighting.
+// The generic tokenizer driven by the description only approximates the grammar of the languages,
+// languages with a scanner in the standard library use it instead.
+type language struct {
+	name string
+	// tokenizer replaces the generic tokenizer
+	tokenizer     func(src string) []token
+	lineComments  []string
+	blockComments [][2]string
+	// quotes delimit strings, quotes with more than one character and backquotes can span multiple lines
+	quotes []string
+	// rawQuotes delimit strings without escape sequences
+	rawQuotes []string
+	keywords  map[string]bool
+	literals  map[string]bool
+	// keys marks identifiers and strings followed by a colon as keys, like in yaml and json
+	keys bool
+	// ignoreCase matches the keywords case insensitively
+	ignoreCase bool
+}
+
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+var (
+	languageGo = &language{
+		name:          "go",
+		tokenizer:     tokenizeGo,
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`},
+		rawQuotes:     []string{"`"},
+		keywords:      words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var"),
+		literals:      languageGoLiterals,
+	}
+	languagePython = &language{
+		name:         "python",
+		lineComments: []string{"#"},
+		quotes:       []string{`"""`, `'''`, `"`, `'`},
+		keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield match case"),
+		literals:     words("True False None self"),
+	}
+	languageJavaScript = &language{
+		name:          "javascript",
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`, "`"},
+		keywords:      words("async await break case catch class const continue debugger default delete do else enum export extends finally for from function if implements import in instanceof interface let new of package private protected public return static super switch this throw try type typeof var void while with yield"),
+		literals:      words("true false null undefined NaN"),
+	}
+	languageJava = &language{
+		name:          "java",
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"""`, `"`, `'`},
+		keywords:      words("abstract assert boolean break byte case catch char class const continue default do double else enum extends final finally float for goto if implements import instanceof int interface long native new package private protected public record return short static strictfp super switch synchronized this throw throws transient try var void volatile while"),
+		literals:      words("true false null"),
+	}
+	languageC = &language{
+		name:          "c",
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`},
+		keywords:      words("auto bool break case catch char class const constexpr continue default delete do double else enum explicit extern float for friend goto if inline int long namespace new operator private protected public register return short signed sizeof static struct switch template this throw try typedef typename union unsigned using virtual void volatile while #include #define #ifdef #ifndef #endif #if #else #pragma"),
+		literals:      words("true false NULL nullptr"),
+	}
+	languageRust = &language{
+		name:          "rust",
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`},
+		keywords:      words("as async await break const continue crate dyn else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while"),
+		literals:      words("true false None Some Ok Err"),
+	}
+	languageShell = &language{
+		name:         "shell",
+		lineComments: []string{"#"},
+		quotes:       []string{`"`},
+		rawQuotes:    []string{`'`},
+		keywords:     words("if then else elif fi case esac for while until do done in function return local export readonly set unset shift exit echo source"),
+		literals:     words("true false"),
+	}
+	languageYAML = &language{
+		name:         "yaml",
+		lineComments: []string{"#"},
+		quotes:       []string{`"`},
+		rawQuotes:    []string{`'`},
+		literals:     words("true false null yes no on off ~"),
+		keys:         true,
+	}
+	languageJSON = &language{
+		name:     "json",
+		quotes:   []string{`"`},
+		literals: words("true false null"),
+		keys:     true,
+	}
+	languageSQL = &language{
+		name:          "sql",
+		lineComments:  []string{"--"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		rawQuotes:     []string{`'`, `"`},
+		keywords:      words("add alter and as asc begin between by case check column commit constraint create cross database default delete desc distinct drop else end exists foreign from full group having if in index inner insert into is join key left like limit not null offset on or order outer primary references right rollback select set table then union unique update values view when where with"),
+		literals:      words("true false"),
+		ignoreCase:    true,
+	}
+	languageCSS = &language{
+		name:          "css",
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`},
+		literals:      words("important inherit initial none auto"),
+		keys:          true,
+	}
+)
+
+var (
+	// languagesByExtension selects the language of a file by its extension
+	languagesByExtension = map[string]*language{
+		"go": languageGo,
+		"py": languagePython, "pyw": languagePython,
+		"js": languageJavaScript, "mjs": languageJavaScript, "cjs": languageJavaScript, "jsx": languageJavaScript,
+		"ts": languageJavaScript, "tsx": languageJavaScript, "vue": languageJavaScript,
+		"java": languageJava, "kt": languageJava, "scala": languageJava,
+		"c": languageC, "h": languageC, "cc": languageC, "cpp": languageC, "hpp": languageC, "cxx": languageC, "cs": languageC,
+		"rs": languageRust,
+		"sh": languageShell, "bash": languageShell, "zsh": languageShell,
+		"yaml": languageYAML, "yml": languageYAML,
+		"json": languageJSON,
+		"sql":  languageSQL,
+		"css":  languageCSS, "scss": languageCSS,
+	}
+	// languagesByMimeType selects the language of a file by its mime type
+	languagesByMimeType = map[string]*language{
+		"text/x-go":                 languageGo,
+		"text/x-python":             languagePython,
+		"text/x-script.python":      languagePython,
+		"application/javascript":    languageJavaScript,
+		"text/javascript":           languageJavaScript,
+		"application/typescript":    languageJavaScript,
+		"text/x-java-source":        languageJava,
+		"text/x-java":               languageJava,
+		"text/x-c":                  languageC,
+		"text/x-csrc":               languageC,
+		"text/x-c++src":             languageC,
+		"text/x-rust":               languageRust,
+		"application/x-sh":          languageShell,
+		"application/x-shellscript": languageShell,
+		"text/x-shellscript":        languageShell,
+		"text/yaml":                 languageYAML,
+		"text/x-yaml":               languageYAML,
+		"application/yaml":          languageYAML,
+		"application/x-yaml":        languageYAML,
+		"application/json":          languageJSON,
+		"application/x-sql":         languageSQL,
+		"application/sql":           languageSQL,
+		"text/x-sql":                languageSQL,
+		"text/css":                  languageCSS,
+	}
+	markdownExtensions = map[string]bool{"md": true, "markdown": true, "mkd": true}
+	markdownMimeTypes  = map[string]bool{"text/markdown": true, "text/x-markdown": true}
+	// genericMimeTypes are the mime types of files which are selected by their extension.
+	// Most source files have no registered mime type and are detected as one of these.
+	genericMimeTypes = map[string]bool{"": true, "text/plain": true, "application/octet-stream": true}
+)
+
+// languageFor selects the language by the mime type or, for generic mime types, by the extension of the file name
+func languageFor(mimeType, filename string) *language {
+	mimeType, _, _ = mime.ParseMediaType(mimeType)
+	if l, ok := languagesByMimeType[mimeType]; ok {
+		return l
+	}
+	if !genericMimeTypes[mimeType] {
+		return nil
+	}
+	return languagesByExtension[extension(filename)]
+}
+
+func isMarkdown(mimeType, filename string) bool {
+	mimeType, _, _ = mime.ParseMediaType(mimeType)
+	return markdownMimeTypes[mimeType] || genericMimeTypes[mimeType] && markdownExtensions[extension(filename)]
+}
+
+func extension(filename string) string {
+	return strings.ToLower(strings.TrimPrefix(path.Ext(filename), "."))
+}
+
+// IsSourceFile returns true if a thumbnail with syntax highlighting can be rendered for the file
+func IsSourceFile(mimeType, filename string) bool {
+	return languageFor(mimeType, filename) != nil || isMarkdown(mimeType, filename)
+}
+
+// tokenize splits the source code into tokens
+func (l *language) tokenize(src string) []token {
+	if l.tokenizer != nil {
+		return l.tokenizer(src)
+	}
+
+	var (
+		tokens []token
+		i      int
+	)
+	emit := func(kind tokenKind, end int) {
+		if end > len(src) {
+			end = len(src)
+		}
+		tokens = append(tokens, token{kind: kind, text: src[i:end]})
+		i = end
+	}
+
+Scan:
+	for i < len(src) {
+		rest := src[i:]
+
+		for _, c := range l.lineComments {
+			if strings.HasPrefix(rest, c) {
+				end := strings.IndexByte(rest, '\n')
+				if end < 0 {
+					end = len(rest)
+				}
+				emit(tokenComment, i+end)
+				continue Scan
+			}
+		}
+		for _, c := range l.blockComments {
+			if strings.HasPrefix(rest, c[0]) {
+				end := strings.Index(rest[len(c[0]):], c[1])
+				if end < 0 {
+					emit(tokenComment, len(src))
+				} else {
+					emit(tokenComment, i+len(c[0])+end+len(c[1]))
+				}
+				continue Scan
+			}
+		}
+		for _, q := range l.quotes {
+			if strings.HasPrefix(rest, q) {
+				emit(tokenString, i+closingQuote(rest, q, true))
+				continue Scan
+			}
+		}
+		for _, q := range l.rawQuotes {
+			if strings.HasPrefix(rest, q) {
+				emit(tokenString, i+closingQuote(rest, q, false))
+				continue Scan
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case unicode.IsDigit(r):
+			end := strings.IndexFunc(rest, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '_'
+			})
+			if end < 0 {
+				end = len(rest)
+			}
+			emit(tokenNumber, i+end)
+		case isIdentifierRune(r) || r == '#' && l == languageC:
+			end := strings.IndexFunc(rest[size:], func(r rune) bool {
+				return !isIdentifierRune(r) && !unicode.IsDigit(r) && r != '-'
+			})
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += size
+			}
+			word := rest[:end]
+			if !l.keys {
+				// dashes are only part of the identifiers of yaml and css keys
+				if j := strings.IndexByte(word, '-'); j > 0 {
+					word = word[:j]
+				}
+			}
+			kind := tokenText
+			switch w := word; {
+			case l.ignoreCase && l.keywords[strings.ToLower(w)], l.keywords[w]:
+				kind = tokenKeyword
+			case l.literals[w]:
+				kind = tokenLiteral
+			}
+			emit(kind, i+len(word))
+		default:
+			emit(tokenText, i+size)
+		}
+	}
+
+	if l.keys {
+		markKeys(tokens)
+	}
+	return tokens
+}
+
+// tokenizeGo splits go source code into tokens using the scanner of the go parser
+func tokenizeGo(src string) []token {
+	var (
+		tokens []token
+		s      scanner.Scanner
+		end    int
+	)
+	fset := gotoken.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	// syntax errors are rendered as text, the scanner continues after them
+	s.Init(file, []byte(src), nil, scanner.ScanComments)
+
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == gotoken.EOF {
+			break
+		}
+		if tok == gotoken.SEMICOLON && lit == "\n" {
+			// automatically inserted semicolons are no part of the source
+			continue
+		}
+
+		start := file.Offset(pos)
+		if start > end {
+			tokens = append(tokens, token{kind: tokenText, text: src[end:start]})
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		end = literalEnd(src, start, lit)
+
+		kind := tokenText
+		switch {
+		case tok.IsKeyword():
+			kind = tokenKeyword
+		case tok == gotoken.COMMENT:
+			kind = tokenComment
+		case tok == gotoken.STRING || tok == gotoken.CHAR:
+			kind = tokenString
+		case tok == gotoken.INT || tok == gotoken.FLOAT || tok == gotoken.IMAG:
+			kind = tokenNumber
+		case tok == gotoken.IDENT && languageGoLiterals[lit]:
+			kind = tokenLiteral
+		}
+		tokens = append(tokens, token{kind: kind, text: src[start:end]})
+	}
+
+	if end < len(src) {
+		tokens = append(tokens, token{kind: tokenText, text: src[end:]})
+	}
+	return tokens
+}
+
+// languageGoLiterals are the predeclared identifiers highlighted as literals
+var languageGoLiterals = words("true false nil iota")
+
+// literalEnd returns the end of the literal in the source. The scanner removes the carriage returns
+// from comments and raw strings, they are skipped when comparing the literal.
+func literalEnd(src string, start int, lit string) int {
+	i, j := start, 0
+	for i < len(src) && j < len(lit) {
+		switch {
+		case src[i] == lit[j]:
+			j++
+		case src[i] != '\r':
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// markKeys marks identifiers and strings followed by a colon as keys
+func markKeys(tokens []token) {
+	for i, t := range tokens {
+		if t.kind != tokenText && t.kind != tokenString || strings.TrimSpace(t.text) == "" {
+			continue
+		}
+		if t.kind == tokenText && !isIdentifierRune([]rune(t.text)[0]) {
+			continue
+		}
+		for _, next := range tokens[i+1:] {
+			if next.text == " " || next.text == "\t" {
+				continue
+			}
+			if next.text == ":" {
+				tokens[i].kind = tokenKey
+			}
+			break
+		}
+	}
+}
+
+// closingQuote returns the end of the string starting with the quote.
+// Strings delimited by a single character other than a backquote end at the end of the line.
+func closingQuote(s, q string, escapes bool) int {
+	multiline := len(q) > 1 || q == "`"
+	for i := len(q); i < len(s); i++ {
+		switch {
+		case escapes && s[i] == '\\':
+			i++
+		case strings.HasPrefix(s[i:], q):
+			return i + len(q)
+		case s[i] == '\n' && !multiline:
+			return i
+		}
+	}
+	return len(s)
+}
+
+func isIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '$'
+}
@@ -279,35 +279,24 @@ func ForType(mimeType string, opts map[string]interface{}) FileConverter {
 	// We can ignore the error here because we parse it in IsMimeTypeSupported before and if it fails
 	// return the service call. So we should only get here when the mimeType parses fine.
 	mimeType, _, _ = mime.ParseMediaType(mimeType)
-	switch mimeType {
-	case "text/plain":
-		fontFileMap := ""
-		fontFaceOpts := &opentype.FaceOptions{
-			Size:    12,
-			DPI:     72,
-			Hinting: font.HintingNone,
+	filename, _ := opts["filename"].(string)
+	switch {
+	case isMarkdown(mimeType, filename):
+		return MarkdownToImageConverter{
+			fontLoader:    fontLoaderFromOpts(opts, 1),
+			headingLoader: fontLoaderFromOpts(opts, 1.5),
 		}
-
-		if optedFontFileMap, ok := opts["fontFileMap"]; ok {
-			if stringFontFileMap, ok := optedFontFileMap.(string); ok {
-				fontFileMap = stringFontFileMap
-			}
-		}
-
-		if optedFontFaceOpts, ok := opts["fontFaceOpts"]; ok {
-			if typedFontFaceOpts, ok := optedFontFaceOpts.(*opentype.FaceOptions); ok {
-				fontFaceOpts = typedFontFaceOpts
-			}
+	case languageFor(mimeType, filename) != nil:
+		return CodeToImageConverter{
+			fontLoader: fontLoaderFromOpts(opts, 1),
+			language:   languageFor(mimeType, filename),
 		}
+	}
 
-		fontLoader, err := NewFontLoader(fontFileMap, fontFaceOpts)
-		if err != nil {
-			// if it couldn't create the FontLoader with the specified fontFileMap,
-			// try to use the default font
-			fontLoader, _ = NewFontLoader("", fontFaceOpts)
-		}
+	switch mimeType {
+	case "text/plain":
 		return TxtToImageConverter{
-			fontLoader: fontLoader,
+			fontLoader: fontLoaderFromOpts(opts, 1),
 		}
 	case "application/vnd.geogebra.slides":
 		return GgsDecoder{"_slide0/geogebra_thumbnail.png"}
@@ -315,6 +304,8 @@ func ForType(mimeType string, opts map[string]interface{}) FileConverter {
 		return GgpDecoder{}
 	case "image/gif":
 		return GifDecoder{}
+	case "image/svg+xml":
+		return SvgDecoder{}
 	case "audio/flac":
 		fallthrough
 	case "audio/mpeg":
@@ -325,3 +316,39 @@ func ForType(mimeType string, opts map[string]interface{}) FileConverter {
 		return ImageDecoder{}
 	}
 }
+
+// fontLoaderFromOpts creates the FontLoader with the fontFileMap and fontFaceOpts options, the font size is multiplied by scale
+func fontLoaderFromOpts(opts map[string]interface{}, scale float64) *FontLoader {
+	fontFileMap := ""
+	fontFaceOpts := &opentype.FaceOptions{
+		Size:    12,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	}
+
+	if optedFontFileMap, ok := opts["fontFileMap"]; ok {
+		if stringFontFileMap, ok := optedFontFileMap.(string); ok {
+			fontFileMap = stringFontFileMap
+		}
+	}
+
+	if optedFontFaceOpts, ok := opts["fontFaceOpts"]; ok {
+		if typedFontFaceOpts, ok := optedFontFaceOpts.(*opentype.FaceOptions); ok {
+			fontFaceOpts = typedFontFaceOpts
+		}
+	}
+
+	if scale != 1 {
+		scaled := *fontFaceOpts
+		scaled.Size *= scale
+		fontFaceOpts = &scaled
+	}
+
+	fontLoader, err := NewFontLoader(fontFileMap, fontFaceOpts)
+	if err != nil {
+		// if it couldn't create the FontLoader with the specified fontFileMap,
+		// try to use the default font
+		fontLoader, _ = NewFontLoader("", fontFaceOpts)
+	}
+	return fontLoader
+}
@@ -3,20 +3,50 @@
 package preprocessor
 
 import (
+	"bytes"
+	"image"
 	"io"
 
 	"github.com/kovidgoyal/imaging"
 	"github.com/pkg/errors"
+
+	"github.com/opencloud-eu/opencloud/pkg/exif"
 )
 
 // ImageDecoder is a converter for the image file
 type ImageDecoder struct{}
 
-// Convert reads the image file and returns the thumbnail image
+// Convert reads the image file and returns the thumbnail image, rotated and flipped according to the EXIF orientation
 func (i ImageDecoder) Convert(r io.Reader) (interface{}, error) {
-	img, err := imaging.Decode(r, imaging.AutoOrientation(true))
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, `could not read the image`)
+	}
+	img, err := imaging.Decode(bytes.NewReader(b))
 	if err != nil {
 		return nil, errors.Wrap(err, `could not decode the image`)
 	}
-	return img, nil
+	return fixOrientation(img, exif.Orientation(b)), nil
+}
+
+// fixOrientation transforms the image so that it is displayed as intended by the EXIF orientation
+func fixOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	default:
+		return img
+	}
 }
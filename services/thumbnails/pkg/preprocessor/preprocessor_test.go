@@ -2,8 +2,11 @@ package preprocessor
 
 import (
 	"bytes"
+	"image"
+	"image/color"
 	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"golang.org/x/image/font"
@@ -11,6 +14,8 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	thumbnailerErrors "github.com/opencloud-eu/opencloud/services/thumbnails/pkg/errors"
 )
 
 func TestImageDecoder(t *testing.T) {
@@ -151,6 +156,184 @@ var _ = Describe("ImageDecoder", func() {
 		})
 	})
 
+	Describe("should render source code", func() {
+		It("should tokenize the source code", func() {
+			tokens := languageGo.tokenize("func main() { // start\n\treturn \"a\\\"b\" }")
+			Expect(tokens).To(ContainElements(
+				token{kind: tokenKeyword, text: "func"},
+				token{kind: tokenText, text: "main"},
+				token{kind: tokenComment, text: "// start"},
+				token{kind: tokenKeyword, text: "return"},
+				token{kind: tokenString, text: `"a\"b"`},
+			))
+		})
+
+		It("should tokenize go with the go scanner", func() {
+			src := "x := `raw\r\nstring` /* a\r\nb */ + 0x1F + 'c' // done\r\n"
+			tokens := languageGo.tokenize(src)
+			Expect(tokens).To(ContainElements(
+				token{kind: tokenString, text: "`raw\r\nstring`"},
+				token{kind: tokenComment, text: "/* a\r\nb */"},
+				token{kind: tokenNumber, text: "0x1F"},
+				token{kind: tokenString, text: "'c'"},
+				token{kind: tokenComment, text: "// done"},
+			))
+
+			var b strings.Builder
+			for _, t := range tokens {
+				b.WriteString(t.text)
+			}
+			Expect(b.String()).To(Equal(src))
+		})
+
+		It("should mark keys", func() {
+			tokens := languageYAML.tokenize("name: \"value\" # comment\nenabled: true")
+			Expect(tokens).To(ContainElements(
+				token{kind: tokenKey, text: "name"},
+				token{kind: tokenString, text: `"value"`},
+				token{kind: tokenComment, text: "# comment"},
+				token{kind: tokenKey, text: "enabled"},
+				token{kind: tokenLiteral, text: "true"},
+			))
+		})
+
+		It("should highlight the code", func() {
+			decoder := ForType("application/octet-stream", map[string]interface{}{"filename": "main.go"})
+			Expect(decoder).To(BeAssignableToTypeOf(CodeToImageConverter{}))
+
+			img, err := decoder.Convert(strings.NewReader("package main\n\nfunc main() {}\n"))
+			Expect(err).ToNot(HaveOccurred())
+			rgba := img.(*image.RGBA)
+			Expect(rgba.Bounds()).To(Equal(image.Rect(0, 0, 640, 480)))
+
+			colors := map[color.Color]bool{}
+			for x := 0; x < 200; x++ {
+				for y := 0; y < 30; y++ {
+					colors[rgba.At(x, y)] = true
+				}
+			}
+			// the keyword is drawn in the keyword color
+			Expect(colors).To(HaveKey(codeColors[tokenKeyword]))
+		})
+
+		It("should render markdown", func() {
+			decoder := ForType("text/markdown", nil)
+			Expect(decoder).To(BeAssignableToTypeOf(MarkdownToImageConverter{}))
+
+			md := "# Title\n\nSome *text* with a [link](https://example.com).\n\n- item\n\n```go\nfunc main() {}\n```\n"
+			img, err := decoder.Convert(strings.NewReader(md))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(img).ToNot(BeNil())
+		})
+
+		It("should return an error for binary files", func() {
+			decoder := ForType("text/plain", map[string]interface{}{"filename": "main.go"})
+			_, err := decoder.Convert(bytes.NewReader([]byte{0x00, 0xff, 0xfe}))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("SvgDecoder", func() {
+		It("should rasterize an svg image", func() {
+			fileContent, err := os.ReadFile("test_assets/logo.svg")
+			Expect(err).ToNot(HaveOccurred())
+
+			img, err := SvgDecoder{}.Convert(bytes.NewReader(fileContent))
+			Expect(err).ToNot(HaveOccurred())
+			rgba, ok := img.(*image.RGBA)
+			Expect(ok).To(BeTrue())
+			Expect(rgba.Bounds()).To(Equal(image.Rect(0, 0, 2048, 1024)))
+
+			// the rect styled by a class, the transformed circle and the stroke, the external image is not loaded
+			Expect(rgba.At(500, 500)).To(Equal(color.RGBA{R: 255, A: 255}))
+			Expect(rgba.At(1500, 500)).To(Equal(color.RGBA{G: 128, A: 255}))
+			Expect(rgba.At(1500, 920)).To(Equal(color.RGBA{B: 255, A: 255}))
+			Expect(rgba.At(1500, 50)).To(Equal(color.RGBA{}))
+		})
+
+		It("should render paths with curves and arcs", func() {
+			svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><path d="M1,5 a4,4 0 1,0 8,0 a4 4 0 1 0-8 0z" fill="#00f"/></svg>`
+			img, err := SvgDecoder{}.Convert(strings.NewReader(svg))
+			Expect(err).ToNot(HaveOccurred())
+			rgba := img.(*image.RGBA)
+			Expect(rgba.At(1024, 1024)).To(Equal(color.RGBA{B: 255, A: 255}))
+			Expect(rgba.At(100, 100)).To(Equal(color.RGBA{}))
+		})
+
+		It("should not expand entities", func() {
+			svg := `<!DOCTYPE svg [<!ENTITY a "aaaaaaaaaa"><!ENTITY b "&a;&a;&a;&a;">]><svg xmlns="http://www.w3.org/2000/svg"><desc>&b;</desc></svg>`
+			_, err := SvgDecoder{}.Convert(strings.NewReader(svg))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject too complex images", func() {
+			svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10">` + strings.Repeat(`<rect width="10" height="10"/>`, 5000) + `</svg>`
+			_, err := SvgDecoder{}.Convert(strings.NewReader(svg))
+			Expect(err).To(MatchError(thumbnailerErrors.ErrSVGTooComplex))
+		})
+
+		It("should reject images using unsupported features", func() {
+			for _, el := range []string{
+				`<text>Text</text>`,
+				`<g><use href="#a"/></g>`,
+				`<defs><clipPath id="c"><rect width="5" height="5"/></clipPath></defs><rect width="10" height="10" clip-path="url(#c)"/>`,
+				`<mask id="m"/><g style="mask: url(#m)"><rect width="10" height="10"/></g>`,
+				`<filter id="f"/><rect width="10" height="10" filter="url(#f)"/>`,
+			} {
+				svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10">` + el + `</svg>`
+				_, err := SvgDecoder{}.Convert(strings.NewReader(svg))
+				Expect(err).To(MatchError(thumbnailerErrors.ErrSVGUnsupported), el)
+			}
+		})
+
+		It("should ignore unsupported elements which are not rendered", func() {
+			svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10">
+				<defs><clipPath id="c"><text>Text</text></clipPath><use href="#a"/></defs>
+				<mask id="m"><rect width="5" height="5"/></mask>
+				<rect width="10" height="10" fill="#f00"/>
+			</svg>`
+			img, err := SvgDecoder{}.Convert(strings.NewReader(svg))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(img.(*image.RGBA).At(1024, 1024)).To(Equal(color.RGBA{R: 255, A: 255}))
+		})
+
+		It("should paint gradients with the color of their first stop", func() {
+			svg := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 30 10">
+				<rect width="10" height="10" fill="url(#b)"/>
+				<rect x="10" width="10" height="10" style="fill: url('#a')"/>
+				<rect x="20" width="10" height="10" fill="url(#p)"/>
+				<defs>
+					<linearGradient id="a"><stop offset="0" style="stop-color: #00f"/><stop offset="1" stop-color="#f00"/></linearGradient>
+					<radialGradient id="b" xlink:href="#c"/>
+					<linearGradient id="c"><stop offset="0" stop-color="#0f0"/></linearGradient>
+					<pattern id="p"><rect width="1" height="1" fill="#f00"/></pattern>
+				</defs>
+			</svg>`
+			img, err := SvgDecoder{}.Convert(strings.NewReader(svg))
+			Expect(err).ToNot(HaveOccurred())
+			rgba := img.(*image.RGBA)
+			Expect(rgba.At(300, 300)).To(Equal(color.RGBA{G: 255, A: 255}))
+			Expect(rgba.At(1000, 300)).To(Equal(color.RGBA{B: 255, A: 255}))
+			// patterns are painted gray
+			Expect(rgba.At(1700, 300)).To(Equal(color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 255}))
+		})
+
+		It("should read the style sheet up to the end of the style element", func() {
+			svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10">
+				<style><x/>.a { fill: #00f }</style>
+				<rect class="a" width="10" height="10"/>
+			</svg>`
+			img, err := SvgDecoder{}.Convert(strings.NewReader(svg))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(img.(*image.RGBA).At(1024, 1024)).To(Equal(color.RGBA{B: 255, A: 255}))
+		})
+
+		It("should return an error if the image is no svg", func() {
+			_, err := SvgDecoder{}.Convert(strings.NewReader(`<html></html>`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("test ForType", func() {
 		It("should return an ImageDecoder for image types", func() {
 			decoder := ForType("image/png", nil)
@@ -168,6 +351,11 @@ var _ = Describe("ImageDecoder", func() {
 			Expect(decoder).To(BeAssignableToTypeOf(ImageDecoder{}))
 		})
 
+		It("should return an SvgDecoder for svg types", func() {
+			decoder := ForType("image/svg+xml", nil)
+			Expect(decoder).To(BeAssignableToTypeOf(SvgDecoder{}))
+		})
+
 		It("should return an AudioDecoder for audio types", func() {
 			decoder := ForType("audio/mpeg", nil)
 			Expect(decoder).To(BeAssignableToTypeOf(AudioDecoder{}))
@@ -178,6 +366,17 @@ var _ = Describe("ImageDecoder", func() {
 			Expect(decoder).To(BeAssignableToTypeOf(TxtToImageConverter{}))
 		})
 
+		It("should return a CodeToImageConverter for source code", func() {
+			Expect(ForType("application/json", nil)).To(BeAssignableToTypeOf(CodeToImageConverter{}))
+			Expect(ForType("text/plain", map[string]interface{}{"filename": "script.py"})).To(BeAssignableToTypeOf(CodeToImageConverter{}))
+			Expect(ForType("text/plain", map[string]interface{}{"filename": "notes.txt"})).To(BeAssignableToTypeOf(TxtToImageConverter{}))
+			Expect(ForType("video/mp2t", map[string]interface{}{"filename": "video.ts"})).To(BeAssignableToTypeOf(ImageDecoder{}))
+		})
+
+		It("should return a MarkdownToImageConverter for markdown", func() {
+			Expect(ForType("text/plain", map[string]interface{}{"filename": "README.md"})).To(BeAssignableToTypeOf(MarkdownToImageConverter{}))
+		})
+
 		It("should return an ImageDecoder for unknown types", func() {
 			decoder := ForType("unknown", nil)
 			Expect(decoder).To(BeAssignableToTypeOf(ImageDecoder{}))
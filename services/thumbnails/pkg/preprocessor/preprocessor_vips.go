@@ -14,7 +14,15 @@ func init() {
 
 type ImageDecoder struct{}
 
+// Convert loads the image and rotates it according to the EXIF orientation
 func (v ImageDecoder) Convert(r io.Reader) (interface{}, error) {
 	img, err := vips.NewImageFromReader(r)
-	return img, err
+	if err != nil {
+		return nil, err
+	}
+	if err := img.AutoRotate(); err != nil {
+		img.Close()
+		return nil, err
+	}
+	return img, nil
 }
@@ -0,0 +1,1323 @@
+package preprocessor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/vector"
+
+	thumbnailerErrors "github.com/opencloud-eu/opencloud/services/thumbnails/pkg/errors"
+)
+
+const (
+	// svgMaxFileSize is the maximum size of an svg file
+	svgMaxFileSize = 10 << 20
+	// svgMaxElements is the maximum number of elements of an svg file
+	svgMaxElements = 20000
+	// svgMaxDepth is the maximum nesting depth of the elements
+	svgMaxDepth = 64
+	// svgMaxPoints is the maximum number of points of all shapes after flattening the curves
+	svgMaxPoints = 1 << 20
+	// svgMaxArea is the maximum number of pixels rasterized for all shapes
+	svgMaxArea = 64 << 20
+	// svgRenderSize is the length of the longest side of the rasterized image
+	svgRenderSize = 2048
+)
+
+// svgIgnoredElements are not rendered together with their children
+var svgIgnoredElements = map[string]bool{
+	"defs": true, "symbol": true, "image": true, "foreignObject": true, "marker": true,
+	"script": true, "style": true, "metadata": true, "title": true, "desc": true,
+	"linearGradient": true, "radialGradient": true, "pattern": true, "clipPath": true, "mask": true, "filter": true,
+}
+
+// svgUnsupportedElements change the rendering in ways the rasterizer can't reproduce, images rendering
+// them are rejected instead of rendering a misleading thumbnail
+var svgUnsupportedElements = map[string]bool{
+	"use": true, "text": true, "textPath": true,
+}
+
+// svgUnsupportedReferences are the properties referencing elements the rasterizer can't apply
+var svgUnsupportedReferences = map[string]bool{
+	"clip-path": true, "mask": true, "filter": true,
+}
+
+// SvgDecoder is a converter for svg images. It rasterizes the shapes and paths with solid colors,
+// gradients are painted with the color of their first stop. External resources are never loaded,
+// images rendering text or `use` references and shapes using filters, clip paths or masks are
+// rejected with ErrSVGUnsupported.
+type SvgDecoder struct{}
+
+// Convert reads the svg file and returns the rasterized image
+func (i SvgDecoder) Convert(r io.Reader) (interface{}, error) {
+	b, err := io.ReadAll(io.LimitReader(r, svgMaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > svgMaxFileSize {
+		return nil, thumbnailerErrors.ErrImageTooLarge
+	}
+
+	// entities defined in a doctype are not expanded, the decoder fails on unknown entities
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	rd := &svgRenderer{css: map[string][]svgDeclaration{}, gradients: svgGradients(b)}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, `could not decode the image`)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if err := rd.start(t); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			rd.end()
+		case xml.CharData:
+			// only the text of the style element itself belongs to the style sheet
+			if rd.inStyle == 1 {
+				rd.styleText.Write(t)
+			}
+		}
+	}
+
+	if rd.img == nil {
+		return nil, errors.New(`could not decode the image: no svg element found`)
+	}
+	return rd.img, nil
+}
+
+type svgPoint struct{ x, y float64 }
+
+// svgMatrix is an affine transformation [a c e; b d f]
+type svgMatrix struct{ a, b, c, d, e, f float64 }
+
+var svgIdentity = svgMatrix{a: 1, d: 1}
+
+func (m svgMatrix) apply(x, y float64) svgPoint {
+	return svgPoint{m.a*x + m.c*y + m.e, m.b*x + m.d*y + m.f}
+}
+
+// mul returns the transformation applying n first and m second
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		a: m.a*n.a + m.c*n.b,
+		b: m.b*n.a + m.d*n.b,
+		c: m.a*n.c + m.c*n.d,
+		d: m.b*n.c + m.d*n.d,
+		e: m.a*n.e + m.c*n.f + m.e,
+		f: m.b*n.e + m.d*n.f + m.f,
+	}
+}
+
+// scale returns the average scale factor of the transformation
+func (m svgMatrix) scale() float64 {
+	return math.Sqrt(math.Abs(m.a*m.d - m.b*m.c))
+}
+
+type svgPaint struct {
+	none    bool
+	current bool
+	c       color.NRGBA
+}
+
+type svgStyle struct {
+	fill, stroke                        svgPaint
+	fillOpacity, strokeOpacity, opacity float64
+	strokeWidth                         float64
+	color                               color.NRGBA
+	hidden                              bool
+	transform                           svgMatrix
+	// unsupported is the property referencing an element the rasterizer can't apply
+	unsupported string
+}
+
+type svgDeclaration struct{ property, value string }
+
+type svgRenderer struct {
+	img *image.RGBA
+	// the styles of the open elements
+	stack []svgStyle
+	// the nesting depth inside an ignored element
+	skip int
+
+	// the nesting depth inside a style element
+	inStyle   int
+	styleText bytes.Buffer
+	// css maps simple selectors like `.class` and `element` to declarations
+	css map[string][]svgDeclaration
+	// gradients maps the ids of the gradients to the color of their first stop
+	gradients map[string]svgPaint
+
+	// the viewport size in user units, used for percentages
+	vw, vh float64
+
+	elements, points, area int
+}
+
+func (rd *svgRenderer) start(e xml.StartElement) error {
+	rd.elements++
+	if rd.elements > svgMaxElements {
+		return thumbnailerErrors.ErrSVGTooComplex
+	}
+
+	name := e.Name.Local
+	if rd.inStyle > 0 {
+		rd.inStyle++
+	} else if name == "style" {
+		rd.inStyle = 1
+		rd.styleText.Reset()
+	}
+	if rd.skip > 0 {
+		rd.skip++
+		return nil
+	}
+	// unsupported elements are only rejected where they would be rendered
+	if svgUnsupportedElements[name] {
+		return errors.Wrapf(thumbnailerErrors.ErrSVGUnsupported, "element '%s'", name)
+	}
+	if len(rd.stack) >= svgMaxDepth {
+		return thumbnailerErrors.ErrSVGTooComplex
+	}
+
+	attrs := make(map[string]string, len(e.Attr))
+	for _, a := range e.Attr {
+		attrs[a.Name.Local] = a.Value
+	}
+
+	if rd.img == nil {
+		if name != "svg" {
+			return errors.New(`could not decode the image: the root element is no svg element`)
+		}
+		m := rd.viewport(attrs)
+		style := rd.style(name, attrs, svgStyle{
+			fill:          svgPaint{c: color.NRGBA{A: 255}},
+			stroke:        svgPaint{none: true},
+			fillOpacity:   1,
+			strokeOpacity: 1,
+			opacity:       1,
+			strokeWidth:   1,
+			color:         color.NRGBA{A: 255},
+			transform:     svgIdentity,
+		})
+		if style.unsupported != "" {
+			return errors.Wrapf(thumbnailerErrors.ErrSVGUnsupported, "property '%s'", style.unsupported)
+		}
+		style.transform = m.mul(style.transform)
+		rd.stack = append(rd.stack, style)
+		return nil
+	}
+
+	if svgIgnoredElements[name] {
+		rd.skip = 1
+		return nil
+	}
+
+	style := rd.style(name, attrs, rd.stack[len(rd.stack)-1])
+	if style.hidden {
+		rd.skip = 1
+		return nil
+	}
+	if style.unsupported != "" {
+		return errors.Wrapf(thumbnailerErrors.ErrSVGUnsupported, "property '%s'", style.unsupported)
+	}
+	rd.stack = append(rd.stack, style)
+
+	switch name {
+	case "g", "svg", "a", "switch":
+		return nil
+	case "path", "rect", "circle", "ellipse", "line", "polyline", "polygon":
+		return rd.shape(name, attrs, style)
+	default:
+		// unknown elements are not rendered
+		rd.stack = rd.stack[:len(rd.stack)-1]
+		rd.skip = 1
+		return nil
+	}
+}
+
+func (rd *svgRenderer) end() {
+	if rd.inStyle > 0 {
+		rd.inStyle--
+		if rd.inStyle == 0 {
+			rd.parseCSS(rd.styleText.String())
+		}
+	}
+	if rd.skip > 0 {
+		rd.skip--
+		return
+	}
+	if len(rd.stack) > 0 {
+		rd.stack = rd.stack[:len(rd.stack)-1]
+	}
+}
+
+// viewport creates the canvas and returns the transformation of the root element
+func (rd *svgRenderer) viewport(attrs map[string]string) svgMatrix {
+	w, _ := svgLength(attrs["width"], 0)
+	h, _ := svgLength(attrs["height"], 0)
+
+	var vb []float64
+	if s, ok := attrs["viewBox"]; ok {
+		vb = svgNumbers(s)
+		if len(vb) != 4 || vb[2] <= 0 || vb[3] <= 0 {
+			vb = nil
+		}
+	}
+
+	switch {
+	case w > 0 && h > 0:
+	case vb != nil && w > 0:
+		h = w * vb[3] / vb[2]
+	case vb != nil && h > 0:
+		w = h * vb[2] / vb[3]
+	case vb != nil:
+		w, h = vb[2], vb[3]
+	default:
+		w, h = 300, 150
+	}
+
+	s := svgRenderSize / math.Max(w, h)
+	rd.img = image.NewRGBA(image.Rect(0, 0, max(int(math.Ceil(w*s)), 1), max(int(math.Ceil(h*s)), 1)))
+
+	m := svgMatrix{a: s, d: s}
+	if vb == nil {
+		rd.vw, rd.vh = w, h
+		return m
+	}
+
+	rd.vw, rd.vh = vb[2], vb[3]
+	sx, sy := w/vb[2], h/vb[3]
+	if !strings.HasPrefix(strings.TrimSpace(attrs["preserveAspectRatio"]), "none") {
+		// xMidYMid meet
+		sx = math.Min(sx, sy)
+		sy = sx
+	}
+	return m.mul(svgMatrix{
+		a: sx,
+		d: sy,
+		e: (w-vb[2]*sx)/2 - vb[0]*sx,
+		f: (h-vb[3]*sy)/2 - vb[1]*sy,
+	})
+}
+
+// style returns the style of an element inheriting from the parent style.
+// Presentation attributes are overridden by style sheets, which are overridden by the style attribute.
+func (rd *svgRenderer) style(name string, attrs map[string]string, parent svgStyle) svgStyle {
+	s := parent
+	s.hidden = false
+
+	var decls []svgDeclaration
+	for _, p := range []string{"fill", "stroke", "fill-opacity", "stroke-opacity", "opacity", "stroke-width", "color", "display", "visibility", "clip-path", "mask", "filter"} {
+		if v, ok := attrs[p]; ok {
+			decls = append(decls, svgDeclaration{p, v})
+		}
+	}
+	decls = append(decls, rd.css[name]...)
+	for _, class := range strings.Fields(attrs["class"]) {
+		decls = append(decls, rd.css["."+class]...)
+	}
+	decls = append(decls, svgDeclarations(attrs["style"])...)
+
+	// the color must be known before resolving currentColor
+	for _, d := range decls {
+		if d.property == "color" {
+			if c, ok := svgColor(d.value); ok {
+				s.color = c
+			}
+		}
+	}
+
+	for _, d := range decls {
+		switch d.property {
+		case "fill":
+			if p, ok := rd.parsePaint(d.value); ok {
+				s.fill = p
+			}
+		case "stroke":
+			if p, ok := rd.parsePaint(d.value); ok {
+				s.stroke = p
+			}
+		case "fill-opacity":
+			s.fillOpacity = svgOpacity(d.value, s.fillOpacity)
+		case "stroke-opacity":
+			s.strokeOpacity = svgOpacity(d.value, s.strokeOpacity)
+		case "opacity":
+			// group opacity is approximated by applying it to every shape
+			s.opacity = parent.opacity * svgOpacity(d.value, 1)
+		case "stroke-width":
+			if w, ok := svgLength(d.value, rd.diagonal()); ok && w >= 0 {
+				s.strokeWidth = w
+			}
+		case "display":
+			s.hidden = s.hidden || strings.TrimSpace(d.value) == "none"
+		case "visibility":
+			v := strings.TrimSpace(d.value)
+			s.hidden = s.hidden || v == "hidden" || v == "collapse"
+		case "clip-path", "mask", "filter":
+			if strings.Contains(d.value, "url(") {
+				s.unsupported = d.property
+			}
+		}
+	}
+
+	if t, ok := attrs["transform"]; ok {
+		s.transform = parent.transform.mul(svgTransform(t))
+	}
+	return s
+}
+
+// parsePaint parses a fill or stroke value, references to gradients are resolved to the color of their first stop
+func (rd *svgRenderer) parsePaint(s string) (svgPaint, bool) {
+	if id, ok := svgReference(s); ok {
+		if p, ok := rd.gradients[id]; ok {
+			return p, true
+		}
+	}
+	return svgParsePaint(s)
+}
+
+func (rd *svgRenderer) diagonal() float64 {
+	return math.Sqrt((rd.vw*rd.vw + rd.vh*rd.vh) / 2)
+}
+
+// shape renders a basic shape or a path
+func (rd *svgRenderer) shape(name string, attrs map[string]string, style svgStyle) error {
+	length := func(attr string, ref float64) float64 {
+		v, _ := svgLength(attrs[attr], ref)
+		return v
+	}
+
+	p := &svgPathBuilder{m: style.transform, maxPoints: svgMaxPoints - rd.points}
+	switch name {
+	case "path":
+		svgParsePath(attrs["d"], p)
+	case "rect":
+		x, y := length("x", rd.vw), length("y", rd.vh)
+		w, h := length("width", rd.vw), length("height", rd.vh)
+		if w <= 0 || h <= 0 {
+			return nil
+		}
+		rx, okx := svgLength(attrs["rx"], rd.vw)
+		ry, oky := svgLength(attrs["ry"], rd.vh)
+		switch {
+		case !okx && oky:
+			rx = ry
+		case okx && !oky:
+			ry = rx
+		}
+		rx, ry = math.Min(math.Max(rx, 0), w/2), math.Min(math.Max(ry, 0), h/2)
+		if rx == 0 || ry == 0 {
+			p.moveTo(x, y)
+			p.lineTo(x+w, y)
+			p.lineTo(x+w, y+h)
+			p.lineTo(x, y+h)
+		} else {
+			p.moveTo(x+rx, y)
+			p.lineTo(x+w-rx, y)
+			p.arcTo(rx, ry, 0, false, true, x+w, y+ry)
+			p.lineTo(x+w, y+h-ry)
+			p.arcTo(rx, ry, 0, false, true, x+w-rx, y+h)
+			p.lineTo(x+rx, y+h)
+			p.arcTo(rx, ry, 0, false, true, x, y+h-ry)
+			p.lineTo(x, y+ry)
+			p.arcTo(rx, ry, 0, false, true, x+rx, y)
+		}
+		p.close()
+	case "circle", "ellipse":
+		cx, cy := length("cx", rd.vw), length("cy", rd.vh)
+		var rx, ry float64
+		if name == "circle" {
+			rx = length("r", rd.diagonal())
+			ry = rx
+		} else {
+			rx, ry = length("rx", rd.vw), length("ry", rd.vh)
+		}
+		if rx <= 0 || ry <= 0 {
+			return nil
+		}
+		p.moveTo(cx+rx, cy)
+		p.arcTo(rx, ry, 0, false, true, cx-rx, cy)
+		p.arcTo(rx, ry, 0, false, true, cx+rx, cy)
+		p.close()
+	case "line":
+		p.moveTo(length("x1", rd.vw), length("y1", rd.vh))
+		p.lineTo(length("x2", rd.vw), length("y2", rd.vh))
+	case "polyline", "polygon":
+		n := svgNumbers(attrs["points"])
+		for i := 0; i+1 < len(n); i += 2 {
+			if i == 0 {
+				p.moveTo(n[i], n[i+1])
+			} else {
+				p.lineTo(n[i], n[i+1])
+			}
+		}
+		if name == "polygon" {
+			p.close()
+		}
+	}
+
+	if p.overflow {
+		return thumbnailerErrors.ErrSVGTooComplex
+	}
+	rd.points += p.points
+
+	// lines have no area to fill
+	if name != "line" && !style.fill.none {
+		if err := rd.fill(p.subpaths, rd.paint(style.fill, style.color, style.fillOpacity*style.opacity)); err != nil {
+			return err
+		}
+	}
+
+	w := style.strokeWidth * style.transform.scale()
+	if !style.stroke.none && w > 0 {
+		var polys [][]svgPoint
+		for i, sp := range p.subpaths {
+			polys = append(polys, svgStroke(sp, p.closed[i], w/2)...)
+		}
+		if err := rd.fill(polys, rd.paint(style.stroke, style.color, style.strokeOpacity*style.opacity)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rd *svgRenderer) paint(p svgPaint, current color.NRGBA, opacity float64) color.NRGBA {
+	c := p.c
+	if p.current {
+		c = current
+	}
+	c.A = uint8(math.Round(float64(c.A) * math.Min(math.Max(opacity, 0), 1)))
+	return c
+}
+
+// fill rasterizes the polygons with the non-zero winding rule
+func (rd *svgRenderer) fill(polys [][]svgPoint, c color.NRGBA) error {
+	if c.A == 0 || len(polys) == 0 {
+		return nil
+	}
+
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, poly := range polys {
+		for _, pt := range poly {
+			minX, minY = math.Min(minX, pt.x), math.Min(minY, pt.y)
+			maxX, maxY = math.Max(maxX, pt.x), math.Max(maxY, pt.y)
+		}
+	}
+	if math.IsInf(minX, 0) || math.IsNaN(minX+minY+maxX+maxY) {
+		return nil
+	}
+
+	b := image.Rect(
+		int(math.Floor(math.Max(minX, -1))), int(math.Floor(math.Max(minY, -1))),
+		int(math.Ceil(math.Min(maxX, float64(rd.img.Rect.Dx()+1)))), int(math.Ceil(math.Min(maxY, float64(rd.img.Rect.Dy()+1)))),
+	).Intersect(rd.img.Rect)
+	if b.Empty() {
+		return nil
+	}
+
+	rd.area += b.Dx() * b.Dy()
+	if rd.area > svgMaxArea {
+		return thumbnailerErrors.ErrSVGTooComplex
+	}
+
+	z := vector.NewRasterizer(b.Dx(), b.Dy())
+	for _, poly := range polys {
+		// the rasterizer walks every row between the points, so clip to the bounds first
+		poly = svgClip(poly, float64(b.Min.X), float64(b.Min.Y), float64(b.Max.X), float64(b.Max.Y))
+		if len(poly) < 3 {
+			continue
+		}
+		z.MoveTo(float32(poly[0].x-float64(b.Min.X)), float32(poly[0].y-float64(b.Min.Y)))
+		for _, pt := range poly[1:] {
+			z.LineTo(float32(pt.x-float64(b.Min.X)), float32(pt.y-float64(b.Min.Y)))
+		}
+		z.ClosePath()
+	}
+	z.Draw(rd.img, b, image.NewUniform(c), image.Point{})
+	return nil
+}
+
+// parseCSS reads the rules with simple selectors of a style sheet
+func (rd *svgRenderer) parseCSS(s string) {
+	for {
+		i := strings.Index(s, "/*")
+		if i < 0 {
+			break
+		}
+		j := strings.Index(s[i+2:], "*/")
+		if j < 0 {
+			s = s[:i]
+			break
+		}
+		s = s[:i] + s[i+2+j+2:]
+	}
+
+	for _, rule := range strings.Split(s, "}") {
+		selectors, body, ok := strings.Cut(rule, "{")
+		if !ok {
+			continue
+		}
+		decls := svgDeclarations(body)
+		for _, sel := range strings.Split(selectors, ",") {
+			sel = strings.TrimSpace(sel)
+			if sel == "" || strings.ContainsAny(sel, " >+~:[#*") || strings.Count(sel, ".") > 1 {
+				continue
+			}
+			rd.css[sel] = append(rd.css[sel], decls...)
+		}
+	}
+}
+
+// svgPathBuilder collects the transformed and flattened subpaths of a shape
+type svgPathBuilder struct {
+	m svgMatrix
+
+	subpaths [][]svgPoint
+	closed   []bool
+
+	// the current point and the start of the subpath in user units
+	cur, start svgPoint
+
+	points, maxPoints int
+	overflow          bool
+}
+
+func (p *svgPathBuilder) add(pt svgPoint) {
+	if p.points >= p.maxPoints {
+		p.overflow = true
+		return
+	}
+	p.points++
+	i := len(p.subpaths) - 1
+	p.subpaths[i] = append(p.subpaths[i], pt)
+}
+
+func (p *svgPathBuilder) moveTo(x, y float64) {
+	p.subpaths = append(p.subpaths, nil)
+	p.closed = append(p.closed, false)
+	p.cur, p.start = svgPoint{x, y}, svgPoint{x, y}
+	p.add(p.m.apply(x, y))
+}
+
+func (p *svgPathBuilder) ensureSubpath() {
+	if len(p.subpaths) == 0 || p.closed[len(p.closed)-1] {
+		p.moveTo(p.cur.x, p.cur.y)
+	}
+}
+
+func (p *svgPathBuilder) lineTo(x, y float64) {
+	p.ensureSubpath()
+	p.cur = svgPoint{x, y}
+	p.add(p.m.apply(x, y))
+}
+
+func (p *svgPathBuilder) close() {
+	if len(p.subpaths) == 0 {
+		return
+	}
+	p.closed[len(p.closed)-1] = true
+	p.cur = p.start
+}
+
+// segments returns the number of line segments to approximate a curve with the control polygon
+func (p *svgPathBuilder) segments(pts ...svgPoint) int {
+	l := 0.0
+	for i := 1; i < len(pts); i++ {
+		l += math.Hypot(pts[i].x-pts[i-1].x, pts[i].y-pts[i-1].y)
+	}
+	return min(max(int(math.Sqrt(l)), 4), 64)
+}
+
+func (p *svgPathBuilder) cubicTo(x1, y1, x2, y2, x, y float64) {
+	p.ensureSubpath()
+	p0, p1, p2, p3 := p.m.apply(p.cur.x, p.cur.y), p.m.apply(x1, y1), p.m.apply(x2, y2), p.m.apply(x, y)
+	n := p.segments(p0, p1, p2, p3)
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+		p.add(svgPoint{a*p0.x + b*p1.x + c*p2.x + d*p3.x, a*p0.y + b*p1.y + c*p2.y + d*p3.y})
+	}
+	p.cur = svgPoint{x, y}
+}
+
+func (p *svgPathBuilder) quadTo(x1, y1, x, y float64) {
+	p.ensureSubpath()
+	p0, p1, p2 := p.m.apply(p.cur.x, p.cur.y), p.m.apply(x1, y1), p.m.apply(x, y)
+	n := p.segments(p0, p1, p2)
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		a, b, c := u*u, 2*u*t, t*t
+		p.add(svgPoint{a*p0.x + b*p1.x + c*p2.x, a*p0.y + b*p1.y + c*p2.y})
+	}
+	p.cur = svgPoint{x, y}
+}
+
+// arcTo adds an elliptical arc, see https://www.w3.org/TR/SVG11/implnote.html#ArcConversionEndpointToCenter
+func (p *svgPathBuilder) arcTo(rx, ry, angle float64, large, sweep bool, x, y float64) {
+	x1, y1 := p.cur.x, p.cur.y
+	if x1 == x && y1 == y {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		p.lineTo(x, y)
+		return
+	}
+	p.ensureSubpath()
+
+	phi := angle * math.Pi / 180
+	sin, cos := math.Sincos(phi)
+	dx, dy := (x1-x)/2, (y1-y)/2
+	x1p, y1p := cos*dx+sin*dy, -sin*dx+cos*dy
+
+	if l := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(num/den, 0))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp, cyp := coef*rx*y1p/ry, -coef*ry*x1p/rx
+	cx, cy := cos*cxp-sin*cyp+(x1+x)/2, sin*cxp+cos*cyp+(y1+y)/2
+
+	angleBetween := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angleBetween(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	delta := angleBetween((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	r := math.Max(rx, ry) * p.m.scale()
+	n := min(max(int(math.Ceil(math.Abs(delta)/(2*math.Pi)*math.Sqrt(r)*8)), 4), 128)
+	for i := 1; i < n; i++ {
+		st, ct := math.Sincos(theta + delta*float64(i)/float64(n))
+		p.add(p.m.apply(cx+rx*ct*cos-ry*st*sin, cy+rx*ct*sin+ry*st*cos))
+	}
+	p.add(p.m.apply(x, y))
+	p.cur = svgPoint{x, y}
+}
+
+// svgParsePath adds the path data to the builder. Like browsers, the path is rendered up to the first error.
+func svgParsePath(d string, p *svgPathBuilder) {
+	sc := &svgScanner{s: d}
+	var (
+		cmd     byte
+		prevCmd byte
+		ctrl    svgPoint // the last control point for smooth curves
+		first   = true
+		numbers = sc.numbers
+	)
+	// origin returns the point relative coordinates refer to
+	origin := func(rel bool) (float64, float64) {
+		if rel {
+			return p.cur.x, p.cur.y
+		}
+		return 0, 0
+	}
+	// reflected returns the first control point of a smooth curve following one of the given commands
+	reflected := func(commands string) svgPoint {
+		if strings.IndexByte(commands, prevCmd|0x20) >= 0 {
+			return svgPoint{2*p.cur.x - ctrl.x, 2*p.cur.y - ctrl.y}
+		}
+		return p.cur
+	}
+
+	for !p.overflow {
+		sc.skip()
+		if sc.done() {
+			return
+		}
+		if c := sc.s[sc.i]; (c|0x20) >= 'a' && (c|0x20) <= 'z' {
+			cmd = c
+			sc.i++
+		} else if cmd == 0 || cmd|0x20 == 'z' {
+			return
+		}
+		if first && cmd|0x20 != 'm' {
+			return
+		}
+		first = false
+
+		rel := cmd >= 'a'
+		switch cmd | 0x20 {
+		case 'm':
+			n, ok := numbers(2)
+			if !ok {
+				return
+			}
+			x, y := origin(rel)
+			p.moveTo(x+n[0], y+n[1])
+			// following coordinate pairs are implicit lineto commands
+			cmd = cmd - 'm' + 'l'
+		case 'l':
+			n, ok := numbers(2)
+			if !ok {
+				return
+			}
+			x, y := origin(rel)
+			p.lineTo(x+n[0], y+n[1])
+		case 'h':
+			n, ok := numbers(1)
+			if !ok {
+				return
+			}
+			x, _ := origin(rel)
+			p.lineTo(x+n[0], p.cur.y)
+		case 'v':
+			n, ok := numbers(1)
+			if !ok {
+				return
+			}
+			_, y := origin(rel)
+			p.lineTo(p.cur.x, y+n[0])
+		case 'c':
+			n, ok := numbers(6)
+			if !ok {
+				return
+			}
+			x, y := origin(rel)
+			ctrl = svgPoint{x + n[2], y + n[3]}
+			p.cubicTo(x+n[0], y+n[1], ctrl.x, ctrl.y, x+n[4], y+n[5])
+		case 's':
+			n, ok := numbers(4)
+			if !ok {
+				return
+			}
+			x, y := origin(rel)
+			c1 := reflected("cs")
+			ctrl = svgPoint{x + n[0], y + n[1]}
+			p.cubicTo(c1.x, c1.y, ctrl.x, ctrl.y, x+n[2], y+n[3])
+		case 'q':
+			n, ok := numbers(4)
+			if !ok {
+				return
+			}
+			x, y := origin(rel)
+			ctrl = svgPoint{x + n[0], y + n[1]}
+			p.quadTo(ctrl.x, ctrl.y, x+n[2], y+n[3])
+		case 't':
+			n, ok := numbers(2)
+			if !ok {
+				return
+			}
+			x, y := origin(rel)
+			ctrl = reflected("qt")
+			p.quadTo(ctrl.x, ctrl.y, x+n[0], y+n[1])
+		case 'a':
+			n, ok := numbers(3)
+			if !ok {
+				return
+			}
+			large, ok1 := sc.flag()
+			sweep, ok2 := sc.flag()
+			end, ok3 := numbers(2)
+			if !ok1 || !ok2 || !ok3 {
+				return
+			}
+			x, y := origin(rel)
+			p.arcTo(n[0], n[1], n[2], large, sweep, x+end[0], y+end[1])
+		case 'z':
+			p.close()
+		default:
+			return
+		}
+		prevCmd = cmd
+	}
+}
+
+// svgScanner reads the numbers of path data and attribute lists
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (sc *svgScanner) done() bool { return sc.i >= len(sc.s) }
+
+func (sc *svgScanner) skip() {
+	for !sc.done() && strings.IndexByte(" \t\r\n,", sc.s[sc.i]) >= 0 {
+		sc.i++
+	}
+}
+
+func (sc *svgScanner) digits() int {
+	start := sc.i
+	for !sc.done() && sc.s[sc.i] >= '0' && sc.s[sc.i] <= '9' {
+		sc.i++
+	}
+	return sc.i - start
+}
+
+func (sc *svgScanner) number() (float64, bool) {
+	sc.skip()
+	start := sc.i
+	if !sc.done() && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+		sc.i++
+	}
+	n := sc.digits()
+	if !sc.done() && sc.s[sc.i] == '.' {
+		sc.i++
+		n += sc.digits()
+	}
+	if n == 0 {
+		sc.i = start
+		return 0, false
+	}
+	if !sc.done() && (sc.s[sc.i] == 'e' || sc.s[sc.i] == 'E') {
+		mark := sc.i
+		sc.i++
+		if !sc.done() && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+			sc.i++
+		}
+		if sc.digits() == 0 {
+			sc.i = mark
+		}
+	}
+	f, err := strconv.ParseFloat(sc.s[start:sc.i], 64)
+	if err != nil || math.IsInf(f, 0) {
+		sc.i = start
+		return 0, false
+	}
+	return f, true
+}
+
+func (sc *svgScanner) numbers(n int) ([]float64, bool) {
+	r := make([]float64, n)
+	for i := range r {
+		f, ok := sc.number()
+		if !ok {
+			return nil, false
+		}
+		r[i] = f
+	}
+	return r, true
+}
+
+func (sc *svgScanner) flag() (bool, bool) {
+	sc.skip()
+	if sc.done() || (sc.s[sc.i] != '0' && sc.s[sc.i] != '1') {
+		return false, false
+	}
+	sc.i++
+	return sc.s[sc.i-1] == '1', true
+}
+
+// svgNumbers returns the numbers of a list separated by whitespace or commas
+func svgNumbers(s string) []float64 {
+	sc := &svgScanner{s: s}
+	var r []float64
+	for {
+		f, ok := sc.number()
+		if !ok {
+			return r
+		}
+		r = append(r, f)
+	}
+}
+
+var svgUnits = map[string]float64{
+	"": 1, "px": 1, "pt": 4.0 / 3, "pc": 16, "mm": 96 / 25.4, "cm": 96 / 2.54, "in": 96, "em": 16, "ex": 8,
+}
+
+// svgLength parses a length, percentages are relative to ref
+func svgLength(s string, ref float64) (float64, bool) {
+	sc := &svgScanner{s: strings.TrimSpace(s)}
+	f, ok := sc.number()
+	if !ok {
+		return 0, false
+	}
+	unit := strings.TrimSpace(sc.s[sc.i:])
+	if unit == "%" {
+		return f * ref / 100, true
+	}
+	u, ok := svgUnits[unit]
+	if !ok {
+		return 0, false
+	}
+	return f * u, true
+}
+
+func svgOpacity(s string, fallback float64) float64 {
+	s = strings.TrimSpace(s)
+	f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || math.IsNaN(f) {
+		return fallback
+	}
+	if strings.HasSuffix(s, "%") {
+		f /= 100
+	}
+	return math.Min(math.Max(f, 0), 1)
+}
+
+// svgDeclarations parses css declarations like `fill: red; stroke: none`
+func svgDeclarations(s string) []svgDeclaration {
+	var decls []svgDeclaration
+	for _, d := range strings.Split(s, ";") {
+		p, v, ok := strings.Cut(d, ":")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "!important"))
+		decls = append(decls, svgDeclaration{strings.ToLower(strings.TrimSpace(p)), v})
+	}
+	return decls
+}
+
+// svgTransform parses a transform list
+func svgTransform(s string) svgMatrix {
+	m := svgIdentity
+	for {
+		open := strings.IndexByte(s, '(')
+		end := strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return m
+		}
+		name := strings.TrimSpace(strings.Trim(s[:open], " \t\r\n,"))
+		n := svgNumbers(s[open+1 : end])
+		s = s[end+1:]
+
+		var t svgMatrix
+		switch {
+		case name == "matrix" && len(n) == 6:
+			t = svgMatrix{n[0], n[1], n[2], n[3], n[4], n[5]}
+		case name == "translate" && len(n) == 1:
+			t = svgMatrix{a: 1, d: 1, e: n[0]}
+		case name == "translate" && len(n) == 2:
+			t = svgMatrix{a: 1, d: 1, e: n[0], f: n[1]}
+		case name == "scale" && len(n) == 1:
+			t = svgMatrix{a: n[0], d: n[0]}
+		case name == "scale" && len(n) == 2:
+			t = svgMatrix{a: n[0], d: n[1]}
+		case name == "rotate" && (len(n) == 1 || len(n) == 3):
+			sin, cos := math.Sincos(n[0] * math.Pi / 180)
+			t = svgMatrix{a: cos, b: sin, c: -sin, d: cos}
+			if len(n) == 3 {
+				t = svgMatrix{a: 1, d: 1, e: n[1], f: n[2]}.mul(t).mul(svgMatrix{a: 1, d: 1, e: -n[1], f: -n[2]})
+			}
+		case name == "skewX" && len(n) == 1:
+			t = svgMatrix{a: 1, c: math.Tan(n[0] * math.Pi / 180), d: 1}
+		case name == "skewY" && len(n) == 1:
+			t = svgMatrix{a: 1, b: math.Tan(n[0] * math.Pi / 180), d: 1}
+		default:
+			// an invalid transform list disables the transformation
+			return svgIdentity
+		}
+		m = m.mul(t)
+	}
+}
+
+// svgGradients returns the color of the first stop of the gradients by id, gradients without stops use the stops
+// of the gradient they reference. Errors are left to the renderer, which reads the same document.
+func svgGradients(b []byte) map[string]svgPaint {
+	stops := map[string]svgPaint{}
+	hrefs := map[string]string{}
+
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	// the id of the open gradient
+	var gradient string
+	for elements := 0; elements < svgMaxElements; {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			elements++
+			attrs := make(map[string]string, len(t.Attr))
+			for _, a := range t.Attr {
+				attrs[a.Name.Local] = a.Value
+			}
+
+			switch t.Name.Local {
+			case "linearGradient", "radialGradient":
+				gradient = attrs["id"]
+				if href, ok := attrs["href"]; ok && gradient != "" {
+					hrefs[gradient] = strings.TrimPrefix(strings.TrimSpace(href), "#")
+				}
+			case "stop":
+				if _, ok := stops[gradient]; ok || gradient == "" {
+					continue
+				}
+				decls := []svgDeclaration{{"stop-color", attrs["stop-color"]}, {"stop-opacity", attrs["stop-opacity"]}}
+				decls = append(decls, svgDeclarations(attrs["style"])...)
+				p, opacity := svgPaint{c: color.NRGBA{A: 255}}, 1.0
+				for _, d := range decls {
+					switch d.property {
+					case "stop-color":
+						if c, ok := svgParsePaint(d.value); ok {
+							p = c
+						}
+					case "stop-opacity":
+						opacity = svgOpacity(d.value, opacity)
+					}
+				}
+				p.c.A = uint8(math.Round(float64(p.c.A) * opacity))
+				stops[gradient] = p
+			}
+		case xml.EndElement:
+			if t.Name.Local == "linearGradient" || t.Name.Local == "radialGradient" {
+				gradient = ""
+			}
+		}
+	}
+
+	gradients := make(map[string]svgPaint, len(hrefs)+len(stops))
+	for id := range hrefs {
+		// follow the references up to the first gradient with stops, cycles end after visiting every gradient
+		ref := id
+		for i := 0; i <= len(hrefs); i++ {
+			if p, ok := stops[ref]; ok {
+				gradients[id] = p
+				break
+			}
+			ref = hrefs[ref]
+		}
+	}
+	for id, p := range stops {
+		gradients[id] = p
+	}
+	return gradients
+}
+
+// svgReference returns the id of a local reference like `url(#id)`
+func svgReference(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "url(") {
+		return "", false
+	}
+	ref, _, ok := strings.Cut(s[len("url("):], ")")
+	if !ok {
+		return "", false
+	}
+	ref = strings.Trim(strings.TrimSpace(ref), `"'`)
+	if !strings.HasPrefix(ref, "#") {
+		return "", false
+	}
+	return ref[1:], true
+}
+
+// svgParsePaint parses a fill or stroke value, unresolved references are rendered with their fallback color or gray
+func svgParsePaint(s string) (svgPaint, bool) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "none" || s == "transparent":
+		return svgPaint{none: true}, true
+	case s == "currentColor":
+		return svgPaint{current: true}, true
+	case strings.HasPrefix(s, "url("):
+		_, fallback, _ := strings.Cut(s, ")")
+		if p, ok := svgParsePaint(fallback); ok {
+			return p, true
+		}
+		return svgPaint{c: color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}}, true
+	}
+	c, ok := svgColor(s)
+	return svgPaint{c: c}, ok
+}
+
+// svgColor parses hex, rgb() and named colors
+func svgColor(s string) (color.NRGBA, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if strings.HasPrefix(s, "#") {
+		h := s[1:]
+		if len(h) == 3 || len(h) == 4 {
+			var b strings.Builder
+			for _, c := range h {
+				b.WriteRune(c)
+				b.WriteRune(c)
+			}
+			h = b.String()
+		}
+		if len(h) == 6 {
+			h += "ff"
+		}
+		v, err := strconv.ParseUint(h, 16, 32)
+		if len(h) != 8 || err != nil {
+			return color.NRGBA{}, false
+		}
+		return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, true
+	}
+
+	if open := strings.IndexByte(s, '('); open > 0 && strings.HasSuffix(s, ")") {
+		if fn := s[:open]; fn != "rgb" && fn != "rgba" {
+			return color.NRGBA{}, false
+		}
+		parts := strings.FieldsFunc(s[open+1:len(s)-1], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(parts) < 3 || len(parts) > 4 {
+			return color.NRGBA{}, false
+		}
+		c := color.NRGBA{A: 255}
+		for i, p := range []*uint8{&c.R, &c.G, &c.B} {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(parts[i], "%"), 64)
+			if err != nil {
+				return color.NRGBA{}, false
+			}
+			if strings.HasSuffix(parts[i], "%") {
+				v = v * 255 / 100
+			}
+			*p = uint8(math.Round(math.Min(math.Max(v, 0), 255)))
+		}
+		if len(parts) == 4 {
+			c.A = uint8(math.Round(svgOpacity(parts[3], 1) * 255))
+		}
+		return c, true
+	}
+
+	c, ok := svgNamedColors[s]
+	return c, ok
+}
+
+// svgStroke returns the polygons covering the stroke of a polyline: a rectangle for every segment and an octagon for every joint
+func svgStroke(pts []svgPoint, closed bool, hw float64) [][]svgPoint {
+	if closed && len(pts) > 1 {
+		pts = append(pts[:len(pts):len(pts)], pts[0])
+	}
+
+	var polys [][]svgPoint
+	for i := 0; i+1 < len(pts); i++ {
+		a, b := pts[i], pts[i+1]
+		l := math.Hypot(b.x-a.x, b.y-a.y)
+		if l == 0 {
+			continue
+		}
+		nx, ny := -(b.y-a.y)/l*hw, (b.x-a.x)/l*hw
+		polys = append(polys, []svgPoint{{a.x + nx, a.y + ny}, {b.x + nx, b.y + ny}, {b.x - nx, b.y - ny}, {a.x - nx, a.y - ny}})
+	}
+
+	// the joints are only needed between segments of wide strokes
+	if hw >= 1 {
+		for i := 1; i < len(pts)-1 || (closed && i < len(pts)); i++ {
+			j := make([]svgPoint, 8)
+			for k := range j {
+				// the same orientation as the rectangles, so the coverage adds up
+				sin, cos := math.Sincos(-float64(k) * math.Pi / 4)
+				j[k] = svgPoint{pts[i].x + cos*hw, pts[i].y + sin*hw}
+			}
+			polys = append(polys, j)
+		}
+	}
+	return polys
+}
+
+// svgClip clips a polygon to a rectangle with the Sutherland-Hodgman algorithm
+func svgClip(poly []svgPoint, minX, minY, maxX, maxY float64) []svgPoint {
+	edges := []struct {
+		inside    func(svgPoint) bool
+		intersect func(a, b svgPoint) svgPoint
+	}{
+		{func(p svgPoint) bool { return p.x >= minX }, func(a, b svgPoint) svgPoint {
+			return svgPoint{minX, a.y + (b.y-a.y)*(minX-a.x)/(b.x-a.x)}
+		}},
+		{func(p svgPoint) bool { return p.x <= maxX }, func(a, b svgPoint) svgPoint {
+			return svgPoint{maxX, a.y + (b.y-a.y)*(maxX-a.x)/(b.x-a.x)}
+		}},
+		{func(p svgPoint) bool { return p.y >= minY }, func(a, b svgPoint) svgPoint {
+			return svgPoint{a.x + (b.x-a.x)*(minY-a.y)/(b.y-a.y), minY}
+		}},
+		{func(p svgPoint) bool { return p.y <= maxY }, func(a, b svgPoint) svgPoint {
+			return svgPoint{a.x + (b.x-a.x)*(maxY-a.y)/(b.y-a.y), maxY}
+		}},
+	}
+
+	for _, e := range edges {
+		if len(poly) == 0 {
+			return nil
+		}
+		in := poly
+		poly = make([]svgPoint, 0, len(in)+4)
+		prev := in[len(in)-1]
+		for _, cur := range in {
+			switch {
+			case e.inside(cur):
+				if !e.inside(prev) {
+					poly = append(poly, e.intersect(prev, cur))
+				}
+				poly = append(poly, cur)
+			case e.inside(prev):
+				poly = append(poly, e.intersect(prev, cur))
+			}
+			prev = cur
+		}
+	}
+	return poly
+}
+
+var svgNamedColors = map[string]color.NRGBA{
+	"black":   {0, 0, 0, 255},
+	"silver":  {192, 192, 192, 255},
+	"gray":    {128, 128, 128, 255},
+	"grey":    {128, 128, 128, 255},
+	"white":   {255, 255, 255, 255},
+	"maroon":  {128, 0, 0, 255},
+	"red":     {255, 0, 0, 255},
+	"purple":  {128, 0, 128, 255},
+	"fuchsia": {255, 0, 255, 255},
+	"magenta": {255, 0, 255, 255},
+	"green":   {0, 128, 0, 255},
+	"lime":    {0, 255, 0, 255},
+	"olive":   {128, 128, 0, 255},
+	"yellow":  {255, 255, 0, 255},
+	"navy":    {0, 0, 128, 255},
+	"blue":    {0, 0, 255, 255},
+	"teal":    {0, 128, 128, 255},
+	"aqua":    {0, 255, 255, 255},
+	"cyan":    {0, 255, 255, 255},
+	"orange":  {255, 165, 0, 255},
+	"pink":    {255, 192, 203, 255},
+	"brown":   {165, 42, 42, 255},
+	"gold":    {255, 215, 0, 255},
+	"indigo":  {75, 0, 130, 255},
+	"violet":  {238, 130, 238, 255},
+
+	"darkgray":   {169, 169, 169, 255},
+	"darkgrey":   {169, 169, 169, 255},
+	"lightgray":  {211, 211, 211, 255},
+	"lightgrey":  {211, 211, 211, 255},
+	"dimgray":    {105, 105, 105, 255},
+	"dimgrey":    {105, 105, 105, 255},
+	"darkblue":   {0, 0, 139, 255},
+	"lightblue":  {173, 216, 230, 255},
+	"skyblue":    {135, 206, 235, 255},
+	"steelblue":  {70, 130, 180, 255},
+	"royalblue":  {65, 105, 225, 255},
+	"darkgreen":  {0, 100, 0, 255},
+	"darkred":    {139, 0, 0, 255},
+	"crimson":    {220, 20, 60, 255},
+	"tomato":     {255, 99, 71, 255},
+	"coral":      {255, 127, 80, 255},
+	"salmon":     {250, 128, 114, 255},
+	"tan":        {210, 180, 140, 255},
+	"beige":      {245, 245, 220, 255},
+	"ivory":      {255, 255, 240, 255},
+	"khaki":      {240, 230, 140, 255},
+	"turquoise":  {64, 224, 208, 255},
+	"orchid":     {218, 112, 214, 255},
+	"plum":       {221, 160, 221, 255},
+	"chocolate":  {210, 105, 30, 255},
+	"firebrick":  {178, 34, 34, 255},
+	"goldenrod":  {218, 165, 32, 255},
+	"slategray":  {112, 128, 144, 255},
+	"slategrey":  {112, 128, 144, 255},
+	"whitesmoke": {245, 245, 245, 255},
+}
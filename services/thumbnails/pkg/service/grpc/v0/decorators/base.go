@@ -61,3 +61,9 @@ type Decorator struct {
 func (deco Decorator) GetThumbnail(ctx context.Context, req *thumbnailssvc.GetThumbnailRequest, resp *thumbnailssvc.GetThumbnailResponse) error {
 	return deco.next.GetThumbnail(ctx, req, resp)
 }
+
+// GetBlurHash is the base implementation for the thumbnailssvc.GetBlurHash.
+// It will just delegate to the underlying decoratedService
+func (deco Decorator) GetBlurHash(ctx context.Context, req *thumbnailssvc.GetBlurHashRequest, resp *thumbnailssvc.GetBlurHashResponse) error {
+	return deco.next.GetBlurHash(ctx, req, resp)
+}
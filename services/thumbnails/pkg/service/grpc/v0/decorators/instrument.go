@@ -37,3 +37,20 @@ func (i instrument) GetThumbnail(ctx context.Context, req *thumbnailssvc.GetThum
 	}
 	return err
 }
+
+// GetBlurHash implements the ThumbnailServiceHandler interface.
+func (i instrument) GetBlurHash(ctx context.Context, req *thumbnailssvc.GetBlurHashRequest, rsp *thumbnailssvc.GetBlurHashResponse) error {
+	timer := prometheus.NewTimer(prometheus.ObserverFunc(func(v float64) {
+		us := v * 1000_000
+		i.metrics.Latency.WithLabelValues().Observe(us)
+		i.metrics.Duration.WithLabelValues().Observe(v)
+	}))
+	defer timer.ObserveDuration()
+
+	err := i.next.GetBlurHash(ctx, req, rsp)
+
+	if err != nil {
+		i.metrics.Counter.WithLabelValues().Inc()
+	}
+	return err
+}
@@ -51,3 +51,32 @@ func (l logging) GetThumbnail(ctx context.Context, req *thumbnailssvc.GetThumbna
 	}
 	return err
 }
+
+// GetBlurHash implements the ThumbnailServiceHandler interface.
+func (l logging) GetBlurHash(ctx context.Context, req *thumbnailssvc.GetBlurHashRequest, rsp *thumbnailssvc.GetBlurHashResponse) error {
+	start := time.Now()
+	err := l.next.GetBlurHash(ctx, req, rsp)
+
+	logger := l.logger.With().
+		Str("method", "Thumbnails.GetBlurHash").
+		Dur("duration", time.Since(start)).
+		Logger()
+
+	if err != nil {
+		fromError := merrors.FromError(err)
+		switch fromError.GetCode() {
+		case http.StatusNotFound:
+			logger.Debug().
+				Str("error_detail", fromError.GetDetail()).
+				Msg("no blurhash found")
+		default:
+			logger.Warn().
+				Err(err).
+				Msg("Failed to execute")
+		}
+	} else {
+		logger.Debug().
+			Msg("")
+	}
+	return err
+}
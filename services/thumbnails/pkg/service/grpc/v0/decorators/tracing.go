@@ -44,3 +44,24 @@ func (t tracing) GetThumbnail(ctx context.Context, req *thumbnailssvc.GetThumbna
 
 	return t.next.GetThumbnail(ctx, req, rsp)
 }
+
+// GetBlurHash implements the ThumbnailServiceHandler interface.
+func (t tracing) GetBlurHash(ctx context.Context, req *thumbnailssvc.GetBlurHashRequest, rsp *thumbnailssvc.GetBlurHashResponse) error {
+	var span trace.Span
+
+	if t.tp != nil {
+		tracer := t.tp.Tracer("thumbnails")
+		spanOpts := []trace.SpanStartOption{
+			trace.WithSpanKind(trace.SpanKindServer),
+		}
+		ctx, span = tracer.Start(ctx, "Thumbnails.GetBlurHash", spanOpts...)
+		defer span.End()
+
+		span.SetAttributes(
+			attribute.KeyValue{Key: "filepath", Value: attribute.StringValue(req.GetFilepath())},
+			attribute.KeyValue{Key: "cached_only", Value: attribute.BoolValue(req.GetCachedOnly())},
+		)
+	}
+
+	return t.next.GetBlurHash(ctx, req, rsp)
+}
@@ -166,6 +166,7 @@ func (g Thumbnail) handleCS3Source(ctx context.Context, req *thumbnailssvc.GetTh
 	defer r.Close()
 	ppOpts := map[string]interface{}{
 		"fontFileMap": g.preprocessorOpts.TxtFontFileMap,
+		"filename":    sRes.GetInfo().GetName(),
 	}
 	pp := preprocessor.ForType(sRes.GetInfo().GetMimeType(), ppOpts)
 	img, err := pp.Convert(r)
@@ -177,6 +178,9 @@ func (g Thumbnail) handleCS3Source(ctx context.Context, req *thumbnailssvc.GetTh
 	if errors.Is(err, terrors.ErrImageTooLarge) {
 		return "", merrors.Forbidden(g.serviceID, "%s", err.Error())
 	}
+	if err == nil {
+		g.storeBlurHash(tr.Checksum, img)
+	}
 	return key, err
 }
 
@@ -258,6 +262,7 @@ func (g Thumbnail) handleWebdavSource(ctx context.Context, req *thumbnailssvc.Ge
 	defer r.Close()
 	ppOpts := map[string]interface{}{
 		"fontFileMap": g.preprocessorOpts.TxtFontFileMap,
+		"filename":    sRes.GetInfo().GetName(),
 	}
 	pp := preprocessor.ForType(sRes.GetInfo().GetMimeType(), ppOpts)
 	img, err := pp.Convert(r)
@@ -269,9 +274,78 @@ func (g Thumbnail) handleWebdavSource(ctx context.Context, req *thumbnailssvc.Ge
 	if errors.Is(err, terrors.ErrImageTooLarge) {
 		return "", merrors.Forbidden(g.serviceID, "%s", err.Error())
 	}
+	if err == nil {
+		g.storeBlurHash(tr.Checksum, img)
+	}
 	return key, err
 }
 
+// GetBlurHash retrieves the BlurHash placeholder of an image
+func (g Thumbnail) GetBlurHash(ctx context.Context, req *thumbnailssvc.GetBlurHashRequest, rsp *thumbnailssvc.GetBlurHashResponse) error {
+	src := req.GetCs3Source()
+	if src == nil {
+		g.logger.Error().Msg("no image source provided")
+		return merrors.BadRequest(g.serviceID, "image source is missing")
+	}
+	sRes, err := g.stat(src.GetPath(), src.GetAuthorization())
+	if err != nil {
+		return err
+	}
+	if !sRes.GetInfo().GetPermissionSet().GetInitiateFileDownload() {
+		return merrors.Forbidden(g.serviceID, "no download permission")
+	}
+
+	checksum := sRes.GetInfo().GetChecksum().GetSum()
+	if hash, ok := g.manager.GetBlurHash(checksum); ok {
+		rsp.BlurHash = hash
+		return nil
+	}
+	if req.GetCachedOnly() {
+		return merrors.NotFound(g.serviceID, "no blurhash found")
+	}
+
+	ctx = imgsource.ContextSetAuthorization(ctx, src.GetAuthorization())
+	r, err := g.cs3Source.Get(ctx, src.GetPath())
+	switch {
+	case errors.Is(err, terrors.ErrImageTooLarge):
+		return merrors.Forbidden(g.serviceID, "%s", err.Error())
+	case err != nil:
+		return merrors.InternalServerError(g.serviceID, "could not get image from source: %s", err.Error())
+	}
+
+	defer r.Close()
+	ppOpts := map[string]interface{}{
+		"fontFileMap": g.preprocessorOpts.TxtFontFileMap,
+		"filename":    sRes.GetInfo().GetName(),
+	}
+	pp := preprocessor.ForType(sRes.GetInfo().GetMimeType(), ppOpts)
+	img, err := pp.Convert(r)
+	if img == nil || err != nil {
+		return merrors.InternalServerError(g.serviceID, "could not get image")
+	}
+
+	hash, err := g.manager.GenerateBlurHash(checksum, img)
+	switch {
+	case errors.Is(err, terrors.ErrImageTooLarge):
+		return merrors.Forbidden(g.serviceID, "%s", err.Error())
+	case err != nil:
+		return merrors.InternalServerError(g.serviceID, "could not compute blurhash: %s", err.Error())
+	}
+	rsp.BlurHash = hash
+	return nil
+}
+
+// storeBlurHash computes the BlurHash placeholder of a freshly converted image unless it already exists.
+// The placeholder is optional, errors are only logged.
+func (g Thumbnail) storeBlurHash(checksum string, img interface{}) {
+	if _, ok := g.manager.GetBlurHash(checksum); ok {
+		return
+	}
+	if _, err := g.manager.GenerateBlurHash(checksum, img); err != nil {
+		g.logger.Debug().Err(err).Str("checksum", checksum).Msg("could not compute blurhash")
+	}
+}
+
 func (g Thumbnail) stat(path, auth string) (*provider.StatResponse, error) {
 	ctx := metadata.AppendToOutgoingContext(context.Background(), revactx.TokenHeader, auth)
 
@@ -319,7 +393,7 @@ func (g Thumbnail) stat(path, auth string) (*provider.StatResponse, error) {
 		g.logger.Error().Msg("resource info is missing checksum")
 		return nil, merrors.NotFound(g.serviceID, "resource info is missing a checksum")
 	}
-	if !thumbnail.IsMimeTypeSupported(rsp.GetInfo().GetMimeType()) {
+	if !thumbnail.IsFileSupported(rsp.GetInfo().GetMimeType(), rsp.GetInfo().GetName()) {
 		return nil, merrors.NotFound(g.serviceID, "Unsupported file type")
 	}
 	return rsp, nil
@@ -0,0 +1,134 @@
+package thumbnail
+
+import (
+	"image"
+	"image/gif"
+	"math"
+	"strings"
+
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/errors"
+)
+
+const (
+	// BlurHashComponentsX is the number of horizontal components of the BlurHash placeholders
+	BlurHashComponentsX = 4
+	// BlurHashComponentsY is the number of vertical components of the BlurHash placeholders
+	BlurHashComponentsY = 3
+
+	// blurHashSamples is the maximum number of pixels sampled in each direction to compute the BlurHash
+	blurHashSamples = 64
+	typeBlurHash    = "blurhash"
+	base83Chars     = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+)
+
+// BlurHash computes the BlurHash of the image, see https://github.com/woltapp/blurhash for the algorithm.
+// Large images are sampled, the placeholder is blurred anyway.
+func BlurHash(img image.Image, componentsX, componentsY int) (string, error) {
+	if componentsX < 1 || componentsX > 9 || componentsY < 1 || componentsY > 9 {
+		return "", errors.ErrInvalidType
+	}
+	b := img.Bounds()
+	if b.Empty() {
+		return "", errors.ErrInvalidType
+	}
+
+	w, h := min(b.Dx(), blurHashSamples), min(b.Dy(), blurHashSamples)
+	pixels := make([][3]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h).RGBA()
+			pixels[y*w+x] = [3]float64{sRGBToLinear(r >> 8), sRGBToLinear(g >> 8), sRGBToLinear(bl >> 8)}
+		}
+	}
+
+	factors := make([][3]float64, 0, componentsX*componentsY)
+	for j := 0; j < componentsY; j++ {
+		for i := 0; i < componentsX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var f [3]float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) * math.Cos(math.Pi*float64(j)*float64(y)/float64(h))
+					for c := range f {
+						f[c] += basis * pixels[y*w+x][c]
+					}
+				}
+			}
+			scale := normalisation / float64(w*h)
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+
+	hash := &strings.Builder{}
+	encode83(hash, (componentsX-1)+(componentsY-1)*9, 1)
+
+	maximumValue := 1.0
+	if len(factors) > 1 {
+		actualMaximum := 0.0
+		for _, f := range factors[1:] {
+			for _, v := range f {
+				actualMaximum = math.Max(actualMaximum, math.Abs(v))
+			}
+		}
+		quantisedMaximum := int(math.Max(0, math.Min(82, math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantisedMaximum+1) / 166
+		encode83(hash, quantisedMaximum, 1)
+	} else {
+		encode83(hash, 0, 1)
+	}
+
+	dc := factors[0]
+	encode83(hash, linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4)
+	for _, f := range factors[1:] {
+		quant := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+		}
+		encode83(hash, quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2)
+	}
+	return hash.String(), nil
+}
+
+// blurHashImage returns the image the BlurHash is computed from
+func blurHashImage(img interface{}) (image.Image, error) {
+	switch m := img.(type) {
+	case *gif.GIF:
+		if len(m.Image) == 0 {
+			return nil, errors.ErrInvalidType
+		}
+		return m.Image[0], nil
+	case image.Image:
+		return m, nil
+	default:
+		return blurHashImageFrom(img)
+	}
+}
+
+func encode83(b *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		b.WriteByte(base83Chars[digit])
+	}
+}
+
+func sRGBToLinear(value uint32) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
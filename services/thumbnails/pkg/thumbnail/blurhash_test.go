@@ -0,0 +1,64 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/errors"
+)
+
+func TestBlurHash(t *testing.T) {
+	white := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(white, white.Bounds(), image.White, image.Point{}, draw.Src)
+
+	hash, err := BlurHash(white, BlurHashComponentsX, BlurHashComponentsY)
+	require.NoError(t, err)
+	assert.Len(t, hash, 4+2*BlurHashComponentsX*BlurHashComponentsY)
+	// the size flag and the average color
+	assert.Equal(t, "L", hash[:1])
+	assert.Equal(t, "TSUA", hash[2:6])
+	whiteHash := hash
+
+	// a gradient needs the AC components
+	gradient := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for x := 0; x < 200; x++ {
+		for y := 0; y < 100; y++ {
+			gradient.Set(x, y, color.RGBA{R: uint8(x), B: uint8(255 - x), A: 255})
+		}
+	}
+	hash, err = BlurHash(gradient, BlurHashComponentsX, BlurHashComponentsY)
+	require.NoError(t, err)
+	assert.Len(t, hash, 4+2*BlurHashComponentsX*BlurHashComponentsY)
+	assert.NotEqual(t, whiteHash, hash)
+
+	_, err = BlurHash(white, 10, 3)
+	assert.ErrorIs(t, err, errors.ErrInvalidType)
+	_, err = BlurHash(image.NewRGBA(image.Rectangle{}), 4, 3)
+	assert.ErrorIs(t, err, errors.ErrInvalidType)
+}
+
+func TestGenerateBlurHash(t *testing.T) {
+	st := memoryStorage{data: map[string][]byte{}}
+	sut := NewSimpleManager(Resolutions{}, st, log.NopLogger(), 100, 100)
+
+	_, exists := sut.GetBlurHash("checksum")
+	assert.False(t, exists)
+
+	frame := image.NewPaletted(image.Rect(0, 0, 10, 10), color.Palette{color.White})
+	hash, err := sut.GenerateBlurHash("checksum", &gif.GIF{Image: []*image.Paletted{frame}})
+	require.NoError(t, err)
+
+	cached, exists := sut.GetBlurHash("checksum")
+	assert.True(t, exists)
+	assert.Equal(t, hash, cached)
+
+	_, err = sut.GenerateBlurHash("large", image.NewRGBA(image.Rect(0, 0, 101, 10)))
+	assert.ErrorIs(t, err, errors.ErrImageTooLarge)
+}
@@ -3,6 +3,7 @@ package thumbnail
 import (
 	"image/gif"
 	"io"
+	"path/filepath"
 	"strings"
 
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/errors"
@@ -79,3 +80,17 @@ func GetExtForMime(fileType string) string {
 		return ""
 	}
 }
+
+// GetExtForFile returns the thumbnail type the clients request for a file. The type of images is
+// derived from the mime type, the other files get the type of their extension which defaults to jpg.
+func GetExtForFile(fileType, filename string) string {
+	if ext := GetExtForMime(fileType); ext != "" {
+		return ext
+	}
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")); ext {
+	case typeGif, typePng:
+		return ext
+	default:
+		return typeJpg
+	}
+}
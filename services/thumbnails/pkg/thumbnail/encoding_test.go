@@ -20,3 +20,22 @@ func TestEncoderForType(t *testing.T) {
 		}
 	}
 }
+
+func TestGetExtForFile(t *testing.T) {
+	table := []struct {
+		mimeType, filename, want string
+	}{
+		{"image/png", "image", "png"},
+		{"image/webp", "image.webp", "webp"},
+		{"text/plain", "notes.txt", "jpg"},
+		{"application/pdf", "scan.PNG", "png"},
+		{"image/svg+xml", "logo.gif", "gif"},
+		{"application/octet-stream", "", "jpg"},
+	}
+
+	for _, tt := range table {
+		if got := GetExtForFile(tt.mimeType, tt.filename); got != tt.want {
+			t.Errorf("GetExtForFile(%q, %q) = %q, want %q", tt.mimeType, tt.filename, got, tt.want)
+		}
+	}
+}
@@ -19,7 +19,9 @@ func (e PngEncoder) Encode(w io.Writer, img interface{}) error {
 		return errors.ErrInvalidType
 	}
 
-	buf, _, err := m.ExportPng(vips.NewPngExportParams())
+	params := vips.NewPngExportParams()
+	params.StripMetadata = true
+	buf, _, err := m.ExportPng(params)
 	if err != nil {
 		return err
 	}
@@ -47,7 +49,9 @@ func (e JpegEncoder) Encode(w io.Writer, img interface{}) error {
 		return errors.ErrInvalidType
 	}
 
-	buf, _, err := m.ExportJpeg(vips.NewJpegExportParams())
+	params := vips.NewJpegExportParams()
+	params.StripMetadata = true
+	buf, _, err := m.ExportJpeg(params)
 	if err != nil {
 		return err
 	}
@@ -50,11 +50,16 @@ func (g GifGenerator) Generate(size image.Rectangle, img interface{}) (interface
 	b := image.Rect(0, 0, srcX, srcY)
 	tmp := image.NewRGBA(b)
 
+	processor := g.processor
 	for i, frame := range m.Image {
 		bounds := frame.Bounds()
 		prev := tmp
 		draw.Draw(tmp, bounds, frame, bounds.Min, draw.Over)
-		processed := g.processor.Process(tmp, size.Dx(), size.Dy(), imaging.Lanczos)
+		if i == 0 && processor.ID() == "smartcrop" {
+			// the frames are cropped to the window of the first one, cropping each frame on its own makes the animation jitter
+			processor = fixedSmartCrop(tmp, size.Dx(), size.Dy())
+		}
+		processed := processor.Process(tmp, size.Dx(), size.Dy(), imaging.Lanczos)
 		m.Image[i] = g.imageToPaletted(processed, frame.Palette)
 
 		switch m.Disposal[i] {
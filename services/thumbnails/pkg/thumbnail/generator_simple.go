@@ -44,3 +44,8 @@ func (g SimpleGenerator) Dimensions(img interface{}) (image.Rectangle, error) {
 	}
 	return m.Bounds(), nil
 }
+
+// blurHashImageFrom converts the image of the generator to compute the BlurHash
+func blurHashImageFrom(_ interface{}) (image.Image, error) {
+	return nil, errors.ErrInvalidType
+}
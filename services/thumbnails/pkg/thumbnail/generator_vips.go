@@ -5,6 +5,7 @@ package thumbnail
 import (
 	"bytes"
 	"image"
+	"image/png"
 	"strings"
 
 	"github.com/davidbyttow/govips/v2/vips"
@@ -27,6 +28,9 @@ func NewSimpleGenerator(filetype, process string) (SimpleGenerator, error) {
 		return SimpleGenerator{crop: vips.InterestingNone, process: process, size: vips.SizeBoth}, nil
 	case "resize":
 		return SimpleGenerator{crop: vips.InterestingNone, process: process, size: vips.SizeForce}, nil
+	case "smartcrop":
+		// libvips has its own saliency based cropping
+		return SimpleGenerator{crop: vips.InterestingAttention, process: process, size: vips.SizeBoth}, nil
 	default:
 		return SimpleGenerator{crop: vips.InterestingAttention, process: process, size: vips.SizeBoth}, nil
 	}
@@ -82,3 +86,24 @@ func (g SimpleGenerator) Dimensions(img interface{}) (image.Rectangle, error) {
 		return image.Rectangle{}, errors.ErrInvalidType
 	}
 }
+
+// blurHashImageFrom converts the image of the generator to compute the BlurHash
+func blurHashImageFrom(img interface{}) (image.Image, error) {
+	m, ok := img.(*vips.ImageRef)
+	if !ok {
+		return nil, errors.ErrInvalidType
+	}
+	small, err := m.Copy()
+	if err != nil {
+		return nil, err
+	}
+	defer small.Close()
+	if err := small.ThumbnailWithSize(blurHashSamples, blurHashSamples, vips.InterestingNone, vips.SizeDown); err != nil {
+		return nil, err
+	}
+	buf, _, err := small.ExportPng(vips.NewPngExportParams())
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(buf))
+}
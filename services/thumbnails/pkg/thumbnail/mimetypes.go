@@ -12,7 +12,18 @@ var (
 		"image/bmp":                         {},
 		"image/x-ms-bmp":                    {},
 		"image/tiff":                        {},
+		"image/svg+xml":                     {},
 		"text/plain":                        {},
+		"text/markdown":                     {},
+		"text/x-markdown":                   {},
+		"text/yaml":                         {},
+		"text/css":                          {},
+		"text/x-c":                          {},
+		"text/x-java-source":                {},
+		"application/json":                  {},
+		"application/javascript":            {},
+		"application/x-sh":                  {},
+		"application/x-sql":                 {},
 		"audio/flac":                        {},
 		"audio/mpeg":                        {},
 		"audio/ogg":                         {},
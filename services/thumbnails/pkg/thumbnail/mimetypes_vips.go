@@ -13,6 +13,16 @@ var (
 		"image/x-ms-bmp":                    {},
 		"image/tiff":                        {},
 		"text/plain":                        {},
+		"text/markdown":                     {},
+		"text/x-markdown":                   {},
+		"text/yaml":                         {},
+		"text/css":                          {},
+		"text/x-c":                          {},
+		"text/x-java-source":                {},
+		"application/json":                  {},
+		"application/javascript":            {},
+		"application/x-sh":                  {},
+		"application/x-sql":                 {},
 		"audio/flac":                        {},
 		"audio/mpeg":                        {},
 		"audio/ogg":                         {},
@@ -40,6 +40,8 @@ func ProcessorFor(id, fileType string) (DefinableProcessor, error) {
 		}}, nil
 	case "thumbnail":
 		return DefinableProcessor{Slug: strings.ToLower(id), Converter: imaging.Thumbnail}, nil
+	case "smartcrop":
+		return DefinableProcessor{Slug: strings.ToLower(id), Converter: SmartCrop}, nil
 	default:
 		switch strings.ToLower(fileType) {
 		case typeGif:
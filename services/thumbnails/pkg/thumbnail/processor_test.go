@@ -70,6 +70,18 @@ func TestProcessorFor(t *testing.T) {
 			wantP:    thumbnail.DefinableProcessor{Slug: "thumbnail"},
 			wantE:    nil,
 		},
+		{
+			id:       "smartcrop",
+			fileType: "jpg",
+			wantP:    thumbnail.DefinableProcessor{Slug: "smartcrop"},
+			wantE:    nil,
+		},
+		{
+			id:       "SMARTCROP",
+			fileType: "jpg",
+			wantP:    thumbnail.DefinableProcessor{Slug: "smartcrop"},
+			wantE:    nil,
+		},
 		{
 			id:       "",
 			fileType: "jpg",
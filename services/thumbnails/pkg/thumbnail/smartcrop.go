@@ -0,0 +1,185 @@
+package thumbnail
+
+import (
+	"image"
+	"math"
+
+	"github.com/kovidgoyal/imaging"
+)
+
+const (
+	// smartCropAnalysisSize is the maximum size of the downscaled image the saliency is computed on
+	smartCropAnalysisSize = 256
+	// smartCropBlockSize is the size of the blocks the local entropy is computed for
+	smartCropBlockSize = 8
+	// smartCropCenterBias prefers crop windows near the center if their saliency is close to the best one
+	smartCropCenterBias = 0.02
+)
+
+// SmartCrop creates an image with the specified dimensions like imaging.Fill, but instead of cropping
+// the center of the image it chooses the crop window containing the most salient part of the image.
+// The saliency of a pixel combines the strength of edges with the entropy of its neighbourhood,
+// subjects like faces or objects usually have more details than the background around them.
+func SmartCrop(img image.Image, width, height int, filter imaging.ResampleFilter) *image.NRGBA {
+	if width <= 0 || height <= 0 || img.Bounds().Empty() {
+		return &image.NRGBA{}
+	}
+	return imaging.Resize(imaging.Crop(img, smartCropWindow(img, width, height)), width, height, filter)
+}
+
+// fixedSmartCrop returns a processor cropping all images to the window SmartCrop chooses for the first
+// one. The frames of animations stay aligned and the saliency is only computed once.
+func fixedSmartCrop(first image.Image, width, height int) Processor {
+	if width <= 0 || height <= 0 || first.Bounds().Empty() {
+		return DefinableProcessor{Slug: "smartcrop", Converter: SmartCrop}
+	}
+	window := smartCropWindow(first, width, height)
+	return DefinableProcessor{Slug: "smartcrop", Converter: func(img image.Image, width, height int, filter imaging.ResampleFilter) *image.NRGBA {
+		return imaging.Resize(imaging.Crop(img, window), width, height, filter)
+	}}
+}
+
+// smartCropWindow returns the largest window with the aspect ratio of the thumbnail with the highest saliency
+func smartCropWindow(img image.Image, width, height int) image.Rectangle {
+	b := img.Bounds()
+
+	// the window spans the full width or height of the image
+	cropW, cropH := b.Dx(), b.Dy()
+	if b.Dx()*height > b.Dy()*width {
+		cropW = max(1, b.Dy()*width/height)
+	} else {
+		cropH = max(1, b.Dx()*height/width)
+	}
+
+	offset := image.Point{}
+	if cropW != b.Dx() || cropH != b.Dy() {
+		offset = smartCropOffset(img, cropW, cropH)
+	}
+
+	return image.Rectangle{Min: b.Min.Add(offset), Max: b.Min.Add(offset).Add(image.Pt(cropW, cropH))}
+}
+
+// smartCropOffset returns the offset of the crop window with the highest saliency
+func smartCropOffset(img image.Image, cropW, cropH int) image.Point {
+	b := img.Bounds()
+	analysis := imaging.Fit(img, smartCropAnalysisSize, smartCropAnalysisSize, imaging.Box)
+	scale := float64(analysis.Bounds().Dx()) / float64(b.Dx())
+	saliency := saliencyMap(analysis)
+	w, h := analysis.Bounds().Dx(), analysis.Bounds().Dy()
+
+	// the window only moves along one axis, so the saliency is summed up per column or row
+	horizontal := cropW != b.Dx()
+	n, window := h, int(math.Round(float64(cropH)*scale))
+	if horizontal {
+		n, window = w, int(math.Round(float64(cropW)*scale))
+	}
+	window = min(max(window, 1), n)
+	sums := make([]float64, n)
+	total := 0.0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if horizontal {
+				sums[x] += saliency[y*w+x]
+			} else {
+				sums[y] += saliency[y*w+x]
+			}
+			total += saliency[y*w+x]
+		}
+	}
+
+	best, bestScore := (n-window)/2, math.Inf(-1)
+	score := 0.0
+	for i := 0; i < window; i++ {
+		score += sums[i]
+	}
+	for pos := 0; pos+window <= n; pos++ {
+		if pos > 0 {
+			score += sums[pos+window-1] - sums[pos-1]
+		}
+		// the distance to the center of the image relative to the possible movement
+		distance := math.Abs(float64(pos)-float64(n-window)/2) / math.Max(1, float64(n-window)/2)
+		s := score - smartCropCenterBias*total*distance
+		if s > bestScore {
+			best, bestScore = pos, s
+		}
+	}
+
+	// map the position back to the source image
+	p := int(math.Round(float64(best) / scale))
+	if horizontal {
+		return image.Pt(min(max(p, 0), b.Dx()-cropW), 0)
+	}
+	return image.Pt(0, min(max(p, 0), b.Dy()-cropH))
+}
+
+// saliencyMap returns the saliency of every pixel of the image as the sum of the normalized edge strength
+// and the normalized entropy of the surrounding block
+func saliencyMap(img *image.NRGBA) []float64 {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(x, y)
+			p := img.Pix[i : i+4 : i+4]
+			// transparent pixels are background
+			lum[y*w+x] = (0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])) * float64(p[3]) / 255
+		}
+	}
+	at := func(x, y int) float64 {
+		return lum[min(max(y, 0), h-1)*w+min(max(x, 0), w-1)]
+	}
+
+	// the sobel operator approximates the gradient of the luminance
+	edges := make([]float64, w*h)
+	maxEdge := 0.0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			e := math.Hypot(gx, gy)
+			edges[y*w+x] = e
+			maxEdge = math.Max(maxEdge, e)
+		}
+	}
+
+	// the entropy of the luminance histogram of every block
+	bw, bh := (w+smartCropBlockSize-1)/smartCropBlockSize, (h+smartCropBlockSize-1)/smartCropBlockSize
+	entropy := make([]float64, bw*bh)
+	maxEntropy := 0.0
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			var histogram [32]int
+			count := 0
+			for y := by * smartCropBlockSize; y < min((by+1)*smartCropBlockSize, h); y++ {
+				for x := bx * smartCropBlockSize; x < min((bx+1)*smartCropBlockSize, w); x++ {
+					histogram[min(int(lum[y*w+x])/8, len(histogram)-1)]++
+					count++
+				}
+			}
+			e := 0.0
+			for _, c := range histogram {
+				if c > 0 {
+					p := float64(c) / float64(count)
+					e -= p * math.Log2(p)
+				}
+			}
+			entropy[by*bw+bx] = e
+			maxEntropy = math.Max(maxEntropy, e)
+		}
+	}
+
+	saliency := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var s float64
+			if maxEdge > 0 {
+				s += edges[y*w+x] / maxEdge
+			}
+			if maxEntropy > 0 {
+				s += entropy[(y/smartCropBlockSize)*bw+x/smartCropBlockSize] / maxEntropy
+			}
+			saliency[y*w+x] = s
+		}
+	}
+	return saliency
+}
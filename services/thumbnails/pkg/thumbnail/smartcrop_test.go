@@ -0,0 +1,130 @@
+//go:build !enable_vips
+
+package thumbnail_test
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"testing"
+
+	"github.com/kovidgoyal/imaging"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail"
+)
+
+// subjectImage returns a plain image with a detailed subject at the given position
+func subjectImage(size, subject image.Rectangle) image.Image {
+	img := image.NewRGBA(size)
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 0x80, G: 0xa0, B: 0xc0, A: 0xff}), image.Point{}, draw.Src)
+	for y := subject.Min.Y; y < subject.Max.Y; y++ {
+		for x := subject.Min.X; x < subject.Max.X; x++ {
+			if (x/8+y/8)%2 == 0 {
+				img.Set(x, y, color.Black)
+			} else {
+				img.Set(x, y, color.RGBA{R: 0xff, A: 0xff})
+			}
+		}
+	}
+	return img
+}
+
+// hasSubject checks if the image contains the red parts of the subject
+func hasSubject(img image.Image) bool {
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if r > g+0x3000 && r > b+0x3000 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestSmartCrop(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    image.Rectangle
+		subject image.Rectangle
+	}{
+		{
+			name:    "landscape with the subject on the right",
+			size:    image.Rect(0, 0, 800, 200),
+			subject: image.Rect(680, 60, 760, 140),
+		},
+		{
+			name:    "landscape with the subject on the left",
+			size:    image.Rect(0, 0, 800, 200),
+			subject: image.Rect(20, 20, 100, 100),
+		},
+		{
+			name:    "portrait with the subject at the top",
+			size:    image.Rect(0, 0, 300, 1200),
+			subject: image.Rect(100, 10, 200, 110),
+		},
+		{
+			name:    "image with an offset",
+			size:    image.Rect(50, 50, 850, 250),
+			subject: image.Rect(730, 110, 810, 190),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := subjectImage(tt.size, tt.subject)
+
+			cropped := thumbnail.SmartCrop(img, 64, 64, imaging.Lanczos)
+			assert.Equal(t, image.Pt(64, 64), cropped.Bounds().Size())
+			assert.True(t, hasSubject(cropped), "the subject was cropped")
+
+			// the subject is not in the center, so fill cuts it off
+			assert.False(t, hasSubject(imaging.Fill(img, 64, 64, imaging.Center, imaging.Lanczos)))
+		})
+	}
+
+	t.Run("plain images are cropped in the center", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+		draw.Draw(img, image.Rect(0, 0, 100, 100), image.NewUniform(color.Black), image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(100, 0, 200, 100), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(200, 0, 300, 100), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+		cropped := thumbnail.SmartCrop(img, 10, 10, imaging.Box)
+		r, g, b, _ := cropped.At(5, 5).RGBA()
+		assert.Equal(t, []uint32{0xffff, 0xffff, 0xffff}, []uint32{r, g, b})
+	})
+
+	t.Run("empty sizes", func(t *testing.T) {
+		img := subjectImage(image.Rect(0, 0, 10, 10), image.Rectangle{})
+		assert.True(t, thumbnail.SmartCrop(img, 0, 10, imaging.Box).Bounds().Empty())
+	})
+}
+
+func TestSmartCropGif(t *testing.T) {
+	frame := func(subject image.Rectangle) *image.Paletted {
+		img := subjectImage(image.Rect(0, 0, 800, 200), subject)
+		p := image.NewPaletted(img.Bounds(), palette.WebSafe)
+		draw.Draw(p, p.Bounds(), img, image.Point{}, draw.Src)
+		return p
+	}
+	// the subject moves from the right to the left
+	g := &gif.GIF{
+		Image:    []*image.Paletted{frame(image.Rect(680, 60, 760, 140)), frame(image.Rect(20, 60, 100, 140))},
+		Delay:    []int{10, 10},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+		Config:   image.Config{Width: 800, Height: 200},
+	}
+
+	generator, err := thumbnail.NewGifGenerator("gif", "smartcrop")
+	assert.NoError(t, err)
+	img, err := generator.Generate(image.Rect(0, 0, 64, 64), g)
+	assert.NoError(t, err)
+
+	// all frames are cropped to the window of the first frame
+	frames := img.(*gif.GIF).Image
+	assert.True(t, hasSubject(frames[0]))
+	assert.False(t, hasSubject(frames[1]))
+}
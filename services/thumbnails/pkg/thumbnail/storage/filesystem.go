@@ -4,9 +4,10 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
+	"sync"
+	"time"
 
+	"github.com/opencloud-eu/reva/v2/pkg/bytesize"
 	"github.com/pkg/errors"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
@@ -19,15 +20,28 @@ const (
 
 // NewFileSystemStorage creates a new instance of FileSystem
 func NewFileSystemStorage(cfg config.FileSystemStorage, logger log.Logger) FileSystem {
+	// the size was validated with the config
+	maxSize, _ := bytesize.Parse(cfg.MaxSize)
 	return FileSystem{
-		root:   cfg.RootDirectory,
-		logger: logger,
+		root:            cfg.RootDirectory,
+		maxSize:         int64(maxSize),
+		maxAge:          cfg.MaxAge,
+		cleanupInterval: cfg.CleanupInterval,
+		dirs:            &sync.RWMutex{},
+		logger:          logger,
 	}
 }
 
 // FileSystem represents a storage for the thumbnails using the local file system.
+// The modification time of the thumbnails is updated when they are used, it is the base for evicting the least recently used thumbnails.
 type FileSystem struct {
-	root   string
+	root            string
+	maxSize         int64
+	maxAge          time.Duration
+	cleanupInterval time.Duration
+	// dirs is held for reading while a thumbnail is written and for writing while the janitor removes
+	// empty directories, otherwise a directory could be removed between creating it and the file in it
+	dirs   *sync.RWMutex
 	logger log.Logger
 }
 
@@ -50,6 +64,8 @@ func (s FileSystem) Get(key string) ([]byte, error) {
 		}
 		return nil, err
 	}
+
+	s.touch(img)
 	return content, nil
 }
 
@@ -57,6 +73,9 @@ func (s FileSystem) Get(key string) ([]byte, error) {
 func (s FileSystem) Put(key string, img []byte) error {
 	imgPath := filepath.Join(s.root, filesDir, key)
 	dir := filepath.Dir(imgPath)
+
+	s.dirs.RLock()
+	defer s.dirs.RUnlock()
 	if err := os.MkdirAll(dir, 0700); err != nil {
 		return errors.Wrapf(err, "error while creating directory %s", dir)
 	}
@@ -103,16 +122,5 @@ func (s FileSystem) Put(key string, img []byte) error {
 //
 // The key also represents the path to the thumbnail in the filesystem under the configured root directory.
 func (s FileSystem) BuildKey(r Request) string {
-	checksum := r.Checksum
-	filetype := r.Types[0]
-
-	parts := []string{strconv.Itoa(r.Resolution.Dx()), "x", strconv.Itoa(r.Resolution.Dy())}
-
-	if r.Characteristic != "" {
-		parts = append(parts, "-", r.Characteristic)
-	}
-
-	parts = append(parts, ".", filetype)
-
-	return filepath.Join(checksum[:2], checksum[2:4], checksum[4:], strings.Join(parts, ""))
+	return filepath.Join(keyParts(r)...)
 }
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// touchInterval limits how often the modification time of a used thumbnail is updated
+const touchInterval = time.Minute
+
+// tmpFileMaxAge is the age after which left over temporary files are deleted
+const tmpFileMaxAge = time.Hour
+
+// Usage summarizes the thumbnails in the filesystem storage
+type Usage struct {
+	Files int
+	Size  int64
+	// Oldest is the time the least recently used thumbnail was used
+	Oldest time.Time
+	// Newest is the time the most recently used thumbnail was used
+	Newest time.Time
+}
+
+type cacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// touch marks the thumbnail as used
+func (s FileSystem) touch(img string) {
+	info, err := os.Stat(img)
+	if err != nil || time.Since(info.ModTime()) < touchInterval {
+		return
+	}
+	now := time.Now()
+	if err := os.Chtimes(img, now, now); err != nil {
+		s.logger.Debug().Err(err).Str("path", img).Msg("could not update the modification time of the thumbnail")
+	}
+}
+
+// Usage returns the number and size of the thumbnails in the storage
+func (s FileSystem) Usage() (Usage, error) {
+	var u Usage
+	entries, err := s.entries()
+	if err != nil {
+		return u, err
+	}
+
+	for _, e := range entries {
+		u.Files++
+		u.Size += e.size
+	}
+	if len(entries) > 0 {
+		u.Oldest, u.Newest = entries[0].modTime, entries[len(entries)-1].modTime
+	}
+	return u, nil
+}
+
+// Purge deletes the thumbnails which were not used for maxAge and the least recently used thumbnails
+// until the size of the storage is below maxSize. A maxAge or maxSize of 0 disables the respective limit.
+// It returns the number and size of the deleted thumbnails.
+func (s FileSystem) Purge(maxAge time.Duration, maxSize int64) (Usage, error) {
+	var purged Usage
+	entries, err := s.entries()
+	if err != nil {
+		return purged, err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+
+	now := time.Now()
+	for _, e := range entries {
+		expired := maxAge > 0 && now.Sub(e.modTime) > maxAge
+		if !expired && (maxSize <= 0 || total <= maxSize) {
+			// the entries are sorted, all remaining thumbnails are newer
+			break
+		}
+
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			s.logger.Error().Err(err).Str("path", e.path).Msg("could not delete thumbnail")
+			continue
+		}
+		s.removeEmptyDirs(filepath.Dir(e.path))
+
+		total -= e.size
+		purged.Files++
+		purged.Size += e.size
+		if purged.Oldest.IsZero() {
+			purged.Oldest = e.modTime
+		}
+		purged.Newest = e.modTime
+	}
+
+	return purged, nil
+}
+
+// RunJanitor purges the storage in the configured interval until the context is done.
+// It returns immediately if neither a maximum size nor a maximum age is configured.
+func (s FileSystem) RunJanitor(ctx context.Context) {
+	if s.maxAge <= 0 && s.maxSize <= 0 {
+		return
+	}
+
+	interval := s.cleanupInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := s.Purge(s.maxAge, s.maxSize)
+		switch {
+		case err != nil:
+			s.logger.Error().Err(err).Msg("could not purge the thumbnail storage")
+		case purged.Files > 0:
+			s.logger.Info().Int("files", purged.Files).Int64("bytes", purged.Size).Msg("purged thumbnails")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// entries returns the thumbnails sorted by their modification time, the least recently used first.
+// Temporary files left over by an interrupted Put are deleted.
+func (s FileSystem) entries() ([]cacheEntry, error) {
+	var entries []cacheEntry
+	root := filepath.Join(s.root, filesDir)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return err
+		case d.IsDir():
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if strings.HasPrefix(d.Name(), "tmpthumb") {
+			if time.Since(info.ModTime()) > tmpFileMaxAge {
+				_ = os.Remove(path)
+			}
+			return nil
+		}
+
+		entries = append(entries, cacheEntry{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	return entries, nil
+}
+
+// removeEmptyDirs removes the empty directories from dir up to the files directory
+func (s FileSystem) removeEmptyDirs(dir string) {
+	s.dirs.Lock()
+	defer s.dirs.Unlock()
+
+	root := filepath.Join(s.root, filesDir)
+	for dir != root && strings.HasPrefix(dir, root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,84 @@
+package storage_test
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail/storage"
+)
+
+func TestFileSystem_Purge(t *testing.T) {
+	root := t.TempDir()
+	s := storage.NewFileSystemStorage(config.FileSystemStorage{RootDirectory: root, MaxSize: "0"}, log.NopLogger())
+
+	now := time.Now()
+	keys := make([]string, 4)
+	for i := range keys {
+		keys[i] = s.BuildKey(storage.Request{
+			Checksum:   "120EA8A25E5D487BF68B5F7096440019",
+			Types:      []string{"png"},
+			Resolution: image.Rect(0, 0, 16*(i+1), 16*(i+1)),
+		})
+		require.NoError(t, s.Put(keys[i], make([]byte, 100)))
+		// the first thumbnail was used least recently
+		used := now.Add(-time.Duration(len(keys)-i) * 24 * time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(root, "files", keys[i]), used, used))
+	}
+
+	// using a thumbnail makes it the most recently used one
+	_, err := s.Get(keys[0])
+	require.NoError(t, err)
+
+	u, err := s.Usage()
+	require.NoError(t, err)
+	require.Equal(t, 4, u.Files)
+	require.Equal(t, int64(400), u.Size)
+
+	// keys[1] is older than two days and keys[2] exceeds the size
+	purged, err := s.Purge(60*time.Hour, 200)
+	require.NoError(t, err)
+	require.Equal(t, 2, purged.Files)
+	require.False(t, s.Stat(keys[1]))
+	require.False(t, s.Stat(keys[2]))
+	require.True(t, s.Stat(keys[0]))
+	require.True(t, s.Stat(keys[3]))
+
+	purged, err = s.Purge(0, 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, purged.Files)
+
+	// the empty directories are removed
+	entries, err := os.ReadDir(filepath.Join(root, "files"))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestFileSystem_PurgeWhilePut(t *testing.T) {
+	s := storage.NewFileSystemStorage(config.FileSystemStorage{RootDirectory: t.TempDir(), MaxSize: "0"}, log.NopLogger())
+	key := s.BuildKey(storage.Request{
+		Checksum:   "120EA8A25E5D487BF68B5F7096440019",
+		Types:      []string{"png"},
+		Resolution: image.Rect(0, 0, 16, 16),
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			_, _ = s.Purge(0, 1)
+		}
+	}()
+
+	// the janitor must not remove the directory of a thumbnail which is being written
+	for i := 0; i < 200; i++ {
+		require.NoError(t, s.Put(key, make([]byte, 100)))
+	}
+	<-done
+}
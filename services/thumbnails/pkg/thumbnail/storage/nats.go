@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config"
+)
+
+// NewNATSStorage creates a new instance of NATS. The object store bucket is created if it does not exist.
+func NewNATSStorage(cfg config.NATSStorage, logger log.Logger) (NATS, error) {
+	opts := []nats.Option{
+		nats.Name("thumbnails"),
+		nats.MaxReconnects(-1),
+	}
+	if cfg.AuthUsername != "" || cfg.AuthPassword != "" {
+		opts = append(opts, nats.UserInfo(cfg.AuthUsername, cfg.AuthPassword))
+	}
+	if cfg.EnableTLS {
+		tlsConf := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: cfg.TLSInsecure, //nolint:gosec
+		}
+		if cfg.TLSRootCACertificate != "" {
+			pem, err := os.ReadFile(cfg.TLSRootCACertificate)
+			if err != nil {
+				return NATS{}, err
+			}
+			tlsConf.RootCAs = x509.NewCertPool()
+			if !tlsConf.RootCAs.AppendCertsFromPEM(pem) {
+				return NATS{}, errors.Errorf("no certificate found in '%s'", cfg.TLSRootCACertificate)
+			}
+			tlsConf.InsecureSkipVerify = false
+		}
+		opts = append(opts, nats.Secure(tlsConf))
+	}
+
+	nc, err := nats.Connect(strings.Join(cfg.Nodes, ","), opts...)
+	if err != nil {
+		return NATS{}, errors.Wrap(err, "could not connect to nats")
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return NATS{}, errors.Wrap(err, "could not create jetstream context")
+	}
+
+	ctx := context.Background()
+	store, err := js.ObjectStore(ctx, cfg.Bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		store, err = js.CreateObjectStore(ctx, jetstream.ObjectStoreConfig{
+			Bucket: cfg.Bucket,
+			TTL:    cfg.TTL,
+		})
+	}
+	if err != nil {
+		return NATS{}, errors.Wrapf(err, "could not open object store '%s'", cfg.Bucket)
+	}
+
+	return NATS{
+		store:  store,
+		logger: logger,
+	}, nil
+}
+
+// NATS represents a storage for the thumbnails using a NATS JetStream object store.
+type NATS struct {
+	store  jetstream.ObjectStore
+	logger log.Logger
+}
+
+// Stat returns if an object for the given key exists in the object store
+func (s NATS) Stat(key string) bool {
+	_, err := s.store.GetInfo(context.Background(), key)
+	return err == nil
+}
+
+// Get returns the object content for the given key
+func (s NATS) Get(key string) ([]byte, error) {
+	content, err := s.store.GetBytes(context.Background(), key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrObjectNotFound) {
+			return nil, errors.Wrapf(fs.ErrNotExist, "object '%s' not found", key)
+		}
+		s.logger.Debug().Str("err", err.Error()).Str("key", key).Msg("could not load thumbnail from store")
+		return nil, err
+	}
+	return content, nil
+}
+
+// Put stores image data in the object store for the given key
+func (s NATS) Put(key string, img []byte) error {
+	if _, err := s.store.PutBytes(context.Background(), key, img); err != nil {
+		return errors.Wrapf(err, "could not store object '%s'", key)
+	}
+	return nil
+}
+
+// BuildKey generate the unique key for a thumbnail.
+// The key has the same structure as the key of the FileSystem storage but always uses slashes.
+func (s NATS) BuildKey(r Request) string {
+	return path.Join(keyParts(r)...)
+}
@@ -0,0 +1,153 @@
+package storage_test
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	nserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail/storage"
+)
+
+// fakeS3 is a minimal in-memory stand-in for an S3 compatible object storage
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		b, _ := io.ReadAll(r.Body)
+		if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			b = decodeChunked(b)
+		}
+		f.objects[r.URL.Path] = b
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		b, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				_, _ = io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(b)
+		}
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// decodeChunked strips the chunk signatures of a streaming signature v4 payload
+func decodeChunked(b []byte) []byte {
+	var out []byte
+	for len(b) > 0 {
+		header, rest, ok := bytes.Cut(b, []byte("\r\n"))
+		if !ok {
+			break
+		}
+		size, err := strconv.ParseInt(string(bytes.SplitN(header, []byte(";"), 2)[0]), 16, 64)
+		if err != nil || size == 0 {
+			break
+		}
+		out = append(out, rest[:size]...)
+		b = rest[size+2:]
+	}
+	return out
+}
+
+var request = storage.Request{
+	Checksum:   "120EA8A25E5D487BF68B5F7096440019",
+	Types:      []string{"png"},
+	Resolution: image.Rect(0, 0, 32, 32),
+}
+
+func testStorage(t *testing.T, s storage.Storage) {
+	key := s.BuildKey(request)
+	require.Equal(t, "12/0E/A8A25E5D487BF68B5F7096440019/32x32.png", key)
+
+	require.False(t, s.Stat(key))
+	_, err := s.Get(key)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.NoError(t, s.Put(key, []byte("thumbnail")))
+	require.True(t, s.Stat(key))
+
+	b, err := s.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, []byte("thumbnail"), b)
+}
+
+func TestS3(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	s, err := storage.NewS3Storage(config.S3Storage{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		AccessKey: "access",
+		SecretKey: "secret",
+		Bucket:    "thumbnails",
+		Prefix:    "cache",
+	}, log.NopLogger())
+	require.NoError(t, err)
+
+	testStorage(t, s)
+	require.Contains(t, fake.objects, "/thumbnails/cache/12/0E/A8A25E5D487BF68B5F7096440019/32x32.png")
+}
+
+func TestS3WithoutScheme(t *testing.T) {
+	for _, endpoint := range []string{"s3.example.com", "s3.example.com:9000", "127.0.0.1:9000"} {
+		_, err := storage.NewS3Storage(config.S3Storage{
+			Endpoint:  endpoint,
+			AccessKey: "access",
+			SecretKey: "secret",
+			Bucket:    "thumbnails",
+		}, log.NopLogger())
+		require.NoError(t, err, endpoint)
+	}
+}
+
+func TestNATS(t *testing.T) {
+	srv, err := nserver.NewServer(&nserver.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+	go srv.Start()
+	defer srv.Shutdown()
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+
+	s, err := storage.NewNATSStorage(config.NATSStorage{
+		Nodes:  []string{srv.ClientURL()},
+		Bucket: "thumbnails",
+	}, log.NopLogger())
+	require.NoError(t, err)
+
+	testStorage(t, s)
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config"
+)
+
+// NewS3Storage creates a new instance of S3
+func NewS3Storage(cfg config.S3Storage, logger log.Logger) (S3, error) {
+	host, secure := cfg.Endpoint, true
+	if u, err := url.Parse(cfg.Endpoint); err == nil && u.Host != "" {
+		// endpoints without a scheme like 's3.example.com:9000' are used as they are
+		host, secure = u.Host, u.Scheme != "http"
+	}
+
+	client, err := minio.New(host, &minio.Options{
+		Region: cfg.Region,
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: secure,
+	})
+	if err != nil {
+		return S3{}, errors.Wrap(err, "failed to setup s3 client")
+	}
+
+	return S3{
+		client: client,
+		bucket: cfg.Bucket,
+		prefix: cfg.Prefix,
+		logger: logger,
+	}, nil
+}
+
+// S3 represents a storage for the thumbnails using an S3 compatible object storage.
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+	logger log.Logger
+}
+
+// Stat returns if an object for the given key exists in the bucket
+func (s S3) Stat(key string) bool {
+	_, err := s.client.StatObject(context.Background(), s.bucket, s.object(key), minio.StatObjectOptions{})
+	return err == nil
+}
+
+// Get returns the object content for the given key
+func (s S3) Get(key string) ([]byte, error) {
+	obj, err := s.client.GetObject(context.Background(), s.bucket, s.object(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.error(err, key)
+	}
+	defer obj.Close()
+
+	content, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, s.error(err, key)
+	}
+	return content, nil
+}
+
+// Put stores image data in the bucket for the given key
+func (s S3) Put(key string, img []byte) error {
+	_, err := s.client.PutObject(context.Background(), s.bucket, s.object(key), bytes.NewReader(img), int64(len(img)), minio.PutObjectOptions{
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "could not store object '%s' into bucket '%s'", s.object(key), s.bucket)
+	}
+	return nil
+}
+
+// BuildKey generate the unique key for a thumbnail.
+// The key has the same structure as the key of the FileSystem storage but always uses slashes.
+func (s S3) BuildKey(r Request) string {
+	return path.Join(keyParts(r)...)
+}
+
+func (s S3) object(key string) string {
+	return path.Join(s.prefix, key)
+}
+
+func (s S3) error(err error, key string) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return errors.Wrapf(fs.ErrNotExist, "object '%s' not found", s.object(key))
+	}
+	s.logger.Debug().Str("err", err.Error()).Str("key", key).Msg("could not load thumbnail from store")
+	return err
+}
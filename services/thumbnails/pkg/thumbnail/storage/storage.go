@@ -2,6 +2,11 @@ package storage
 
 import (
 	"image"
+	"strconv"
+	"strings"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/config"
 )
 
 // Request combines different attributes needed for storage operations.
@@ -30,3 +35,31 @@ type Storage interface {
 	Put(key string, img []byte) error
 	BuildKey(r Request) string
 }
+
+// New returns the storage configured for the thumbnails
+func New(cfg config.Thumbnail, logger log.Logger) (Storage, error) {
+	switch cfg.Storage {
+	case "s3":
+		return NewS3Storage(cfg.S3Storage, logger)
+	case "nats-js-os":
+		return NewNATSStorage(cfg.NATSStorage, logger)
+	default:
+		return NewFileSystemStorage(cfg.FileSystemStorage, logger), nil
+	}
+}
+
+// keyParts returns the segments of the key of a thumbnail. See FileSystem.BuildKey for the structure.
+func keyParts(r Request) []string {
+	checksum := r.Checksum
+	filetype := r.Types[0]
+
+	parts := []string{strconv.Itoa(r.Resolution.Dx()), "x", strconv.Itoa(r.Resolution.Dy())}
+
+	if r.Characteristic != "" {
+		parts = append(parts, "-", r.Characteristic)
+	}
+
+	parts = append(parts, ".", filetype)
+
+	return []string{checksum[:2], checksum[2:4], checksum[4:], strings.Join(parts, "")}
+}
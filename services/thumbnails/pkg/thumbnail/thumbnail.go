@@ -3,10 +3,12 @@ package thumbnail
 import (
 	"bytes"
 	"image"
+	"image/gif"
 	"mime"
 
 	"github.com/opencloud-eu/opencloud/pkg/log"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/errors"
+	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/preprocessor"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail/storage"
 )
 
@@ -28,6 +30,11 @@ type Manager interface {
 	CheckThumbnail(r Request) (string, bool)
 	// GetThumbnail will load the thumbnail from the storage and return its content.
 	GetThumbnail(key string) ([]byte, error)
+	// GenerateBlurHash computes the BlurHash placeholder of the image and stores it.
+	GenerateBlurHash(checksum string, img interface{}) (string, error)
+	// GetBlurHash loads the BlurHash placeholder of the file with the checksum from the storage.
+	// The function will return false if the placeholder does not exist.
+	GetBlurHash(checksum string) (string, bool)
 }
 
 // NewSimpleManager creates a new instance of SimpleManager
@@ -92,6 +99,57 @@ func (s SimpleManager) GetThumbnail(key string) ([]byte, error) {
 	return s.storage.Get(key)
 }
 
+// GenerateBlurHash computes the BlurHash placeholder of the image and stores it
+func (s SimpleManager) GenerateBlurHash(checksum string, img interface{}) (string, error) {
+	var generator Generator = SimpleGenerator{}
+	if _, ok := img.(*gif.GIF); ok {
+		generator = GifGenerator{}
+	}
+	inputDimensions, err := generator.Dimensions(img)
+	if err != nil {
+		return "", err
+	}
+	if inputDimensions.Size().X > s.maxDimension.X || inputDimensions.Size().Y > s.maxDimension.Y {
+		return "", errors.ErrImageTooLarge
+	}
+
+	m, err := blurHashImage(img)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := BlurHash(m, BlurHashComponentsX, BlurHashComponentsY)
+	if err != nil {
+		return "", err
+	}
+	if err := s.storage.Put(s.blurHashKey(checksum), []byte(hash)); err != nil {
+		s.logger.Error().Err(err).Msg("could not store blurhash")
+		return "", err
+	}
+	return hash, nil
+}
+
+// GetBlurHash loads the BlurHash placeholder from the storage
+func (s SimpleManager) GetBlurHash(checksum string) (string, bool) {
+	k := s.blurHashKey(checksum)
+	if !s.storage.Stat(k) {
+		return "", false
+	}
+	hash, err := s.storage.Get(k)
+	if err != nil || len(hash) == 0 {
+		return "", false
+	}
+	return string(hash), true
+}
+
+func (s SimpleManager) blurHashKey(checksum string) string {
+	return s.storage.BuildKey(storage.Request{
+		Checksum:   checksum,
+		Types:      []string{typeBlurHash},
+		Resolution: image.Rect(0, 0, BlurHashComponentsX, BlurHashComponentsY),
+	})
+}
+
 func mapToStorageRequest(r Request) storage.Request {
 	return storage.Request{
 		Checksum:       r.Checksum,
@@ -111,6 +169,11 @@ func IsMimeTypeSupported(m string) bool {
 	return supported
 }
 
+// IsFileSupported validate if the file is supported, source code files are also recognized by their file name
+func IsFileSupported(mimeType, filename string) bool {
+	return IsMimeTypeSupported(mimeType) || preprocessor.IsSourceFile(mimeType, filename)
+}
+
 // PrepareRequest prepare the request based on image parameters
 func PrepareRequest(width, height int, tType, checksum, pID string) (Request, error) {
 	generator, err := GeneratorFor(tType, pID)
@@ -1,6 +1,7 @@
 package thumbnail
 
 import (
+	"bytes"
 	"image"
 	"os"
 	"path"
@@ -11,6 +12,7 @@ import (
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/errors"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/preprocessor"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -31,6 +33,25 @@ func (m NoOpManager) Set(_, _ string, _ []byte) error {
 	return nil
 }
 
+type memoryStorage struct {
+	NoOpManager
+	data map[string][]byte
+}
+
+func (m memoryStorage) Put(key string, img []byte) error {
+	m.data[key] = img
+	return nil
+}
+
+func (m memoryStorage) Stat(key string) bool {
+	_, ok := m.data[key]
+	return ok
+}
+
+func (m memoryStorage) Get(key string) ([]byte, error) {
+	return m.data[key], nil
+}
+
 func BenchmarkGet(b *testing.B) {
 
 	sut := NewSimpleManager(
@@ -196,3 +217,30 @@ func TestPreviewGenerationTooBigImage(t *testing.T) {
 		})
 	}
 }
+
+func TestPreviewGenerationStripsMetadata(t *testing.T) {
+	f, err := os.ReadFile("../../testdata/test.jpg")
+	require.NoError(t, err)
+	require.True(t, bytes.Contains(f, []byte("Exif")))
+
+	for _, tType := range []string{"jpg", "png"} {
+		t.Run(tType, func(t *testing.T) {
+			st := memoryStorage{data: map[string][]byte{}}
+			sut := NewSimpleManager(Resolutions{}, st, log.NopLogger(), 6016, 4000)
+
+			img, err := preprocessor.ForType("image/jpeg", nil).Convert(bytes.NewReader(f))
+			require.NoError(t, err)
+
+			req, err := PrepareRequest(32, 32, tType, "checksum", "fit")
+			require.NoError(t, err)
+			key, err := sut.Generate(req, img)
+			require.NoError(t, err)
+
+			thumbnail := st.data[key]
+			require.NotEmpty(t, thumbnail)
+			for _, marker := range []string{"Exif", "eXIf", "http://ns.adobe.com/xap/1.0/", "tEXt", "iTXt"} {
+				assert.False(t, bytes.Contains(thumbnail, []byte(marker)), "thumbnail contains %s", marker)
+			}
+		})
+	}
+}
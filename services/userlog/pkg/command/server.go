@@ -19,6 +19,7 @@ import (
 	"github.com/opencloud-eu/opencloud/pkg/version"
 	ehsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/eventhistory/v0"
 	settingssvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/settings/v0"
+	searchevent "github.com/opencloud-eu/opencloud/services/search/pkg/event"
 	"github.com/opencloud-eu/opencloud/services/userlog/pkg/config"
 	"github.com/opencloud-eu/opencloud/services/userlog/pkg/config/parser"
 	"github.com/opencloud-eu/opencloud/services/userlog/pkg/logging"
@@ -43,6 +44,9 @@ var _registeredEvents = []events.Unmarshaller{
 	events.ShareCreated{},
 	events.ShareRemoved{},
 	events.ShareExpired{},
+
+	// search related
+	searchevent.SavedSearchMatched{},
 }
 
 // Server is the entrypoint for the server command.
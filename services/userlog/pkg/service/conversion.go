@@ -15,6 +15,8 @@ import (
 	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
 	storageprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/opencloud-eu/opencloud/pkg/l10n"
+	"github.com/opencloud-eu/opencloud/pkg/quarantine"
+	searchevent "github.com/opencloud-eu/opencloud/services/search/pkg/event"
 	"github.com/opencloud-eu/reva/v2/pkg/events"
 	"github.com/opencloud-eu/reva/v2/pkg/rgrpc/todo/pool"
 	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
@@ -89,7 +91,11 @@ func (c *Converter) ConvertEvent(eventid string, event interface{}) (OC10Notific
 		switch ev.FinishedStep {
 		case events.PPStepAntivirus:
 			res := ev.Result.(events.VirusscanResult)
-			return c.virusMessage(eventid, VirusFound, ev.ExecutingUser, res.ResourceID, ev.Filename, res.Description, res.Scandate)
+			tmpl := VirusFound
+			if ev.Outcome == quarantine.PPOutcomeQuarantine {
+				tmpl = VirusQuarantined
+			}
+			return c.virusMessage(eventid, tmpl, ev.ExecutingUser, res.ResourceID, ev.Filename, res.Description, res.Scandate)
 		case events.PPStepPolicies:
 			return c.policiesMessage(eventid, PoliciesEnforced, ev.ExecutingUser, ev.Filename, time.Now())
 		default:
@@ -115,6 +121,10 @@ func (c *Converter) ConvertEvent(eventid string, event interface{}) (OC10Notific
 		return c.shareMessage(eventid, ShareExpired, ev.ShareOwner, ev.ItemID, ev.ShareID, ev.ExpiredAt)
 	case events.ShareRemoved:
 		return c.shareMessage(eventid, ShareRemoved, ev.Executant, ev.ItemID, ev.ShareID, ev.Timestamp)
+
+	// search related
+	case searchevent.SavedSearchMatched:
+		return c.savedSearchMessage(eventid, SavedSearchMatched, ev.UserID, ev.ResourceID, ev.SavedSearchID, ev.SavedSearchName, ev.Timestamp)
 	}
 }
 
@@ -233,6 +243,46 @@ func (c *Converter) shareMessage(eventid string, nt NotificationTemplate, execut
 	}, nil
 }
 
+func (c *Converter) savedSearchMessage(eventid string, nt NotificationTemplate, userID *user.UserId, resourceid *storageprovider.ResourceId, searchid, searchname string, ts time.Time) (OC10Notification, error) {
+	usr, err := c.getUser(context.Background(), userID)
+	if err != nil {
+		return OC10Notification{}, err
+	}
+
+	info, err := c.getResource(c.serviceAccountContext, resourceid)
+	if err != nil {
+		return OC10Notification{}, err
+	}
+
+	subj, subjraw, msg, msgraw, err := composeMessage(nt, c.locale, c.defaultLanguage, c.translationPath, map[string]interface{}{
+		"resourcename": info.GetName(),
+		"searchname":   searchname,
+	})
+	if err != nil {
+		return OC10Notification{}, err
+	}
+
+	dets := generateDetails(usr, nil, info, nil)
+	dets["search"] = map[string]string{
+		"id":   searchid,
+		"name": searchname,
+	}
+
+	return OC10Notification{
+		EventID:        eventid,
+		Service:        c.serviceName,
+		UserName:       usr.GetUsername(),
+		Timestamp:      ts.Format(time.RFC3339Nano),
+		ResourceID:     storagespace.FormatResourceID(info.GetId()),
+		ResourceType:   _resourceTypeResource,
+		Subject:        subj,
+		SubjectRaw:     subjraw,
+		Message:        msg,
+		MessageRaw:     msgraw,
+		MessageDetails: dets,
+	}, nil
+}
+
 func (c *Converter) virusMessage(eventid string, nt NotificationTemplate, executant *user.User, rid *storageprovider.ResourceId, filename string, virus string, ts time.Time) (OC10Notification, error) {
 	subj, subjraw, msg, msgraw, err := composeMessage(nt, c.locale, c.defaultLanguage, c.translationPath, map[string]interface{}{
 		"resourcename":     filename,
@@ -9,6 +9,11 @@ var (
 		Message: l10n.Template("Virus found in {resource}. Upload not possible. Virus: {virus}"),
 	}
 
+	VirusQuarantined = NotificationTemplate{
+		Subject: l10n.Template("Virus found"),
+		Message: l10n.Template("Virus found in {resource}. The file has been quarantined for review by an administrator. Virus: {virus}"),
+	}
+
 	PoliciesEnforced = NotificationTemplate{
 		Subject: l10n.Template("Policies enforced"),
 		Message: l10n.Template("File {resource} was deleted because it violates the policies"),
@@ -54,6 +59,11 @@ var (
 		Message: l10n.Template("Access to {resource} expired"),
 	}
 
+	SavedSearchMatched = NotificationTemplate{
+		Subject: l10n.Template("New search result"),
+		Message: l10n.Template("{resource} matches your saved search {search}"),
+	}
+
 	PlatformDeprovision = NotificationTemplate{
 		Subject: l10n.Template("Instance will be shut down and deprovisioned"),
 		Message: l10n.Template("Attention! The instance will be shut down and deprovisioned on {date}. Download all your data before that date as no access past that date is possible."),
@@ -67,6 +77,7 @@ var _placeholders = map[string]string{
 	"{resource}": "{{ .resourcename }}",
 	"{virus}":    "{{ .virusdescription }}",
 	"{date}":     "{{ .date }}",
+	"{search}":   "{{ .searchname }}",
 }
 
 // NotificationTemplate is the data structure for the notifications
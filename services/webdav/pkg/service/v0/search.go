@@ -10,10 +10,12 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	merrors "go-micro.dev/v4/errors"
 	"go-micro.dev/v4/metadata"
+	"golang.org/x/sync/errgroup"
 
 	revactx "github.com/opencloud-eu/reva/v2/pkg/ctx"
 	"github.com/opencloud-eu/reva/v2/pkg/storagespace"
@@ -21,7 +23,9 @@ import (
 	"github.com/opencloud-eu/reva/v2/pkg/utils"
 
 	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	thumbnailsmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/thumbnails/v0"
 	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	thumbnailssvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/thumbnails/v0"
 	"github.com/opencloud-eu/opencloud/services/thumbnails/pkg/thumbnail"
 	"github.com/opencloud-eu/opencloud/services/webdav/pkg/constants"
 	"github.com/opencloud-eu/opencloud/services/webdav/pkg/net"
@@ -32,6 +36,14 @@ import (
 const (
 	elementNameSearchFiles = "search-files"
 	// TODO elementNameFilterFiles = "filter-files"
+
+	// blurHashConcurrency limits the concurrent requests for the BlurHash placeholders of the matches
+	blurHashConcurrency = 10
+)
+
+var (
+	// propBlurHash is the property of the BlurHash placeholder of images
+	propBlurHash = xml.Name{Space: "http://owncloud.org/ns", Local: "blurhash"}
 )
 
 // Search is the endpoint for retrieving search results for REPORT requests
@@ -89,12 +101,61 @@ func (g Webdav) Search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	g.sendSearchResponse(rsp, w, r)
+	var blurHashes map[string]string
+	if rep.SearchFiles.Prop.Contains(propBlurHash) {
+		blurHashes = g.blurHashes(r.Context(), t, rsp.Matches)
+	}
+
+	g.sendSearchResponse(rsp, blurHashes, w, r)
 }
 
-func (g Webdav) sendSearchResponse(rsp *searchsvc.SearchResponse, w http.ResponseWriter, r *http.Request) {
+// blurHashes returns the cached BlurHash placeholders of the matching files by their resource id.
+// The placeholders are not computed on demand to keep the search fast, files without one are omitted.
+func (g Webdav) blurHashes(ctx context.Context, token string, matches []*searchmsg.Match) map[string]string {
+	hashes := map[string]string{}
+	if g.thumbnailsClient == nil {
+		return hashes
+	}
+
+	var (
+		mu sync.Mutex
+		eg errgroup.Group
+	)
+	eg.SetLimit(blurHashConcurrency)
+	for _, match := range matches {
+		if match.GetEntity().GetId() == nil || match.GetEntity().GetType() == uint64(provider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+			continue
+		}
+		if !thumbnail.IsFileSupported(match.GetEntity().GetMimeType(), match.GetEntity().GetName()) {
+			continue
+		}
+		id := matchResourceID(match)
+		name := match.GetEntity().GetName()
+		eg.Go(func() error {
+			rsp, err := g.thumbnailsClient.GetBlurHash(ctx, &thumbnailssvc.GetBlurHashRequest{
+				Filepath: name,
+				Cs3Source: &thumbnailsmsg.CS3Source{
+					Path:          id,
+					Authorization: token,
+				},
+				CachedOnly: true,
+			})
+			if err != nil || rsp.GetBlurHash() == "" {
+				return nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			hashes[id] = rsp.GetBlurHash()
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	return hashes
+}
+
+func (g Webdav) sendSearchResponse(rsp *searchsvc.SearchResponse, blurHashes map[string]string, w http.ResponseWriter, r *http.Request) {
 	logger := g.log.SubloggerWithRequestID(r.Context())
-	responsesXML, err := multistatusResponse(r.Context(), g.config.OpenCloudPublicURL, rsp.Matches)
+	responsesXML, err := multistatusResponse(r.Context(), g.config.OpenCloudPublicURL, rsp.Matches, blurHashes)
 	if err != nil {
 		logger.Error().Err(err).Msg("error formatting propfind")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -112,10 +173,10 @@ func (g Webdav) sendSearchResponse(rsp *searchsvc.SearchResponse, w http.Respons
 }
 
 // multistatusResponse converts a list of matches into a multistatus response string
-func multistatusResponse(ctx context.Context, publicURL string, matches []*searchmsg.Match) ([]byte, error) {
+func multistatusResponse(ctx context.Context, publicURL string, matches []*searchmsg.Match, blurHashes map[string]string) ([]byte, error) {
 	responses := make([]*propfind.ResponseXML, 0, len(matches))
 	for i := range matches {
-		res, err := matchToPropResponse(ctx, publicURL, matches[i])
+		res, err := matchToPropResponse(ctx, publicURL, matches[i], blurHashes[matchResourceID(matches[i])])
 		if err != nil {
 			return nil, err
 		}
@@ -131,7 +192,7 @@ func multistatusResponse(ctx context.Context, publicURL string, matches []*searc
 	return msg, nil
 }
 
-func matchToPropResponse(ctx context.Context, publicURL string, match *searchmsg.Match) (*propfind.ResponseXML, error) {
+func matchToPropResponse(ctx context.Context, publicURL string, match *searchmsg.Match, blurHash string) (*propfind.ResponseXML, error) {
 	// unfortunately, search uses own versions of ResourceId and Ref. So we need to assert them here
 	var (
 		ref string
@@ -167,17 +228,22 @@ func matchToPropResponse(ctx context.Context, publicURL string, match *searchmsg
 		Href:     net.EncodePath(path.Join("/remote.php/dav/spaces/", ref)),
 		Propstat: []propfind.PropstatXML{},
 	}
+	if versionKey := match.GetEntity().GetVersionKey(); versionKey != "" {
+		// the previous versions of a file are addressed like in the version listing
+		response.Href = net.EncodePath(path.Join("/remote.php/dav/meta/", matchResourceID(match), "v", versionKey))
+	}
+	if trashItemKey := match.GetEntity().GetTrashItemKey(); trashItemKey != "" {
+		// trashed resources are addressed by their key in the trash of the space
+		spaceID := storagespace.FormatStorageID(match.Entity.Ref.ResourceId.StorageId, match.Entity.Ref.ResourceId.SpaceId)
+		response.Href = net.EncodePath(path.Join("/remote.php/dav/spaces/trash-bin/", spaceID, trashItemKey))
+	}
 
 	propstatOK := propfind.PropstatXML{
 		Status: "HTTP/1.1 200 OK",
 		Prop:   []prop.PropertyXML{},
 	}
 
-	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:fileid", storagespace.FormatResourceID(&provider.ResourceId{
-		StorageId: match.Entity.Id.StorageId,
-		SpaceId:   match.Entity.Id.SpaceId,
-		OpaqueId:  match.Entity.Id.OpaqueId,
-	})))
+	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:fileid", matchResourceID(match)))
 	if match.Entity.ParentId != nil {
 		propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:file-parent", storagespace.FormatResourceID(&provider.ResourceId{
 			StorageId: match.Entity.ParentId.StorageId,
@@ -195,6 +261,10 @@ func matchToPropResponse(ctx context.Context, publicURL string, match *searchmsg
 		})))
 	}
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:name", match.Entity.Name))
+	if match.GetEntity().GetDeleted() {
+		// trashed resources are restored to the location they had before
+		propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:trashbin-original-location", strings.TrimPrefix(match.Entity.Ref.Path, "./")))
+	}
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("d:getlastmodified", match.Entity.LastModifiedTime.AsTime().Format(constants.RFC1123)))
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:permissions", match.Entity.Permissions))
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:highlights", match.Entity.Highlights))
@@ -234,6 +304,10 @@ func matchToPropResponse(ctx context.Context, publicURL string, match *searchmsg
 		propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:privatelink", privateURL.String()))
 	}
 
+	if blurHash != "" {
+		propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:blurhash", blurHash))
+	}
+
 	if len(propstatOK.Prop) > 0 {
 		response.Propstat = append(response.Propstat, propstatOK)
 	}
@@ -241,6 +315,15 @@ func matchToPropResponse(ctx context.Context, publicURL string, match *searchmsg
 	return &response, nil
 }
 
+// matchResourceID returns the formatted resource id of the match
+func matchResourceID(match *searchmsg.Match) string {
+	return storagespace.FormatResourceID(&provider.ResourceId{
+		StorageId: match.GetEntity().GetId().GetStorageId(),
+		SpaceId:   match.GetEntity().GetId().GetSpaceId(),
+		OpaqueId:  match.GetEntity().GetId().GetOpaqueId(),
+	})
+}
+
 func hasPreview(md *provider.ResourceInfo, appendToOK func(p ...prop.PropertyXML)) {
 	_, match := thumbnail.SupportedMimeTypes[md.MimeType]
 	if match {
@@ -283,6 +366,39 @@ type reportFilterFilesRules struct {
 // http://www.webdav.org/specs/rfc4918.html#ELEMENT_prop (for propfind)
 type Props []xml.Name
 
+// Contains returns true if the property was requested
+func (p Props) Contains(name xml.Name) bool {
+	for _, n := range p {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalXML appends the property names enclosed within start to p
+func (p *Props) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		t, err := prop.Next(d)
+		if err != nil {
+			return err
+		}
+		switch e := t.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			t, err = prop.Next(d)
+			if err != nil {
+				return err
+			}
+			if _, ok := t.(xml.EndElement); !ok {
+				return fmt.Errorf("unexpected token %T", t)
+			}
+			*p = append(*p, e.Name)
+		}
+	}
+}
+
 // XML holds the xml representation of a propfind
 // http://www.webdav.org/specs/rfc4918.html#ELEMENT_propfind
 type XML struct {
@@ -0,0 +1,123 @@
+package svc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go-micro.dev/v4/client"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/opencloud-eu/opencloud/pkg/log"
+	searchmsg "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/messages/search/v0"
+	searchsvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0"
+	searchmocks "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/search/v0/mocks"
+	thumbnailssvc "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/thumbnails/v0"
+	thumbnailsmocks "github.com/opencloud-eu/opencloud/protogen/gen/opencloud/services/thumbnails/v0/mocks"
+	"github.com/opencloud-eu/opencloud/services/webdav/pkg/config"
+)
+
+const searchReport = `<?xml version="1.0"?>
+<oc:search-files xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+  <d:prop><oc:fileid/><oc:blurhash/></d:prop>
+  <oc:search><oc:pattern>holiday</oc:pattern><oc:limit>10</oc:limit></oc:search>
+</oc:search-files>`
+
+func match(id, name, mimeType string, resourceType provider.ResourceType) *searchmsg.Match {
+	return &searchmsg.Match{
+		Entity: &searchmsg.Entity{
+			Ref: &searchmsg.Reference{
+				ResourceId: &searchmsg.ResourceID{StorageId: "storage", SpaceId: "space", OpaqueId: "space"},
+				Path:       "./" + name,
+			},
+			Id:               &searchmsg.ResourceID{StorageId: "storage", SpaceId: "space", OpaqueId: id},
+			Name:             name,
+			MimeType:         mimeType,
+			Type:             uint64(resourceType),
+			LastModifiedTime: timestamppb.Now(),
+		},
+	}
+}
+
+func TestSearchBlurHash(t *testing.T) {
+	matches := []*searchmsg.Match{
+		match("image", "holiday.png", "image/png", provider.ResourceType_RESOURCE_TYPE_FILE),
+		match("code", "holiday.go", "text/plain", provider.ResourceType_RESOURCE_TYPE_FILE),
+		match("uncached", "holiday.jpg", "image/jpeg", provider.ResourceType_RESOURCE_TYPE_FILE),
+		match("archive", "holiday.zip", "application/zip", provider.ResourceType_RESOURCE_TYPE_FILE),
+		match("folder", "holiday", "httpd/unix-directory", provider.ResourceType_RESOURCE_TYPE_CONTAINER),
+	}
+
+	search := searchmocks.NewSearchProviderService(t)
+	search.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchResponse{
+		Matches:      matches,
+		TotalMatches: int32(len(matches)),
+	}, nil)
+
+	thumbnails := thumbnailsmocks.NewThumbnailService(t)
+	hashes := map[string]string{
+		"storage$space!image": "LEHV6nWB2yk8pyo0adR*.7kCMdnj",
+		"storage$space!code":  "L00000fQfQfQfQfQfQfQfQfQfQfQ",
+	}
+	// only the files which can have a thumbnail are looked up, the folder and the archive are skipped
+	thumbnails.On("GetBlurHash", mock.Anything, mock.MatchedBy(func(req *thumbnailssvc.GetBlurHashRequest) bool {
+		return req.GetCachedOnly() && req.GetCs3Source().GetAuthorization() == "token"
+	})).Return(func(_ context.Context, req *thumbnailssvc.GetBlurHashRequest, _ ...client.CallOption) *thumbnailssvc.GetBlurHashResponse {
+		return &thumbnailssvc.GetBlurHashResponse{BlurHash: hashes[req.GetCs3Source().GetPath()]}
+	}, nil).Times(3)
+
+	g := Webdav{
+		config:           &config.Config{},
+		log:              log.NopLogger(),
+		searchClient:     search,
+		thumbnailsClient: thumbnails,
+	}
+
+	req := httptest.NewRequest("REPORT", "/dav/files/user", strings.NewReader(searchReport))
+	req.Header.Set("X-Access-Token", "token")
+	rec := httptest.NewRecorder()
+	g.Search(rec, req)
+
+	require.Equal(t, http.StatusMultiStatus, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "<oc:blurhash>LEHV6nWB2yk8pyo0adR*.7kCMdnj</oc:blurhash>")
+	assert.Contains(t, body, "<oc:blurhash>L00000fQfQfQfQfQfQfQfQfQfQfQ</oc:blurhash>")
+	assert.Equal(t, 2, strings.Count(body, "<oc:blurhash>"), "files without a cached placeholder have no property")
+}
+
+func TestSearchTrashed(t *testing.T) {
+	trashed := match("child", "folder/child.txt", "text/plain", provider.ResourceType_RESOURCE_TYPE_FILE)
+	trashed.Entity.Deleted = true
+	trashed.Entity.TrashItemKey = "folderid/child.txt"
+
+	search := searchmocks.NewSearchProviderService(t)
+	search.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchResponse{
+		Matches:      []*searchmsg.Match{trashed},
+		TotalMatches: 1,
+	}, nil)
+
+	g := Webdav{
+		config:       &config.Config{},
+		log:          log.NopLogger(),
+		searchClient: search,
+	}
+
+	req := httptest.NewRequest("REPORT", "/dav/files/user", strings.NewReader(`<?xml version="1.0"?>
+<oc:search-files xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+  <oc:search><oc:pattern>is:trashed child</oc:pattern></oc:search>
+</oc:search-files>`))
+	req.Header.Set("X-Access-Token", "token")
+	rec := httptest.NewRecorder()
+	g.Search(rec, req)
+
+	require.Equal(t, http.StatusMultiStatus, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "<d:href>/remote.php/dav/spaces/trash-bin/storage$space/folderid/child.txt</d:href>")
+	assert.Contains(t, body, "<oc:trashbin-original-location>folder/child.txt</oc:trashbin-original-location>")
+}